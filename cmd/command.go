@@ -0,0 +1,69 @@
+package main
+
+import (
+	"candyshop/pkg/db"
+	"candyshop/pkg/summary"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const usage = `usage:
+  candyshop                                                   run the api server
+  candyshop worker [-concurrency n]                           run the background jobs
+  candyshop summary rebuild -from YYYY-MM-DD -to YYYY-MM-DD [-store id]
+                                                              recompute the daily sales summaries of the days`
+
+// runCommand runs a command given on the command line instead of the server and returns the exit code.
+func runCommand(args []string) int {
+	if len(args) >= 1 && args[0] == "worker" {
+		return runWorker(args[1:])
+	}
+
+	if len(args) >= 2 && args[0] == "summary" && args[1] == "rebuild" {
+		return rebuildSummary(args[2:])
+	}
+
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+func rebuildSummary(args []string) int {
+	flags := flag.NewFlagSet("summary rebuild", flag.ContinueOnError)
+	from := flags.String("from", "", "first day, YYYY-MM-DD")
+	to := flags.String("to", "", "last day, YYYY-MM-DD")
+	store := flags.String("store", "", "store id, every store when empty")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	fromDate, errFrom := time.Parse(time.DateOnly, *from)
+	toDate, errTo := time.Parse(time.DateOnly, *to)
+	if errFrom != nil || errTo != nil || toDate.Before(fromDate) {
+		fmt.Fprintln(os.Stderr, "from and to must be dates formatted as YYYY-MM-DD and to must not be before from")
+		return 2
+	}
+
+	var storeIDs []uuid.UUID
+	if *store != "" {
+		storeID, errStore := uuid.Parse(*store)
+		if errStore != nil {
+			fmt.Fprintln(os.Stderr, "store must be a store id")
+			return 2
+		}
+
+		storeIDs = []uuid.UUID{storeID}
+	}
+
+	rebuilt, err := summary.Rebuild(db.ConnectDBCandyShop(), storeIDs, fromDate, toDate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rebuilt %d store days before failing: %v\n", rebuilt, err)
+		return 1
+	}
+
+	fmt.Printf("rebuilt %d store days\n", rebuilt)
+	return 0
+}
@@ -1,13 +1,36 @@
 package main
 
 import (
+	category "candyshop/internal/category"
+	coupon "candyshop/internal/coupon"
 	customer "candyshop/internal/customer"
+	inventory "candyshop/internal/inventory"
+	job "candyshop/internal/job"
+	media "candyshop/internal/media"
 	product "candyshop/internal/product"
+	promotion "candyshop/internal/promotion"
+	purchase "candyshop/internal/purchase"
+	receipt "candyshop/internal/receipt"
+	report "candyshop/internal/report"
+	sale "candyshop/internal/sale"
+	salereturn "candyshop/internal/salereturn"
+	shift "candyshop/internal/shift"
+	staff "candyshop/internal/staff"
+	stocktake "candyshop/internal/stocktake"
 	store "candyshop/internal/store"
+	supplier "candyshop/internal/supplier"
+	tag "candyshop/internal/tag"
+	tax "candyshop/internal/tax"
+	transfer "candyshop/internal/transfer"
 	user "candyshop/internal/user"
 	"candyshop/pkg/db"
+	"candyshop/pkg/payment"
+	"candyshop/pkg/storage"
 	"os"
+	"strings"
 	"time"
+	// store opening hours are evaluated in the store timezone, embed the database for hosts without zoneinfo
+	_ "time/tzdata"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -36,9 +59,22 @@ func initZeroLogger() {
 
 func main() {
 	initZeroLogger()
-	r := fiber.New()
+
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	// product images are uploaded in the request body, leave room above the image size limit for the form
+	r := fiber.New(fiber.Config{BodyLimit: 8 * 1024 * 1024})
 	r.Use(loggerMiddleware)
 	db := db.ConnectDBCandyShop()
+	files := storage.ConnectStorage()
+	payments := payment.ConnectProvider()
+
+	// local uploads are served by this server, other storages serve their own files
+	if local, ok := files.(*storage.LocalStorage); ok && strings.HasPrefix(local.PublicURL, "/") {
+		r.Static(local.PublicURL, local.Dir)
+	}
 
 	r.Get("", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -51,6 +87,24 @@ func main() {
 	product.Init(r, db)
 	store.Init(r, db)
 	customer.Init(r, db)
+	staff.Init(r, db)
+	inventory.Init(r, db)
+	transfer.Init(r, db)
+	promotion.Init(r, db)
+	coupon.Init(r, db)
+	category.Init(r, db)
+	tag.Init(r, db)
+	media.Init(r, db, files)
+	sale.Init(r, db, payments)
+	salereturn.Init(r, db, payments)
+	supplier.Init(r, db)
+	stocktake.Init(r, db)
+	shift.Init(r, db)
+	tax.Init(r, db)
+	receipt.Init(r, db, payments)
+	report.Init(r, db)
+	purchase.Init(r, db)
+	job.Init(r, db)
 
 	r.Listen(":5000")
 }
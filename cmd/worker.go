@@ -0,0 +1,97 @@
+package main
+
+import (
+	inventory "candyshop/internal/inventory"
+	"candyshop/pkg/db"
+	"candyshop/pkg/event"
+	"candyshop/pkg/job"
+	"candyshop/pkg/purge"
+	"candyshop/pkg/storage"
+	"candyshop/pkg/summary"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// initJobs registers the handlers and schedules of every module with background jobs.
+func initJobs(worker *job.Worker, db *sqlx.DB) error {
+	for _, initModule := range []func(*job.Worker, *sqlx.DB) error{
+		summary.InitJobs,
+		inventory.InitJobs,
+		func(worker *job.Worker, db *sqlx.DB) error {
+			return purge.InitJobs(worker, db, storage.ConnectStorage())
+		},
+	} {
+		if err := initModule(worker, db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runWorker runs the background jobs until the process is interrupted, the running jobs finish before it exits.
+func runWorker(args []string) int {
+	flags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	concurrency := flags.Int("concurrency", 4, "jobs run at the same time")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "concurrency must be at least 1")
+		return 2
+	}
+
+	database := db.ConnectDBCandyShop()
+
+	worker := job.NewWorker(database)
+	worker.Concurrency = *concurrency
+
+	if err := initJobs(worker, database); err != nil {
+		log.Error().Err(err).Str("function", "init jobs").Msg("failed to init jobs")
+		return 1
+	}
+
+	// the dispatcher queues the deliveries of the outbox events, the same worker delivers them
+	dispatcher := event.NewDispatcher(database)
+	for _, subscriber := range event.ConnectWebhooks() {
+		dispatcher.Subscribe(subscriber)
+	}
+
+	if err := dispatcher.InitJobs(worker); err != nil {
+		log.Error().Err(err).Str("function", "init jobs").Msg("failed to init jobs")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Info().Int("concurrency", *concurrency).Msg("worker started")
+
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		dispatcher.Run(ctx)
+	}()
+
+	errRun := worker.Run(ctx)
+
+	// a worker that failed to start takes the dispatcher down with it
+	stop()
+	<-dispatched
+
+	if errRun != nil {
+		log.Error().Err(errRun).Str("function", "run worker").Msg("failed to run worker")
+		return 1
+	}
+
+	log.Info().Msg("worker stopped")
+	return 0
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_customers_name_trgm;
+DROP INDEX IF EXISTS idx_customers_phone_number;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS merged_into;
@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS merged_into UUID NULL REFERENCES customers(id);
+
+-- not unique, existing duplicates have to be merged first
+CREATE INDEX IF NOT EXISTS idx_customers_phone_number ON customers(phone_number) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_customers_name_trgm ON customers USING gin (name gin_trgm_ops);
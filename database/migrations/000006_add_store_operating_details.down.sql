@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS store_holidays;
+DROP TABLE IF EXISTS store_opening_hours;
+
+DROP INDEX IF EXISTS idx_stores_location;
+
+ALTER TABLE stores
+    DROP COLUMN IF EXISTS manager_id,
+    DROP COLUMN IF EXISTS phone_number,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,28 @@
+ALTER TABLE stores
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION NULL,
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION NULL,
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta',
+    ADD COLUMN IF NOT EXISTS phone_number VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS manager_id UUID NULL REFERENCES users(id);
+
+CREATE INDEX IF NOT EXISTS idx_stores_location ON stores(latitude, longitude) WHERE deleted_at IS NULL;
+
+-- weekday follows Go time.Weekday, 0 is Sunday
+CREATE TABLE IF NOT EXISTS store_opening_hours (
+    store_id UUID NOT NULL REFERENCES stores(id),
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    open_time TIME NULL,
+    close_time TIME NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (store_id, weekday)
+);
+
+CREATE TABLE IF NOT EXISTS store_holidays (
+    store_id UUID NOT NULL REFERENCES stores(id),
+    date DATE NOT NULL,
+    open_time TIME NULL,
+    close_time TIME NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT true,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (store_id, date)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS store_staff (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL REFERENCES stores(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    role VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_store_staff_active ON store_staff(store_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_store_staff_user_id ON store_staff(user_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS inventories (
+    store_id UUID NOT NULL REFERENCES stores(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    in_transit_quantity INT NOT NULL DEFAULT 0 CHECK (in_transit_quantity >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    PRIMARY KEY (store_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventories_product_id ON inventories(product_id);
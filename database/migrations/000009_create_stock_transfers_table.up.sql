@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id UUID PRIMARY KEY,
+    source_store_id UUID NOT NULL REFERENCES stores(id),
+    destination_store_id UUID NOT NULL REFERENCES stores(id),
+    status VARCHAR(20) NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    requested_by UUID NULL REFERENCES users(id),
+    shipped_at TIMESTAMP WITH TIME ZONE NULL,
+    received_at TIMESTAMP WITH TIME ZONE NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    CHECK (source_store_id <> destination_store_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_source_store_id ON stock_transfers(source_store_id);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_destination_store_id ON stock_transfers(destination_store_id);
+
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+    transfer_id UUID NOT NULL REFERENCES stock_transfers(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    received_quantity INT NOT NULL DEFAULT 0 CHECK (received_quantity >= 0 AND received_quantity <= quantity),
+    PRIMARY KEY (transfer_id, product_id)
+);
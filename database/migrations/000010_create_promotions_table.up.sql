@@ -0,0 +1,34 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price BIGINT NOT NULL DEFAULT 0 CHECK (price >= 0);
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY,
+    name VARCHAR(250) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    type VARCHAR(50) NOT NULL,
+    discount_percent NUMERIC(5,2) NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    discount_amount BIGINT NULL CHECK (discount_amount > 0),
+    buy_quantity INT NULL CHECK (buy_quantity > 0),
+    get_quantity INT NULL CHECK (get_quantity > 0),
+    bundle_price BIGINT NULL CHECK (bundle_price >= 0),
+    member_only BOOLEAN NOT NULL DEFAULT false,
+    store_id UUID NULL REFERENCES stores(id),
+    product_type VARCHAR(150) NULL,
+    brand VARCHAR(250) NULL,
+    priority INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NULL,
+    status BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL,
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_active ON promotions(starts_at, ends_at) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS promotion_products (
+    promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    PRIMARY KEY (promotion_id, product_id)
+);
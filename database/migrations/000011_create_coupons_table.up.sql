@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS coupon_batches (
+    id UUID PRIMARY KEY,
+    name VARCHAR(250) NOT NULL,
+    discount_type VARCHAR(50) NOT NULL,
+    discount_percent NUMERIC(5,2) NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    discount_amount BIGINT NULL CHECK (discount_amount > 0),
+    max_discount BIGINT NULL CHECK (max_discount > 0),
+    min_spend BIGINT NOT NULL DEFAULT 0 CHECK (min_spend >= 0),
+    usage_limit INT NOT NULL DEFAULT 1 CHECK (usage_limit > 0),
+    per_customer_limit INT NULL CHECK (per_customer_limit > 0),
+    store_id UUID NULL REFERENCES stores(id),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NULL,
+    created_by UUID NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id UUID PRIMARY KEY,
+    batch_id UUID NOT NULL REFERENCES coupon_batches(id),
+    code VARCHAR(20) NOT NULL UNIQUE,
+    usage_count INT NOT NULL DEFAULT 0 CHECK (usage_count >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupons_batch_id ON coupons(batch_id);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id UUID PRIMARY KEY,
+    coupon_id UUID NOT NULL REFERENCES coupons(id),
+    customer_id UUID NULL REFERENCES customers(id),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    order_amount BIGINT NOT NULL CHECK (order_amount >= 0),
+    discount BIGINT NOT NULL CHECK (discount >= 0),
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    redeemed_by UUID NULL REFERENCES users(id),
+    redeemed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_customer ON coupon_redemptions(coupon_id, customer_id);
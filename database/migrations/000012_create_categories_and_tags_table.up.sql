@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    slug VARCHAR(200) NOT NULL UNIQUE,
+    parent_id UUID NULL REFERENCES categories(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS product_tags (
+    product_id UUID NOT NULL REFERENCES products(id),
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_tags_tag_id ON product_tags(tag_id);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id UUID NULL REFERENCES categories(id);
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+
+-- every existing free text type becomes a top level category
+INSERT INTO categories (id, name, slug)
+SELECT gen_random_uuid(), type_name, trim(BOTH '-' FROM lower(regexp_replace(type_name, '[^a-zA-Z0-9]+', '-', 'g')))
+FROM (SELECT DISTINCT trim(type) AS type_name FROM products WHERE trim(type) <> '') types
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE products p SET category_id = c.id
+FROM categories c
+WHERE p.category_id IS NULL AND c.slug = trim(BOTH '-' FROM lower(regexp_replace(trim(p.type), '[^a-zA-Z0-9]+', '-', 'g')));
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_products_allergens;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS ingredients,
+    DROP COLUMN IF EXISTS allergens,
+    DROP COLUMN IF EXISTS calories_per_serving,
+    DROP COLUMN IF EXISTS serving_size_grams,
+    DROP COLUMN IF EXISTS net_weight_grams,
+    DROP COLUMN IF EXISTS is_halal,
+    DROP COLUMN IF EXISTS is_kosher;
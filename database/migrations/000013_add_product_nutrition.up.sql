@@ -0,0 +1,11 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS ingredients TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS allergens TEXT[] NULL,
+    ADD COLUMN IF NOT EXISTS calories_per_serving INT NULL CHECK (calories_per_serving >= 0),
+    ADD COLUMN IF NOT EXISTS serving_size_grams INT NULL CHECK (serving_size_grams > 0),
+    ADD COLUMN IF NOT EXISTS net_weight_grams INT NULL CHECK (net_weight_grams > 0),
+    ADD COLUMN IF NOT EXISTS is_halal BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS is_kosher BOOLEAN NOT NULL DEFAULT false;
+
+-- NULL allergens means not declared yet, such products never match an allergen free filter
+CREATE INDEX IF NOT EXISTS idx_products_allergens ON products USING gin (allergens);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products(id),
+    code VARCHAR(13) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    -- the code padded to 14 digits, UPC-A and the same EAN-13 with a leading zero collide here
+    gtin CHAR(14) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes(product_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_parent_id;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS parent_id,
+    DROP COLUMN IF EXISTS variant_name;
@@ -0,0 +1,6 @@
+-- a variant is a product of its own under a parent, one level deep
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS parent_id UUID NULL REFERENCES products(id),
+    ADD COLUMN IF NOT EXISTS variant_name VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_products_parent_id ON products(parent_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sale_promotions;
+DROP TABLE IF EXISTS sale_items;
+DROP TABLE IF EXISTS sales;
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS sales (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL REFERENCES stores(id),
+    customer_id UUID NULL REFERENCES customers(id),
+    cashier_id UUID NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL,
+    subtotal BIGINT NOT NULL,
+    discount BIGINT NOT NULL DEFAULT 0,
+    total BIGINT NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    sold_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_store_id_sold_at ON sales(store_id, sold_at);
+CREATE INDEX IF NOT EXISTS idx_sales_customer_id ON sales(customer_id);
+
+-- sku, name and prices are copied so a sale reads the same after the product changes
+CREATE TABLE IF NOT EXISTS sale_items (
+    id UUID PRIMARY KEY,
+    sale_id UUID NOT NULL REFERENCES sales(id),
+    line_no INT NOT NULL,
+    product_id UUID NOT NULL REFERENCES products(id),
+    sku VARCHAR(255) NOT NULL,
+    name VARCHAR(250) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_price BIGINT NOT NULL,
+    subtotal BIGINT NOT NULL,
+    discount BIGINT NOT NULL DEFAULT 0,
+    total BIGINT NOT NULL,
+    UNIQUE (sale_id, line_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_items_product_id ON sale_items(product_id);
+
+CREATE TABLE IF NOT EXISTS sale_promotions (
+    sale_id UUID NOT NULL REFERENCES sales(id),
+    promotion_id UUID NOT NULL REFERENCES promotions(id),
+    name VARCHAR(255) NOT NULL,
+    discount BIGINT NOT NULL,
+    PRIMARY KEY (sale_id, promotion_id)
+);
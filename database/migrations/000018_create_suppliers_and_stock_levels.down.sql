@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS reorder_suggestions;
+ALTER TABLE inventories
+    DROP CONSTRAINT IF EXISTS inventories_max_quantity_check,
+    DROP COLUMN IF EXISTS min_quantity,
+    DROP COLUMN IF EXISTS max_quantity;
+DROP TABLE IF EXISTS product_suppliers;
+DROP TABLE IF EXISTS suppliers;
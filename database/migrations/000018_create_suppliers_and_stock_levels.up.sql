@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS suppliers (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255) NOT NULL DEFAULT '',
+    phone_number VARCHAR(20) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    -- days between ordering and receiving the goods
+    lead_time_days INT NOT NULL DEFAULT 7 CHECK (lead_time_days >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- a product is bought from one supplier, ordered in multiples of the pack size
+CREATE TABLE IF NOT EXISTS product_suppliers (
+    product_id UUID PRIMARY KEY REFERENCES products(id),
+    supplier_id UUID NOT NULL REFERENCES suppliers(id),
+    supplier_sku VARCHAR(255) NOT NULL DEFAULT '',
+    pack_size INT NOT NULL DEFAULT 1 CHECK (pack_size > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_suppliers_supplier_id ON product_suppliers(supplier_id);
+
+ALTER TABLE inventories
+    ADD COLUMN IF NOT EXISTS min_quantity INT NULL CHECK (min_quantity >= 0),
+    ADD COLUMN IF NOT EXISTS max_quantity INT NULL CHECK (max_quantity >= 0),
+    ADD CONSTRAINT inventories_max_quantity_check CHECK (max_quantity >= min_quantity);
+
+-- the last run of the reorder suggestion job per store, replaced on every run
+CREATE TABLE IF NOT EXISTS reorder_suggestions (
+    store_id UUID NOT NULL REFERENCES stores(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    supplier_id UUID NULL REFERENCES suppliers(id),
+    quantity INT NOT NULL,
+    in_transit_quantity INT NOT NULL,
+    min_quantity INT NULL,
+    max_quantity INT NULL,
+    daily_sales NUMERIC(12, 4) NOT NULL,
+    lead_time_days INT NOT NULL,
+    suggested_quantity INT NOT NULL CHECK (suggested_quantity > 0),
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (store_id, product_id)
+);
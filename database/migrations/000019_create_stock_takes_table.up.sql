@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS stock_takes (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL REFERENCES stores(id),
+    status VARCHAR(20) NOT NULL,
+    -- counters of a blind stock-take don't see the expected quantities
+    blind BOOLEAN NOT NULL DEFAULT FALSE,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_by UUID NULL REFERENCES users(id),
+    approved_by UUID NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    approved_at TIMESTAMP WITH TIME ZONE NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_takes_store_id_created_at ON stock_takes(store_id, created_at);
+
+-- a store counts one stock-take at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_takes_open_store_id ON stock_takes(store_id) WHERE status = 'open';
+
+-- expected quantity and unit price are a snapshot of when the product joined the stock-take,
+-- adjusted quantity is the variance posted to the inventory on approval
+CREATE TABLE IF NOT EXISTS stock_take_items (
+    stock_take_id UUID NOT NULL REFERENCES stock_takes(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    expected_quantity INT NOT NULL,
+    unit_price BIGINT NOT NULL,
+    adjusted_quantity INT NULL,
+    PRIMARY KEY (stock_take_id, product_id)
+);
+
+-- the count of a product in one location by one staff, a recount of the location replaces the older count
+CREATE TABLE IF NOT EXISTS stock_take_counts (
+    stock_take_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    location VARCHAR(100) NOT NULL DEFAULT '',
+    counted_by UUID NOT NULL REFERENCES users(id),
+    quantity INT NOT NULL CHECK (quantity >= 0),
+    counted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (stock_take_id, product_id, location, counted_by),
+    FOREIGN KEY (stock_take_id, product_id) REFERENCES stock_take_items(stock_take_id, product_id)
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS inventory_movements_append_only ON inventory_movements;
+DROP FUNCTION IF EXISTS inventory_movements_append_only();
+DROP TABLE IF EXISTS inventory_movements;
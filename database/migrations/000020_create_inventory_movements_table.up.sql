@@ -0,0 +1,35 @@
+-- every change of the stock on hand, the serial id keeps the order in which the movements of a product happened
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id BIGSERIAL PRIMARY KEY,
+    store_id UUID NOT NULL REFERENCES stores(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    type VARCHAR(20) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    balance_after INT NOT NULL CHECK (balance_after >= 0),
+    reference_type VARCHAR(30) NOT NULL DEFAULT '',
+    reference_id UUID NULL,
+    created_by UUID NULL REFERENCES users(id),
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    moved_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_store_id_product_id ON inventory_movements(store_id, product_id, id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_reference ON inventory_movements(reference_type, reference_id);
+
+-- the ledger is append-only, a wrong movement is corrected by another movement
+CREATE OR REPLACE FUNCTION inventory_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'inventory movements can not be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER inventory_movements_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON inventory_movements
+    FOR EACH STATEMENT EXECUTE FUNCTION inventory_movements_append_only();
+
+-- the stock that existed before the ledger becomes its opening balance
+INSERT INTO inventory_movements (store_id, product_id, type, quantity, balance_after, moved_at)
+SELECT store_id, product_id, 'opening', quantity, quantity, CURRENT_TIMESTAMP
+FROM inventories
+WHERE quantity <> 0
+ORDER BY store_id, product_id;
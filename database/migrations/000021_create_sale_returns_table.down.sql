@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS sale_return_refunds;
+DROP TABLE IF EXISTS sale_return_items;
+DROP TABLE IF EXISTS sale_returns;
+ALTER TABLE sale_items DROP CONSTRAINT IF EXISTS sale_items_returned_quantity_check, DROP COLUMN IF EXISTS returned_quantity;
+ALTER TABLE sales DROP COLUMN IF EXISTS reversed_points, DROP COLUMN IF EXISTS refunded_amount, DROP COLUMN IF EXISTS loyalty_points;
+ALTER TABLE customers DROP COLUMN IF EXISTS loyalty_points;
@@ -0,0 +1,46 @@
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS loyalty_points INT NOT NULL DEFAULT 0;
+
+ALTER TABLE sales
+    ADD COLUMN IF NOT EXISTS loyalty_points INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS reversed_points INT NOT NULL DEFAULT 0;
+
+ALTER TABLE sale_items
+    ADD COLUMN IF NOT EXISTS returned_quantity INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT sale_items_returned_quantity_check CHECK (returned_quantity >= 0 AND returned_quantity <= quantity);
+
+CREATE TABLE IF NOT EXISTS sale_returns (
+    id UUID PRIMARY KEY,
+    sale_id UUID NOT NULL REFERENCES sales(id),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    customer_id UUID NULL REFERENCES customers(id),
+    processed_by UUID NULL REFERENCES users(id),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    refund_amount BIGINT NOT NULL,
+    reversed_points INT NOT NULL DEFAULT 0,
+    returned_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_returns_sale_id ON sale_returns(sale_id);
+CREATE INDEX IF NOT EXISTS idx_sale_returns_store_id_returned_at ON sale_returns(store_id, returned_at);
+
+-- a restocked line goes back to the store stock, a written off line is thrown away
+CREATE TABLE IF NOT EXISTS sale_return_items (
+    return_id UUID NOT NULL REFERENCES sale_returns(id),
+    sale_item_id UUID NOT NULL REFERENCES sale_items(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    sku VARCHAR(255) NOT NULL,
+    name VARCHAR(250) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    disposition VARCHAR(20) NOT NULL,
+    refund_amount BIGINT NOT NULL,
+    PRIMARY KEY (return_id, sale_item_id)
+);
+
+CREATE TABLE IF NOT EXISTS sale_return_refunds (
+    return_id UUID NOT NULL REFERENCES sale_returns(id),
+    method VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (return_id, method)
+);
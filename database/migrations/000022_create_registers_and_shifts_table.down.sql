@@ -0,0 +1,5 @@
+ALTER TABLE sale_returns DROP COLUMN IF EXISTS shift_id;
+ALTER TABLE sales DROP COLUMN IF EXISTS payment_method, DROP COLUMN IF EXISTS shift_id;
+DROP TABLE IF EXISTS shift_cash_movements;
+DROP TABLE IF EXISTS shifts;
+DROP TABLE IF EXISTS registers;
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS registers (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL REFERENCES stores(id),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registers_store_id_name ON registers(store_id, name) WHERE deleted_at IS NULL;
+
+-- expected cash, counted cash and variance are set when the shift is closed
+CREATE TABLE IF NOT EXISTS shifts (
+    id UUID PRIMARY KEY,
+    register_id UUID NOT NULL REFERENCES registers(id),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    status VARCHAR(20) NOT NULL,
+    opened_by UUID NOT NULL REFERENCES users(id),
+    closed_by UUID NULL REFERENCES users(id),
+    opening_float BIGINT NOT NULL CHECK (opening_float >= 0),
+    expected_cash BIGINT NULL,
+    counted_cash BIGINT NULL CHECK (counted_cash >= 0),
+    variance BIGINT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_shifts_store_id_opened_at ON shifts(store_id, opened_at);
+
+-- a register has one open shift at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open_register_id ON shifts(register_id) WHERE status = 'open';
+
+-- cash put into or taken out of the drawer that is not a sale or a refund
+CREATE TABLE IF NOT EXISTS shift_cash_movements (
+    id UUID PRIMARY KEY,
+    shift_id UUID NOT NULL REFERENCES shifts(id),
+    type VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_cash_movements_shift_id ON shift_cash_movements(shift_id);
+
+ALTER TABLE sales
+    ADD COLUMN IF NOT EXISTS shift_id UUID NULL REFERENCES shifts(id),
+    ADD COLUMN IF NOT EXISTS payment_method VARCHAR(20) NOT NULL DEFAULT 'cash';
+
+CREATE INDEX IF NOT EXISTS idx_sales_shift_id ON sales(shift_id);
+
+ALTER TABLE sale_returns ADD COLUMN IF NOT EXISTS shift_id UUID NULL REFERENCES shifts(id);
+
+CREATE INDEX IF NOT EXISTS idx_sale_returns_shift_id ON sale_returns(shift_id);
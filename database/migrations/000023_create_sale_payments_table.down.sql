@@ -0,0 +1,3 @@
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS payment_method VARCHAR(20) NOT NULL DEFAULT 'cash';
+UPDATE sales sa SET payment_method = (SELECT p.method FROM sale_payments p WHERE p.sale_id = sa.id ORDER BY p.amount DESC, p.created_at LIMIT 1) WHERE EXISTS (SELECT 1 FROM sale_payments p WHERE p.sale_id = sa.id);
+DROP TABLE IF EXISTS sale_payments;
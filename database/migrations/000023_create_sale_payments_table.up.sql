@@ -0,0 +1,27 @@
+-- a sale is paid with one or more tenders, the change of a cash tender is what goes back to the customer
+CREATE TABLE IF NOT EXISTS sale_payments (
+    id UUID PRIMARY KEY,
+    sale_id UUID NOT NULL REFERENCES sales(id),
+    method VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    tendered BIGINT NOT NULL,
+    change_amount BIGINT NOT NULL DEFAULT 0,
+    refunded_amount BIGINT NOT NULL DEFAULT 0,
+    provider VARCHAR(50) NOT NULL DEFAULT '',
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    CHECK (change_amount = tendered - amount AND change_amount >= 0),
+    CHECK (refunded_amount >= 0 AND refunded_amount <= amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_payments_sale_id ON sale_payments(sale_id);
+
+-- sales made so far were paid in full with their single payment method
+INSERT INTO sale_payments (id, sale_id, method, status, amount, tendered, created_at)
+SELECT gen_random_uuid(), id, payment_method, 'captured', total, total, sold_at
+FROM sales
+WHERE total > 0;
+
+ALTER TABLE sales DROP COLUMN IF EXISTS payment_method;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS sale_taxes;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS tax_amount, DROP COLUMN IF EXISTS tax_rate, DROP COLUMN IF EXISTS tax_id;
+ALTER TABLE sales DROP COLUMN IF EXISTS tax_amount, DROP COLUMN IF EXISTS tax_mode;
+DROP TABLE IF EXISTS store_tax_settings;
+DROP TABLE IF EXISTS tax_assignments;
+DROP TABLE IF EXISTS tax_rates;
+DROP TABLE IF EXISTS taxes;
@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS taxes (
+    id UUID PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_taxes_code ON taxes(code) WHERE deleted_at IS NULL;
+
+-- the default tax applies to products without a tax of their own or of their categories
+CREATE UNIQUE INDEX IF NOT EXISTS idx_taxes_default ON taxes(is_default) WHERE is_default AND deleted_at IS NULL;
+
+-- a rate applies from its effective time until the next rate of the tax, rates are in basis points
+CREATE TABLE IF NOT EXISTS tax_rates (
+    tax_id UUID NOT NULL REFERENCES taxes(id),
+    rate INT NOT NULL CHECK (rate >= 0 AND rate <= 10000),
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    PRIMARY KEY (tax_id, effective_from)
+);
+
+-- a product tax wins over a category tax, the closest category wins over its parents
+CREATE TABLE IF NOT EXISTS tax_assignments (
+    id UUID PRIMARY KEY,
+    tax_id UUID NOT NULL REFERENCES taxes(id),
+    category_id UUID NULL REFERENCES categories(id),
+    product_id UUID NULL REFERENCES products(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL,
+    CHECK ((category_id IS NULL) <> (product_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_assignments_category_id ON tax_assignments(category_id) WHERE category_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_assignments_product_id ON tax_assignments(product_id) WHERE product_id IS NOT NULL;
+
+-- stores without settings sell at prices that include tax, rounded per line
+CREATE TABLE IF NOT EXISTS store_tax_settings (
+    store_id UUID PRIMARY KEY REFERENCES stores(id),
+    mode VARCHAR(20) NOT NULL,
+    rounding VARCHAR(20) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+ALTER TABLE sales
+    ADD COLUMN IF NOT EXISTS tax_mode VARCHAR(20) NOT NULL DEFAULT 'inclusive',
+    ADD COLUMN IF NOT EXISTS tax_amount BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE sale_items
+    ADD COLUMN IF NOT EXISTS tax_id UUID NULL REFERENCES taxes(id),
+    ADD COLUMN IF NOT EXISTS tax_rate INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_amount BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS sale_taxes (
+    sale_id UUID NOT NULL REFERENCES sales(id),
+    tax_id UUID NOT NULL REFERENCES taxes(id),
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    rate INT NOT NULL,
+    taxable_amount BIGINT NOT NULL,
+    tax_amount BIGINT NOT NULL,
+    PRIMARY KEY (sale_id, tax_id)
+);
@@ -0,0 +1,11 @@
+-- a store without a template prints receipts with the defaults
+CREATE TABLE IF NOT EXISTS receipt_templates (
+    store_id UUID PRIMARY KEY REFERENCES stores(id),
+    paper_width INT NOT NULL DEFAULT 42 CHECK (paper_width >= 24 AND paper_width <= 64),
+    header TEXT NOT NULL DEFAULT '',
+    footer TEXT NOT NULL DEFAULT '',
+    show_cashier BOOLEAN NOT NULL DEFAULT true,
+    show_tax_breakdown BOOLEAN NOT NULL DEFAULT true,
+    show_loyalty BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMP WITH TIME ZONE NULL
+);
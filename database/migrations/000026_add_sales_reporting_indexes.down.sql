@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sale_return_items_sale_item_id;
+DROP INDEX IF EXISTS idx_sales_sold_at;
@@ -0,0 +1,3 @@
+-- reports over every store scan sales by time, refunds are summed per sold line
+CREATE INDEX IF NOT EXISTS idx_sales_sold_at ON sales(sold_at);
+CREATE INDEX IF NOT EXISTS idx_sale_return_items_sale_item_id ON sale_return_items(sale_item_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sales_summary_queue;
+DROP TABLE IF EXISTS daily_product_sales;
+DROP TABLE IF EXISTS daily_store_sales;
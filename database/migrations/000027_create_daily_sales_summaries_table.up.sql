@@ -0,0 +1,54 @@
+-- the completed sales of a store on a day in the store timezone, a day is recomputed from its sales
+-- whenever it is queued so the rows never drift from the sales. Refunds count on the day of the sale.
+CREATE TABLE IF NOT EXISTS daily_store_sales (
+    store_id UUID NOT NULL REFERENCES stores(id),
+    day DATE NOT NULL,
+    sales_count INT NOT NULL DEFAULT 0,
+    quantity BIGINT NOT NULL DEFAULT 0,
+    returned_quantity BIGINT NOT NULL DEFAULT 0,
+    gross_sales BIGINT NOT NULL DEFAULT 0,
+    discount BIGINT NOT NULL DEFAULT 0,
+    tax BIGINT NOT NULL DEFAULT 0,
+    net_sales BIGINT NOT NULL DEFAULT 0,
+    refunded BIGINT NOT NULL DEFAULT 0,
+    cost BIGINT NOT NULL DEFAULT 0,
+    margin BIGINT GENERATED ALWAYS AS (net_sales - cost) STORED,
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (store_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_store_sales_day ON daily_store_sales(day);
+
+CREATE TABLE IF NOT EXISTS daily_product_sales (
+    store_id UUID NOT NULL REFERENCES stores(id),
+    day DATE NOT NULL,
+    product_id UUID NOT NULL REFERENCES products(id),
+    sales_count INT NOT NULL DEFAULT 0,
+    quantity BIGINT NOT NULL DEFAULT 0,
+    returned_quantity BIGINT NOT NULL DEFAULT 0,
+    gross_sales BIGINT NOT NULL DEFAULT 0,
+    discount BIGINT NOT NULL DEFAULT 0,
+    tax BIGINT NOT NULL DEFAULT 0,
+    net_sales BIGINT NOT NULL DEFAULT 0,
+    refunded BIGINT NOT NULL DEFAULT 0,
+    cost BIGINT NOT NULL DEFAULT 0,
+    margin BIGINT GENERATED ALWAYS AS (net_sales - cost) STORED,
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (store_id, day, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_product_sales_day ON daily_product_sales(day);
+
+-- days waiting to be recomputed, queued in the transaction that changed their sales
+CREATE TABLE IF NOT EXISTS sales_summary_queue (
+    store_id UUID NOT NULL REFERENCES stores(id),
+    day DATE NOT NULL,
+    queued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (store_id, day)
+);
+
+INSERT INTO sales_summary_queue (store_id, day)
+SELECT DISTINCT s.store_id, (s.sold_at AT TIME ZONE st.timezone)::date
+FROM sales s
+JOIN stores st ON st.id = s.store_id
+ON CONFLICT DO NOTHING;
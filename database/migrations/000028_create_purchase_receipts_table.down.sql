@@ -0,0 +1,7 @@
+ALTER TABLE sales DROP COLUMN IF EXISTS cost;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS cost, DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE stock_transfer_items DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE inventories DROP COLUMN IF EXISTS average_cost;
+DROP TABLE IF EXISTS purchase_receipt_items;
+DROP TABLE IF EXISTS purchase_receipts;
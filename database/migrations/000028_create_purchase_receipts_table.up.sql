@@ -0,0 +1,35 @@
+-- goods received into a store, the unit cost of every line moves the average cost of the product in the store
+CREATE TABLE IF NOT EXISTS purchase_receipts (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL REFERENCES stores(id),
+    supplier_id UUID NULL REFERENCES suppliers(id),
+    -- the delivery note or invoice number of the supplier
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    received_by UUID NULL REFERENCES users(id),
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_receipts_store_id_received_at ON purchase_receipts(store_id, received_at);
+
+CREATE TABLE IF NOT EXISTS purchase_receipt_items (
+    receipt_id UUID NOT NULL REFERENCES purchase_receipts(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_cost BIGINT NOT NULL CHECK (unit_cost >= 0),
+    PRIMARY KEY (receipt_id, product_id)
+);
+
+-- weighted average cost of the stock on hand, zero until stock comes in with a cost
+ALTER TABLE inventories ADD COLUMN IF NOT EXISTS average_cost BIGINT NOT NULL DEFAULT 0;
+
+-- unit cost of the stock a movement brought in, null when it came in at the average cost
+ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS unit_cost BIGINT NULL;
+
+-- average cost of the source store when the transfer was shipped
+ALTER TABLE stock_transfer_items ADD COLUMN IF NOT EXISTS unit_cost BIGINT NULL;
+
+-- cost of the goods sold, stamped from the average cost when the stock is taken
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS unit_cost BIGINT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS cost BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS cost BIGINT NOT NULL DEFAULT 0;
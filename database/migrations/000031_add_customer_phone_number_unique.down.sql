@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_customers_phone_number;
+CREATE INDEX IF NOT EXISTS idx_customers_phone_number ON customers(phone_number) WHERE deleted_at IS NULL;
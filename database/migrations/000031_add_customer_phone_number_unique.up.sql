@@ -0,0 +1,4 @@
+-- two customers created at the same time with the same phone number both passed the check of the service,
+-- the index refuses the second one. Duplicates left from before have to be merged before this runs.
+DROP INDEX IF EXISTS idx_customers_phone_number;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone_number ON customers(phone_number) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS sale_return_payment_refunds;
+DROP INDEX IF EXISTS idx_sale_returns_pending;
+DROP INDEX IF EXISTS idx_sale_returns_sale_id_idempotency_key;
+ALTER TABLE sale_returns DROP COLUMN IF EXISTS idempotency_key, DROP COLUMN IF EXISTS status;
@@ -0,0 +1,20 @@
+-- a return is saved before its card and e-wallet refunds go to the provider, it stays pending until all of them went through.
+-- The idempotency key lets the client retry a return without refunding twice.
+ALTER TABLE sale_returns
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed',
+    ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(100) NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sale_returns_sale_id_idempotency_key ON sale_returns(sale_id, idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_sale_returns_pending ON sale_returns(returned_at) WHERE status = 'pending';
+
+-- the part of a return given back on one payment of the sale, the id is sent to the provider with the refund
+CREATE TABLE IF NOT EXISTS sale_return_payment_refunds (
+    id UUID PRIMARY KEY,
+    return_id UUID NOT NULL REFERENCES sale_returns(id),
+    payment_id UUID NOT NULL REFERENCES sale_payments(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL,
+    refunded_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_return_payment_refunds_return_id ON sale_return_payment_refunds(return_id);
@@ -0,0 +1,17 @@
+package category
+
+import "github.com/google/uuid"
+
+type CreateCategoryRequest struct {
+	Name     string     `json:"name"`
+	Slug     string     `json:"slug"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type UpdateCategoryRequest struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Slug string    `json:"slug"`
+	// ParentID moves the category, uuid.Nil moves it to the top level
+	ParentID *uuid.UUID `json:"parent_id"`
+}
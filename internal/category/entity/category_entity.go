@@ -0,0 +1,18 @@
+package category
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Category struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Slug      string     `json:"slug" db:"slug"`
+	ParentID  *uuid.UUID `json:"parent_id" db:"parent_id"`
+	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"-" db:"updated_at"`
+	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+	Children  []Category `json:"children" db:"-"`
+}
@@ -0,0 +1,172 @@
+package category
+
+import (
+	dto "candyshop/internal/category/dto"
+	service "candyshop/internal/category/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CategoryHandler struct {
+	service service.CategoryService
+}
+
+func NewCategoryHandler(service service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service}
+}
+
+func (h *CategoryHandler) GetCategoryTree(c *fiber.Ctx) error {
+	categories, errCategory := h.service.GetCategoryTree()
+	if errCategory != nil {
+		return c.Status(errCategory.StatusCode).JSON(fiber.Map{
+			"status_code": errCategory.StatusCode,
+			"message":     "failed to fetch categories",
+			"error":       errCategory.Error.Error(),
+		})
+	}
+
+	if len(categories) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch categories",
+			"error":       "category not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data categories",
+		"data":        categories,
+	})
+}
+
+func (h *CategoryHandler) GetCategoryByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	category, errCategory := h.service.GetCategoryByID(parseID)
+	if errCategory != nil {
+		return c.Status(errCategory.StatusCode).JSON(fiber.Map{
+			"status_code": errCategory.StatusCode,
+			"message":     "failed to fetch category",
+			"error":       errCategory.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data category",
+		"data":        category,
+	})
+}
+
+func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
+	var req dto.CreateCategoryRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data category",
+			"error":       err.Error(),
+		})
+	}
+
+	category, errCategory := h.service.CreateCategory(req)
+	if errCategory != nil {
+		if errCategory.StatusCode == fiber.StatusConflict {
+			return c.Status(errCategory.StatusCode).JSON(fiber.Map{
+				"status_code": errCategory.StatusCode,
+				"message":     "failed to create category",
+				"error":       errCategory.Message,
+			})
+		}
+
+		return c.Status(errCategory.StatusCode).JSON(fiber.Map{
+			"status_code": errCategory.StatusCode,
+			"message":     "failed to create category",
+			"error":       errCategory.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data category",
+		"data":        category,
+	})
+}
+
+func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	var req dto.UpdateCategoryRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data category",
+			"error":       err.Error(),
+		})
+	}
+
+	errUpdate := h.service.UpdateCategory(req)
+	if errUpdate != nil {
+		if errUpdate.StatusCode == fiber.StatusConflict {
+			return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+				"status_code": errUpdate.StatusCode,
+				"message":     "failed to update category",
+				"error":       errUpdate.Message,
+			})
+		}
+
+		return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+			"status_code": errUpdate.StatusCode,
+			"message":     "failed to update category",
+			"error":       errUpdate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success update data category",
+		"data":        nil,
+	})
+}
+
+func (h *CategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errDelete := h.service.DeleteCategory(parseID)
+	if errDelete != nil {
+		if errDelete.StatusCode == fiber.StatusConflict {
+			return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+				"status_code": errDelete.StatusCode,
+				"message":     "failed to delete category",
+				"error":       errDelete.Message,
+			})
+		}
+
+		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+			"status_code": errDelete.StatusCode,
+			"message":     "failed to delete category",
+			"error":       errDelete.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete data category",
+		"data":        nil,
+	})
+}
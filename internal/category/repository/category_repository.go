@@ -0,0 +1,253 @@
+package category
+
+import (
+	entity "candyshop/internal/category/entity"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+type CategoryRepository interface {
+	GetAllCategory() ([]entity.Category, *response.Error)
+	GetCategoryByID(id uuid.UUID) (*entity.Category, *response.Error)
+	GetCategoryBySlug(slug string) (*entity.Category, *response.Error)
+	CreateCategory(data entity.Category) (*entity.Category, *response.Error)
+	UpdateCategory(data entity.Category) *response.Error
+	DeleteCategory(id uuid.UUID, deletedAt time.Time) *response.Error
+	CountCategoryUsage(id uuid.UUID) (int, int, *response.Error)
+}
+
+type categoryRepository struct {
+	db *sqlx.DB
+}
+
+// GetAllCategory implements CategoryRepository.
+func (c *categoryRepository) GetAllCategory() ([]entity.Category, *response.Error) {
+	var categories []entity.Category
+
+	query := `
+		SELECT id, name, slug, parent_id, created_at, updated_at, deleted_at
+		FROM categories
+		WHERE deleted_at IS NULL
+		ORDER BY name, id
+	`
+
+	err := c.db.Select(&categories, query)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get all category").Msg("failed to get all category")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch categories",
+			Error:      err,
+		}
+	}
+
+	return categories, nil
+}
+
+// GetCategoryByID implements CategoryRepository.
+func (c *categoryRepository) GetCategoryByID(id uuid.UUID) (*entity.Category, *response.Error) {
+	return c.getCategory("get category by id", `WHERE id = $1`, id)
+}
+
+// GetCategoryBySlug implements CategoryRepository.
+func (c *categoryRepository) GetCategoryBySlug(slug string) (*entity.Category, *response.Error) {
+	return c.getCategory("get category by slug", `WHERE slug = $1`, slug)
+}
+
+func (c *categoryRepository) getCategory(function string, where string, arg any) (*entity.Category, *response.Error) {
+	var category entity.Category
+
+	query := `SELECT id, name, slug, parent_id, created_at, updated_at, deleted_at FROM categories ` + where
+
+	err := c.db.Get(&category, query, arg)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch category",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch category",
+			Error:      err,
+		}
+	}
+
+	return &category, nil
+}
+
+// CreateCategory implements CategoryRepository.
+func (c *categoryRepository) CreateCategory(data entity.Category) (*entity.Category, *response.Error) {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create category").Msg("failed to create category")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO categories (id, name, slug, parent_id) VALUES ($1, $2, $3, $4)
+		RETURNING id, name, slug, parent_id, created_at
+	`
+
+	var model entity.Category
+
+	errInsert := tx.QueryRowx(query,
+		data.ID,
+		data.Name,
+		data.Slug,
+		data.ParentID).Scan(&model.ID,
+		&model.Name,
+		&model.Slug,
+		&model.ParentID,
+		&model.CreatedAt)
+
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "create category").Msg("failed to create category")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create category",
+			Error:      errInsert,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create category").Msg("failed to create category")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// UpdateCategory implements CategoryRepository.
+func (c *categoryRepository) UpdateCategory(data entity.Category) *response.Error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update category").Msg("failed to update category")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE categories SET name = $2, slug = $3, parent_id = $4, updated_at = $5 WHERE id = $1`
+
+	_, errExec := tx.Exec(query,
+		data.ID,
+		data.Name,
+		data.Slug,
+		data.ParentID,
+		data.UpdatedAt,
+	)
+
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "update category").Msg("failed to update category")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update category",
+			Error:      errExec,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update category").Msg("failed to update category")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// DeleteCategory implements CategoryRepository.
+func (c *categoryRepository) DeleteCategory(id uuid.UUID, deletedAt time.Time) *response.Error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete category").Msg("failed to delete category")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the slug is released so a new category can take it
+	query := `UPDATE categories SET deleted_at = $2, slug = slug || '-deleted-' || id WHERE id = $1`
+
+	_, errExec := tx.Exec(query, id, deletedAt)
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "delete category").Msg("failed to delete category")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete category",
+			Error:      errExec,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete category").Msg("failed to delete category")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// CountCategoryUsage implements CategoryRepository.
+func (c *categoryRepository) CountCategoryUsage(id uuid.UUID) (int, int, *response.Error) {
+	var usage struct {
+		Children int `db:"children"`
+		Products int `db:"products"`
+	}
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL) AS children,
+			(SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL) AS products
+	`
+
+	err := c.db.Get(&usage, query, id)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "count category usage").Msg("failed to count category usage")
+		return 0, 0, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch category",
+			Error:      err,
+		}
+	}
+
+	return usage.Children, usage.Products, nil
+}
+
+func NewCategoryRepository(db *sqlx.DB) CategoryRepository {
+	return &categoryRepository{db}
+}
@@ -0,0 +1,24 @@
+package category
+
+import (
+	handler "candyshop/internal/category/handler"
+	repository "candyshop/internal/category/repository"
+	service "candyshop/internal/category/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewCategoryRepository(db)
+	service := service.NewCategoryService(repo)
+	handler := handler.NewCategoryHandler(service)
+
+	categoryRoute := router.Group("api/v1/categories")
+
+	categoryRoute.Get("", handler.GetCategoryTree)
+	categoryRoute.Post("", handler.CreateCategory)
+	categoryRoute.Get("/:id", handler.GetCategoryByID)
+	categoryRoute.Patch("", handler.UpdateCategory)
+	categoryRoute.Patch("/delete/:id", handler.DeleteCategory)
+}
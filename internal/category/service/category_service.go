@@ -0,0 +1,297 @@
+package category
+
+import (
+	dto "candyshop/internal/category/dto"
+	entity "candyshop/internal/category/entity"
+	repository "candyshop/internal/category/repository"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CategoryService interface {
+	GetCategoryTree() ([]entity.Category, *response.Error)
+	GetCategoryByID(id uuid.UUID) (*entity.Category, *response.Error)
+	CreateCategory(data dto.CreateCategoryRequest) (*entity.Category, *response.Error)
+	UpdateCategory(data dto.UpdateCategoryRequest) *response.Error
+	DeleteCategory(id uuid.UUID) *response.Error
+}
+
+type categoryService struct {
+	repository repository.CategoryRepository
+}
+
+// GetCategoryTree implements CategoryService.
+func (c *categoryService) GetCategoryTree() ([]entity.Category, *response.Error) {
+	categories, errCategory := c.repository.GetAllCategory()
+	if errCategory != nil {
+		return nil, errCategory
+	}
+
+	return buildTree(categories, nil), nil
+}
+
+// GetCategoryByID implements CategoryService.
+func (c *categoryService) GetCategoryByID(id uuid.UUID) (*entity.Category, *response.Error) {
+	category, errCategory := c.repository.GetCategoryByID(id)
+	if errCategory != nil {
+		return nil, errCategory
+	}
+
+	if category.DeletedAt != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusNotFound,
+			Message:    "category is not active",
+			Error:      errors.New("category is not active"),
+		}
+	}
+
+	categories, errCategories := c.repository.GetAllCategory()
+	if errCategories != nil {
+		return nil, errCategories
+	}
+
+	category.Children = buildTree(categories, &category.ID)
+
+	return category, nil
+}
+
+// CreateCategory implements CategoryService.
+func (c *categoryService) CreateCategory(data dto.CreateCategoryRequest) (*entity.Category, *response.Error) {
+	data.Name = strings.TrimSpace(data.Name)
+	if data.Name == "" {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "name is required",
+			Error:      errors.New("name is required"),
+		}
+	}
+
+	if data.ParentID != nil {
+		if errParent := c.checkParent(*data.ParentID, uuid.Nil); errParent != nil {
+			return nil, errParent
+		}
+	}
+
+	slug, errSlug := c.checkSlug(data.Slug, data.Name, uuid.Nil)
+	if errSlug != nil {
+		return nil, errSlug
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataCategory := &entity.Category{
+		ID:       newUUID,
+		Name:     data.Name,
+		Slug:     slug,
+		ParentID: data.ParentID,
+	}
+
+	return c.repository.CreateCategory(*dataCategory)
+}
+
+// UpdateCategory implements CategoryService.
+func (c *categoryService) UpdateCategory(data dto.UpdateCategoryRequest) *response.Error {
+	// check if category is exist
+	category, errCategory := c.repository.GetCategoryByID(data.ID)
+	if errCategory != nil {
+		return errCategory
+	}
+
+	if category.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "category is not active",
+			Error:      nil,
+		}
+	}
+
+	if strings.TrimSpace(data.Name) != "" {
+		category.Name = strings.TrimSpace(data.Name)
+	}
+
+	if data.Slug != "" {
+		slug, errSlug := c.checkSlug(data.Slug, category.Name, category.ID)
+		if errSlug != nil {
+			return errSlug
+		}
+
+		category.Slug = slug
+	}
+
+	if data.ParentID != nil {
+		if *data.ParentID == uuid.Nil {
+			category.ParentID = nil
+		} else {
+			if errParent := c.checkParent(*data.ParentID, category.ID); errParent != nil {
+				return errParent
+			}
+
+			category.ParentID = data.ParentID
+		}
+	}
+
+	currentTime := time.Now()
+	category.UpdatedAt = &currentTime
+
+	return c.repository.UpdateCategory(*category)
+}
+
+// DeleteCategory implements CategoryService.
+func (c *categoryService) DeleteCategory(id uuid.UUID) *response.Error {
+	category, errCategory := c.repository.GetCategoryByID(id)
+	if errCategory != nil {
+		return errCategory
+	}
+
+	// check if category is deleted
+	if category.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "category is not active",
+			Error:      nil,
+		}
+	}
+
+	children, products, errUsage := c.repository.CountCategoryUsage(id)
+	if errUsage != nil {
+		return errUsage
+	}
+
+	if children > 0 || products > 0 {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("category still has %d sub categories and %d products", children, products),
+			Error:      nil,
+		}
+	}
+
+	currentTime := time.Now()
+
+	return c.repository.DeleteCategory(id, currentTime)
+}
+
+// checkParent makes sure the parent is active and is not the category itself or one of its descendants.
+func (c *categoryService) checkParent(parentID uuid.UUID, id uuid.UUID) *response.Error {
+	categories, errCategory := c.repository.GetAllCategory()
+	if errCategory != nil {
+		return errCategory
+	}
+
+	parents := map[uuid.UUID]*uuid.UUID{}
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	if _, ok := parents[parentID]; !ok {
+		return &response.Error{
+			StatusCode: fiber.StatusNotFound,
+			Message:    "parent category not found",
+			Error:      errors.New("parent category not found"),
+		}
+	}
+
+	// walk up from the new parent, meeting the category itself means a cycle
+	for current := &parentID; current != nil; current = parents[*current] {
+		if *current == id {
+			return &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    "category can not be moved under itself",
+				Error:      errors.New("category can not be moved under itself"),
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkSlug builds the slug from the name when it is empty and checks that no other category uses it.
+func (c *categoryService) checkSlug(slug string, name string, id uuid.UUID) (string, *response.Error) {
+	if slug == "" {
+		slug = name
+	}
+
+	slug = slugify(slug)
+	if slug == "" {
+		return "", &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "slug is invalid",
+			Error:      errors.New("slug is invalid"),
+		}
+	}
+
+	checkSlug, errSlug := c.repository.GetCategoryBySlug(slug)
+	if errSlug != nil && errSlug.StatusCode != 404 {
+		return "", errSlug
+	}
+
+	if checkSlug != nil && checkSlug.ID != id {
+		return "", &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("slug %s already registered", slug),
+			Error:      nil,
+		}
+	}
+
+	return slug, nil
+}
+
+// slugify lower cases the text and joins its letters and digits with dashes, Dark Chocolate becomes dark-chocolate.
+func slugify(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+
+		dash = true
+	}
+
+	return b.String()
+}
+
+// buildTree nests the categories under the given parent, nil builds the whole tree.
+func buildTree(categories []entity.Category, parentID *uuid.UUID) []entity.Category {
+	children := map[uuid.UUID][]entity.Category{}
+	var roots []entity.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var nest func(nodes []entity.Category) []entity.Category
+	nest = func(nodes []entity.Category) []entity.Category {
+		result := make([]entity.Category, len(nodes))
+		for i, node := range nodes {
+			node.Children = nest(children[node.ID])
+			result[i] = node
+		}
+
+		return result
+	}
+
+	if parentID != nil {
+		return nest(children[*parentID])
+	}
+
+	return nest(roots)
+}
+
+func NewCategoryService(repository repository.CategoryRepository) CategoryService {
+	return &categoryService{repository}
+}
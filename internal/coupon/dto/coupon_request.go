@@ -0,0 +1,31 @@
+package coupon
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateBatchRequest struct {
+	Name             string     `json:"name"`
+	DiscountType     string     `json:"discount_type"`
+	DiscountPercent  *float64   `json:"discount_percent"`
+	DiscountAmount   *int64     `json:"discount_amount"`
+	MaxDiscount      *int64     `json:"max_discount"`
+	MinSpend         int64      `json:"min_spend"`
+	UsageLimit       int        `json:"usage_limit"`
+	PerCustomerLimit *int       `json:"per_customer_limit"`
+	StoreID          *uuid.UUID `json:"store_id"`
+	StartsAt         *time.Time `json:"starts_at"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	// Quantity is the number of codes to generate
+	Quantity int `json:"quantity"`
+}
+
+type RedeemCouponRequest struct {
+	Code        string     `json:"code"`
+	StoreID     uuid.UUID  `json:"store_id"`
+	CustomerID  *uuid.UUID `json:"customer_id"`
+	OrderAmount int64      `json:"order_amount"`
+	Reference   string     `json:"reference"`
+}
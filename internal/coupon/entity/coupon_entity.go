@@ -0,0 +1,75 @@
+package coupon
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// discount type of a coupon batch, the discount is taken off the order amount
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+)
+
+// Batch is a set of coupon codes issued together with the same rules.
+type Batch struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	Name             string     `json:"name" db:"name"`
+	DiscountType     string     `json:"discount_type" db:"discount_type"`
+	DiscountPercent  *float64   `json:"discount_percent" db:"discount_percent"`
+	DiscountAmount   *int64     `json:"discount_amount" db:"discount_amount"`
+	MaxDiscount      *int64     `json:"max_discount" db:"max_discount"`
+	MinSpend         int64      `json:"min_spend" db:"min_spend"`
+	UsageLimit       int        `json:"usage_limit" db:"usage_limit"`
+	PerCustomerLimit *int       `json:"per_customer_limit" db:"per_customer_limit"`
+	StoreID          *uuid.UUID `json:"store_id" db:"store_id"`
+	StartsAt         time.Time  `json:"starts_at" db:"starts_at"`
+	ExpiresAt        *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedBy        *uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt        *time.Time `json:"created_at" db:"created_at"`
+	Coupons          []Coupon   `json:"coupons,omitempty" db:"-"`
+}
+
+type Coupon struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	BatchID    uuid.UUID  `json:"batch_id" db:"batch_id"`
+	Code       string     `json:"code" db:"code"`
+	UsageCount int        `json:"usage_count" db:"usage_count"`
+	CreatedAt  *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time `json:"-" db:"updated_at"`
+}
+
+type Redemption struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	CouponID    uuid.UUID  `json:"coupon_id" db:"coupon_id"`
+	Code        string     `json:"code" db:"code"`
+	CustomerID  *uuid.UUID `json:"customer_id" db:"customer_id"`
+	StoreID     uuid.UUID  `json:"store_id" db:"store_id"`
+	OrderAmount int64      `json:"order_amount" db:"order_amount"`
+	Discount    int64      `json:"discount" db:"discount"`
+	Reference   string     `json:"reference" db:"reference"`
+	RedeemedBy  *uuid.UUID `json:"redeemed_by" db:"redeemed_by"`
+	RedeemedAt  time.Time  `json:"redeemed_at" db:"redeemed_at"`
+}
+
+// Quote is the discount a coupon gives to an order without redeeming it.
+type Quote struct {
+	Code        string    `json:"code"`
+	BatchID     uuid.UUID `json:"batch_id"`
+	OrderAmount int64     `json:"order_amount"`
+	Discount    int64     `json:"discount"`
+	Total       int64     `json:"total"`
+}
+
+// BatchReport compares the issued codes of a batch with their redemptions.
+type BatchReport struct {
+	BatchID         uuid.UUID  `json:"batch_id" db:"batch_id"`
+	Name            string     `json:"name" db:"name"`
+	StoreID         *uuid.UUID `json:"store_id" db:"store_id"`
+	ExpiresAt       *time.Time `json:"expires_at" db:"expires_at"`
+	IssuedCount     int        `json:"issued_count" db:"issued_count"`
+	RedeemedCount   int        `json:"redeemed_count" db:"redeemed_count"`
+	RedemptionCount int        `json:"redemption_count" db:"redemption_count"`
+	TotalDiscount   int64      `json:"total_discount" db:"total_discount"`
+}
@@ -0,0 +1,188 @@
+package coupon
+
+import (
+	dto "candyshop/internal/coupon/dto"
+	service "candyshop/internal/coupon/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CouponHandler struct {
+	service service.CouponService
+}
+
+func NewCouponHandler(service service.CouponService) *CouponHandler {
+	return &CouponHandler{service}
+}
+
+func (h *CouponHandler) GetBatchReport(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	reports, errReport := h.service.GetBatchReport(auth.GetCaller(c), offset, limit)
+	if errReport != nil {
+		return c.Status(errReport.StatusCode).JSON(fiber.Map{
+			"status_code": errReport.StatusCode,
+			"message":     "failed to fetch coupon report",
+			"error":       errReport.Error.Error(),
+		})
+	}
+
+	if len(reports) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch coupon report",
+			"error":       "coupon batch not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get coupon report",
+		"data":        reports,
+	})
+}
+
+func (h *CouponHandler) GetBatchByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 100)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	batch, errBatch := h.service.GetBatchByID(auth.GetCaller(c), parseID, offset, limit)
+	if errBatch != nil {
+		return c.Status(errBatch.StatusCode).JSON(fiber.Map{
+			"status_code": errBatch.StatusCode,
+			"message":     "failed to fetch coupon batch",
+			"error":       errBatch.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data coupon batch",
+		"data":        batch,
+	})
+}
+
+func (h *CouponHandler) CreateBatch(c *fiber.Ctx) error {
+	var req dto.CreateBatchRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data coupon batch",
+			"error":       err.Error(),
+		})
+	}
+
+	batch, errBatch := h.service.CreateBatch(auth.GetCaller(c), req)
+	if errBatch != nil {
+		return c.Status(errBatch.StatusCode).JSON(fiber.Map{
+			"status_code": errBatch.StatusCode,
+			"message":     "failed to create coupon batch",
+			"error":       errBatch.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create coupon batch",
+		"data":        batch,
+	})
+}
+
+func (h *CouponHandler) QuoteCoupon(c *fiber.Ctx) error {
+	var req dto.RedeemCouponRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data coupon",
+			"error":       err.Error(),
+		})
+	}
+
+	quote, errQuote := h.service.QuoteCoupon(auth.GetCaller(c), req)
+	if errQuote != nil {
+		if errQuote.StatusCode == fiber.StatusConflict {
+			return c.Status(errQuote.StatusCode).JSON(fiber.Map{
+				"status_code": errQuote.StatusCode,
+				"message":     "coupon can not be used",
+				"error":       errQuote.Message,
+			})
+		}
+
+		return c.Status(errQuote.StatusCode).JSON(fiber.Map{
+			"status_code": errQuote.StatusCode,
+			"message":     "coupon can not be used",
+			"error":       errQuote.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success quote coupon",
+		"data":        quote,
+	})
+}
+
+func (h *CouponHandler) RedeemCoupon(c *fiber.Ctx) error {
+	var req dto.RedeemCouponRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data coupon",
+			"error":       err.Error(),
+		})
+	}
+
+	redemption, errRedeem := h.service.RedeemCoupon(auth.GetCaller(c), req)
+	if errRedeem != nil {
+		if errRedeem.StatusCode == fiber.StatusConflict {
+			return c.Status(errRedeem.StatusCode).JSON(fiber.Map{
+				"status_code": errRedeem.StatusCode,
+				"message":     "failed to redeem coupon",
+				"error":       errRedeem.Message,
+			})
+		}
+
+		return c.Status(errRedeem.StatusCode).JSON(fiber.Map{
+			"status_code": errRedeem.StatusCode,
+			"message":     "failed to redeem coupon",
+			"error":       errRedeem.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success redeem coupon",
+		"data":        redemption,
+	})
+}
@@ -0,0 +1,376 @@
+package coupon
+
+import (
+	entity "candyshop/internal/coupon/entity"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// QuoteFunc checks a coupon against its batch and the number of times the customer used it,
+// and returns the discount for the order.
+type QuoteFunc func(batch entity.Batch, coupon entity.Coupon, customerUsage int) (int64, *response.Error)
+
+// maxCodeAttempts bounds the retries when a generated code is already taken
+const maxCodeAttempts = 5
+
+type CouponRepository interface {
+	GetBatchReport(storeIDs []uuid.UUID, offset, limit int) ([]entity.BatchReport, *response.Error)
+	GetBatchByID(id uuid.UUID) (*entity.Batch, *response.Error)
+	GetCouponByBatch(batchID uuid.UUID, offset, limit int) ([]entity.Coupon, *response.Error)
+	CreateBatch(data entity.Batch, quantity int, newCode func() (string, error)) (*entity.Batch, *response.Error)
+	QuoteCoupon(code string, customerID *uuid.UUID, quote QuoteFunc) (*entity.Coupon, int64, *response.Error)
+	RedeemCoupon(data entity.Redemption, quote QuoteFunc) (*entity.Redemption, *response.Error)
+}
+
+type couponRepository struct {
+	db *sqlx.DB
+}
+
+const batchColumns = `id, name, discount_type, discount_percent, discount_amount, max_discount, min_spend, usage_limit,
+		per_customer_limit, store_id, starts_at, expires_at, created_by, created_at`
+
+// GetBatchReport implements CouponRepository.
+func (c *couponRepository) GetBatchReport(storeIDs []uuid.UUID, offset int, limit int) ([]entity.BatchReport, *response.Error) {
+	var reports []entity.BatchReport
+
+	// nil store ids means the caller is not limited to some stores
+	query := `
+		SELECT b.id AS batch_id, b.name, b.store_id, b.expires_at,
+			COUNT(c.id) AS issued_count,
+			COUNT(c.id) FILTER (WHERE c.usage_count > 0) AS redeemed_count,
+			COALESCE(SUM(r.redemption_count), 0) AS redemption_count,
+			COALESCE(SUM(r.total_discount), 0) AS total_discount
+		FROM coupon_batches b
+		LEFT JOIN coupons c ON c.batch_id = b.id
+		LEFT JOIN (
+			SELECT coupon_id, COUNT(*) AS redemption_count, SUM(discount) AS total_discount
+			FROM coupon_redemptions
+			GROUP BY coupon_id
+		) r ON r.coupon_id = c.id
+		WHERE $3::uuid[] IS NULL OR b.store_id = ANY($3)
+		GROUP BY b.id
+		ORDER BY b.created_at DESC, b.id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := c.db.Select(&reports, query, limit, offset, pq.Array(storeIDs))
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get batch report").Msg("failed to get batch report")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch coupon report",
+			Error:      err,
+		}
+	}
+
+	return reports, nil
+}
+
+// GetBatchByID implements CouponRepository.
+func (c *couponRepository) GetBatchByID(id uuid.UUID) (*entity.Batch, *response.Error) {
+	var batch entity.Batch
+
+	err := c.db.Get(&batch, `SELECT `+batchColumns+` FROM coupon_batches WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("status", 404).Str("function", "get batch by id").Msg("failed to get batch by id")
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch coupon batch",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get batch by id").Msg("failed to get batch by id")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch coupon batch",
+			Error:      err,
+		}
+	}
+
+	return &batch, nil
+}
+
+// GetCouponByBatch implements CouponRepository.
+func (c *couponRepository) GetCouponByBatch(batchID uuid.UUID, offset int, limit int) ([]entity.Coupon, *response.Error) {
+	var coupons []entity.Coupon
+
+	query := `
+		SELECT id, batch_id, code, usage_count, created_at, updated_at
+		FROM coupons
+		WHERE batch_id = $1
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`
+
+	err := c.db.Select(&coupons, query, batchID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get coupon by batch").Msg("failed to get coupon by batch")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch coupons",
+			Error:      err,
+		}
+	}
+
+	return coupons, nil
+}
+
+// CreateBatch implements CouponRepository.
+func (c *couponRepository) CreateBatch(data entity.Batch, quantity int, newCode func() (string, error)) (*entity.Batch, *response.Error) {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create batch").Msg("failed to create batch")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO coupon_batches (id, name, discount_type, discount_percent, discount_amount, max_discount, min_spend, usage_limit,
+			per_customer_limit, store_id, starts_at, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING ` + batchColumns
+
+	var model entity.Batch
+
+	errInsert := tx.QueryRowx(query,
+		data.ID,
+		data.Name,
+		data.DiscountType,
+		data.DiscountPercent,
+		data.DiscountAmount,
+		data.MaxDiscount,
+		data.MinSpend,
+		data.UsageLimit,
+		data.PerCustomerLimit,
+		data.StoreID,
+		data.StartsAt,
+		data.ExpiresAt,
+		data.CreatedBy).StructScan(&model)
+
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "create batch").Msg("failed to create batch")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create coupon batch",
+			Error:      errInsert,
+		}
+	}
+
+	for range quantity {
+		coupon, errCoupon := insertCoupon(tx, model.ID, newCode)
+		if errCoupon != nil {
+			return nil, errCoupon
+		}
+
+		model.Coupons = append(model.Coupons, *coupon)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create batch").Msg("failed to create batch")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// QuoteCoupon implements CouponRepository.
+func (c *couponRepository) QuoteCoupon(code string, customerID *uuid.UUID, quote QuoteFunc) (*entity.Coupon, int64, *response.Error) {
+	coupon, batch, customerUsage, errLoad := loadCoupon(c.db, code, customerID, false)
+	if errLoad != nil {
+		return nil, 0, errLoad
+	}
+
+	discount, errQuote := quote(*batch, *coupon, customerUsage)
+	if errQuote != nil {
+		return nil, 0, errQuote
+	}
+
+	return coupon, discount, nil
+}
+
+// RedeemCoupon implements CouponRepository.
+func (c *couponRepository) RedeemCoupon(data entity.Redemption, quote QuoteFunc) (*entity.Redemption, *response.Error) {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "redeem coupon").Msg("failed to redeem coupon")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the coupon row stays locked until commit, concurrent checkouts with the same code wait here
+	// and see the usage of the checkout before them
+	coupon, batch, customerUsage, errLoad := loadCoupon(tx, data.Code, data.CustomerID, true)
+	if errLoad != nil {
+		return nil, errLoad
+	}
+
+	discount, errQuote := quote(*batch, *coupon, customerUsage)
+	if errQuote != nil {
+		return nil, errQuote
+	}
+
+	model := data
+	model.CouponID = coupon.ID
+	model.Code = coupon.Code
+	model.Discount = discount
+
+	_, errInsert := tx.Exec(`
+		INSERT INTO coupon_redemptions (id, coupon_id, customer_id, store_id, order_amount, discount, reference, redeemed_by, redeemed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, model.ID, model.CouponID, model.CustomerID, model.StoreID, model.OrderAmount, model.Discount, model.Reference, model.RedeemedBy, model.RedeemedAt)
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "redeem coupon").Msg("failed to insert redemption")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to redeem coupon",
+			Error:      errInsert,
+		}
+	}
+
+	_, errUpdate := tx.Exec(`UPDATE coupons SET usage_count = usage_count + 1, updated_at = $2 WHERE id = $1`, coupon.ID, model.RedeemedAt)
+	if errUpdate != nil {
+		log.Error().Err(errUpdate).Int("status", 500).Str("function", "redeem coupon").Msg("failed to update coupon usage")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to redeem coupon",
+			Error:      errUpdate,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "redeem coupon").Msg("failed to redeem coupon")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// loadCoupon reads a coupon with its batch and how many times the customer redeemed it.
+func loadCoupon(q sqlx.Queryer, code string, customerID *uuid.UUID, lock bool) (*entity.Coupon, *entity.Batch, int, *response.Error) {
+	query := `SELECT id, batch_id, code, usage_count, created_at, updated_at FROM coupons WHERE code = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	var coupon entity.Coupon
+
+	errCoupon := sqlx.Get(q, &coupon, query, code)
+	if errCoupon != nil {
+		if errors.Is(errCoupon, sql.ErrNoRows) {
+			return nil, nil, 0, &response.Error{
+				StatusCode: 404,
+				Message:    "coupon not found",
+				Error:      errors.New("coupon not found"),
+			}
+		}
+
+		log.Error().Err(errCoupon).Int("status", 500).Str("function", "load coupon").Msg("failed to get coupon")
+		return nil, nil, 0, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch coupon",
+			Error:      errCoupon,
+		}
+	}
+
+	var batch entity.Batch
+
+	errBatch := sqlx.Get(q, &batch, `SELECT `+batchColumns+` FROM coupon_batches WHERE id = $1`, coupon.BatchID)
+	if errBatch != nil {
+		log.Error().Err(errBatch).Int("status", 500).Str("function", "load coupon").Msg("failed to get coupon batch")
+		return nil, nil, 0, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch coupon batch",
+			Error:      errBatch,
+		}
+	}
+
+	customerUsage := 0
+	if customerID != nil {
+		errUsage := sqlx.Get(q, &customerUsage, `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND customer_id = $2`, coupon.ID, *customerID)
+		if errUsage != nil {
+			log.Error().Err(errUsage).Int("status", 500).Str("function", "load coupon").Msg("failed to count customer usage")
+			return nil, nil, 0, &response.Error{
+				StatusCode: 500,
+				Message:    "failed to fetch coupon usage",
+				Error:      errUsage,
+			}
+		}
+	}
+
+	return &coupon, &batch, customerUsage, nil
+}
+
+// insertCoupon stores a new code, a code that already exists is replaced by a fresh one.
+func insertCoupon(tx *sqlx.Tx, batchID uuid.UUID, newCode func() (string, error)) (*entity.Coupon, *response.Error) {
+	for range maxCodeAttempts {
+		code, errCode := newCode()
+		if errCode != nil {
+			log.Error().Err(errCode).Int("status", 500).Str("function", "insert coupon").Msg("failed to generate coupon code")
+			return nil, &response.Error{
+				StatusCode: 500,
+				Message:    "failed to generate coupon code",
+				Error:      errCode,
+			}
+		}
+
+		id, _ := uuid.NewV7()
+
+		var coupon entity.Coupon
+
+		errInsert := tx.QueryRowx(`
+			INSERT INTO coupons (id, batch_id, code) VALUES ($1, $2, $3)
+			ON CONFLICT (code) DO NOTHING
+			RETURNING id, batch_id, code, usage_count, created_at
+		`, id, batchID, code).Scan(&coupon.ID, &coupon.BatchID, &coupon.Code, &coupon.UsageCount, &coupon.CreatedAt)
+		if errors.Is(errInsert, sql.ErrNoRows) {
+			continue
+		}
+
+		if errInsert != nil {
+			log.Error().Err(errInsert).Int("status", 500).Str("function", "insert coupon").Msg("failed to insert coupon")
+			return nil, &response.Error{
+				StatusCode: 500,
+				Message:    "failed to create coupon",
+				Error:      errInsert,
+			}
+		}
+
+		return &coupon, nil
+	}
+
+	return nil, &response.Error{
+		StatusCode: 500,
+		Message:    "failed to generate unique coupon code",
+		Error:      errors.New("failed to generate unique coupon code"),
+	}
+}
+
+func NewCouponRepository(db *sqlx.DB) CouponRepository {
+	return &couponRepository{db}
+}
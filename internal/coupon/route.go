@@ -0,0 +1,25 @@
+package coupon
+
+import (
+	handler "candyshop/internal/coupon/handler"
+	repository "candyshop/internal/coupon/repository"
+	service "candyshop/internal/coupon/service"
+	staff "candyshop/internal/staff"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewCouponRepository(db)
+	service := service.NewCouponService(repo)
+	handler := handler.NewCouponHandler(service)
+
+	couponRoute := router.Group("api/v1/coupons", staff.Authenticate(db))
+
+	couponRoute.Get("/report", handler.GetBatchReport)
+	couponRoute.Post("/batches", handler.CreateBatch)
+	couponRoute.Get("/batches/:id", handler.GetBatchByID)
+	couponRoute.Post("/quote", handler.QuoteCoupon)
+	couponRoute.Post("/redeem", handler.RedeemCoupon)
+}
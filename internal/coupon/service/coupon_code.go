@@ -0,0 +1,55 @@
+package coupon
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// codeAlphabet leaves out 0, 1, I and O so printed codes can't be misread,
+// its 32 letters map a random byte without bias.
+const codeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// codeLength gives 60 random bits per code
+const (
+	codeLength    = 12
+	codeGroupSize = 4
+)
+
+// newCode generates a random code like 7KQ2-M9XD-4HTB from crypto/rand.
+func newCode() (string, error) {
+	random := make([]byte, codeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, codeLength)
+	for i, b := range random {
+		code[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+
+	return formatCode(string(code)), nil
+}
+
+// normalizeCode accepts a code typed in lower case, with spaces or without dashes.
+func normalizeCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+
+	return formatCode(b.String())
+}
+
+func formatCode(code string) string {
+	var b strings.Builder
+	for i, r := range code {
+		if i > 0 && i%codeGroupSize == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,307 @@
+package coupon
+
+import (
+	dto "candyshop/internal/coupon/dto"
+	entity "candyshop/internal/coupon/entity"
+	repository "candyshop/internal/coupon/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MaxBatchQuantity limits the codes generated by one request
+const MaxBatchQuantity = 10000
+
+type CouponService interface {
+	GetBatchReport(caller *auth.Caller, offset, limit int) ([]entity.BatchReport, *response.Error)
+	GetBatchByID(caller *auth.Caller, id uuid.UUID, offset, limit int) (*entity.Batch, *response.Error)
+	CreateBatch(caller *auth.Caller, data dto.CreateBatchRequest) (*entity.Batch, *response.Error)
+	QuoteCoupon(caller *auth.Caller, data dto.RedeemCouponRequest) (*entity.Quote, *response.Error)
+	RedeemCoupon(caller *auth.Caller, data dto.RedeemCouponRequest) (*entity.Redemption, *response.Error)
+}
+
+type couponService struct {
+	repository repository.CouponRepository
+}
+
+// GetBatchReport implements CouponService.
+func (c *couponService) GetBatchReport(caller *auth.Caller, offset int, limit int) ([]entity.BatchReport, *response.Error) {
+	return c.repository.GetBatchReport(caller.StoreScope(), offset, limit)
+}
+
+// GetBatchByID implements CouponService.
+func (c *couponService) GetBatchByID(caller *auth.Caller, id uuid.UUID, offset int, limit int) (*entity.Batch, *response.Error) {
+	batch, errBatch := c.repository.GetBatchByID(id)
+	if errBatch != nil {
+		return nil, errBatch
+	}
+
+	if errAccess := canManageBatch(caller, batch.StoreID); errAccess != nil {
+		return nil, errAccess
+	}
+
+	coupons, errCoupons := c.repository.GetCouponByBatch(id, offset, limit)
+	if errCoupons != nil {
+		return nil, errCoupons
+	}
+
+	batch.Coupons = coupons
+
+	return batch, nil
+}
+
+// CreateBatch implements CouponService.
+func (c *couponService) CreateBatch(caller *auth.Caller, data dto.CreateBatchRequest) (*entity.Batch, *response.Error) {
+	if errAccess := canManageBatch(caller, data.StoreID); errAccess != nil {
+		return nil, errAccess
+	}
+
+	startsAt := time.Now()
+	if data.StartsAt != nil {
+		startsAt = *data.StartsAt
+	}
+
+	if data.UsageLimit == 0 {
+		data.UsageLimit = 1
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataBatch := &entity.Batch{
+		ID:               newUUID,
+		Name:             data.Name,
+		DiscountType:     data.DiscountType,
+		DiscountPercent:  data.DiscountPercent,
+		DiscountAmount:   data.DiscountAmount,
+		MaxDiscount:      data.MaxDiscount,
+		MinSpend:         data.MinSpend,
+		UsageLimit:       data.UsageLimit,
+		PerCustomerLimit: data.PerCustomerLimit,
+		StoreID:          data.StoreID,
+		StartsAt:         startsAt,
+		ExpiresAt:        data.ExpiresAt,
+		CreatedBy:        &caller.UserID,
+	}
+
+	if errValidate := validateBatch(dataBatch, data.Quantity); errValidate != nil {
+		return nil, errValidate
+	}
+
+	return c.repository.CreateBatch(*dataBatch, data.Quantity, newCode)
+}
+
+// QuoteCoupon implements CouponService.
+func (c *couponService) QuoteCoupon(caller *auth.Caller, data dto.RedeemCouponRequest) (*entity.Quote, *response.Error) {
+	if errRequest := checkRedeemRequest(caller, data); errRequest != nil {
+		return nil, errRequest
+	}
+
+	coupon, discount, errQuote := c.repository.QuoteCoupon(normalizeCode(data.Code), data.CustomerID, quoteFunc(data, time.Now()))
+	if errQuote != nil {
+		return nil, errQuote
+	}
+
+	return &entity.Quote{
+		Code:        coupon.Code,
+		BatchID:     coupon.BatchID,
+		OrderAmount: data.OrderAmount,
+		Discount:    discount,
+		Total:       data.OrderAmount - discount,
+	}, nil
+}
+
+// RedeemCoupon implements CouponService.
+func (c *couponService) RedeemCoupon(caller *auth.Caller, data dto.RedeemCouponRequest) (*entity.Redemption, *response.Error) {
+	if errRequest := checkRedeemRequest(caller, data); errRequest != nil {
+		return nil, errRequest
+	}
+
+	currentTime := time.Now()
+	newUUID, _ := uuid.NewV7()
+
+	dataRedemption := &entity.Redemption{
+		ID:          newUUID,
+		Code:        normalizeCode(data.Code),
+		CustomerID:  data.CustomerID,
+		StoreID:     data.StoreID,
+		OrderAmount: data.OrderAmount,
+		Reference:   data.Reference,
+		RedeemedBy:  &caller.UserID,
+		RedeemedAt:  currentTime,
+	}
+
+	return c.repository.RedeemCoupon(*dataRedemption, quoteFunc(data, currentTime))
+}
+
+// quoteFunc returns the rules a coupon has to pass for the order, the repository runs them
+// while the coupon is locked so the usage counts can't change in between.
+func quoteFunc(data dto.RedeemCouponRequest, now time.Time) repository.QuoteFunc {
+	return func(batch entity.Batch, coupon entity.Coupon, customerUsage int) (int64, *response.Error) {
+		rejected := func(message string) (int64, *response.Error) {
+			return 0, &response.Error{
+				StatusCode: fiber.StatusConflict,
+				Message:    message,
+				Error:      nil,
+			}
+		}
+
+		if batch.StoreID != nil && *batch.StoreID != data.StoreID {
+			return rejected("coupon is not valid in this store")
+		}
+
+		if now.Before(batch.StartsAt) {
+			return rejected("coupon is not active yet")
+		}
+
+		if batch.ExpiresAt != nil && !now.Before(*batch.ExpiresAt) {
+			return rejected("coupon is expired")
+		}
+
+		if coupon.UsageCount >= batch.UsageLimit {
+			return rejected("coupon usage limit reached")
+		}
+
+		if batch.PerCustomerLimit != nil {
+			if data.CustomerID == nil {
+				return 0, &response.Error{
+					StatusCode: fiber.StatusBadRequest,
+					Message:    "customer_id is required for this coupon",
+					Error:      errors.New("customer_id is required for this coupon"),
+				}
+			}
+
+			if customerUsage >= *batch.PerCustomerLimit {
+				return rejected("coupon usage limit for customer reached")
+			}
+		}
+
+		if data.OrderAmount < batch.MinSpend {
+			return rejected(fmt.Sprintf("order amount is below minimum spend %d", batch.MinSpend))
+		}
+
+		return batchDiscount(batch, data.OrderAmount), nil
+	}
+}
+
+// batchDiscount rounds a percentage discount half up and never goes above the order amount.
+func batchDiscount(batch entity.Batch, orderAmount int64) int64 {
+	var discount int64
+	switch batch.DiscountType {
+	case entity.DiscountPercentage:
+		if batch.DiscountPercent != nil {
+			basisPoints := int64(math.Round(*batch.DiscountPercent * 100))
+			discount = (orderAmount*basisPoints + 5000) / 10000
+		}
+	case entity.DiscountFixed:
+		if batch.DiscountAmount != nil {
+			discount = *batch.DiscountAmount
+		}
+	}
+
+	if batch.MaxDiscount != nil {
+		discount = min(discount, *batch.MaxDiscount)
+	}
+
+	return min(discount, orderAmount)
+}
+
+func checkRedeemRequest(caller *auth.Caller, data dto.RedeemCouponRequest) *response.Error {
+	if !caller.CanAccessStore(data.StoreID) {
+		return auth.ForbiddenStore(data.StoreID)
+	}
+
+	if strings.TrimSpace(data.Code) == "" || data.OrderAmount < 0 {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "code and order_amount is required",
+			Error:      errors.New("code and order_amount is required"),
+		}
+	}
+
+	return nil
+}
+
+func validateBatch(batch *entity.Batch, quantity int) *response.Error {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if strings.TrimSpace(batch.Name) == "" {
+		return invalid("name is required")
+	}
+
+	if quantity <= 0 || quantity > MaxBatchQuantity {
+		return invalid(fmt.Sprintf("quantity must be between 1 and %d", MaxBatchQuantity))
+	}
+
+	switch batch.DiscountType {
+	case entity.DiscountPercentage:
+		if batch.DiscountPercent == nil || *batch.DiscountPercent <= 0 || *batch.DiscountPercent > 100 {
+			return invalid("discount_percent must be more than 0 and at most 100")
+		}
+
+		batch.DiscountAmount = nil
+	case entity.DiscountFixed:
+		if batch.DiscountAmount == nil || *batch.DiscountAmount <= 0 {
+			return invalid("discount_amount must be more than 0")
+		}
+
+		batch.DiscountPercent = nil
+	default:
+		return invalid(fmt.Sprintf("discount_type must be %s or %s", entity.DiscountPercentage, entity.DiscountFixed))
+	}
+
+	if batch.MaxDiscount != nil && *batch.MaxDiscount <= 0 {
+		return invalid("max_discount must be more than 0")
+	}
+
+	if batch.MinSpend < 0 || batch.UsageLimit < 0 {
+		return invalid("min_spend and usage_limit can not be negative")
+	}
+
+	if batch.PerCustomerLimit != nil && *batch.PerCustomerLimit <= 0 {
+		return invalid("per_customer_limit must be more than 0")
+	}
+
+	if batch.ExpiresAt != nil && !batch.ExpiresAt.After(batch.StartsAt) {
+		return invalid("expires_at must be after starts_at")
+	}
+
+	return nil
+}
+
+// canManageBatch allows owners to manage every batch and managers the batches of their store.
+func canManageBatch(caller *auth.Caller, storeID *uuid.UUID) *response.Error {
+	if storeID == nil {
+		if caller.IsOwner() {
+			return nil
+		}
+
+		return &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "only owner can manage coupon for every store",
+			Error:      errors.New("only owner can manage coupon for every store"),
+		}
+	}
+
+	if !caller.CanManageStore(*storeID) {
+		return auth.ForbiddenStore(*storeID)
+	}
+
+	return nil
+}
+
+func NewCouponService(repository repository.CouponRepository) CouponService {
+	return &couponService{repository}
+}
@@ -14,3 +14,8 @@ type UpdateCustomerRequest struct {
 	PhoneNumber string    `json:"phone_number"`
 	Address     string    `json:"address"`
 }
+
+type MergeCustomerRequest struct {
+	SourceID uuid.UUID `json:"source_id"`
+	TargetID uuid.UUID `json:"target_id"`
+}
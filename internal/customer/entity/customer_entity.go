@@ -7,13 +7,28 @@ import (
 )
 
 type Customer struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	Name        string     `json:"name" db:"name"`
-	PhoneNumber string     `json:"phone_number" db:"phone_number"`
-	Address     string     `json:"address" db:"address"`
-	Status      bool       `json:"status" db:"status"`
-	IsMember    bool       `json:"is_member" db:"is_member"`
-	CreatedAt   *time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   *time.Time `json:"-" db:"updated_at"`
-	DeletedAt   *time.Time `json:"-" db:"deleted_at"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	Name          string     `json:"name" db:"name"`
+	PhoneNumber   string     `json:"phone_number" db:"phone_number"`
+	Address       string     `json:"address" db:"address"`
+	Status        bool       `json:"status" db:"status"`
+	IsMember      bool       `json:"is_member" db:"is_member"`
+	LoyaltyPoints int        `json:"loyalty_points" db:"loyalty_points"`
+	MergedInto    *uuid.UUID `json:"merged_into,omitempty" db:"merged_into"`
+	CreatedAt     *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     *time.Time `json:"-" db:"updated_at"`
+	DeletedAt     *time.Time `json:"-" db:"deleted_at"`
+}
+
+// DuplicateCandidate is a pair of active customers that probably belong to the same person.
+type DuplicateCandidate struct {
+	CustomerID           uuid.UUID `json:"customer_id" db:"customer_id"`
+	CustomerName         string    `json:"customer_name" db:"customer_name"`
+	CustomerPhoneNumber  string    `json:"customer_phone_number" db:"customer_phone_number"`
+	CandidateID          uuid.UUID `json:"candidate_id" db:"candidate_id"`
+	CandidateName        string    `json:"candidate_name" db:"candidate_name"`
+	CandidatePhoneNumber string    `json:"candidate_phone_number" db:"candidate_phone_number"`
+	NameSimilarity       float64   `json:"name_similarity" db:"name_similarity"`
+	PhoneMatch           bool      `json:"phone_match" db:"phone_match"`
+	Score                float64   `json:"score" db:"score"`
 }
@@ -1,11 +1,16 @@
 package customer
 
 import (
+	"bufio"
 	dto "candyshop/internal/customer/dto"
 	service "candyshop/internal/customer/service"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/export"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 type CustomerHandler struct {
@@ -110,6 +115,14 @@ func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
 
 	createCustomer, errCust := h.service.CreateCustomer(req)
 	if errCust != nil {
+		if errCust.StatusCode == fiber.StatusConflict {
+			return c.Status(errCust.StatusCode).JSON(fiber.Map{
+				"status_code": errCust.StatusCode,
+				"message":     "failed to create customer",
+				"error":       errCust.Message,
+			})
+		}
+
 		return c.Status(errCust.StatusCode).JSON(fiber.Map{
 			"status_code": errCust.StatusCode,
 			"message":     "failed to create customer",
@@ -195,3 +208,135 @@ func (h *CustomerHandler) DeactiveCustomer(c *fiber.Ctx) error {
 		"data":        nil,
 	})
 }
+
+func (h *CustomerHandler) GetDuplicateCandidates(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+	threshold := c.QueryFloat("threshold", 0.5)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	if threshold <= 0 || threshold > 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "threshold must be between 0 and 1",
+			"error":       nil,
+		})
+	}
+
+	candidates, errCust := h.service.GetDuplicateCandidates(offset, limit, threshold)
+	if errCust != nil {
+		return c.Status(errCust.StatusCode).JSON(fiber.Map{
+			"status_code": errCust.StatusCode,
+			"message":     "failed to fetch duplicate customers",
+			"error":       errCust.Error.Error(),
+		})
+	}
+
+	if len(candidates) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch duplicate customers",
+			"error":       "duplicate customer not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get duplicate customers",
+		"data":        candidates,
+	})
+}
+
+func (h *CustomerHandler) MergeCustomer(c *fiber.Ctx) error {
+	var req dto.MergeCustomerRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data customer",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.SourceID == uuid.Nil || req.TargetID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "source_id and target_id is required",
+			"error":       nil,
+		})
+	}
+
+	customer, errMerge := h.service.MergeCustomer(auth.GetCaller(c), req)
+	if errMerge != nil {
+		if errMerge.StatusCode == fiber.StatusConflict {
+			return c.Status(errMerge.StatusCode).JSON(fiber.Map{
+				"status_code": errMerge.StatusCode,
+				"message":     "failed to merge customer",
+				"error":       errMerge.Message,
+			})
+		}
+
+		return c.Status(errMerge.StatusCode).JSON(fiber.Map{
+			"status_code": errMerge.StatusCode,
+			"message":     "failed to merge customer",
+			"error":       errMerge.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success merge customer",
+		"data":        customer,
+	})
+}
+
+func (h *CustomerHandler) ExportCustomer(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit")
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	format, errFormat := export.ParseFormat(c.Query("format"))
+	if errFormat != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "format is invalid",
+			"error":       errFormat.Error(),
+		})
+	}
+
+	columns, errColumns := h.service.ExportColumns(c.Query("columns"))
+	if errColumns != nil {
+		return c.Status(errColumns.StatusCode).JSON(fiber.Map{
+			"status_code": errColumns.StatusCode,
+			"message":     errColumns.Message,
+			"error":       errColumns.Error.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, format.Filename("customers")))
+
+	// rows are written while they are read from database, the status is already sent so errors can only be logged
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		errExport := h.service.ExportCustomer(offset, limit, columns, export.NewWriter(format, w))
+		if errExport != nil {
+			log.Error().Err(errExport.Error).Int("status", errExport.StatusCode).Str("function", "export customer").Msg(errExport.Message)
+		}
+	})
+
+	return nil
+}
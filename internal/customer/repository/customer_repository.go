@@ -2,13 +2,17 @@ package customer
 
 import (
 	entity "candyshop/internal/customer/entity"
+	"candyshop/pkg/event"
 	"candyshop/pkg/response"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
@@ -18,6 +22,10 @@ type CustomerRepository interface {
 	CreateCustomer(data entity.Customer) (*entity.Customer, *response.Error)
 	UpdateCustomer(data entity.Customer) *response.Error
 	DeleteCustomer(id uuid.UUID, deletedAt time.Time) *response.Error
+	GetActiveCustomerByPhoneNumber(phoneNumber string) (*entity.Customer, *response.Error)
+	GetDuplicateCandidates(offset, limit int, threshold float64) ([]entity.DuplicateCandidate, *response.Error)
+	MergeCustomer(source entity.Customer, target entity.Customer, mergedAt time.Time) *response.Error
+	StreamCustomer(offset, limit int, fn func(entity.Customer) error) *response.Error
 }
 
 type customerRepository struct {
@@ -40,7 +48,7 @@ func (c *customerRepository) CreateCustomer(data entity.Customer) (*entity.Custo
 
 	query := `
 		INSERT INTO customers (id, name, phone_number, address, status, is_member) VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, name, phone_number, address, status, is_member, created_at
+		RETURNING id, name, phone_number, address, status, is_member, loyalty_points, created_at
 	`
 
 	var model entity.Customer
@@ -60,6 +68,10 @@ func (c *customerRepository) CreateCustomer(data entity.Customer) (*entity.Custo
 		&model.CreatedAt)
 
 	if errInsert != nil {
+		if errPhone := phoneNumberTaken(errInsert, data.PhoneNumber); errPhone != nil {
+			return nil, errPhone
+		}
+
 		log.Error().Err(errInsert).Int("status", 500).Str("function", "create customer").Msg("failed to create customer")
 		return nil, &response.Error{
 			StatusCode: 500,
@@ -68,6 +80,10 @@ func (c *customerRepository) CreateCustomer(data entity.Customer) (*entity.Custo
 		}
 	}
 
+	if errEvent := emitCustomer(tx, event.CustomerCreated, model.ID); errEvent != nil {
+		return nil, errEvent
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Error().Err(err).Int("status", 500).Str("function", "create customer").Msg("failed to create customer")
 		return nil, &response.Error{
@@ -108,6 +124,16 @@ func (c *customerRepository) DeleteCustomer(id uuid.UUID, deletedAt time.Time) *
 		}
 	}
 
+	errEvent := event.Emit(tx, event.CustomerDeactivated, id, map[string]any{"id": id, "deleted_at": deletedAt})
+	if errEvent != nil {
+		log.Error().Err(errEvent).Int("status", 500).Str("function", "delete customer").Msg("failed to emit customer deactivated")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete customer",
+			Error:      errEvent,
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Error().Err(err).Int("status", 500).Str("function", "delete customer").Msg("failed to delete customer")
 		return &response.Error{
@@ -156,7 +182,7 @@ func (c *customerRepository) GetCustomerByID(id uuid.UUID) (*entity.Customer, *r
 	var customer entity.Customer
 
 	query := `
-		SELECT id, name, phone_number, address, status, created_at, updated_at, deleted_at FROM customers
+		SELECT id, name, phone_number, address, status, is_member, loyalty_points, merged_into, created_at, updated_at, deleted_at FROM customers
 		WHERE id = $1
 	`
 
@@ -208,6 +234,10 @@ func (c *customerRepository) UpdateCustomer(data entity.Customer) *response.Erro
 		data.UpdatedAt)
 
 	if errExec != nil {
+		if errPhone := phoneNumberTaken(errExec, data.PhoneNumber); errPhone != nil {
+			return errPhone
+		}
+
 		log.Error().Err(errExec).Int("status", 500).Str("function", "update customer").Msg("failed to update customer")
 		return &response.Error{
 			StatusCode: 500,
@@ -216,6 +246,10 @@ func (c *customerRepository) UpdateCustomer(data entity.Customer) *response.Erro
 		}
 	}
 
+	if errEvent := emitCustomer(tx, event.CustomerUpdated, data.ID); errEvent != nil {
+		return errEvent
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Error().Err(err).Int("status", 500).Str("function", "update customer").Msg("failed to update customer")
 		return &response.Error{
@@ -228,6 +262,347 @@ func (c *customerRepository) UpdateCustomer(data entity.Customer) *response.Erro
 	return nil
 }
 
+// StreamCustomer implements CustomerRepository.
+func (c *customerRepository) StreamCustomer(offset int, limit int, fn func(entity.Customer) error) *response.Error {
+	// limit 0 means no limit for export
+	var queryLimit *int
+	if limit > 0 {
+		queryLimit = &limit
+	}
+
+	query := `
+		SELECT id, name, phone_number, address, status, is_member, loyalty_points, created_at
+		FROM customers
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := c.db.Queryx(query, queryLimit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "stream customer").Msg("failed to stream customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch customers",
+			Error:      err,
+		}
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var customer entity.Customer
+		if err := rows.StructScan(&customer); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "stream customer").Msg("failed to stream customer")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to fetch customers",
+				Error:      err,
+			}
+		}
+
+		if err := fn(customer); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "stream customer").Msg("failed to write customer")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to write customers",
+				Error:      err,
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "stream customer").Msg("failed to stream customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch customers",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// GetActiveCustomerByPhoneNumber implements CustomerRepository.
+func (c *customerRepository) GetActiveCustomerByPhoneNumber(phoneNumber string) (*entity.Customer, *response.Error) {
+	var customer entity.Customer
+
+	query := `
+		SELECT id, name, phone_number, address, status, is_member, loyalty_points, created_at, updated_at, deleted_at FROM customers
+		WHERE phone_number = $1 AND deleted_at IS NULL
+		LIMIT 1
+	`
+
+	err := c.db.Get(&customer, query, phoneNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch customer",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get customer by phone number").Msg("failed to get customer by phone number")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch customer",
+			Error:      err,
+		}
+	}
+
+	return &customer, nil
+}
+
+// GetDuplicateCandidates implements CustomerRepository.
+func (c *customerRepository) GetDuplicateCandidates(offset int, limit int, threshold float64) ([]entity.DuplicateCandidate, *response.Error) {
+	var candidates []entity.DuplicateCandidate
+
+	tx, err := c.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get duplicate candidates").Msg("failed to get duplicate candidates")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the threshold of the % operator only holds for the transaction, it lets the trigram index find the similar names
+	_, errLimit := tx.Exec(`SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(threshold, 'f', -1, 64))
+	if errLimit != nil {
+		log.Error().Err(errLimit).Int("status", 500).Str("function", "get duplicate candidates").Msg("failed to get duplicate candidates")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch duplicate candidates",
+			Error:      errLimit,
+		}
+	}
+
+	// each customer looks up the similar names in the trigram index and the same phone numbers by hash, instead of
+	// being compared with every other customer. Phone numbers are compared on their last 9 digits so rows saved before
+	// normalisation still match, the score weighs a phone match higher than a similar name
+	query := `
+		WITH pairs AS (
+			SELECT a.id AS customer_id, b.id AS candidate_id
+			FROM customers a
+			JOIN customers b ON b.name % a.name AND b.id > a.id AND b.deleted_at IS NULL
+			WHERE a.deleted_at IS NULL
+			UNION
+			SELECT a.id, b.id
+			FROM customers a
+			JOIN customers b ON RIGHT(regexp_replace(b.phone_number, '\D', '', 'g'), 9) = RIGHT(regexp_replace(a.phone_number, '\D', '', 'g'), 9)
+				AND b.id > a.id AND b.deleted_at IS NULL
+			WHERE a.deleted_at IS NULL
+		)
+		SELECT customer_id, customer_name, customer_phone_number,
+			candidate_id, candidate_name, candidate_phone_number,
+			name_similarity, phone_match,
+			ROUND((CASE WHEN phone_match THEN 0.6 ELSE 0 END + 0.4 * name_similarity)::numeric, 4)::float8 AS score
+		FROM (
+			SELECT a.id AS customer_id, a.name AS customer_name, a.phone_number AS customer_phone_number,
+				b.id AS candidate_id, b.name AS candidate_name, b.phone_number AS candidate_phone_number,
+				similarity(a.name, b.name)::float8 AS name_similarity,
+				RIGHT(regexp_replace(a.phone_number, '\D', '', 'g'), 9) = RIGHT(regexp_replace(b.phone_number, '\D', '', 'g'), 9) AS phone_match
+			FROM pairs p
+			JOIN customers a ON a.id = p.customer_id
+			JOIN customers b ON b.id = p.candidate_id
+		) scored
+		ORDER BY score DESC, customer_id, candidate_id
+		LIMIT $1 OFFSET $2
+	`
+
+	errSelect := tx.Select(&candidates, query, limit, offset)
+	if errSelect != nil {
+		log.Error().Err(errSelect).Int("status", 500).Str("function", "get duplicate candidates").Msg("failed to get duplicate candidates")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch duplicate candidates",
+			Error:      errSelect,
+		}
+	}
+
+	return candidates, nil
+}
+
+// MergeCustomer implements CustomerRepository.
+func (c *customerRepository) MergeCustomer(source entity.Customer, target entity.Customer, mergedAt time.Time) *response.Error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the loyalty points of the source are added to the target
+	_, errPoints := tx.Exec(`UPDATE customers SET loyalty_points = loyalty_points + (SELECT loyalty_points FROM customers WHERE id = $1) WHERE id = $2`,
+		source.ID, target.ID)
+	if errPoints != nil {
+		log.Error().Err(errPoints).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errPoints,
+		}
+	}
+
+	// the source is soft deleted and keeps a pointer to the customer it was merged into
+	querySource := `
+		UPDATE customers SET merged_into = $2, status = false, loyalty_points = 0, updated_at = $3, deleted_at = $3 WHERE id = $1
+	`
+
+	_, errSource := tx.Exec(querySource, source.ID, target.ID, mergedAt)
+	if errSource != nil {
+		log.Error().Err(errSource).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errSource,
+		}
+	}
+
+	// the target is updated after the source is deleted, it may take over the phone number of the source
+	queryTarget := `
+		UPDATE customers SET name = $2, phone_number = $3, address = $4, is_member = $5, updated_at = $6 WHERE id = $1
+	`
+
+	_, errTarget := tx.Exec(queryTarget,
+		target.ID,
+		target.Name,
+		target.PhoneNumber,
+		target.Address,
+		target.IsMember,
+		mergedAt)
+
+	if errTarget != nil {
+		if errPhone := phoneNumberTaken(errTarget, target.PhoneNumber); errPhone != nil {
+			return errPhone
+		}
+
+		log.Error().Err(errTarget).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errTarget,
+		}
+	}
+
+	// the purchase history follows the customer
+	_, errSales := tx.Exec(`UPDATE sales SET customer_id = $2 WHERE customer_id = $1`, source.ID, target.ID)
+	if errSales != nil {
+		log.Error().Err(errSales).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errSales,
+		}
+	}
+
+	// the returns stay with the sales they came from
+	_, errReturns := tx.Exec(`UPDATE sale_returns SET customer_id = $2 WHERE customer_id = $1`, source.ID, target.ID)
+	if errReturns != nil {
+		log.Error().Err(errReturns).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errReturns,
+		}
+	}
+
+	// the coupons the source redeemed count against the per customer limit of the target
+	_, errRedemptions := tx.Exec(`UPDATE coupon_redemptions SET customer_id = $2 WHERE customer_id = $1`, source.ID, target.ID)
+	if errRedemptions != nil {
+		log.Error().Err(errRedemptions).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errRedemptions,
+		}
+	}
+
+	// customers merged into the source earlier now point to the target
+	_, errChain := tx.Exec(`UPDATE customers SET merged_into = $2 WHERE merged_into = $1`, source.ID, target.ID)
+	if errChain != nil {
+		log.Error().Err(errChain).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errChain,
+		}
+	}
+
+	// the target took over the details and the points of the source
+	if errEvent := emitCustomer(tx, event.CustomerUpdated, target.ID); errEvent != nil {
+		return errEvent
+	}
+
+	errEvent := event.Emit(tx, event.CustomerMerged, source.ID, map[string]any{"id": source.ID, "merged_into": target.ID, "merged_at": mergedAt})
+	if errEvent != nil {
+		log.Error().Err(errEvent).Int("status", 500).Str("function", "merge customer").Msg("failed to emit customer merged")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to merge customer",
+			Error:      errEvent,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "merge customer").Msg("failed to merge customer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// phoneNumberTaken turns a violation of the unique phone number of active customers into the conflict
+// the service reports, another customer took the number after the service checked it.
+func phoneNumberTaken(err error, phoneNumber string) *response.Error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return nil
+	}
+
+	return &response.Error{
+		StatusCode: 409,
+		Message:    fmt.Sprintf("phone number %s already registered", phoneNumber),
+		Error:      nil,
+	}
+}
+
+// emitCustomer emits the customer as stored in the transaction.
+func emitCustomer(tx *sqlx.Tx, eventType string, id uuid.UUID) *response.Error {
+	var customer entity.Customer
+
+	err := tx.Get(&customer, `
+		SELECT id, name, phone_number, address, status, is_member, loyalty_points, merged_into, created_at, updated_at, deleted_at FROM customers
+		WHERE id = $1
+	`, id)
+	if err == nil {
+		err = event.Emit(tx, eventType, id, customer)
+	}
+
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "emit customer event").Msg("failed to emit " + eventType)
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to emit " + eventType,
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
 func NewCustomerRepository(db *sqlx.DB) CustomerRepository {
 	return &customerRepository{db}
 }
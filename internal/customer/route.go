@@ -4,6 +4,7 @@ import (
 	handler "candyshop/internal/customer/handler"
 	repository "candyshop/internal/customer/repository"
 	service "candyshop/internal/customer/service"
+	staff "candyshop/internal/staff"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
@@ -17,8 +18,11 @@ func Init(router fiber.Router, db *sqlx.DB) {
 	customerRoute := router.Group("api/v1/customers")
 
 	customerRoute.Get("", handler.GetAllCustomer)
+	customerRoute.Get("export", handler.ExportCustomer)
+	customerRoute.Get("duplicates", handler.GetDuplicateCandidates)
 	customerRoute.Get(":id", handler.GetCustomerByID)
 	customerRoute.Post("", handler.CreateCustomer)
 	customerRoute.Patch("", handler.UpdateCustomer)
+	customerRoute.Post("merge", staff.Authenticate(db), handler.MergeCustomer)
 	customerRoute.Patch("deactive/:id", handler.DeactiveCustomer)
 }
@@ -4,9 +4,16 @@ import (
 	dto "candyshop/internal/customer/dto"
 	entity "candyshop/internal/customer/entity"
 	repository "candyshop/internal/customer/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/export"
+	"candyshop/pkg/phone"
 	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
@@ -16,20 +23,91 @@ type CustomerService interface {
 	CreateCustomer(data dto.CreateCustomerRequest) (*entity.Customer, *response.Error)
 	UpdateCustomer(data dto.UpdateCustomerRequest) *response.Error
 	DeactiveCustomer(id uuid.UUID) *response.Error
+	GetDuplicateCandidates(offset, limit int, threshold float64) ([]entity.DuplicateCandidate, *response.Error)
+	MergeCustomer(caller *auth.Caller, data dto.MergeCustomerRequest) (*entity.Customer, *response.Error)
+	ExportColumns(columns string) ([]string, *response.Error)
+	ExportCustomer(offset, limit int, columns []string, writer export.Writer) *response.Error
 }
 
 type customerService struct {
 	repository repository.CustomerRepository
 }
 
+// customerColumns lists the columns available for customer export.
+var customerColumns = export.Columns[entity.Customer]{
+	{Name: "id", Value: func(c entity.Customer) string { return c.ID.String() }},
+	{Name: "name", Value: func(c entity.Customer) string { return c.Name }},
+	{Name: "phone_number", Value: func(c entity.Customer) string { return c.PhoneNumber }},
+	{Name: "address", Value: func(c entity.Customer) string { return c.Address }},
+	{Name: "status", Value: func(c entity.Customer) string { return strconv.FormatBool(c.Status) }},
+	{Name: "is_member", Value: func(c entity.Customer) string { return strconv.FormatBool(c.IsMember) }},
+	{Name: "created_at", Value: func(c entity.Customer) string { return export.FormatTime(c.CreatedAt) }},
+}
+
+// ExportColumns implements CustomerService.
+func (c *customerService) ExportColumns(columns string) ([]string, *response.Error) {
+	selected, err := customerColumns.Select(export.SplitColumns(columns))
+	if err != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "columns is invalid",
+			Error:      err,
+		}
+	}
+
+	return selected.Names(), nil
+}
+
+// ExportCustomer implements CustomerService.
+func (c *customerService) ExportCustomer(offset int, limit int, columns []string, writer export.Writer) *response.Error {
+	selected, err := customerColumns.Select(columns)
+	if err != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "columns is invalid",
+			Error:      err,
+		}
+	}
+
+	if err := writer.WriteHeader(selected.Names()); err != nil {
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to write customers",
+			Error:      err,
+		}
+	}
+
+	errStream := c.repository.StreamCustomer(offset, limit, func(customer entity.Customer) error {
+		return writer.WriteRow(selected.Values(customer))
+	})
+	if errStream != nil {
+		return errStream
+	}
+
+	if err := writer.Close(); err != nil {
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to write customers",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
 // CreateCustomer implements CustomerService.
 func (c *customerService) CreateCustomer(data dto.CreateCustomerRequest) (*entity.Customer, *response.Error) {
+	phoneNumber, errPhone := c.checkPhoneNumber(data.PhoneNumber, uuid.Nil)
+	if errPhone != nil {
+		return nil, errPhone
+	}
+
 	newUUID, _ := uuid.NewV7()
 
 	dataCustomer := &entity.Customer{
 		ID:          newUUID,
 		Name:        data.Name,
-		PhoneNumber: data.PhoneNumber,
+		PhoneNumber: phoneNumber,
 		Address:     data.Address,
 		Status:      true,
 		IsMember:    false,
@@ -91,6 +169,13 @@ func (c *customerService) UpdateCustomer(data dto.UpdateCustomerRequest) *respon
 
 	if data.PhoneNumber == "" {
 		data.PhoneNumber = checkCustomer.PhoneNumber
+	} else {
+		phoneNumber, errPhone := c.checkPhoneNumber(data.PhoneNumber, checkCustomer.ID)
+		if errPhone != nil {
+			return errPhone
+		}
+
+		data.PhoneNumber = phoneNumber
 	}
 
 	if data.Address == "" {
@@ -108,6 +193,98 @@ func (c *customerService) UpdateCustomer(data dto.UpdateCustomerRequest) *respon
 	return c.repository.UpdateCustomer(*dataCustomer)
 }
 
+// checkPhoneNumber normalises the phone number to E.164 and makes sure no other active customer uses it.
+func (c *customerService) checkPhoneNumber(phoneNumber string, customerID uuid.UUID) (string, *response.Error) {
+	normalized, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return "", &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    fmt.Sprintf("phone number %s is invalid", phoneNumber),
+			Error:      err,
+		}
+	}
+
+	existing, errExisting := c.repository.GetActiveCustomerByPhoneNumber(normalized)
+	if errExisting != nil && errExisting.StatusCode != 404 {
+		return "", errExisting
+	}
+
+	if existing != nil && existing.ID != customerID {
+		return "", &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("phone number %s already registered", normalized),
+			Error:      nil,
+		}
+	}
+
+	return normalized, nil
+}
+
+// GetDuplicateCandidates implements CustomerService.
+func (c *customerService) GetDuplicateCandidates(offset int, limit int, threshold float64) ([]entity.DuplicateCandidate, *response.Error) {
+	return c.repository.GetDuplicateCandidates(offset, limit, threshold)
+}
+
+// MergeCustomer implements CustomerService.
+func (c *customerService) MergeCustomer(caller *auth.Caller, data dto.MergeCustomerRequest) (*entity.Customer, *response.Error) {
+	// a merge can't be undone, only the owner and store managers may fold customers together
+	if !caller.IsOwner() && len(caller.ManagedStores()) == 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "only owner or manager can merge customers",
+			Error:      errors.New("only owner or manager can merge customers"),
+		}
+	}
+
+	if data.SourceID == data.TargetID {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "source and target customer must be different",
+			Error:      errors.New("source and target customer must be different"),
+		}
+	}
+
+	source, errSource := c.repository.GetCustomerByID(data.SourceID)
+	if errSource != nil {
+		return nil, errSource
+	}
+
+	target, errTarget := c.repository.GetCustomerByID(data.TargetID)
+	if errTarget != nil {
+		return nil, errTarget
+	}
+
+	if source.DeletedAt != nil || target.DeletedAt != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "customer is deactive",
+			Error:      nil,
+		}
+	}
+
+	// the target keeps its own data, empty fields are filled from the source
+	if target.Address == "" {
+		target.Address = source.Address
+	}
+
+	if target.PhoneNumber == "" {
+		target.PhoneNumber = source.PhoneNumber
+	}
+
+	target.IsMember = target.IsMember || source.IsMember
+
+	currentTime := time.Now()
+
+	errMerge := c.repository.MergeCustomer(*source, *target, currentTime)
+	if errMerge != nil {
+		return nil, errMerge
+	}
+
+	target.UpdatedAt = &currentTime
+
+	return target, nil
+}
+
 func NewCustomerService(repository repository.CustomerRepository) CustomerService {
 	return &customerService{repository}
 }
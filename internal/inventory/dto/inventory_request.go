@@ -0,0 +1,25 @@
+package inventory
+
+import "github.com/google/uuid"
+
+type AdjustInventoryRequest struct {
+	StoreID   uuid.UUID `json:"store_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	// Quantity is added to the current stock, use a negative value to reduce it
+	Quantity int `json:"quantity"`
+	// Note explains the adjustment in the movement history
+	Note string `json:"note"`
+}
+
+// SetStockLevelRequest replaces both levels, a missing level removes it
+type SetStockLevelRequest struct {
+	StoreID     uuid.UUID `json:"store_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	MinQuantity *int      `json:"min_quantity"`
+	MaxQuantity *int      `json:"max_quantity"`
+}
+
+// GenerateReorderRequest runs the reorder suggestion job, every store when store_id is empty
+type GenerateReorderRequest struct {
+	StoreID uuid.UUID `json:"store_id"`
+}
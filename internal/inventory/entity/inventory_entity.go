@@ -0,0 +1,94 @@
+package inventory
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Inventory is the stock of a product in a store, in transit quantity is shipped to the store but not received yet.
+// Min and max quantity are the stock levels set by the store, nil when the product has no level.
+// Average cost is the weighted average cost of the stock on hand, nil when it is hidden from the caller.
+type Inventory struct {
+	StoreID           uuid.UUID  `json:"store_id" db:"store_id"`
+	ProductID         uuid.UUID  `json:"product_id" db:"product_id"`
+	ProductSKU        string     `json:"product_sku" db:"product_sku"`
+	ProductName       string     `json:"product_name" db:"product_name"`
+	Quantity          int        `json:"quantity" db:"quantity"`
+	InTransitQuantity int        `json:"in_transit_quantity" db:"in_transit_quantity"`
+	MinQuantity       *int       `json:"min_quantity" db:"min_quantity"`
+	MaxQuantity       *int       `json:"max_quantity" db:"max_quantity"`
+	AverageCost       *int64     `json:"average_cost" db:"average_cost"`
+	CreatedAt         *time.Time `json:"-" db:"created_at"`
+	UpdatedAt         *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ReorderSuggestion is a quantity of a product the store should order from its supplier.
+type ReorderSuggestion struct {
+	StoreID           uuid.UUID  `json:"store_id" db:"store_id"`
+	ProductID         uuid.UUID  `json:"product_id" db:"product_id"`
+	ProductSKU        string     `json:"product_sku" db:"product_sku"`
+	ProductName       string     `json:"product_name" db:"product_name"`
+	SupplierID        *uuid.UUID `json:"supplier_id" db:"supplier_id"`
+	SupplierName      string     `json:"supplier_name" db:"supplier_name"`
+	SupplierSKU       string     `json:"supplier_sku" db:"supplier_sku"`
+	PackSize          int        `json:"pack_size" db:"pack_size"`
+	Quantity          int        `json:"quantity" db:"quantity"`
+	InTransitQuantity int        `json:"in_transit_quantity" db:"in_transit_quantity"`
+	MinQuantity       *int       `json:"min_quantity" db:"min_quantity"`
+	MaxQuantity       *int       `json:"max_quantity" db:"max_quantity"`
+	DailySales        float64    `json:"daily_sales" db:"daily_sales"`
+	LeadTimeDays      int        `json:"lead_time_days" db:"lead_time_days"`
+	SuggestedQuantity int        `json:"suggested_quantity" db:"suggested_quantity"`
+	GeneratedAt       time.Time  `json:"generated_at" db:"generated_at"`
+}
+
+// ReorderCandidate is a product with a stock level, with what it sold in the velocity window.
+// LeadTimeDays is nil when the product has no supplier.
+type ReorderCandidate struct {
+	StoreID           uuid.UUID  `db:"store_id"`
+	ProductID         uuid.UUID  `db:"product_id"`
+	SupplierID        *uuid.UUID `db:"supplier_id"`
+	PackSize          int        `db:"pack_size"`
+	Quantity          int        `db:"quantity"`
+	InTransitQuantity int        `db:"in_transit_quantity"`
+	MinQuantity       int        `db:"min_quantity"`
+	MaxQuantity       *int       `db:"max_quantity"`
+	LeadTimeDays      *int       `db:"lead_time_days"`
+	SoldQuantity      int        `db:"sold_quantity"`
+}
+
+// SupplierOrder groups the suggestions of a store by supplier, a nil supplier holds the products nobody supplies yet.
+type SupplierOrder struct {
+	SupplierID   *uuid.UUID          `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	Items        []ReorderSuggestion `json:"items"`
+}
+
+// Movement is a row of the inventory ledger, balance after is the stock right after the movement.
+// Unit cost is what the incoming stock cost, nil when it came in at the average cost or is hidden from the caller.
+type Movement struct {
+	ID            int64      `json:"id" db:"id"`
+	StoreID       uuid.UUID  `json:"store_id" db:"store_id"`
+	ProductID     uuid.UUID  `json:"product_id" db:"product_id"`
+	Type          string     `json:"type" db:"type"`
+	Quantity      int        `json:"quantity" db:"quantity"`
+	BalanceAfter  int        `json:"balance_after" db:"balance_after"`
+	ReferenceType string     `json:"reference_type" db:"reference_type"`
+	ReferenceID   *uuid.UUID `json:"reference_id" db:"reference_id"`
+	CreatedBy     *uuid.UUID `json:"created_by" db:"created_by"`
+	Note          string     `json:"note" db:"note"`
+	UnitCost      *int64     `json:"unit_cost" db:"unit_cost"`
+	MovedAt       time.Time  `json:"moved_at" db:"moved_at"`
+}
+
+// LedgerMismatch is an inventory whose stock can't be explained by its movements.
+type LedgerMismatch struct {
+	StoreID        uuid.UUID `json:"store_id" db:"store_id"`
+	ProductID      uuid.UUID `json:"product_id" db:"product_id"`
+	ProductSKU     string    `json:"product_sku" db:"product_sku"`
+	ProductName    string    `json:"product_name" db:"product_name"`
+	Quantity       int       `json:"quantity" db:"quantity"`
+	LedgerQuantity int       `json:"ledger_quantity" db:"ledger_quantity"`
+	LastBalance    int       `json:"last_balance" db:"last_balance"`
+}
@@ -0,0 +1,330 @@
+package inventory
+
+import (
+	dto "candyshop/internal/inventory/dto"
+	service "candyshop/internal/inventory/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type InventoryHandler struct {
+	service service.InventoryService
+}
+
+func NewInventoryHandler(service service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service}
+}
+
+func (h *InventoryHandler) GetInventoryByStore(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	storeID, errParse := uuid.Parse(c.Query("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	inventories, errInventory := h.service.GetInventoryByStore(auth.GetCaller(c), storeID, offset, limit)
+	if errInventory != nil {
+		return c.Status(errInventory.StatusCode).JSON(fiber.Map{
+			"status_code": errInventory.StatusCode,
+			"message":     "failed to fetch inventories",
+			"error":       errInventory.Error.Error(),
+		})
+	}
+
+	if len(inventories) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch inventories",
+			"error":       "inventory not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data inventories",
+		"data":        inventories,
+	})
+}
+
+func (h *InventoryHandler) AdjustInventory(c *fiber.Ctx) error {
+	var req dto.AdjustInventoryRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data inventory",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil || req.ProductID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id and product_id is required",
+			"error":       nil,
+		})
+	}
+
+	inventory, errInventory := h.service.AdjustInventory(auth.GetCaller(c), req)
+	if errInventory != nil {
+		if errInventory.StatusCode == fiber.StatusConflict {
+			return c.Status(errInventory.StatusCode).JSON(fiber.Map{
+				"status_code": errInventory.StatusCode,
+				"message":     "failed to adjust inventory",
+				"error":       errInventory.Message,
+			})
+		}
+
+		return c.Status(errInventory.StatusCode).JSON(fiber.Map{
+			"status_code": errInventory.StatusCode,
+			"message":     "failed to adjust inventory",
+			"error":       errInventory.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success adjust inventory",
+		"data":        inventory,
+	})
+}
+
+func (h *InventoryHandler) SetStockLevel(c *fiber.Ctx) error {
+	var req dto.SetStockLevelRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data stock level",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil || req.ProductID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id and product_id is required",
+			"error":       nil,
+		})
+	}
+
+	inventory, errInventory := h.service.SetStockLevel(auth.GetCaller(c), req)
+	if errInventory != nil {
+		return c.Status(errInventory.StatusCode).JSON(fiber.Map{
+			"status_code": errInventory.StatusCode,
+			"message":     "failed to set stock level",
+			"error":       errInventory.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success set stock level",
+		"data":        inventory,
+	})
+}
+
+func (h *InventoryHandler) GetLowStock(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	storeID, errParse := uuid.Parse(c.Query("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	inventories, errInventory := h.service.GetLowStock(auth.GetCaller(c), storeID, offset, limit)
+	if errInventory != nil {
+		return c.Status(errInventory.StatusCode).JSON(fiber.Map{
+			"status_code": errInventory.StatusCode,
+			"message":     "failed to fetch low stock",
+			"error":       errInventory.Error.Error(),
+		})
+	}
+
+	if len(inventories) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch low stock",
+			"error":       "no product is low on stock",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data low stock",
+		"data":        inventories,
+	})
+}
+
+func (h *InventoryHandler) GenerateReorderSuggestion(c *fiber.Ctx) error {
+	var req dto.GenerateReorderRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "failed to input data reorder suggestion",
+				"error":       err.Error(),
+			})
+		}
+	}
+
+	suggestions, errSuggestion := h.service.GenerateReorderSuggestion(auth.GetCaller(c), req)
+	if errSuggestion != nil {
+		return c.Status(errSuggestion.StatusCode).JSON(fiber.Map{
+			"status_code": errSuggestion.StatusCode,
+			"message":     "failed to generate reorder suggestions",
+			"error":       errSuggestion.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success generate reorder suggestions",
+		"data":        suggestions,
+	})
+}
+
+func (h *InventoryHandler) GetReorderSuggestion(c *fiber.Ctx) error {
+	storeID, errParse := uuid.Parse(c.Query("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	orders, errSuggestion := h.service.GetReorderSuggestion(auth.GetCaller(c), storeID)
+	if errSuggestion != nil {
+		return c.Status(errSuggestion.StatusCode).JSON(fiber.Map{
+			"status_code": errSuggestion.StatusCode,
+			"message":     "failed to fetch reorder suggestions",
+			"error":       errSuggestion.Error.Error(),
+		})
+	}
+
+	if len(orders) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch reorder suggestions",
+			"error":       "reorder suggestion not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data reorder suggestions",
+		"data":        orders,
+	})
+}
+
+func (h *InventoryHandler) GetMovement(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	storeID, errParseStore := uuid.Parse(c.Query("store_id"))
+	productID, errParseProduct := uuid.Parse(c.Query("product_id"))
+	if errParseStore != nil || errParseProduct != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id and product_id is required",
+			"error":       "store_id or product_id is invalid",
+		})
+	}
+
+	movements, errMovement := h.service.GetMovement(auth.GetCaller(c), storeID, productID, offset, limit)
+	if errMovement != nil {
+		return c.Status(errMovement.StatusCode).JSON(fiber.Map{
+			"status_code": errMovement.StatusCode,
+			"message":     "failed to fetch movements",
+			"error":       errMovement.Error.Error(),
+		})
+	}
+
+	if len(movements) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch movements",
+			"error":       "movement not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data movements",
+		"data":        movements,
+	})
+}
+
+func (h *InventoryHandler) VerifyInventory(c *fiber.Ctx) error {
+	storeID, errParse := uuid.Parse(c.Query("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	mismatches, errVerify := h.service.VerifyInventory(auth.GetCaller(c), storeID)
+	if errVerify != nil {
+		return c.Status(errVerify.StatusCode).JSON(fiber.Map{
+			"status_code": errVerify.StatusCode,
+			"message":     "failed to verify inventories",
+			"error":       errVerify.Error.Error(),
+		})
+	}
+
+	// an empty list is the good outcome, every stock matches its movements
+	if len(mismatches) == 0 {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status_code": fiber.StatusOK,
+			"message":     "inventories match the ledger",
+			"data":        []any{},
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "inventories do not match the ledger",
+		"data":        mismatches,
+	})
+}
@@ -0,0 +1,38 @@
+package inventory
+
+import (
+	repository "candyshop/internal/inventory/repository"
+	service "candyshop/internal/inventory/service"
+	"candyshop/pkg/job"
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeReorderSuggestion regenerates the reorder suggestions of every store.
+const TypeReorderSuggestion = "inventory.reorder_suggestion"
+
+// InitJobs registers the background jobs of the inventory on the worker.
+func InitJobs(worker *job.Worker, db *sqlx.DB) error {
+	repo := repository.NewInventoryRepository(db)
+	service := service.NewInventoryService(repo)
+
+	job.Register(worker, TypeReorderSuggestion, func(ctx context.Context, payload struct{}) error {
+		suggestions, errSuggestion := service.RegenerateReorderSuggestion()
+		if errSuggestion != nil {
+			if errSuggestion.Error != nil {
+				return errSuggestion.Error
+			}
+
+			return errors.New(errSuggestion.Message)
+		}
+
+		log.Info().Int("suggestions", len(suggestions)).Str("function", "regenerate reorder suggestion").Msg("reorder suggestions generated")
+		return nil
+	})
+
+	// 05:00 in Jakarta, the suggestions are ready before the stores open
+	return worker.Schedule(job.Schedule{Name: TypeReorderSuggestion, Spec: "0 22 * * *", Type: TypeReorderSuggestion})
+}
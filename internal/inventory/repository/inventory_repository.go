@@ -0,0 +1,444 @@
+package inventory
+
+import (
+	entity "candyshop/internal/inventory/entity"
+	saleEntity "candyshop/internal/sale/entity"
+	"candyshop/pkg/ledger"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type InventoryRepository interface {
+	GetInventoryByStore(storeID uuid.UUID, offset, limit int) ([]entity.Inventory, *response.Error)
+	GetInventory(storeID, productID uuid.UUID) (*entity.Inventory, *response.Error)
+	AdjustInventory(storeID, productID uuid.UUID, quantity int, createdBy uuid.UUID, note string, updatedAt time.Time) (*entity.Inventory, *response.Error)
+	GetMovement(storeID, productID uuid.UUID, offset, limit int) ([]entity.Movement, *response.Error)
+	GetLedgerMismatch(storeID uuid.UUID) ([]entity.LedgerMismatch, *response.Error)
+	SetStockLevel(storeID, productID uuid.UUID, minQuantity, maxQuantity *int, updatedAt time.Time) (*entity.Inventory, *response.Error)
+	GetLowStock(storeID uuid.UUID, offset, limit int) ([]entity.Inventory, *response.Error)
+	GetReorderCandidate(storeIDs []uuid.UUID, soldSince time.Time) ([]entity.ReorderCandidate, *response.Error)
+	ReplaceReorderSuggestion(storeIDs []uuid.UUID, suggestions []entity.ReorderSuggestion) *response.Error
+	GetReorderSuggestion(storeID uuid.UUID) ([]entity.ReorderSuggestion, *response.Error)
+}
+
+type inventoryRepository struct {
+	db *sqlx.DB
+}
+
+// GetInventoryByStore implements InventoryRepository.
+func (i *inventoryRepository) GetInventoryByStore(storeID uuid.UUID, offset int, limit int) ([]entity.Inventory, *response.Error) {
+	var inventories []entity.Inventory
+
+	query := `
+		SELECT i.store_id, i.product_id, p.sku AS product_sku, p.name AS product_name, i.quantity, i.in_transit_quantity, i.min_quantity, i.max_quantity, i.average_cost, i.created_at, i.updated_at
+		FROM inventories i
+		JOIN products p ON p.id = i.product_id
+		WHERE i.store_id = $1
+		ORDER BY p.name
+		LIMIT $2 OFFSET $3
+	`
+
+	err := i.db.Select(&inventories, query, storeID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get inventory by store").Msg("failed to get inventory by store")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch inventories",
+			Error:      err,
+		}
+	}
+
+	return inventories, nil
+}
+
+// GetInventory implements InventoryRepository.
+func (i *inventoryRepository) GetInventory(storeID uuid.UUID, productID uuid.UUID) (*entity.Inventory, *response.Error) {
+	var inventory entity.Inventory
+
+	query := `
+		SELECT i.store_id, i.product_id, p.sku AS product_sku, p.name AS product_name, i.quantity, i.in_transit_quantity, i.min_quantity, i.max_quantity, i.average_cost, i.created_at, i.updated_at
+		FROM inventories i
+		JOIN products p ON p.id = i.product_id
+		WHERE i.store_id = $1 AND i.product_id = $2
+	`
+
+	err := i.db.Get(&inventory, query, storeID, productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch inventory",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get inventory").Msg("failed to get inventory")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch inventory",
+			Error:      err,
+		}
+	}
+
+	return &inventory, nil
+}
+
+// AdjustInventory implements InventoryRepository.
+func (i *inventoryRepository) AdjustInventory(storeID uuid.UUID, productID uuid.UUID, quantity int, createdBy uuid.UUID, note string, updatedAt time.Time) (*entity.Inventory, *response.Error) {
+	tx, err := i.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "adjust inventory").Msg("failed to adjust inventory")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the stock can't go below zero
+	errMove := ledger.Move(tx, ledger.Movement{
+		StoreID:   storeID,
+		ProductID: productID,
+		Type:      ledger.TypeAdjustment,
+		Quantity:  quantity,
+		CreatedBy: &createdBy,
+		Note:      note,
+		MovedAt:   updatedAt,
+	})
+	if errMove != nil {
+		if errors.Is(errMove, ledger.ErrInsufficientStock) {
+			return nil, &response.Error{
+				StatusCode: 409,
+				Message:    "insufficient stock",
+				Error:      nil,
+			}
+		}
+
+		log.Error().Err(errMove).Int("status", 500).Str("function", "adjust inventory").Msg("failed to adjust inventory")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to adjust inventory",
+			Error:      errMove,
+		}
+	}
+
+	query := `
+		SELECT store_id, product_id, quantity, in_transit_quantity, min_quantity, max_quantity, average_cost, created_at, updated_at
+		FROM inventories
+		WHERE store_id = $1 AND product_id = $2
+	`
+
+	var model entity.Inventory
+
+	errGet := tx.Get(&model, query, storeID, productID)
+	if errGet != nil {
+		log.Error().Err(errGet).Int("status", 500).Str("function", "adjust inventory").Msg("failed to adjust inventory")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to adjust inventory",
+			Error:      errGet,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "adjust inventory").Msg("failed to adjust inventory")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// GetMovement implements InventoryRepository.
+func (i *inventoryRepository) GetMovement(storeID uuid.UUID, productID uuid.UUID, offset int, limit int) ([]entity.Movement, *response.Error) {
+	var movements []entity.Movement
+
+	// the newest movement first, its balance is the current stock
+	query := `
+		SELECT id, store_id, product_id, type, quantity, balance_after, reference_type, reference_id, created_by, note, unit_cost, moved_at
+		FROM inventory_movements
+		WHERE store_id = $1 AND product_id = $2
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	err := i.db.Select(&movements, query, storeID, productID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get movement").Msg("failed to get movement")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch movements",
+			Error:      err,
+		}
+	}
+
+	return movements, nil
+}
+
+// GetLedgerMismatch implements InventoryRepository.
+func (i *inventoryRepository) GetLedgerMismatch(storeID uuid.UUID) ([]entity.LedgerMismatch, *response.Error) {
+	var mismatches []entity.LedgerMismatch
+
+	// the sum of the movements and the balance of the last movement must both equal the stock
+	query := `
+		SELECT i.store_id, i.product_id, p.sku AS product_sku, p.name AS product_name, i.quantity,
+			COALESCE(m.total, 0) AS ledger_quantity, COALESCE(last.balance_after, 0) AS last_balance
+		FROM inventories i
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(quantity) AS total FROM inventory_movements WHERE store_id = i.store_id AND product_id = i.product_id
+		) m ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT balance_after FROM inventory_movements WHERE store_id = i.store_id AND product_id = i.product_id ORDER BY id DESC LIMIT 1
+		) last ON TRUE
+		WHERE i.store_id = $1 AND (i.quantity <> COALESCE(m.total, 0) OR i.quantity <> COALESCE(last.balance_after, 0))
+		ORDER BY p.name, p.id
+	`
+
+	err := i.db.Select(&mismatches, query, storeID)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get ledger mismatch").Msg("failed to get ledger mismatch")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to verify inventories",
+			Error:      err,
+		}
+	}
+
+	return mismatches, nil
+}
+
+// SetStockLevel implements InventoryRepository.
+func (i *inventoryRepository) SetStockLevel(storeID uuid.UUID, productID uuid.UUID, minQuantity *int, maxQuantity *int, updatedAt time.Time) (*entity.Inventory, *response.Error) {
+	tx, err := i.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "set stock level").Msg("failed to set stock level")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	_, errInit := tx.Exec(`INSERT INTO inventories (store_id, product_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, storeID, productID)
+	if errInit != nil {
+		var pqErr *pq.Error
+		if errors.As(errInit, &pqErr) && pqErr.Code == "23503" {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "store or product not found",
+				Error:      errors.New("store or product not found"),
+			}
+		}
+
+		log.Error().Err(errInit).Int("status", 500).Str("function", "set stock level").Msg("failed to set stock level")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to set stock level",
+			Error:      errInit,
+		}
+	}
+
+	query := `
+		UPDATE inventories SET min_quantity = $3, max_quantity = $4, updated_at = $5
+		WHERE store_id = $1 AND product_id = $2
+		RETURNING store_id, product_id, quantity, in_transit_quantity, min_quantity, max_quantity, average_cost, created_at, updated_at
+	`
+
+	var model entity.Inventory
+
+	errUpdate := tx.QueryRowx(query, storeID, productID, minQuantity, maxQuantity, updatedAt).StructScan(&model)
+	if errUpdate != nil {
+		log.Error().Err(errUpdate).Int("status", 500).Str("function", "set stock level").Msg("failed to set stock level")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to set stock level",
+			Error:      errUpdate,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "set stock level").Msg("failed to set stock level")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// GetLowStock implements InventoryRepository.
+func (i *inventoryRepository) GetLowStock(storeID uuid.UUID, offset int, limit int) ([]entity.Inventory, *response.Error) {
+	var inventories []entity.Inventory
+
+	// the stock on the shelf decides, what is in transit can still arrive late
+	query := `
+		SELECT i.store_id, i.product_id, p.sku AS product_sku, p.name AS product_name, i.quantity, i.in_transit_quantity, i.min_quantity, i.max_quantity, i.average_cost, i.created_at, i.updated_at
+		FROM inventories i
+		JOIN products p ON p.id = i.product_id
+		WHERE i.store_id = $1 AND p.deleted_at IS NULL AND i.min_quantity IS NOT NULL AND i.quantity <= i.min_quantity
+		ORDER BY i.quantity - i.min_quantity, p.name
+		LIMIT $2 OFFSET $3
+	`
+
+	err := i.db.Select(&inventories, query, storeID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get low stock").Msg("failed to get low stock")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch low stock",
+			Error:      err,
+		}
+	}
+
+	return inventories, nil
+}
+
+// GetReorderCandidate implements InventoryRepository.
+func (i *inventoryRepository) GetReorderCandidate(storeIDs []uuid.UUID, soldSince time.Time) ([]entity.ReorderCandidate, *response.Error) {
+	var candidates []entity.ReorderCandidate
+
+	// nil store ids means every active store
+	query := `
+		SELECT i.store_id, i.product_id, ps.supplier_id, COALESCE(ps.pack_size, 1) AS pack_size,
+			i.quantity, i.in_transit_quantity, i.min_quantity, i.max_quantity, su.lead_time_days,
+			COALESCE(sold.quantity, 0) AS sold_quantity
+		FROM inventories i
+		JOIN stores s ON s.id = i.store_id AND s.deleted_at IS NULL
+		JOIN products p ON p.id = i.product_id AND p.deleted_at IS NULL
+		LEFT JOIN product_suppliers ps ON ps.product_id = i.product_id
+		LEFT JOIN suppliers su ON su.id = ps.supplier_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(si.quantity - si.returned_quantity) AS quantity
+			FROM sale_items si
+			JOIN sales sa ON sa.id = si.sale_id
+			WHERE sa.store_id = i.store_id AND si.product_id = i.product_id AND sa.status = $3 AND sa.sold_at >= $2
+		) sold ON TRUE
+		WHERE i.min_quantity IS NOT NULL AND ($1::uuid[] IS NULL OR i.store_id = ANY($1))
+	`
+
+	err := i.db.Select(&candidates, query, pq.Array(storeIDs), soldSince, saleEntity.StatusCompleted)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get reorder candidate").Msg("failed to get reorder candidate")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch reorder candidates",
+			Error:      err,
+		}
+	}
+
+	return candidates, nil
+}
+
+// ReplaceReorderSuggestion implements InventoryRepository.
+func (i *inventoryRepository) ReplaceReorderSuggestion(storeIDs []uuid.UUID, suggestions []entity.ReorderSuggestion) *response.Error {
+	tx, err := i.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "replace reorder suggestion").Msg("failed to replace reorder suggestion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// a product that is no longer short must not keep its old suggestion
+	_, errDelete := tx.Exec(`DELETE FROM reorder_suggestions WHERE $1::uuid[] IS NULL OR store_id = ANY($1)`, pq.Array(storeIDs))
+	if errDelete != nil {
+		log.Error().Err(errDelete).Int("status", 500).Str("function", "replace reorder suggestion").Msg("failed to replace reorder suggestion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to replace reorder suggestions",
+			Error:      errDelete,
+		}
+	}
+
+	for _, suggestion := range suggestions {
+		_, errInsert := tx.Exec(`
+			INSERT INTO reorder_suggestions (store_id, product_id, supplier_id, quantity, in_transit_quantity, min_quantity, max_quantity,
+				daily_sales, lead_time_days, suggested_quantity, generated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, suggestion.StoreID,
+			suggestion.ProductID,
+			suggestion.SupplierID,
+			suggestion.Quantity,
+			suggestion.InTransitQuantity,
+			suggestion.MinQuantity,
+			suggestion.MaxQuantity,
+			suggestion.DailySales,
+			suggestion.LeadTimeDays,
+			suggestion.SuggestedQuantity,
+			suggestion.GeneratedAt)
+		if errInsert != nil {
+			log.Error().Err(errInsert).Int("status", 500).Str("function", "replace reorder suggestion").Msg("failed to replace reorder suggestion")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to replace reorder suggestions",
+				Error:      errInsert,
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "replace reorder suggestion").Msg("failed to replace reorder suggestion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// GetReorderSuggestion implements InventoryRepository.
+func (i *inventoryRepository) GetReorderSuggestion(storeID uuid.UUID) ([]entity.ReorderSuggestion, *response.Error) {
+	var suggestions []entity.ReorderSuggestion
+
+	query := `
+		SELECT r.store_id, r.product_id, p.sku AS product_sku, p.name AS product_name, r.supplier_id,
+			COALESCE(su.name, '') AS supplier_name, COALESCE(ps.supplier_sku, '') AS supplier_sku, COALESCE(ps.pack_size, 1) AS pack_size,
+			r.quantity, r.in_transit_quantity, r.min_quantity, r.max_quantity, r.daily_sales, r.lead_time_days,
+			r.suggested_quantity, r.generated_at
+		FROM reorder_suggestions r
+		JOIN products p ON p.id = r.product_id
+		LEFT JOIN suppliers su ON su.id = r.supplier_id
+		LEFT JOIN product_suppliers ps ON ps.product_id = r.product_id AND ps.supplier_id = r.supplier_id
+		WHERE r.store_id = $1
+		ORDER BY su.name NULLS LAST, r.supplier_id, p.name
+	`
+
+	err := i.db.Select(&suggestions, query, storeID)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get reorder suggestion").Msg("failed to get reorder suggestion")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch reorder suggestions",
+			Error:      err,
+		}
+	}
+
+	return suggestions, nil
+}
+
+func NewInventoryRepository(db *sqlx.DB) InventoryRepository {
+	return &inventoryRepository{db}
+}
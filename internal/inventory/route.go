@@ -0,0 +1,28 @@
+package inventory
+
+import (
+	handler "candyshop/internal/inventory/handler"
+	repository "candyshop/internal/inventory/repository"
+	service "candyshop/internal/inventory/service"
+	staff "candyshop/internal/staff"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewInventoryRepository(db)
+	service := service.NewInventoryService(repo)
+	handler := handler.NewInventoryHandler(service)
+
+	inventoryRoute := router.Group("api/v1/inventories", staff.Authenticate(db))
+
+	inventoryRoute.Get("", handler.GetInventoryByStore)
+	inventoryRoute.Post("/adjust", handler.AdjustInventory)
+	inventoryRoute.Get("/movements", handler.GetMovement)
+	inventoryRoute.Get("/verify", handler.VerifyInventory)
+	inventoryRoute.Patch("/levels", handler.SetStockLevel)
+	inventoryRoute.Get("/low-stock", handler.GetLowStock)
+	inventoryRoute.Get("/reorder-suggestions", handler.GetReorderSuggestion)
+	inventoryRoute.Post("/reorder-suggestions", handler.GenerateReorderSuggestion)
+}
@@ -0,0 +1,307 @@
+package inventory
+
+import (
+	dto "candyshop/internal/inventory/dto"
+	entity "candyshop/internal/inventory/entity"
+	repository "candyshop/internal/inventory/repository"
+	supplierService "candyshop/internal/supplier/service"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// VelocityDays is the window of recent sales used to measure how fast a product sells
+	VelocityDays = 28
+	// CoverDays is how long an order should last after arriving when the product has no max quantity
+	CoverDays = 14
+)
+
+type InventoryService interface {
+	GetInventoryByStore(caller *auth.Caller, storeID uuid.UUID, offset, limit int) ([]entity.Inventory, *response.Error)
+	AdjustInventory(caller *auth.Caller, data dto.AdjustInventoryRequest) (*entity.Inventory, *response.Error)
+	GetMovement(caller *auth.Caller, storeID, productID uuid.UUID, offset, limit int) ([]entity.Movement, *response.Error)
+	VerifyInventory(caller *auth.Caller, storeID uuid.UUID) ([]entity.LedgerMismatch, *response.Error)
+	SetStockLevel(caller *auth.Caller, data dto.SetStockLevelRequest) (*entity.Inventory, *response.Error)
+	GetLowStock(caller *auth.Caller, storeID uuid.UUID, offset, limit int) ([]entity.Inventory, *response.Error)
+	GenerateReorderSuggestion(caller *auth.Caller, data dto.GenerateReorderRequest) ([]entity.ReorderSuggestion, *response.Error)
+	// RegenerateReorderSuggestion generates the suggestions of every store without a caller, for the background job
+	RegenerateReorderSuggestion() ([]entity.ReorderSuggestion, *response.Error)
+	GetReorderSuggestion(caller *auth.Caller, storeID uuid.UUID) ([]entity.SupplierOrder, *response.Error)
+}
+
+type inventoryService struct {
+	repository repository.InventoryRepository
+}
+
+// GetInventoryByStore implements InventoryService.
+func (i *inventoryService) GetInventoryByStore(caller *auth.Caller, storeID uuid.UUID, offset int, limit int) ([]entity.Inventory, *response.Error) {
+	if !caller.CanAccessStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	inventories, errInventory := i.repository.GetInventoryByStore(storeID, offset, limit)
+	if errInventory != nil {
+		return nil, errInventory
+	}
+
+	hideAverageCost(caller, storeID, inventories)
+
+	return inventories, nil
+}
+
+// AdjustInventory implements InventoryService.
+func (i *inventoryService) AdjustInventory(caller *auth.Caller, data dto.AdjustInventoryRequest) (*entity.Inventory, *response.Error) {
+	if !caller.CanManageStore(data.StoreID) {
+		return nil, auth.ForbiddenStore(data.StoreID)
+	}
+
+	if data.Quantity == 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "quantity is invalid",
+			Error:      errors.New("quantity must not be zero"),
+		}
+	}
+
+	note := strings.TrimSpace(data.Note)
+	if len(note) > 255 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "note is invalid",
+			Error:      errors.New("note can not be longer than 255 characters"),
+		}
+	}
+
+	currentTime := time.Now()
+
+	return i.repository.AdjustInventory(data.StoreID, data.ProductID, data.Quantity, caller.UserID, note, currentTime)
+}
+
+// GetMovement implements InventoryService.
+func (i *inventoryService) GetMovement(caller *auth.Caller, storeID uuid.UUID, productID uuid.UUID, offset int, limit int) ([]entity.Movement, *response.Error) {
+	if !caller.CanAccessStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	movements, errMovement := i.repository.GetMovement(storeID, productID, offset, limit)
+	if errMovement != nil {
+		return nil, errMovement
+	}
+
+	// costs are for those who manage the store
+	if !caller.CanManageStore(storeID) {
+		for j := range movements {
+			movements[j].UnitCost = nil
+		}
+	}
+
+	return movements, nil
+}
+
+// VerifyInventory implements InventoryService.
+func (i *inventoryService) VerifyInventory(caller *auth.Caller, storeID uuid.UUID) ([]entity.LedgerMismatch, *response.Error) {
+	if !caller.CanManageStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	return i.repository.GetLedgerMismatch(storeID)
+}
+
+// SetStockLevel implements InventoryService.
+func (i *inventoryService) SetStockLevel(caller *auth.Caller, data dto.SetStockLevelRequest) (*entity.Inventory, *response.Error) {
+	if !caller.CanManageStore(data.StoreID) {
+		return nil, auth.ForbiddenStore(data.StoreID)
+	}
+
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if (data.MinQuantity != nil && *data.MinQuantity < 0) || (data.MaxQuantity != nil && *data.MaxQuantity < 0) {
+		return nil, invalid("min_quantity and max_quantity can not be negative")
+	}
+
+	if data.MinQuantity != nil && data.MaxQuantity != nil && *data.MaxQuantity < *data.MinQuantity {
+		return nil, invalid("max_quantity can not be less than min_quantity")
+	}
+
+	currentTime := time.Now()
+
+	return i.repository.SetStockLevel(data.StoreID, data.ProductID, data.MinQuantity, data.MaxQuantity, currentTime)
+}
+
+// GetLowStock implements InventoryService.
+func (i *inventoryService) GetLowStock(caller *auth.Caller, storeID uuid.UUID, offset int, limit int) ([]entity.Inventory, *response.Error) {
+	if !caller.CanAccessStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	inventories, errInventory := i.repository.GetLowStock(storeID, offset, limit)
+	if errInventory != nil {
+		return nil, errInventory
+	}
+
+	hideAverageCost(caller, storeID, inventories)
+
+	return inventories, nil
+}
+
+// GenerateReorderSuggestion implements InventoryService.
+func (i *inventoryService) GenerateReorderSuggestion(caller *auth.Caller, data dto.GenerateReorderRequest) ([]entity.ReorderSuggestion, *response.Error) {
+	var storeIDs []uuid.UUID
+
+	if data.StoreID == uuid.Nil {
+		if !caller.IsOwner() {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusForbidden,
+				Message:    "only owner can generate reorder suggestions for every store",
+				Error:      errors.New("only owner can generate reorder suggestions for every store"),
+			}
+		}
+	} else {
+		if !caller.CanManageStore(data.StoreID) {
+			return nil, auth.ForbiddenStore(data.StoreID)
+		}
+
+		storeIDs = []uuid.UUID{data.StoreID}
+	}
+
+	return i.generateReorderSuggestion(storeIDs)
+}
+
+// RegenerateReorderSuggestion implements InventoryService.
+func (i *inventoryService) RegenerateReorderSuggestion() ([]entity.ReorderSuggestion, *response.Error) {
+	return i.generateReorderSuggestion(nil)
+}
+
+// generateReorderSuggestion replaces the suggestions of the stores, nil store ids means every store.
+func (i *inventoryService) generateReorderSuggestion(storeIDs []uuid.UUID) ([]entity.ReorderSuggestion, *response.Error) {
+	currentTime := time.Now()
+
+	candidates, errCandidate := i.repository.GetReorderCandidate(storeIDs, currentTime.AddDate(0, 0, -VelocityDays))
+	if errCandidate != nil {
+		return nil, errCandidate
+	}
+
+	suggestions := []entity.ReorderSuggestion{}
+	for _, candidate := range candidates {
+		if suggestion, ok := suggestReorder(candidate, currentTime); ok {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	if errReplace := i.repository.ReplaceReorderSuggestion(storeIDs, suggestions); errReplace != nil {
+		return nil, errReplace
+	}
+
+	return suggestions, nil
+}
+
+// GetReorderSuggestion implements InventoryService.
+func (i *inventoryService) GetReorderSuggestion(caller *auth.Caller, storeID uuid.UUID) ([]entity.SupplierOrder, *response.Error) {
+	if !caller.CanAccessStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	suggestions, errSuggestion := i.repository.GetReorderSuggestion(storeID)
+	if errSuggestion != nil {
+		return nil, errSuggestion
+	}
+
+	// the suggestions come ordered by supplier, every change of supplier starts a new order
+	var orders []entity.SupplierOrder
+	for _, suggestion := range suggestions {
+		last := len(orders) - 1
+		if last < 0 || !sameSupplier(orders[last].SupplierID, suggestion.SupplierID) {
+			orders = append(orders, entity.SupplierOrder{
+				SupplierID:   suggestion.SupplierID,
+				SupplierName: suggestion.SupplierName,
+			})
+			last++
+		}
+
+		orders[last].Items = append(orders[last].Items, suggestion)
+	}
+
+	return orders, nil
+}
+
+// suggestReorder decides if a product has to be ordered. The reorder point is the min quantity plus what sells
+// during the lead time, below it the store orders up to the max quantity, or enough for CoverDays without a max.
+// The quantity is rounded up to whole packs of the supplier.
+func suggestReorder(candidate entity.ReorderCandidate, generatedAt time.Time) (entity.ReorderSuggestion, bool) {
+	leadTimeDays := supplierService.DefaultLeadTimeDays
+	if candidate.LeadTimeDays != nil {
+		leadTimeDays = *candidate.LeadTimeDays
+	}
+
+	dailySales := float64(candidate.SoldQuantity) / VelocityDays
+	available := candidate.Quantity + candidate.InTransitQuantity
+	reorderPoint := candidate.MinQuantity + int(math.Ceil(dailySales*float64(leadTimeDays)))
+
+	if available > reorderPoint {
+		return entity.ReorderSuggestion{}, false
+	}
+
+	target := reorderPoint + int(math.Ceil(dailySales*CoverDays))
+	if candidate.MaxQuantity != nil {
+		target = *candidate.MaxQuantity
+	}
+
+	needed := target - available
+	if needed <= 0 {
+		return entity.ReorderSuggestion{}, false
+	}
+
+	packSize := max(candidate.PackSize, 1)
+	minQuantity := candidate.MinQuantity
+
+	return entity.ReorderSuggestion{
+		StoreID:           candidate.StoreID,
+		ProductID:         candidate.ProductID,
+		SupplierID:        candidate.SupplierID,
+		PackSize:          packSize,
+		Quantity:          candidate.Quantity,
+		InTransitQuantity: candidate.InTransitQuantity,
+		MinQuantity:       &minQuantity,
+		MaxQuantity:       candidate.MaxQuantity,
+		DailySales:        math.Round(dailySales*10000) / 10000,
+		LeadTimeDays:      leadTimeDays,
+		SuggestedQuantity: (needed + packSize - 1) / packSize * packSize,
+		GeneratedAt:       generatedAt,
+	}, true
+}
+
+// hideAverageCost leaves the cost of the stock to those who manage the store.
+func hideAverageCost(caller *auth.Caller, storeID uuid.UUID, inventories []entity.Inventory) {
+	if caller.CanManageStore(storeID) {
+		return
+	}
+
+	for i := range inventories {
+		inventories[i].AverageCost = nil
+	}
+}
+
+func sameSupplier(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func NewInventoryService(repository repository.InventoryRepository) InventoryService {
+	return &inventoryService{repository}
+}
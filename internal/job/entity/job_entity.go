@@ -0,0 +1,43 @@
+package job
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job is a background job as the admin inspects it. Last error is the error of the last failed attempt,
+// locked until is when the worker running it loses it.
+type Job struct {
+	ID          int64           `json:"id" db:"id"`
+	Type        string          `json:"type" db:"type"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	Status      string          `json:"status" db:"status"`
+	Attempts    int             `json:"attempts" db:"attempts"`
+	MaxAttempts int             `json:"max_attempts" db:"max_attempts"`
+	UniqueKey   *string         `json:"unique_key" db:"unique_key"`
+	LastError   string          `json:"last_error" db:"last_error"`
+	RunAt       time.Time       `json:"run_at" db:"run_at"`
+	LockedUntil *time.Time      `json:"locked_until" db:"locked_until"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time      `json:"updated_at" db:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at" db:"finished_at"`
+}
+
+// Schedule is a cron schedule a worker queues its job on, times are in UTC.
+type Schedule struct {
+	Name      string          `json:"name" db:"name"`
+	Spec      string          `json:"spec" db:"spec"`
+	Type      string          `json:"type" db:"type"`
+	Payload   json.RawMessage `json:"payload" db:"payload"`
+	NextRunAt time.Time       `json:"next_run_at" db:"next_run_at"`
+	LastRunAt *time.Time      `json:"last_run_at" db:"last_run_at"`
+	UpdatedAt *time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Stat counts the jobs of a type in a status, due counts the queued ones whose time has come.
+type Stat struct {
+	Type   string `json:"type" db:"type"`
+	Status string `json:"status" db:"status"`
+	Count  int64  `json:"count" db:"count"`
+	Due    int64  `json:"due" db:"due"`
+}
@@ -0,0 +1,147 @@
+package job
+
+import (
+	service "candyshop/internal/job/service"
+	"candyshop/pkg/auth"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobHandler struct {
+	service service.JobService
+}
+
+func NewJobHandler(service service.JobService) *JobHandler {
+	return &JobHandler{service}
+}
+
+func (h *JobHandler) GetAllJob(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	jobs, errJob := h.service.GetAllJob(auth.GetCaller(c), c.Query("status"), c.Query("type"), offset, limit)
+	if errJob != nil {
+		return c.Status(errJob.StatusCode).JSON(fiber.Map{
+			"status_code": errJob.StatusCode,
+			"message":     "failed to fetch jobs",
+			"error":       errJob.Error.Error(),
+		})
+	}
+
+	if len(jobs) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch jobs",
+			"error":       "job not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data jobs",
+		"data":        jobs,
+	})
+}
+
+func (h *JobHandler) GetJobByID(c *fiber.Ctx) error {
+	id, errParse := strconv.ParseInt(c.Params("id"), 10, 64)
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	job, errJob := h.service.GetJobByID(auth.GetCaller(c), id)
+	if errJob != nil {
+		return c.Status(errJob.StatusCode).JSON(fiber.Map{
+			"status_code": errJob.StatusCode,
+			"message":     "failed to fetch job",
+			"error":       errJob.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data job",
+		"data":        job,
+	})
+}
+
+func (h *JobHandler) GetJobStat(c *fiber.Ctx) error {
+	stats, errStat := h.service.GetJobStat(auth.GetCaller(c))
+	if errStat != nil {
+		return c.Status(errStat.StatusCode).JSON(fiber.Map{
+			"status_code": errStat.StatusCode,
+			"message":     "failed to fetch job stats",
+			"error":       errStat.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data job stats",
+		"data":        stats,
+	})
+}
+
+func (h *JobHandler) GetAllSchedule(c *fiber.Ctx) error {
+	schedules, errSchedule := h.service.GetAllSchedule(auth.GetCaller(c))
+	if errSchedule != nil {
+		return c.Status(errSchedule.StatusCode).JSON(fiber.Map{
+			"status_code": errSchedule.StatusCode,
+			"message":     "failed to fetch job schedules",
+			"error":       errSchedule.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data job schedules",
+		"data":        schedules,
+	})
+}
+
+func (h *JobHandler) RetryJob(c *fiber.Ctx) error {
+	id, errParse := strconv.ParseInt(c.Params("id"), 10, 64)
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	job, errJob := h.service.RetryJob(auth.GetCaller(c), id)
+	if errJob != nil {
+		if errJob.StatusCode == fiber.StatusConflict {
+			return c.Status(errJob.StatusCode).JSON(fiber.Map{
+				"status_code": errJob.StatusCode,
+				"message":     "failed to retry job",
+				"error":       errJob.Message,
+			})
+		}
+
+		return c.Status(errJob.StatusCode).JSON(fiber.Map{
+			"status_code": errJob.StatusCode,
+			"message":     "failed to retry job",
+			"error":       errJob.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success retry job",
+		"data":        job,
+	})
+}
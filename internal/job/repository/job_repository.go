@@ -0,0 +1,148 @@
+package job
+
+import (
+	entity "candyshop/internal/job/entity"
+	"candyshop/pkg/job"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type JobRepository interface {
+	GetAllJob(status, jobType string, offset, limit int) ([]entity.Job, *response.Error)
+	GetJobByID(id int64) (*entity.Job, *response.Error)
+	GetJobStat() ([]entity.Stat, *response.Error)
+	GetAllSchedule() ([]entity.Schedule, *response.Error)
+	RetryJob(id int64, runAt time.Time) (*entity.Job, *response.Error)
+}
+
+type jobRepository struct {
+	db *sqlx.DB
+}
+
+const jobColumns = `id, type, payload, status, attempts, max_attempts, unique_key, last_error, run_at, locked_until, created_at, updated_at, finished_at`
+
+// GetAllJob implements JobRepository.
+func (j *jobRepository) GetAllJob(status string, jobType string, offset int, limit int) ([]entity.Job, *response.Error) {
+	var jobs []entity.Job
+
+	// an empty status or type matches every job, the newest jobs come first
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE ($3 = '' OR status = $3) AND ($4 = '' OR type = $4)
+		ORDER BY id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	err := j.db.Select(&jobs, query, limit, offset, status, jobType)
+	if err != nil {
+		return nil, jobError("get all job", err)
+	}
+
+	return jobs, nil
+}
+
+// GetJobByID implements JobRepository.
+func (j *jobRepository) GetJobByID(id int64) (*entity.Job, *response.Error) {
+	var model entity.Job
+
+	err := j.db.Get(&model, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch job",
+				Error:      err,
+			}
+		}
+
+		return nil, jobError("get job by id", err)
+	}
+
+	return &model, nil
+}
+
+// GetJobStat implements JobRepository.
+func (j *jobRepository) GetJobStat() ([]entity.Stat, *response.Error) {
+	var stats []entity.Stat
+
+	err := j.db.Select(&stats, `
+		SELECT type, status, COUNT(*) AS count, COUNT(*) FILTER (WHERE status = $1 AND run_at <= now()) AS due
+		FROM jobs
+		GROUP BY type, status
+		ORDER BY type, status
+	`, job.StatusQueued)
+	if err != nil {
+		return nil, jobError("get job stat", err)
+	}
+
+	return stats, nil
+}
+
+// GetAllSchedule implements JobRepository.
+func (j *jobRepository) GetAllSchedule() ([]entity.Schedule, *response.Error) {
+	var schedules []entity.Schedule
+
+	err := j.db.Select(&schedules, `
+		SELECT name, spec, type, payload, next_run_at, last_run_at, updated_at
+		FROM job_schedules
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, jobError("get all schedule", err)
+	}
+
+	return schedules, nil
+}
+
+// RetryJob implements JobRepository.
+func (j *jobRepository) RetryJob(id int64, runAt time.Time) (*entity.Job, *response.Error) {
+	var model entity.Job
+
+	// a retried job starts over with all of its attempts, the last error stays until it runs again
+	err := j.db.Get(&model, `
+		UPDATE jobs SET status = $3, attempts = 0, run_at = $2, locked_until = NULL, finished_at = NULL, updated_at = $2
+		WHERE id = $1 AND status = $4
+		RETURNING `+jobColumns, id, runAt, job.StatusQueued, job.StatusDead)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 409,
+				Message:    "only a dead job can be retried",
+				Error:      nil,
+			}
+		}
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, &response.Error{
+				StatusCode: 409,
+				Message:    "a job with the same unique key is already waiting",
+				Error:      nil,
+			}
+		}
+
+		return nil, jobError("retry job", err)
+	}
+
+	return &model, nil
+}
+
+func jobError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewJobRepository(db *sqlx.DB) JobRepository {
+	return &jobRepository{db}
+}
@@ -0,0 +1,25 @@
+package job
+
+import (
+	handler "candyshop/internal/job/handler"
+	repository "candyshop/internal/job/repository"
+	service "candyshop/internal/job/service"
+	staff "candyshop/internal/staff"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewJobRepository(db)
+	service := service.NewJobService(repo)
+	handler := handler.NewJobHandler(service)
+
+	jobRoute := router.Group("api/v1/jobs", staff.Authenticate(db))
+
+	jobRoute.Get("", handler.GetAllJob)
+	jobRoute.Get("/stats", handler.GetJobStat)
+	jobRoute.Get("/schedules", handler.GetAllSchedule)
+	jobRoute.Get("/:id", handler.GetJobByID)
+	jobRoute.Post("/:id/retry", handler.RetryJob)
+}
@@ -0,0 +1,102 @@
+package job
+
+import (
+	entity "candyshop/internal/job/entity"
+	repository "candyshop/internal/job/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/job"
+	"candyshop/pkg/response"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobService interface {
+	GetAllJob(caller *auth.Caller, status, jobType string, offset, limit int) ([]entity.Job, *response.Error)
+	GetJobByID(caller *auth.Caller, id int64) (*entity.Job, *response.Error)
+	GetJobStat(caller *auth.Caller) ([]entity.Stat, *response.Error)
+	GetAllSchedule(caller *auth.Caller) ([]entity.Schedule, *response.Error)
+	RetryJob(caller *auth.Caller, id int64) (*entity.Job, *response.Error)
+}
+
+type jobService struct {
+	repository repository.JobRepository
+}
+
+// GetAllJob implements JobService.
+func (j *jobService) GetAllJob(caller *auth.Caller, status string, jobType string, offset int, limit int) ([]entity.Job, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	switch status {
+	case "", job.StatusQueued, job.StatusRunning, job.StatusSucceeded, job.StatusDead:
+	default:
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "status must be queued, running, succeeded or dead",
+			Error:      errors.New("status must be queued, running, succeeded or dead"),
+		}
+	}
+
+	return j.repository.GetAllJob(status, jobType, offset, limit)
+}
+
+// GetJobByID implements JobService.
+func (j *jobService) GetJobByID(caller *auth.Caller, id int64) (*entity.Job, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	return j.repository.GetJobByID(id)
+}
+
+// GetJobStat implements JobService.
+func (j *jobService) GetJobStat(caller *auth.Caller) ([]entity.Stat, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	return j.repository.GetJobStat()
+}
+
+// GetAllSchedule implements JobService.
+func (j *jobService) GetAllSchedule(caller *auth.Caller) ([]entity.Schedule, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	return j.repository.GetAllSchedule()
+}
+
+// RetryJob implements JobService.
+func (j *jobService) RetryJob(caller *auth.Caller, id int64) (*entity.Job, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	// a missing job is told apart from a job that is not dead
+	if _, errJob := j.repository.GetJobByID(id); errJob != nil {
+		return nil, errJob
+	}
+
+	return j.repository.RetryJob(id, time.Now())
+}
+
+// onlyOwner keeps the jobs to the owner, they run for every store.
+func onlyOwner(caller *auth.Caller) *response.Error {
+	if caller.IsOwner() {
+		return nil
+	}
+
+	return &response.Error{
+		StatusCode: fiber.StatusForbidden,
+		Message:    "only owner can manage jobs",
+		Error:      errors.New("only owner can manage jobs"),
+	}
+}
+
+func NewJobService(repository repository.JobRepository) JobService {
+	return &jobService{repository}
+}
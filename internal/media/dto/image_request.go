@@ -0,0 +1,8 @@
+package media
+
+import "github.com/google/uuid"
+
+type ReorderImageRequest struct {
+	// ImageIDs lists every image of the product in the new order
+	ImageIDs []uuid.UUID `json:"image_ids"`
+}
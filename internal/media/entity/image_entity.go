@@ -0,0 +1,22 @@
+package media
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Image struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ProductID    uuid.UUID  `json:"product_id" db:"product_id"`
+	StorageKey   string     `json:"-" db:"storage_key"`
+	ThumbnailKey string     `json:"-" db:"thumbnail_key"`
+	URL          string     `json:"url" db:"-"`
+	ThumbnailURL string     `json:"thumbnail_url" db:"-"`
+	ContentType  string     `json:"content_type" db:"content_type"`
+	SizeBytes    int64      `json:"size_bytes" db:"size_bytes"`
+	Width        int        `json:"width" db:"width"`
+	Height       int        `json:"height" db:"height"`
+	Position     int        `json:"position" db:"position"`
+	CreatedAt    *time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,191 @@
+package media
+
+import (
+	dto "candyshop/internal/media/dto"
+	service "candyshop/internal/media/service"
+	"fmt"
+	"io"
+	"mime"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ImageHandler struct {
+	service service.ImageService
+}
+
+func NewImageHandler(service service.ImageService) *ImageHandler {
+	return &ImageHandler{service}
+}
+
+func (h *ImageHandler) GetProductImage(c *fiber.Ctx) error {
+	productID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	images, errImage := h.service.GetProductImage(productID)
+	if errImage != nil {
+		return c.Status(errImage.StatusCode).JSON(fiber.Map{
+			"status_code": errImage.StatusCode,
+			"message":     "failed to fetch images",
+			"error":       errImage.Error.Error(),
+		})
+	}
+
+	if len(images) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch images",
+			"error":       "image not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data images",
+		"data":        images,
+	})
+}
+
+func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
+	productID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	// the image is sent as multipart form field "image"
+	file, errFile := c.FormFile("image")
+	if errFile != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input image",
+			"error":       errFile.Error(),
+		})
+	}
+
+	if file.Size > service.MaxImageSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"status_code": fiber.StatusRequestEntityTooLarge,
+			"message":     "failed to input image",
+			"error":       fmt.Sprintf("image can not be larger than %d bytes", service.MaxImageSize),
+		})
+	}
+
+	opened, errOpen := file.Open()
+	if errOpen != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input image",
+			"error":       errOpen.Error(),
+		})
+	}
+
+	defer opened.Close()
+
+	data, errRead := io.ReadAll(io.LimitReader(opened, service.MaxImageSize+1))
+	if errRead != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input image",
+			"error":       errRead.Error(),
+		})
+	}
+
+	contentType, _, _ := mime.ParseMediaType(file.Header.Get(fiber.HeaderContentType))
+
+	image, errImage := h.service.UploadImage(productID, contentType, data)
+	if errImage != nil {
+		if errImage.StatusCode == fiber.StatusConflict {
+			return c.Status(errImage.StatusCode).JSON(fiber.Map{
+				"status_code": errImage.StatusCode,
+				"message":     "failed to upload image",
+				"error":       errImage.Message,
+			})
+		}
+
+		return c.Status(errImage.StatusCode).JSON(fiber.Map{
+			"status_code": errImage.StatusCode,
+			"message":     "failed to upload image",
+			"error":       errImage.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success upload image",
+		"data":        image,
+	})
+}
+
+func (h *ImageHandler) ReorderImage(c *fiber.Ctx) error {
+	productID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.ReorderImageRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input image order",
+			"error":       err.Error(),
+		})
+	}
+
+	images, errImage := h.service.ReorderImage(productID, req.ImageIDs)
+	if errImage != nil {
+		return c.Status(errImage.StatusCode).JSON(fiber.Map{
+			"status_code": errImage.StatusCode,
+			"message":     "failed to reorder images",
+			"error":       errImage.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success reorder images",
+		"data":        images,
+	})
+}
+
+func (h *ImageHandler) DeleteImage(c *fiber.Ctx) error {
+	productID, errParse := uuid.Parse(c.Params("id"))
+	imageID, errParseImage := uuid.Parse(c.Params("image_id"))
+	if errParse != nil || errParseImage != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       "id is invalid",
+		})
+	}
+
+	errImage := h.service.DeleteImage(productID, imageID)
+	if errImage != nil {
+		return c.Status(errImage.StatusCode).JSON(fiber.Map{
+			"status_code": errImage.StatusCode,
+			"message":     "failed to delete image",
+			"error":       errImage.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete image",
+		"data":        nil,
+	})
+}
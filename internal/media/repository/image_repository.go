@@ -0,0 +1,202 @@
+package media
+
+import (
+	entity "candyshop/internal/media/entity"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type ImageRepository interface {
+	IsProductActive(productID uuid.UUID) (bool, *response.Error)
+	GetProductImage(productID uuid.UUID) ([]entity.Image, *response.Error)
+	GetImageByID(id uuid.UUID) (*entity.Image, *response.Error)
+	CreateImage(data entity.Image) (*entity.Image, *response.Error)
+	ReorderImage(productID uuid.UUID, imageIDs []uuid.UUID) *response.Error
+	DeleteImage(id uuid.UUID) *response.Error
+}
+
+type imageRepository struct {
+	db *sqlx.DB
+}
+
+// IsProductActive implements ImageRepository.
+func (i *imageRepository) IsProductActive(productID uuid.UUID) (bool, *response.Error) {
+	var active bool
+
+	err := i.db.Get(&active, `SELECT deleted_at IS NULL FROM products WHERE id = $1`, productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, &response.Error{
+				StatusCode: 404,
+				Message:    "product not found",
+				Error:      errors.New("product not found"),
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "is product active").Msg("failed to get product")
+		return false, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch product",
+			Error:      err,
+		}
+	}
+
+	return active, nil
+}
+
+// GetProductImage implements ImageRepository.
+func (i *imageRepository) GetProductImage(productID uuid.UUID) ([]entity.Image, *response.Error) {
+	var images []entity.Image
+
+	query := `
+		SELECT id, product_id, storage_key, thumbnail_key, content_type, size_bytes, width, height, position, created_at
+		FROM product_images
+		WHERE product_id = $1
+		ORDER BY position, created_at
+	`
+
+	err := i.db.Select(&images, query, productID)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get product image").Msg("failed to get product image")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch images",
+			Error:      err,
+		}
+	}
+
+	return images, nil
+}
+
+// GetImageByID implements ImageRepository.
+func (i *imageRepository) GetImageByID(id uuid.UUID) (*entity.Image, *response.Error) {
+	var image entity.Image
+
+	query := `
+		SELECT id, product_id, storage_key, thumbnail_key, content_type, size_bytes, width, height, position, created_at
+		FROM product_images
+		WHERE id = $1
+	`
+
+	err := i.db.Get(&image, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "image not found",
+				Error:      errors.New("image not found"),
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get image by id").Msg("failed to get image by id")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch image",
+			Error:      err,
+		}
+	}
+
+	return &image, nil
+}
+
+// CreateImage implements ImageRepository.
+func (i *imageRepository) CreateImage(data entity.Image) (*entity.Image, *response.Error) {
+	var model entity.Image
+
+	// a new image goes after the existing ones
+	query := `
+		INSERT INTO product_images (id, product_id, storage_key, thumbnail_key, content_type, size_bytes, width, height, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			(SELECT COALESCE(MAX(position) + 1, 0) FROM product_images WHERE product_id = $2))
+		RETURNING id, product_id, storage_key, thumbnail_key, content_type, size_bytes, width, height, position, created_at
+	`
+
+	err := i.db.QueryRowx(query,
+		data.ID,
+		data.ProductID,
+		data.StorageKey,
+		data.ThumbnailKey,
+		data.ContentType,
+		data.SizeBytes,
+		data.Width,
+		data.Height).StructScan(&model)
+
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create image").Msg("failed to create image")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create image",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// ReorderImage implements ImageRepository.
+func (i *imageRepository) ReorderImage(productID uuid.UUID, imageIDs []uuid.UUID) *response.Error {
+	tx, err := i.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "reorder image").Msg("failed to reorder image")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the position of an image is its index in the list
+	query := `
+		UPDATE product_images pi SET position = ordered.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS ordered(id, position)
+		WHERE pi.id = ordered.id AND pi.product_id = $1
+	`
+
+	_, errExec := tx.Exec(query, productID, pq.Array(imageIDs))
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "reorder image").Msg("failed to reorder image")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to reorder image",
+			Error:      errExec,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "reorder image").Msg("failed to reorder image")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// DeleteImage implements ImageRepository.
+func (i *imageRepository) DeleteImage(id uuid.UUID) *response.Error {
+	_, err := i.db.Exec(`DELETE FROM product_images WHERE id = $1`, id)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete image").Msg("failed to delete image")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete image",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+func NewImageRepository(db *sqlx.DB) ImageRepository {
+	return &imageRepository{db}
+}
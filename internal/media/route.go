@@ -0,0 +1,24 @@
+package media
+
+import (
+	handler "candyshop/internal/media/handler"
+	repository "candyshop/internal/media/repository"
+	service "candyshop/internal/media/service"
+	"candyshop/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB, storage storage.Storage) {
+	repo := repository.NewImageRepository(db)
+	service := service.NewImageService(repo, storage)
+	handler := handler.NewImageHandler(service)
+
+	imageRoute := router.Group("api/v1/products/:id/images")
+
+	imageRoute.Get("", handler.GetProductImage)
+	imageRoute.Post("", handler.UploadImage)
+	imageRoute.Patch("/order", handler.ReorderImage)
+	imageRoute.Delete("/:image_id", handler.DeleteImage)
+}
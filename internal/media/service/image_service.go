@@ -0,0 +1,269 @@
+package media
+
+import (
+	"bytes"
+	entity "candyshop/internal/media/entity"
+	repository "candyshop/internal/media/repository"
+	"candyshop/pkg/response"
+	"candyshop/pkg/storage"
+	"candyshop/pkg/thumbnail"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// MaxImageSize is the largest upload accepted, in bytes
+	MaxImageSize = 5 * 1024 * 1024
+	// MaxImagePixels stops images that are small on disk but huge once decoded
+	MaxImagePixels = 40_000_000
+	// MaxProductImages limits the images of one product
+	MaxProductImages = 20
+	// ThumbnailSize is the longest side of a thumbnail in pixels
+	ThumbnailSize = 320
+)
+
+// imageExtensions maps the accepted content types to the extension of the stored file
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+type ImageService interface {
+	GetProductImage(productID uuid.UUID) ([]entity.Image, *response.Error)
+	UploadImage(productID uuid.UUID, contentType string, data []byte) (*entity.Image, *response.Error)
+	ReorderImage(productID uuid.UUID, imageIDs []uuid.UUID) ([]entity.Image, *response.Error)
+	DeleteImage(productID uuid.UUID, id uuid.UUID) *response.Error
+}
+
+type imageService struct {
+	repository repository.ImageRepository
+	storage    storage.Storage
+}
+
+// GetProductImage implements ImageService.
+func (i *imageService) GetProductImage(productID uuid.UUID) ([]entity.Image, *response.Error) {
+	images, errImage := i.repository.GetProductImage(productID)
+	if errImage != nil {
+		return nil, errImage
+	}
+
+	for index := range images {
+		i.setURL(&images[index])
+	}
+
+	return images, nil
+}
+
+// UploadImage implements ImageService.
+func (i *imageService) UploadImage(productID uuid.UUID, contentType string, data []byte) (*entity.Image, *response.Error) {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, invalid("image is required")
+	}
+
+	if len(data) > MaxImageSize {
+		return nil, invalid(fmt.Sprintf("image can not be larger than %d bytes", MaxImageSize))
+	}
+
+	// the declared type comes from the client, the bytes decide
+	detected := http.DetectContentType(data)
+	extension, ok := imageExtensions[detected]
+	if !ok {
+		return nil, invalid("image must be jpeg, png or gif")
+	}
+
+	if contentType != "" && contentType != "application/octet-stream" && contentType != detected {
+		return nil, invalid(fmt.Sprintf("content type %s does not match the image %s", contentType, detected))
+	}
+
+	config, _, errConfig := image.DecodeConfig(bytes.NewReader(data))
+	if errConfig != nil {
+		return nil, invalid("image can not be read")
+	}
+
+	if config.Width*config.Height > MaxImagePixels {
+		return nil, invalid(fmt.Sprintf("image can not have more than %d pixels", MaxImagePixels))
+	}
+
+	active, errProduct := i.repository.IsProductActive(productID)
+	if errProduct != nil {
+		return nil, errProduct
+	}
+
+	if !active {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "product is not active",
+			Error:      nil,
+		}
+	}
+
+	images, errImage := i.repository.GetProductImage(productID)
+	if errImage != nil {
+		return nil, errImage
+	}
+
+	if len(images) >= MaxProductImages {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("product already has %d images", MaxProductImages),
+			Error:      nil,
+		}
+	}
+
+	decoded, _, errDecode := image.Decode(bytes.NewReader(data))
+	if errDecode != nil {
+		return nil, invalid("image can not be read")
+	}
+
+	thumb, thumbType, errThumb := thumbnail.Encode(thumbnail.Resize(decoded, ThumbnailSize), detected != "image/jpeg")
+	if errThumb != nil {
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create thumbnail",
+			Error:      errThumb,
+		}
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataImage := &entity.Image{
+		ID:           newUUID,
+		ProductID:    productID,
+		StorageKey:   fmt.Sprintf("products/%s/%s.%s", productID, newUUID, extension),
+		ThumbnailKey: fmt.Sprintf("products/%s/%s_thumb.%s", productID, newUUID, imageExtensions[thumbType]),
+		ContentType:  detected,
+		SizeBytes:    int64(len(data)),
+		Width:        config.Width,
+		Height:       config.Height,
+	}
+
+	if err := i.storage.Put(dataImage.StorageKey, detected, data); err != nil {
+		return nil, storageError("failed to store image", err)
+	}
+
+	if err := i.storage.Put(dataImage.ThumbnailKey, thumbType, thumb); err != nil {
+		i.removeFiles(*dataImage)
+		return nil, storageError("failed to store thumbnail", err)
+	}
+
+	productImage, errCreate := i.repository.CreateImage(*dataImage)
+	if errCreate != nil {
+		i.removeFiles(*dataImage)
+		return nil, errCreate
+	}
+
+	i.setURL(productImage)
+
+	return productImage, nil
+}
+
+// ReorderImage implements ImageService.
+func (i *imageService) ReorderImage(productID uuid.UUID, imageIDs []uuid.UUID) ([]entity.Image, *response.Error) {
+	images, errImage := i.repository.GetProductImage(productID)
+	if errImage != nil {
+		return nil, errImage
+	}
+
+	current := map[uuid.UUID]bool{}
+	for _, productImage := range images {
+		current[productImage.ID] = true
+	}
+
+	// the new order has to name every image of the product once
+	seen := map[uuid.UUID]bool{}
+	for _, id := range imageIDs {
+		if !current[id] || seen[id] {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("image %s is unknown or listed twice", id),
+				Error:      fmt.Errorf("image %s is unknown or listed twice", id),
+			}
+		}
+
+		seen[id] = true
+	}
+
+	if len(seen) != len(current) {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "image_ids must list every image of the product",
+			Error:      errors.New("image_ids must list every image of the product"),
+		}
+	}
+
+	if errReorder := i.repository.ReorderImage(productID, imageIDs); errReorder != nil {
+		return nil, errReorder
+	}
+
+	return i.GetProductImage(productID)
+}
+
+// DeleteImage implements ImageService.
+func (i *imageService) DeleteImage(productID uuid.UUID, id uuid.UUID) *response.Error {
+	productImage, errImage := i.repository.GetImageByID(id)
+	if errImage != nil {
+		return errImage
+	}
+
+	if productImage.ProductID != productID {
+		return &response.Error{
+			StatusCode: fiber.StatusNotFound,
+			Message:    "image not found",
+			Error:      errors.New("image not found"),
+		}
+	}
+
+	if errDelete := i.repository.DeleteImage(id); errDelete != nil {
+		return errDelete
+	}
+
+	// the row is gone already, a file left behind only costs space
+	i.removeFiles(*productImage)
+
+	return nil
+}
+
+func (i *imageService) setURL(productImage *entity.Image) {
+	productImage.URL = i.storage.URL(productImage.StorageKey)
+	productImage.ThumbnailURL = i.storage.URL(productImage.ThumbnailKey)
+}
+
+// removeFiles deletes the stored files of an image, failures are only logged.
+func (i *imageService) removeFiles(productImage entity.Image) {
+	for _, key := range []string{productImage.StorageKey, productImage.ThumbnailKey} {
+		if err := i.storage.Delete(key); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "remove image file").Str("key", key).Msg("failed to remove image file")
+		}
+	}
+}
+
+func storageError(message string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", "store image").Msg(message)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    message,
+		Error:      err,
+	}
+}
+
+func NewImageService(repository repository.ImageRepository, storage storage.Storage) ImageService {
+	return &imageService{repository, storage}
+}
@@ -3,13 +3,23 @@ package product
 import "github.com/google/uuid"
 
 type CreateProductRequest struct {
-	SKU            string `json:"sku"`
-	Type           string `json:"type"`
-	Name           string `json:"name"`
-	Brand          string `json:"brand"`
-	SugarLevel     int    `json:"sugar_level"`
-	ProductionYear string `json:"production_year"`
-	Distributor    string `json:"distributor"`
+	SKU                string     `json:"sku"`
+	Type               string     `json:"type"`
+	Name               string     `json:"name"`
+	Brand              string     `json:"brand"`
+	SugarLevel         int        `json:"sugar_level"`
+	ProductionYear     string     `json:"production_year"`
+	Distributor        string     `json:"distributor"`
+	Price              int64      `json:"price"`
+	CategoryID         *uuid.UUID `json:"category_id"`
+	Tags               []string   `json:"tags"`
+	Ingredients        string     `json:"ingredients"`
+	Allergens          []string   `json:"allergens"`
+	CaloriesPerServing *int       `json:"calories_per_serving"`
+	ServingSizeGrams   *int       `json:"serving_size_grams"`
+	NetWeightGrams     *int       `json:"net_weight_grams"`
+	IsHalal            bool       `json:"is_halal"`
+	IsKosher           bool       `json:"is_kosher"`
 }
 
 type UpdateProductRequest struct {
@@ -21,4 +31,31 @@ type UpdateProductRequest struct {
 	SugarLevel     int       `json:"sugar_level"`
 	ProductionYear string    `json:"production_year"`
 	Distributor    string    `json:"distributor"`
+	Price          int64     `json:"price"`
+	// CategoryID moves the product to another category, uuid.Nil removes the category
+	CategoryID *uuid.UUID `json:"category_id"`
+	// Tags replaces the tags of the product when it is sent, an empty list removes them
+	Tags        []string `json:"tags"`
+	Ingredients string   `json:"ingredients"`
+	// Allergens replaces the declared allergens when it is sent, an empty list declares the product free of all of them
+	Allergens          []string `json:"allergens"`
+	CaloriesPerServing *int     `json:"calories_per_serving"`
+	ServingSizeGrams   *int     `json:"serving_size_grams"`
+	NetWeightGrams     *int     `json:"net_weight_grams"`
+	IsHalal            *bool    `json:"is_halal"`
+	IsKosher           *bool    `json:"is_kosher"`
+	// VariantName renames a variant, the other shared fields of a variant follow its parent and are ignored here
+	VariantName string `json:"variant_name"`
+}
+
+type AddBarcodeRequest struct {
+	Code string `json:"code"`
+}
+
+// CreateVariantRequest adds a variant under a parent product, everything else is copied from the parent.
+type CreateVariantRequest struct {
+	SKU            string `json:"sku"`
+	VariantName    string `json:"variant_name"`
+	Price          int64  `json:"price"`
+	NetWeightGrams *int   `json:"net_weight_grams"`
 }
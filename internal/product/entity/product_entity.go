@@ -4,19 +4,56 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Product struct {
-	ID             uuid.UUID  `json:"id" db:"id"`
-	SKU            string     `json:"sku" db:"sku"`
-	Type           string     `json:"type" db:"type"`
-	Name           string     `json:"name" db:"name"`
-	Brand          string     `json:"brand" db:"brand"`
-	SugarLevel     int        `json:"sugar_level" db:"sugar_level"`
-	ProductionYear string     `json:"production_year" db:"production_year"`
-	Distributor    string     `json:"distributor" db:"distributor"`
-	Status         bool       `json:"status" db:"status"`
-	CreatedAt      *time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      *time.Time `json:"-" db:"updated_at"`
-	DeletedAt      *time.Time `json:"-" db:"deleted_at"`
+	ID                 uuid.UUID      `json:"id" db:"id"`
+	SKU                string         `json:"sku" db:"sku"`
+	Type               string         `json:"type" db:"type"` // free text from before categories, new products copy the category name
+	Name               string         `json:"name" db:"name"`
+	Brand              string         `json:"brand" db:"brand"`
+	SugarLevel         int            `json:"sugar_level" db:"sugar_level"`
+	ProductionYear     string         `json:"production_year" db:"production_year"`
+	Distributor        string         `json:"distributor" db:"distributor"`
+	Price              int64          `json:"price" db:"price"` // selling price in the smallest currency unit
+	CategoryID         *uuid.UUID     `json:"category_id" db:"category_id"`
+	ParentID           *uuid.UUID     `json:"parent_id" db:"parent_id"`       // set on variants, they share everything but sku, price and weight with the parent
+	VariantName        string         `json:"variant_name" db:"variant_name"` // 250g, pack of 6
+	Tags               pq.StringArray `json:"tags" db:"tags"`
+	Barcodes           pq.StringArray `json:"barcodes" db:"barcodes"`
+	Ingredients        string         `json:"ingredients" db:"ingredients"`
+	Allergens          pq.StringArray `json:"allergens" db:"allergens"` // nil means the allergens are not declared yet
+	CaloriesPerServing *int           `json:"calories_per_serving" db:"calories_per_serving"`
+	ServingSizeGrams   *int           `json:"serving_size_grams" db:"serving_size_grams"`
+	NetWeightGrams     *int           `json:"net_weight_grams" db:"net_weight_grams"`
+	IsHalal            bool           `json:"is_halal" db:"is_halal"`
+	IsKosher           bool           `json:"is_kosher" db:"is_kosher"`
+	Status             bool           `json:"status" db:"status"`
+	CreatedAt          *time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt          *time.Time     `json:"-" db:"updated_at"`
+	DeletedAt          *time.Time     `json:"-" db:"deleted_at"`
+	Variants           []Product      `json:"variants,omitempty" db:"-"`
+}
+
+type Barcode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ProductID uuid.UUID  `json:"product_id" db:"product_id"`
+	Code      string     `json:"code" db:"code"`
+	Format    string     `json:"format" db:"format"`
+	GTIN      string     `json:"-" db:"gtin"`
+	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+}
+
+// ProductFilter narrows the product listing, empty fields are not filtered.
+type ProductFilter struct {
+	// CategoryID also matches the products of every sub category
+	CategoryID *uuid.UUID
+	Tag        string
+	// AllergenFree keeps the products that declared none of these allergens
+	AllergenFree []string
+	Halal        *bool
+	Kosher       *bool
+	// ParentID lists the variants of a product, top level products are listed without it
+	ParentID *uuid.UUID
 }
@@ -1,11 +1,19 @@
 package product
 
 import (
+	"bufio"
 	dto "candyshop/internal/product/dto"
+	entity "candyshop/internal/product/entity"
 	service "candyshop/internal/product/service"
+	"candyshop/pkg/export"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 type ProductHandler struct {
@@ -28,7 +36,16 @@ func (h *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	products, errProduct := h.service.GetAllProduct(offset, limit)
+	filter, message, errFilter := productFilter(c)
+	if errFilter != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     message,
+			"error":       errFilter.Error(),
+		})
+	}
+
+	products, errProduct := h.service.GetAllProduct(filter, offset, limit)
 	if errProduct != nil {
 		return c.Status(errProduct.StatusCode).JSON(fiber.Map{
 			"status_code": errProduct.StatusCode,
@@ -127,6 +144,50 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	})
 }
 
+func (h *ProductHandler) CreateVariant(c *fiber.Ctx) error {
+	parentID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.CreateVariantRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data variant",
+			"error":       err.Error(),
+		})
+	}
+
+	variant, errVariant := h.service.CreateVariant(parentID, req)
+	if errVariant != nil {
+		if errVariant.StatusCode == fiber.StatusConflict {
+			return c.Status(errVariant.StatusCode).JSON(fiber.Map{
+				"status_code": errVariant.StatusCode,
+				"message":     "failed to create variant",
+				"error":       errVariant.Message,
+			})
+		}
+
+		return c.Status(errVariant.StatusCode).JSON(fiber.Map{
+			"status_code": errVariant.StatusCode,
+			"message":     "failed to create variant",
+			"error":       errVariant.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data variant",
+		"data":        variant,
+	})
+}
+
 func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	var req dto.UpdateProductRequest
 
@@ -191,3 +252,206 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 		"data":        nil,
 	})
 }
+
+func (h *ProductHandler) ExportProduct(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit")
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "invalid offset or limit",
+			"error":       nil,
+		})
+	}
+
+	format, errFormat := export.ParseFormat(c.Query("format"))
+	if errFormat != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "format is invalid",
+			"error":       errFormat.Error(),
+		})
+	}
+
+	// the export takes the filters of the list
+	filter, message, errFilter := productFilter(c)
+	if errFilter != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     message,
+			"error":       errFilter.Error(),
+		})
+	}
+
+	normalized, errNormalize := h.service.NormalizeFilter(filter)
+	if errNormalize != nil {
+		return c.Status(errNormalize.StatusCode).JSON(fiber.Map{
+			"status_code": errNormalize.StatusCode,
+			"message":     errNormalize.Message,
+			"error":       errNormalize.Error.Error(),
+		})
+	}
+
+	columns, errColumns := h.service.ExportColumns(c.Query("columns"))
+	if errColumns != nil {
+		return c.Status(errColumns.StatusCode).JSON(fiber.Map{
+			"status_code": errColumns.StatusCode,
+			"message":     errColumns.Message,
+			"error":       errColumns.Error.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, format.Filename("products")))
+
+	// rows are written while they are read from database, the status is already sent so errors can only be logged
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		errExport := h.service.ExportProduct(*normalized, offset, limit, columns, export.NewWriter(format, w))
+		if errExport != nil {
+			log.Error().Err(errExport.Error).Int("status", errExport.StatusCode).Str("function", "export product").Msg(errExport.Message)
+		}
+	})
+
+	return nil
+}
+
+func (h *ProductHandler) LookupProduct(c *fiber.Ctx) error {
+	product, errProduct := h.service.LookupProduct(c.Query("barcode"), c.Query("sku"))
+	if errProduct != nil {
+		return c.Status(errProduct.StatusCode).JSON(fiber.Map{
+			"status_code": errProduct.StatusCode,
+			"message":     "failed to lookup product",
+			"error":       errProduct.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success lookup product",
+		"data":        product,
+	})
+}
+
+func (h *ProductHandler) AddBarcode(c *fiber.Ctx) error {
+	productID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.AddBarcodeRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data barcode",
+			"error":       err.Error(),
+		})
+	}
+
+	barcode, errBarcode := h.service.AddBarcode(productID, req.Code)
+	if errBarcode != nil {
+		if errBarcode.StatusCode == fiber.StatusConflict {
+			return c.Status(errBarcode.StatusCode).JSON(fiber.Map{
+				"status_code": errBarcode.StatusCode,
+				"message":     "failed to add barcode",
+				"error":       errBarcode.Message,
+			})
+		}
+
+		return c.Status(errBarcode.StatusCode).JSON(fiber.Map{
+			"status_code": errBarcode.StatusCode,
+			"message":     "failed to add barcode",
+			"error":       errBarcode.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success add barcode",
+		"data":        barcode,
+	})
+}
+
+func (h *ProductHandler) DeleteBarcode(c *fiber.Ctx) error {
+	productID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errBarcode := h.service.DeleteBarcode(productID, c.Params("code"))
+	if errBarcode != nil {
+		return c.Status(errBarcode.StatusCode).JSON(fiber.Map{
+			"status_code": errBarcode.StatusCode,
+			"message":     "failed to delete barcode",
+			"error":       errBarcode.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete barcode",
+		"data":        nil,
+	})
+}
+
+// productFilter reads the filters shared by the product list and export, the message names the invalid one.
+func productFilter(c *fiber.Ctx) (entity.ProductFilter, string, error) {
+	filter := entity.ProductFilter{Tag: c.Query("tag")}
+
+	if c.Query("category_id") != "" {
+		categoryID, errParse := uuid.Parse(c.Query("category_id"))
+		if errParse != nil {
+			return filter, "invalid category_id", errParse
+		}
+
+		filter.CategoryID = &categoryID
+	}
+
+	// parent_id lists the variants of a product instead of the top level products
+	if c.Query("parent_id") != "" {
+		parentID, errParse := uuid.Parse(c.Query("parent_id"))
+		if errParse != nil {
+			return filter, "invalid parent_id", errParse
+		}
+
+		filter.ParentID = &parentID
+	}
+
+	// allergen_free=nuts,peanuts lists the nut free products
+	if c.Query("allergen_free") != "" {
+		filter.AllergenFree = strings.Split(c.Query("allergen_free"), ",")
+	}
+
+	halal, errHalal := queryBool(c, "halal")
+	kosher, errKosher := queryBool(c, "kosher")
+	if errHalal != nil || errKosher != nil {
+		return filter, "invalid halal or kosher", errors.Join(errHalal, errKosher)
+	}
+
+	filter.Halal, filter.Kosher = halal, kosher
+
+	return filter, "", nil
+}
+
+// queryBool reads an optional boolean query, nil when it is not sent.
+func queryBool(c *fiber.Ctx, key string) (*bool, error) {
+	if c.Query(key) == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseBool(c.Query(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
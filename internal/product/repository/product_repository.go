@@ -2,35 +2,68 @@ package product
 
 import (
 	entity "candyshop/internal/product/entity"
+	"candyshop/pkg/event"
 	"candyshop/pkg/response"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
 type ProductRepository interface {
-	GetAllProduct(offset, limit int) ([]entity.Product, *response.Error)
+	GetAllProduct(filter entity.ProductFilter, offset, limit int) ([]entity.Product, *response.Error)
 	GetProductByID(id uuid.UUID) (*entity.Product, *response.Error)
 	GetProductBySKU(sku string) (*entity.Product, *response.Error)
 	CreateProduct(data entity.Product) (*entity.Product, *response.Error)
 	UpdateProduct(data entity.Product) *response.Error
 	DeleteProduct(id uuid.UUID, deletedAt time.Time) *response.Error
+	StreamProduct(filter entity.ProductFilter, offset, limit int, fn func(entity.Product) error) *response.Error
+	GetCategoryName(id uuid.UUID) (string, *response.Error)
+	LookupProduct(gtin, sku string) (*entity.Product, *response.Error)
+	GetBarcode(gtin string) (*entity.Barcode, *response.Error)
+	CreateBarcode(data entity.Barcode) (*entity.Barcode, *response.Error)
+	DeleteBarcode(productID uuid.UUID, gtin string) *response.Error
+	GetProductVariant(parentIDs []uuid.UUID) ([]entity.Product, *response.Error)
 }
 
 type productRepository struct {
 	db *sqlx.DB
 }
 
+// productFilterQuery walks down the category tree and matches the products of an entity.ProductFilter,
+// its arguments come after the limit and offset, from $3 on, as productFilterArgs gives them.
+// The category filter lists the products of the sub categories, the allergen filter skips products
+// that never declared their allergens. Without a parent only top level products match.
+const productFilterQuery = `
+	WITH RECURSIVE category_tree AS (
+		SELECT id FROM categories WHERE id = $3 AND deleted_at IS NULL
+		UNION ALL
+		SELECT c.id FROM categories c JOIN category_tree ct ON c.parent_id = ct.id WHERE c.deleted_at IS NULL
+	), matched AS (
+		SELECT p.id FROM products p
+		WHERE ($3::uuid IS NULL OR p.category_id IN (SELECT id FROM category_tree))
+			AND ($4 = '' OR EXISTS (SELECT 1 FROM product_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.product_id = p.id AND t.name = $4))
+			AND ($5::text[] IS NULL OR (p.allergens IS NOT NULL AND NOT p.allergens && $5))
+			AND ($6::boolean IS NULL OR p.is_halal = $6)
+			AND ($7::boolean IS NULL OR p.is_kosher = $7)
+			AND (($8::uuid IS NULL AND p.parent_id IS NULL) OR p.parent_id = $8)
+	)`
+
+func productFilterArgs(filter entity.ProductFilter) []any {
+	return []any{filter.CategoryID, filter.Tag, pq.Array(filter.AllergenFree), filter.Halal, filter.Kosher, filter.ParentID}
+}
+
 // GetProductBySKU implements ProductRepository.
 func (p *productRepository) GetProductBySKU(sku string) (*entity.Product, *response.Error) {
 	var product entity.Product
 
 	query := `
-		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, status, created_at
+		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id, parent_id, variant_name, ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, created_at
 		FROM products
 		WHERE sku = $1
 	`
@@ -71,8 +104,11 @@ func (p *productRepository) CreateProduct(data entity.Product) (*entity.Product,
 	defer tx.Rollback()
 
 	query := `
-		INSERT INTO products (id, sku, type, name, brand, sugar_level, production_year, distributor, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, sku, type, name, brand, sugar_level, production_year, distributor, status, created_at
+		INSERT INTO products (id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id,
+			ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, parent_id, variant_name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		RETURNING id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id, parent_id, variant_name,
+			ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, created_at
 	`
 
 	var model entity.Product
@@ -86,7 +122,18 @@ func (p *productRepository) CreateProduct(data entity.Product) (*entity.Product,
 		data.SugarLevel,
 		data.ProductionYear,
 		data.Distributor,
-		data.Status).Scan(&model.ID,
+		data.Price,
+		data.CategoryID,
+		data.Ingredients,
+		data.Allergens,
+		data.CaloriesPerServing,
+		data.ServingSizeGrams,
+		data.NetWeightGrams,
+		data.IsHalal,
+		data.IsKosher,
+		data.Status,
+		data.ParentID,
+		data.VariantName).Scan(&model.ID,
 		&model.SKU,
 		&model.Type,
 		&model.Name,
@@ -94,6 +141,17 @@ func (p *productRepository) CreateProduct(data entity.Product) (*entity.Product,
 		&model.SugarLevel,
 		&model.ProductionYear,
 		&model.Distributor,
+		&model.Price,
+		&model.CategoryID,
+		&model.ParentID,
+		&model.VariantName,
+		&model.Ingredients,
+		&model.Allergens,
+		&model.CaloriesPerServing,
+		&model.ServingSizeGrams,
+		&model.NetWeightGrams,
+		&model.IsHalal,
+		&model.IsKosher,
 		&model.Status,
 		&model.CreatedAt)
 
@@ -106,6 +164,21 @@ func (p *productRepository) CreateProduct(data entity.Product) (*entity.Product,
 		}
 	}
 
+	if errTags := setProductTags(tx, model.ID, data.Tags); errTags != nil {
+		return nil, errTags
+	}
+
+	model.Tags = data.Tags
+
+	if errEvent := event.Emit(tx, event.ProductCreated, model.ID, model); errEvent != nil {
+		log.Error().Err(errEvent).Int("status", 500).Str("function", "create product").Msg("failed to emit product created")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create product",
+			Error:      errEvent,
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Error().Err(err).Int("status", 500).Str("function", "create product").Msg("failed to create product")
 		return nil, &response.Error{
@@ -132,9 +205,12 @@ func (p *productRepository) DeleteProduct(id uuid.UUID, deletedAt time.Time) *re
 
 	defer tx.Rollback()
 
-	query := `UPDATE products SET deleted_at = $2, status = false WHERE id = $1`
+	// the variants of a parent go with it
+	query := `UPDATE products SET deleted_at = $2, status = false WHERE (id = $1 OR parent_id = $1) AND deleted_at IS NULL RETURNING id`
 
-	_, errExec := tx.Exec(query, id, deletedAt)
+	var deleted []uuid.UUID
+
+	errExec := tx.Select(&deleted, query, id, deletedAt)
 	if errExec != nil {
 		log.Error().Err(errExec).Int("status", 500).Str("function", "delete product").Msg("failed to delete product")
 		return &response.Error{
@@ -144,6 +220,18 @@ func (p *productRepository) DeleteProduct(id uuid.UUID, deletedAt time.Time) *re
 		}
 	}
 
+	for _, deletedID := range deleted {
+		errEvent := event.Emit(tx, event.ProductDeleted, deletedID, map[string]any{"id": deletedID, "deleted_at": deletedAt})
+		if errEvent != nil {
+			log.Error().Err(errEvent).Int("status", 500).Str("function", "delete product").Msg("failed to emit product deleted")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to delete product",
+				Error:      errEvent,
+			}
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Error().Err(err).Int("status", 500).Str("function", "delete product").Msg("failed to delete product")
 		return &response.Error{
@@ -157,16 +245,21 @@ func (p *productRepository) DeleteProduct(id uuid.UUID, deletedAt time.Time) *re
 }
 
 // GetAllProduct implements ProductRepository.
-func (p *productRepository) GetAllProduct(offset int, limit int) ([]entity.Product, *response.Error) {
+func (p *productRepository) GetAllProduct(filter entity.ProductFilter, offset int, limit int) ([]entity.Product, *response.Error) {
 	var products []entity.Product
 
-	query := `
-		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, status, created_at
-		FROM products
+	// the variants of the top level products are attached by the service
+	query := productFilterQuery + `
+		SELECT p.id, p.sku, p.type, p.name, p.brand, p.sugar_level, p.production_year, p.distributor, p.price, p.category_id, p.parent_id, p.variant_name, p.ingredients, p.allergens, p.calories_per_serving, p.serving_size_grams, p.net_weight_grams, p.is_halal, p.is_kosher, p.status, p.created_at,
+			ARRAY(SELECT t.name FROM product_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.product_id = p.id ORDER BY t.name) AS tags,
+			ARRAY(SELECT b.code FROM product_barcodes b WHERE b.product_id = p.id ORDER BY b.code) AS barcodes
+		FROM products p
+		WHERE p.id IN (SELECT id FROM matched)
+		ORDER BY p.id
 		LIMIT $1 OFFSET $2
 		`
 
-	err := p.db.Select(&products, query, limit, offset)
+	err := p.db.Select(&products, query, append([]any{limit, offset}, productFilterArgs(filter)...)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Error().Err(err).Int("status", 404).Str("function", "get all product").Msg("failed to get all product")
@@ -193,7 +286,9 @@ func (p *productRepository) GetProductByID(id uuid.UUID) (*entity.Product, *resp
 	var product entity.Product
 
 	query := `
-		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, status, created_at
+		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id, parent_id, variant_name, ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, created_at,
+			ARRAY(SELECT t.name FROM product_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.product_id = products.id ORDER BY t.name) AS tags,
+			ARRAY(SELECT b.code FROM product_barcodes b WHERE b.product_id = products.id ORDER BY b.code) AS barcodes
 		FROM products
 		WHERE id = $1
 	`
@@ -234,7 +329,10 @@ func (p *productRepository) UpdateProduct(data entity.Product) *response.Error {
 	defer tx.Rollback()
 
 	query := `
-		UPDATE products SET sku = $2, type = $3, name = $4, brand = $5, sugar_level = $6, production_year = $7, distributor = $8, updated_at = $9 WHERE id = $1
+		UPDATE products SET sku = $2, type = $3, name = $4, brand = $5, sugar_level = $6, production_year = $7, distributor = $8, price = $9, category_id = $10,
+			ingredients = $11, allergens = $12, calories_per_serving = $13, serving_size_grams = $14, net_weight_grams = $15, is_halal = $16, is_kosher = $17,
+			updated_at = $18, variant_name = $19
+		WHERE id = $1
 	`
 
 	_, errExec := tx.Exec(query,
@@ -246,7 +344,17 @@ func (p *productRepository) UpdateProduct(data entity.Product) *response.Error {
 		data.SugarLevel,
 		data.ProductionYear,
 		data.Distributor,
+		data.Price,
+		data.CategoryID,
+		data.Ingredients,
+		data.Allergens,
+		data.CaloriesPerServing,
+		data.ServingSizeGrams,
+		data.NetWeightGrams,
+		data.IsHalal,
+		data.IsKosher,
 		data.UpdatedAt,
+		data.VariantName,
 	)
 
 	if errExec != nil {
@@ -258,6 +366,21 @@ func (p *productRepository) UpdateProduct(data entity.Product) *response.Error {
 		}
 	}
 
+	// nil tags keep the current tags
+	if data.Tags != nil {
+		if errTags := setProductTags(tx, data.ID, data.Tags); errTags != nil {
+			return errTags
+		}
+	}
+
+	if errVariant := syncVariants(tx, data.ID, data.Tags != nil); errVariant != nil {
+		return errVariant
+	}
+
+	if errEvent := emitProductUpdated(tx, data.ID); errEvent != nil {
+		return errEvent
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Error().Err(err).Int("status", 500).Str("function", "update product").Msg("failed to update product")
 		return &response.Error{
@@ -270,6 +393,369 @@ func (p *productRepository) UpdateProduct(data entity.Product) *response.Error {
 	return nil
 }
 
+// StreamProduct implements ProductRepository.
+func (p *productRepository) StreamProduct(filter entity.ProductFilter, offset int, limit int, fn func(entity.Product) error) *response.Error {
+	// limit 0 means no limit for export
+	var queryLimit *int
+	if limit > 0 {
+		queryLimit = &limit
+	}
+
+	// the list attaches the variants of its products, the export writes them right after their parent
+	query := productFilterQuery + `
+		SELECT p.id, p.sku, p.type, p.name, p.brand, p.sugar_level, p.production_year, p.distributor, p.price, p.category_id, p.parent_id, p.variant_name, p.ingredients, p.allergens, p.calories_per_serving, p.serving_size_grams, p.net_weight_grams, p.is_halal, p.is_kosher, p.status, p.created_at,
+			ARRAY(SELECT t.name FROM product_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.product_id = p.id ORDER BY t.name) AS tags,
+			ARRAY(SELECT b.code FROM product_barcodes b WHERE b.product_id = p.id ORDER BY b.code) AS barcodes
+		FROM products p
+		WHERE p.id IN (SELECT id FROM matched) OR p.parent_id IN (SELECT id FROM matched)
+		ORDER BY COALESCE(p.parent_id, p.id), p.parent_id NULLS FIRST, p.id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := p.db.Queryx(query, append([]any{queryLimit, offset}, productFilterArgs(filter)...)...)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "stream product").Msg("failed to stream product")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch products",
+			Error:      err,
+		}
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var product entity.Product
+		if err := rows.StructScan(&product); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "stream product").Msg("failed to stream product")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to fetch products",
+				Error:      err,
+			}
+		}
+
+		if err := fn(product); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "stream product").Msg("failed to write product")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to write products",
+				Error:      err,
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "stream product").Msg("failed to stream product")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch products",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// GetCategoryName implements ProductRepository.
+func (p *productRepository) GetCategoryName(id uuid.UUID) (string, *response.Error) {
+	var name string
+
+	err := p.db.Get(&name, `SELECT name FROM categories WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", &response.Error{
+				StatusCode: 404,
+				Message:    "category not found",
+				Error:      errors.New("category not found"),
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get category name").Msg("failed to get category name")
+		return "", &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch category",
+			Error:      err,
+		}
+	}
+
+	return name, nil
+}
+
+// LookupProduct implements ProductRepository.
+func (p *productRepository) LookupProduct(gtin string, sku string) (*entity.Product, *response.Error) {
+	var product entity.Product
+
+	// one indexed round trip, the register waits on it for every scan
+	query := `
+		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id, parent_id, variant_name,
+			ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, created_at
+		FROM products
+		WHERE deleted_at IS NULL AND sku = $1
+	`
+	arg := sku
+
+	if gtin != "" {
+		query = `
+			SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id, parent_id, variant_name,
+				ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, created_at
+			FROM products
+			WHERE deleted_at IS NULL AND id = (SELECT product_id FROM product_barcodes WHERE gtin = $1)
+		`
+		arg = gtin
+	}
+
+	err := p.db.Get(&product, query, arg)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "product not found",
+				Error:      errors.New("product not found"),
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "lookup product").Msg("failed to lookup product")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch product",
+			Error:      err,
+		}
+	}
+
+	return &product, nil
+}
+
+// GetBarcode implements ProductRepository.
+func (p *productRepository) GetBarcode(gtin string) (*entity.Barcode, *response.Error) {
+	var barcode entity.Barcode
+
+	err := p.db.Get(&barcode, `SELECT id, product_id, code, format, gtin, created_at FROM product_barcodes WHERE gtin = $1`, gtin)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "barcode not found",
+				Error:      errors.New("barcode not found"),
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get barcode").Msg("failed to get barcode")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch barcode",
+			Error:      err,
+		}
+	}
+
+	return &barcode, nil
+}
+
+// CreateBarcode implements ProductRepository.
+func (p *productRepository) CreateBarcode(data entity.Barcode) (*entity.Barcode, *response.Error) {
+	var model entity.Barcode
+
+	query := `
+		INSERT INTO product_barcodes (id, product_id, code, format, gtin) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, product_id, code, format, gtin, created_at
+	`
+
+	err := p.db.QueryRowx(query, data.ID, data.ProductID, data.Code, data.Format, data.GTIN).StructScan(&model)
+	if err != nil {
+		// two requests registering the same barcode at once, the unique index keeps one
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, &response.Error{
+				StatusCode: 409,
+				Message:    fmt.Sprintf("barcode %s already registered", data.Code),
+				Error:      nil,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "create barcode").Msg("failed to create barcode")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create barcode",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// DeleteBarcode implements ProductRepository.
+func (p *productRepository) DeleteBarcode(productID uuid.UUID, gtin string) *response.Error {
+	result, err := p.db.Exec(`DELETE FROM product_barcodes WHERE product_id = $1 AND gtin = $2`, productID, gtin)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete barcode").Msg("failed to delete barcode")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete barcode",
+			Error:      err,
+		}
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &response.Error{
+			StatusCode: 404,
+			Message:    "barcode not found",
+			Error:      errors.New("barcode not found"),
+		}
+	}
+
+	return nil
+}
+
+// GetProductVariant implements ProductRepository.
+func (p *productRepository) GetProductVariant(parentIDs []uuid.UUID) ([]entity.Product, *response.Error) {
+	var variants []entity.Product
+
+	query := `
+		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id, parent_id, variant_name, ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, created_at,
+			ARRAY(SELECT b.code FROM product_barcodes b WHERE b.product_id = products.id ORDER BY b.code) AS barcodes
+		FROM products
+		WHERE parent_id = ANY($1) AND deleted_at IS NULL
+		ORDER BY parent_id, net_weight_grams NULLS LAST, sku
+	`
+
+	err := p.db.Select(&variants, query, pq.Array(parentIDs))
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get product variant").Msg("failed to get product variant")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch variants",
+			Error:      err,
+		}
+	}
+
+	return variants, nil
+}
+
+// syncVariants copies the shared fields of a parent product to its variants, tags only when they changed.
+func syncVariants(tx *sqlx.Tx, parentID uuid.UUID, tags bool) *response.Error {
+	_, errUpdate := tx.Exec(`
+		UPDATE products v SET type = p.type, name = p.name, brand = p.brand, sugar_level = p.sugar_level,
+			production_year = p.production_year, distributor = p.distributor, category_id = p.category_id,
+			ingredients = p.ingredients, allergens = p.allergens, calories_per_serving = p.calories_per_serving,
+			serving_size_grams = p.serving_size_grams, is_halal = p.is_halal, is_kosher = p.is_kosher, updated_at = p.updated_at
+		FROM products p
+		WHERE p.id = $1 AND v.parent_id = p.id
+	`, parentID)
+	if errUpdate != nil {
+		log.Error().Err(errUpdate).Int("status", 500).Str("function", "sync variants").Msg("failed to update variants")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update variants",
+			Error:      errUpdate,
+		}
+	}
+
+	if !tags {
+		return nil
+	}
+
+	_, errDelete := tx.Exec(`DELETE FROM product_tags WHERE product_id IN (SELECT id FROM products WHERE parent_id = $1)`, parentID)
+	if errDelete != nil {
+		log.Error().Err(errDelete).Int("status", 500).Str("function", "sync variants").Msg("failed to delete variant tags")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update variants",
+			Error:      errDelete,
+		}
+	}
+
+	_, errInsert := tx.Exec(`
+		INSERT INTO product_tags (product_id, tag_id)
+		SELECT v.id, pt.tag_id FROM products v JOIN product_tags pt ON pt.product_id = v.parent_id
+		WHERE v.parent_id = $1
+	`, parentID)
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "sync variants").Msg("failed to insert variant tags")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update variants",
+			Error:      errInsert,
+		}
+	}
+
+	return nil
+}
+
+// emitProductUpdated emits the product as stored after the update, with its variants since they follow their parent.
+func emitProductUpdated(tx *sqlx.Tx, id uuid.UUID) *response.Error {
+	var products []entity.Product
+
+	errSelect := tx.Select(&products, `
+		SELECT id, sku, type, name, brand, sugar_level, production_year, distributor, price, category_id, parent_id, variant_name, ingredients, allergens, calories_per_serving, serving_size_grams, net_weight_grams, is_halal, is_kosher, status, created_at,
+			ARRAY(SELECT t.name FROM product_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.product_id = products.id ORDER BY t.name) AS tags,
+			ARRAY(SELECT b.code FROM product_barcodes b WHERE b.product_id = products.id ORDER BY b.code) AS barcodes
+		FROM products
+		WHERE (id = $1 OR parent_id = $1) AND deleted_at IS NULL
+		ORDER BY parent_id NULLS FIRST, id
+	`, id)
+	if errSelect != nil {
+		log.Error().Err(errSelect).Int("status", 500).Str("function", "update product").Msg("failed to emit product updated")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update product",
+			Error:      errSelect,
+		}
+	}
+
+	for _, product := range products {
+		if errEvent := event.Emit(tx, event.ProductUpdated, product.ID, product); errEvent != nil {
+			log.Error().Err(errEvent).Int("status", 500).Str("function", "update product").Msg("failed to emit product updated")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to update product",
+				Error:      errEvent,
+			}
+		}
+	}
+
+	return nil
+}
+
+// setProductTags replaces the tags of a product, every tag has to exist already.
+func setProductTags(tx *sqlx.Tx, productID uuid.UUID, tags []string) *response.Error {
+	_, errDelete := tx.Exec(`DELETE FROM product_tags WHERE product_id = $1`, productID)
+	if errDelete != nil {
+		log.Error().Err(errDelete).Int("status", 500).Str("function", "set product tags").Msg("failed to delete product tags")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to save product tags",
+			Error:      errDelete,
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	result, errInsert := tx.Exec(`
+		INSERT INTO product_tags (product_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2)
+	`, productID, pq.Array(tags))
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "set product tags").Msg("failed to insert product tags")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to save product tags",
+			Error:      errInsert,
+		}
+	}
+
+	if affected, _ := result.RowsAffected(); int(affected) != len(tags) {
+		return &response.Error{
+			StatusCode: 404,
+			Message:    "tag not found",
+			Error:      fmt.Errorf("some of tags %v are not registered", tags),
+		}
+	}
+
+	return nil
+}
+
 func NewProductRepository(db *sqlx.DB) ProductRepository {
 	return &productRepository{db}
 }
@@ -18,7 +18,12 @@ func Init(router fiber.Router, db *sqlx.DB) {
 
 	productRoute.Get("", handler.GetAllProduct)
 	productRoute.Post("", handler.CreateProduct)
+	productRoute.Get("/export", handler.ExportProduct)
+	productRoute.Get("/lookup", handler.LookupProduct)
 	productRoute.Get("/:id", handler.GetProductByID)
 	productRoute.Patch("", handler.UpdateProduct)
 	productRoute.Patch("/delete/:id", handler.DeleteProduct)
+	productRoute.Post("/:id/variants", handler.CreateVariant)
+	productRoute.Post("/:id/barcodes", handler.AddBarcode)
+	productRoute.Delete("/:id/barcodes/:code", handler.DeleteBarcode)
 }
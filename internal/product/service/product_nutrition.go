@@ -0,0 +1,92 @@
+package product
+
+import (
+	entity "candyshop/internal/product/entity"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Allergens that have to be declared on the label, tree nuts and peanuts are kept apart
+// because customers ask for them separately.
+var Allergens = []string{"nuts", "peanuts", "milk", "gluten", "soy", "egg", "sesame"}
+
+// allergenAliases maps the common ways of writing an allergen to its name.
+var allergenAliases = map[string]string{
+	"nut":       "nuts",
+	"tree-nuts": "nuts",
+	"tree_nuts": "nuts",
+	"peanut":    "peanuts",
+	"dairy":     "milk",
+	"wheat":     "gluten",
+	"soya":      "soy",
+	"eggs":      "egg",
+}
+
+// normalizeAllergens lower cases the allergens, removes duplicates and rejects unknown ones.
+// A nil list stays nil because it means the allergens are not declared.
+func normalizeAllergens(allergens []string) ([]string, *response.Error) {
+	if allergens == nil {
+		return nil, nil
+	}
+
+	normalized := []string{}
+	for _, allergen := range allergens {
+		allergen = strings.ToLower(strings.TrimSpace(allergen))
+		if alias, ok := allergenAliases[allergen]; ok {
+			allergen = alias
+		}
+
+		if !slices.Contains(Allergens, allergen) {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("allergen %s is invalid, use %s", allergen, strings.Join(Allergens, ", ")),
+				Error:      fmt.Errorf("allergen %s is invalid", allergen),
+			}
+		}
+
+		if !slices.Contains(normalized, allergen) {
+			normalized = append(normalized, allergen)
+		}
+	}
+
+	slices.Sort(normalized)
+
+	return normalized, nil
+}
+
+func validateNutrition(product entity.Product) *response.Error {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if product.CaloriesPerServing != nil && *product.CaloriesPerServing < 0 {
+		return invalid("calories_per_serving can not be negative")
+	}
+
+	if product.ServingSizeGrams != nil && *product.ServingSizeGrams <= 0 {
+		return invalid("serving_size_grams must be more than 0")
+	}
+
+	if product.NetWeightGrams != nil && *product.NetWeightGrams <= 0 {
+		return invalid("net_weight_grams must be more than 0")
+	}
+
+	if product.CaloriesPerServing != nil && product.ServingSizeGrams == nil {
+		return invalid("serving_size_grams is required with calories_per_serving")
+	}
+
+	if product.ServingSizeGrams != nil && product.NetWeightGrams != nil && *product.ServingSizeGrams > *product.NetWeightGrams {
+		return invalid("serving_size_grams can not be more than net_weight_grams")
+	}
+
+	return nil
+}
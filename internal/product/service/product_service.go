@@ -4,8 +4,14 @@ import (
 	dto "candyshop/internal/product/dto"
 	entity "candyshop/internal/product/entity"
 	repository "candyshop/internal/product/repository"
+	tag "candyshop/internal/tag/service"
+	"candyshop/pkg/barcode"
+	"candyshop/pkg/export"
 	"candyshop/pkg/response"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,19 +19,127 @@ import (
 )
 
 type ProductService interface {
-	GetAllProduct(offset, limit int) ([]entity.Product, *response.Error)
+	GetAllProduct(filter entity.ProductFilter, offset, limit int) ([]entity.Product, *response.Error)
 	GetProductByID(id uuid.UUID) (*entity.Product, *response.Error)
 	CreateProduct(data dto.CreateProductRequest) (*entity.Product, *response.Error)
+	CreateVariant(parentID uuid.UUID, data dto.CreateVariantRequest) (*entity.Product, *response.Error)
 	UpdateProduct(data dto.UpdateProductRequest) *response.Error
 	DeleteProduct(id uuid.UUID) *response.Error
+	ExportColumns(columns string) ([]string, *response.Error)
+	ExportProduct(filter entity.ProductFilter, offset, limit int, columns []string, writer export.Writer) *response.Error
+	NormalizeFilter(filter entity.ProductFilter) (*entity.ProductFilter, *response.Error)
+	LookupProduct(code, sku string) (*entity.Product, *response.Error)
+	AddBarcode(productID uuid.UUID, code string) (*entity.Barcode, *response.Error)
+	DeleteBarcode(productID uuid.UUID, code string) *response.Error
 }
 
 type productService struct {
 	repository repository.ProductRepository
 }
 
+func negativePriceError() *response.Error {
+	return &response.Error{
+		StatusCode: fiber.StatusBadRequest,
+		Message:    "price can not be negative",
+		Error:      errors.New("price can not be negative"),
+	}
+}
+
+// productColumns lists the columns available for product export.
+var productColumns = export.Columns[entity.Product]{
+	{Name: "id", Value: func(p entity.Product) string { return p.ID.String() }},
+	{Name: "sku", Value: func(p entity.Product) string { return p.SKU }},
+	{Name: "type", Value: func(p entity.Product) string { return p.Type }},
+	{Name: "name", Value: func(p entity.Product) string { return p.Name }},
+	{Name: "brand", Value: func(p entity.Product) string { return p.Brand }},
+	{Name: "sugar_level", Value: func(p entity.Product) string { return strconv.Itoa(p.SugarLevel) }},
+	{Name: "production_year", Value: func(p entity.Product) string { return p.ProductionYear }},
+	{Name: "distributor", Value: func(p entity.Product) string { return p.Distributor }},
+	{Name: "price", Value: func(p entity.Product) string { return strconv.FormatInt(p.Price, 10) }},
+	{Name: "category_id", Value: func(p entity.Product) string {
+		if p.CategoryID == nil {
+			return ""
+		}
+		return p.CategoryID.String()
+	}},
+	{Name: "parent_id", Value: func(p entity.Product) string {
+		if p.ParentID == nil {
+			return ""
+		}
+		return p.ParentID.String()
+	}},
+	{Name: "variant_name", Value: func(p entity.Product) string { return p.VariantName }},
+	{Name: "allergens", Value: func(p entity.Product) string { return strings.Join(p.Allergens, ",") }},
+	{Name: "tags", Value: func(p entity.Product) string { return strings.Join(p.Tags, ",") }},
+	{Name: "barcodes", Value: func(p entity.Product) string { return strings.Join(p.Barcodes, ",") }},
+	{Name: "is_halal", Value: func(p entity.Product) string { return strconv.FormatBool(p.IsHalal) }},
+	{Name: "is_kosher", Value: func(p entity.Product) string { return strconv.FormatBool(p.IsKosher) }},
+	{Name: "status", Value: func(p entity.Product) string { return strconv.FormatBool(p.Status) }},
+	{Name: "created_at", Value: func(p entity.Product) string { return export.FormatTime(p.CreatedAt) }},
+}
+
+// ExportColumns implements ProductService.
+func (p *productService) ExportColumns(columns string) ([]string, *response.Error) {
+	selected, err := productColumns.Select(export.SplitColumns(columns))
+	if err != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "columns is invalid",
+			Error:      err,
+		}
+	}
+
+	return selected.Names(), nil
+}
+
+// ExportProduct implements ProductService.
+func (p *productService) ExportProduct(filter entity.ProductFilter, offset int, limit int, columns []string, writer export.Writer) *response.Error {
+	normalized, errFilter := p.NormalizeFilter(filter)
+	if errFilter != nil {
+		return errFilter
+	}
+
+	selected, err := productColumns.Select(columns)
+	if err != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "columns is invalid",
+			Error:      err,
+		}
+	}
+
+	if err := writer.WriteHeader(selected.Names()); err != nil {
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to write products",
+			Error:      err,
+		}
+	}
+
+	errStream := p.repository.StreamProduct(*normalized, offset, limit, func(product entity.Product) error {
+		return writer.WriteRow(selected.Values(product))
+	})
+	if errStream != nil {
+		return errStream
+	}
+
+	if err := writer.Close(); err != nil {
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to write products",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
 // CreateProduct implements ProductService.
 func (p *productService) CreateProduct(data dto.CreateProductRequest) (*entity.Product, *response.Error) {
+	if data.Price < 0 {
+		return nil, negativePriceError()
+	}
+
 	checkSKU, errSKU := p.repository.GetProductBySKU(data.SKU)
 	if errSKU != nil && errSKU.StatusCode != 404 {
 		return nil, errSKU
@@ -39,18 +153,50 @@ func (p *productService) CreateProduct(data dto.CreateProductRequest) (*entity.P
 		}
 	}
 
+	if data.CategoryID != nil {
+		categoryName, errCategory := p.repository.GetCategoryName(*data.CategoryID)
+		if errCategory != nil {
+			return nil, errCategory
+		}
+
+		// keep the old type column in line for reports that still read it
+		if data.Type == "" {
+			data.Type = categoryName
+		}
+	}
+
 	newUUID, _ := uuid.NewV7()
 
 	dataProduct := &entity.Product{
-		ID:             newUUID,
-		SKU:            data.SKU,
-		Type:           data.Type,
-		Name:           data.Name,
-		Brand:          data.Brand,
-		SugarLevel:     data.SugarLevel,
-		ProductionYear: data.ProductionYear,
-		Distributor:    data.Distributor,
-		Status:         true,
+		ID:                 newUUID,
+		SKU:                data.SKU,
+		Type:               data.Type,
+		Name:               data.Name,
+		Brand:              data.Brand,
+		SugarLevel:         data.SugarLevel,
+		ProductionYear:     data.ProductionYear,
+		Distributor:        data.Distributor,
+		Price:              data.Price,
+		CategoryID:         data.CategoryID,
+		Tags:               productTags(data.Tags),
+		Ingredients:        strings.TrimSpace(data.Ingredients),
+		CaloriesPerServing: data.CaloriesPerServing,
+		ServingSizeGrams:   data.ServingSizeGrams,
+		NetWeightGrams:     data.NetWeightGrams,
+		IsHalal:            data.IsHalal,
+		IsKosher:           data.IsKosher,
+		Status:             true,
+	}
+
+	allergens, errAllergen := normalizeAllergens(data.Allergens)
+	if errAllergen != nil {
+		return nil, errAllergen
+	}
+
+	dataProduct.Allergens = allergens
+
+	if errNutrition := validateNutrition(*dataProduct); errNutrition != nil {
+		return nil, errNutrition
 	}
 
 	return p.repository.CreateProduct(*dataProduct)
@@ -77,18 +223,198 @@ func (p *productService) DeleteProduct(id uuid.UUID) *response.Error {
 	return p.repository.DeleteProduct(id, currentTime)
 }
 
+// NormalizeFilter implements ProductService.
+func (p *productService) NormalizeFilter(filter entity.ProductFilter) (*entity.ProductFilter, *response.Error) {
+	filter.Tag = tag.NormalizeName(filter.Tag)
+
+	if filter.AllergenFree != nil {
+		allergens, errAllergen := normalizeAllergens(filter.AllergenFree)
+		if errAllergen != nil {
+			return nil, errAllergen
+		}
+
+		filter.AllergenFree = allergens
+	}
+
+	return &filter, nil
+}
+
 // GetAllProduct implements ProductService.
-func (p *productService) GetAllProduct(offset int, limit int) ([]entity.Product, *response.Error) {
-	return p.repository.GetAllProduct(offset, limit)
+func (p *productService) GetAllProduct(filter entity.ProductFilter, offset int, limit int) ([]entity.Product, *response.Error) {
+	normalized, errFilter := p.NormalizeFilter(filter)
+	if errFilter != nil {
+		return nil, errFilter
+	}
+
+	filter = *normalized
+
+	products, errProduct := p.repository.GetAllProduct(filter, offset, limit)
+	if errProduct != nil {
+		return nil, errProduct
+	}
+
+	// a listing of variants has nothing to attach
+	if filter.ParentID != nil {
+		return products, nil
+	}
+
+	if errVariant := p.attachVariants(products); errVariant != nil {
+		return nil, errVariant
+	}
+
+	return products, nil
 }
 
 // GetProductByID implements ProductService.
 func (p *productService) GetProductByID(id uuid.UUID) (*entity.Product, *response.Error) {
-	return p.repository.GetProductByID(id)
+	product, errProduct := p.repository.GetProductByID(id)
+	if errProduct != nil {
+		return nil, errProduct
+	}
+
+	if product.ParentID == nil {
+		products := []entity.Product{*product}
+		if errVariant := p.attachVariants(products); errVariant != nil {
+			return nil, errVariant
+		}
+
+		product = &products[0]
+	}
+
+	return product, nil
+}
+
+// CreateVariant implements ProductService.
+func (p *productService) CreateVariant(parentID uuid.UUID, data dto.CreateVariantRequest) (*entity.Product, *response.Error) {
+	data.SKU = strings.TrimSpace(data.SKU)
+	data.VariantName = strings.TrimSpace(data.VariantName)
+
+	if data.SKU == "" || data.VariantName == "" {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "sku and variant_name is required",
+			Error:      errors.New("sku and variant_name is required"),
+		}
+	}
+
+	if data.Price < 0 {
+		return nil, negativePriceError()
+	}
+
+	parent, errParent := p.GetProductByID(parentID)
+	if errParent != nil {
+		return nil, errParent
+	}
+
+	if parent.DeletedAt != nil || !parent.Status {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "product is not active",
+			Error:      nil,
+		}
+	}
+
+	// variants stay one level deep
+	if parent.ParentID != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "a variant can not have variants",
+			Error:      nil,
+		}
+	}
+
+	for _, variant := range parent.Variants {
+		if strings.EqualFold(variant.VariantName, data.VariantName) {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusConflict,
+				Message:    fmt.Sprintf("variant %s already registered", data.VariantName),
+				Error:      nil,
+			}
+		}
+	}
+
+	checkSKU, errSKU := p.repository.GetProductBySKU(data.SKU)
+	if errSKU != nil && errSKU.StatusCode != 404 {
+		return nil, errSKU
+	}
+
+	if checkSKU != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("sku %s already registered", data.SKU),
+			Error:      nil,
+		}
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataProduct := &entity.Product{
+		ID:                 newUUID,
+		SKU:                data.SKU,
+		Type:               parent.Type,
+		Name:               parent.Name,
+		Brand:              parent.Brand,
+		SugarLevel:         parent.SugarLevel,
+		ProductionYear:     parent.ProductionYear,
+		Distributor:        parent.Distributor,
+		Price:              data.Price,
+		CategoryID:         parent.CategoryID,
+		ParentID:           &parent.ID,
+		VariantName:        data.VariantName,
+		Tags:               parent.Tags,
+		Ingredients:        parent.Ingredients,
+		Allergens:          parent.Allergens,
+		CaloriesPerServing: parent.CaloriesPerServing,
+		ServingSizeGrams:   parent.ServingSizeGrams,
+		NetWeightGrams:     data.NetWeightGrams,
+		IsHalal:            parent.IsHalal,
+		IsKosher:           parent.IsKosher,
+		Status:             true,
+	}
+
+	if errNutrition := validateNutrition(*dataProduct); errNutrition != nil {
+		return nil, errNutrition
+	}
+
+	return p.repository.CreateProduct(*dataProduct)
+}
+
+// attachVariants fills the variants of the top level products with one query.
+func (p *productService) attachVariants(products []entity.Product) *response.Error {
+	parentIDs := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		if product.ParentID == nil {
+			parentIDs = append(parentIDs, product.ID)
+		}
+	}
+
+	if len(parentIDs) == 0 {
+		return nil
+	}
+
+	variants, errVariant := p.repository.GetProductVariant(parentIDs)
+	if errVariant != nil {
+		return errVariant
+	}
+
+	byParent := map[uuid.UUID][]entity.Product{}
+	for _, variant := range variants {
+		byParent[*variant.ParentID] = append(byParent[*variant.ParentID], variant)
+	}
+
+	for i := range products {
+		products[i].Variants = byParent[products[i].ID]
+	}
+
+	return nil
 }
 
 // UpdateProduct implements ProductService.
 func (p *productService) UpdateProduct(data dto.UpdateProductRequest) *response.Error {
+	if data.Price < 0 {
+		return negativePriceError()
+	}
+
 	// check if product is exist
 	product, errProduct := p.repository.GetProductByID(data.ID)
 	if errProduct != nil {
@@ -137,24 +463,229 @@ func (p *productService) UpdateProduct(data dto.UpdateProductRequest) *response.
 		data.Distributor = product.Distributor
 	}
 
+	if data.Price == 0 {
+		data.Price = product.Price
+	}
+
+	if data.CategoryID == nil {
+		data.CategoryID = product.CategoryID
+	} else if *data.CategoryID != uuid.Nil {
+		if _, errCategory := p.repository.GetCategoryName(*data.CategoryID); errCategory != nil {
+			return errCategory
+		}
+	} else {
+		// uuid.Nil removes the product from its category
+		data.CategoryID = nil
+	}
+
+	if data.Ingredients == "" {
+		data.Ingredients = product.Ingredients
+	}
+
+	allergens := []string(product.Allergens)
+	if data.Allergens != nil {
+		var errAllergen *response.Error
+		allergens, errAllergen = normalizeAllergens(data.Allergens)
+		if errAllergen != nil {
+			return errAllergen
+		}
+	}
+
+	if data.CaloriesPerServing == nil {
+		data.CaloriesPerServing = product.CaloriesPerServing
+	}
+
+	if data.ServingSizeGrams == nil {
+		data.ServingSizeGrams = product.ServingSizeGrams
+	}
+
+	if data.NetWeightGrams == nil {
+		data.NetWeightGrams = product.NetWeightGrams
+	}
+
+	if data.IsHalal == nil {
+		data.IsHalal = &product.IsHalal
+	}
+
+	if data.IsKosher == nil {
+		data.IsKosher = &product.IsKosher
+	}
+
+	if data.VariantName == "" {
+		data.VariantName = product.VariantName
+	} else if product.ParentID == nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "variant_name can only be set on a variant",
+			Error:      errors.New("variant_name can only be set on a variant"),
+		}
+	}
+
 	currentTime := time.Now()
 
 	// assign the value from request to entity
 	dataProduct := &entity.Product{
-		ID:             data.ID,
-		SKU:            data.SKU,
-		Type:           data.Type,
-		Name:           data.Name,
-		Brand:          data.Brand,
-		SugarLevel:     data.SugarLevel,
-		ProductionYear: data.ProductionYear,
-		Distributor:    data.Distributor,
-		UpdatedAt:      &currentTime,
+		ID:                 data.ID,
+		SKU:                data.SKU,
+		Type:               data.Type,
+		Name:               data.Name,
+		Brand:              data.Brand,
+		SugarLevel:         data.SugarLevel,
+		ProductionYear:     data.ProductionYear,
+		Distributor:        data.Distributor,
+		Price:              data.Price,
+		CategoryID:         data.CategoryID,
+		Tags:               productTags(data.Tags),
+		Ingredients:        strings.TrimSpace(data.Ingredients),
+		Allergens:          allergens,
+		CaloriesPerServing: data.CaloriesPerServing,
+		ServingSizeGrams:   data.ServingSizeGrams,
+		NetWeightGrams:     data.NetWeightGrams,
+		IsHalal:            *data.IsHalal,
+		IsKosher:           *data.IsKosher,
+		VariantName:        strings.TrimSpace(data.VariantName),
+		UpdatedAt:          &currentTime,
+	}
+
+	// a variant shares everything but sku, price, weight and its name with the parent
+	if product.ParentID != nil {
+		parent, errParent := p.repository.GetProductByID(*product.ParentID)
+		if errParent != nil {
+			return errParent
+		}
+
+		dataProduct.Type, dataProduct.Name, dataProduct.Brand = parent.Type, parent.Name, parent.Brand
+		dataProduct.SugarLevel, dataProduct.ProductionYear, dataProduct.Distributor = parent.SugarLevel, parent.ProductionYear, parent.Distributor
+		dataProduct.CategoryID, dataProduct.Ingredients, dataProduct.Allergens = parent.CategoryID, parent.Ingredients, parent.Allergens
+		dataProduct.CaloriesPerServing, dataProduct.ServingSizeGrams = parent.CaloriesPerServing, parent.ServingSizeGrams
+		dataProduct.IsHalal, dataProduct.IsKosher = parent.IsHalal, parent.IsKosher
+		dataProduct.Tags = nil
+	}
+
+	if errNutrition := validateNutrition(*dataProduct); errNutrition != nil {
+		return errNutrition
 	}
 
 	return p.repository.UpdateProduct(*dataProduct)
 }
 
+// LookupProduct implements ProductService.
+func (p *productService) LookupProduct(code string, sku string) (*entity.Product, *response.Error) {
+	code = strings.TrimSpace(code)
+	sku = strings.TrimSpace(sku)
+
+	if (code == "") == (sku == "") {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "either barcode or sku is required",
+			Error:      errors.New("either barcode or sku is required"),
+		}
+	}
+
+	if sku != "" {
+		return p.repository.LookupProduct("", sku)
+	}
+
+	digits, _, errParse := parseBarcode(code)
+	if errParse != nil {
+		return nil, errParse
+	}
+
+	return p.repository.LookupProduct(barcode.GTIN(digits), "")
+}
+
+// AddBarcode implements ProductService.
+func (p *productService) AddBarcode(productID uuid.UUID, code string) (*entity.Barcode, *response.Error) {
+	digits, format, errParse := parseBarcode(code)
+	if errParse != nil {
+		return nil, errParse
+	}
+
+	product, errProduct := p.repository.GetProductByID(productID)
+	if errProduct != nil {
+		return nil, errProduct
+	}
+
+	if product.DeletedAt != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "product is not active",
+			Error:      nil,
+		}
+	}
+
+	gtin := barcode.GTIN(digits)
+
+	checkBarcode, errBarcode := p.repository.GetBarcode(gtin)
+	if errBarcode != nil && errBarcode.StatusCode != 404 {
+		return nil, errBarcode
+	}
+
+	if checkBarcode != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("barcode %s already registered", digits),
+			Error:      nil,
+		}
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataBarcode := &entity.Barcode{
+		ID:        newUUID,
+		ProductID: productID,
+		Code:      digits,
+		Format:    format,
+		GTIN:      gtin,
+	}
+
+	return p.repository.CreateBarcode(*dataBarcode)
+}
+
+// DeleteBarcode implements ProductService.
+func (p *productService) DeleteBarcode(productID uuid.UUID, code string) *response.Error {
+	digits, _, errParse := parseBarcode(code)
+	if errParse != nil {
+		return errParse
+	}
+
+	return p.repository.DeleteBarcode(productID, barcode.GTIN(digits))
+}
+
+func parseBarcode(code string) (string, string, *response.Error) {
+	digits, format, err := barcode.Parse(code)
+	if err != nil {
+		return "", "", &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    err.Error(),
+			Error:      err,
+		}
+	}
+
+	return digits, format, nil
+}
+
+// productTags normalizes and removes duplicate tags, nil stays nil so an update keeps the current tags.
+func productTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, name := range tags {
+		name = tag.NormalizeName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+
+	return normalized
+}
+
 func NewProductService(repository repository.ProductRepository) ProductService {
 	return &productService{repository}
 }
@@ -0,0 +1,66 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PromotionProductRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type CreatePromotionRequest struct {
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	Type            string                    `json:"type"`
+	DiscountPercent *float64                  `json:"discount_percent"`
+	DiscountAmount  *int64                    `json:"discount_amount"`
+	BuyQuantity     *int                      `json:"buy_quantity"`
+	GetQuantity     *int                      `json:"get_quantity"`
+	BundlePrice     *int64                    `json:"bundle_price"`
+	MemberOnly      bool                      `json:"member_only"`
+	StoreID         *uuid.UUID                `json:"store_id"`
+	ProductType     *string                   `json:"product_type"`
+	Brand           *string                   `json:"brand"`
+	Priority        int                       `json:"priority"`
+	StartsAt        *time.Time                `json:"starts_at"`
+	EndsAt          *time.Time                `json:"ends_at"`
+	Products        []PromotionProductRequest `json:"products"`
+}
+
+// UpdatePromotionRequest keeps the current value of every field that is not sent,
+// products are replaced only when the list is sent.
+type UpdatePromotionRequest struct {
+	ID              uuid.UUID                 `json:"id"`
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	Type            string                    `json:"type"`
+	DiscountPercent *float64                  `json:"discount_percent"`
+	DiscountAmount  *int64                    `json:"discount_amount"`
+	BuyQuantity     *int                      `json:"buy_quantity"`
+	GetQuantity     *int                      `json:"get_quantity"`
+	BundlePrice     *int64                    `json:"bundle_price"`
+	MemberOnly      *bool                     `json:"member_only"`
+	ProductType     *string                   `json:"product_type"`
+	Brand           *string                   `json:"brand"`
+	Priority        *int                      `json:"priority"`
+	StartsAt        *time.Time                `json:"starts_at"`
+	EndsAt          *time.Time                `json:"ends_at"`
+	Status          *bool                     `json:"status"`
+	Products        []PromotionProductRequest `json:"products"`
+}
+
+type CartItemRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type EvaluateCartRequest struct {
+	StoreID    uuid.UUID  `json:"store_id"`
+	CustomerID *uuid.UUID `json:"customer_id"`
+	// At prices the cart at another moment, now when empty
+	At    *time.Time        `json:"at"`
+	Items []CartItemRequest `json:"items"`
+}
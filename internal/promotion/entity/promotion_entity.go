@@ -0,0 +1,92 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// type of a promotion
+const (
+	// TypePercentage takes a percentage off every matching unit
+	TypePercentage = "percentage"
+	// TypeFixed takes a fixed amount off every matching unit
+	TypeFixed = "fixed"
+	// TypeBuyXGetY gives the cheapest get_quantity units free for every buy_quantity units bought
+	TypeBuyXGetY = "buy_x_get_y"
+	// TypeBundle sells the listed products together for bundle_price
+	TypeBundle = "bundle"
+)
+
+type Promotion struct {
+	ID              uuid.UUID          `json:"id" db:"id"`
+	Name            string             `json:"name" db:"name"`
+	Description     string             `json:"description" db:"description"`
+	Type            string             `json:"type" db:"type"`
+	DiscountPercent *float64           `json:"discount_percent" db:"discount_percent"`
+	DiscountAmount  *int64             `json:"discount_amount" db:"discount_amount"`
+	BuyQuantity     *int               `json:"buy_quantity" db:"buy_quantity"`
+	GetQuantity     *int               `json:"get_quantity" db:"get_quantity"`
+	BundlePrice     *int64             `json:"bundle_price" db:"bundle_price"`
+	MemberOnly      bool               `json:"member_only" db:"member_only"`
+	StoreID         *uuid.UUID         `json:"store_id" db:"store_id"`
+	ProductType     *string            `json:"product_type" db:"product_type"`
+	Brand           *string            `json:"brand" db:"brand"`
+	Priority        int                `json:"priority" db:"priority"`
+	StartsAt        time.Time          `json:"starts_at" db:"starts_at"`
+	EndsAt          *time.Time         `json:"ends_at" db:"ends_at"`
+	Status          bool               `json:"status" db:"status"`
+	CreatedAt       *time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt       *time.Time         `json:"-" db:"updated_at"`
+	DeletedAt       *time.Time         `json:"-" db:"deleted_at"`
+	Products        []PromotionProduct `json:"products" db:"-"`
+}
+
+// PromotionProduct limits a promotion to some products, for a bundle it is a component with its quantity.
+type PromotionProduct struct {
+	PromotionID uuid.UUID `json:"-" db:"promotion_id"`
+	ProductID   uuid.UUID `json:"product_id" db:"product_id"`
+	Quantity    int       `json:"quantity" db:"quantity"`
+}
+
+// CartProduct is the product data needed to price a cart line.
+type CartProduct struct {
+	ID    uuid.UUID `json:"id" db:"id"`
+	SKU   string    `json:"sku" db:"sku"`
+	Name  string    `json:"name" db:"name"`
+	Type  string    `json:"type" db:"type"`
+	Brand string    `json:"brand" db:"brand"`
+	Price int64     `json:"price" db:"price"`
+}
+
+// Evaluation is a priced cart, amounts are in the smallest currency unit.
+type Evaluation struct {
+	StoreID     uuid.UUID          `json:"store_id"`
+	CustomerID  *uuid.UUID         `json:"customer_id"`
+	IsMember    bool               `json:"is_member"`
+	EvaluatedAt time.Time          `json:"evaluated_at"`
+	Lines       []EvaluatedLine    `json:"lines"`
+	Promotions  []AppliedPromotion `json:"promotions"`
+	Subtotal    int64              `json:"subtotal"`
+	Discount    int64              `json:"discount"`
+	Total       int64              `json:"total"`
+}
+
+type EvaluatedLine struct {
+	ProductID    uuid.UUID   `json:"product_id"`
+	SKU          string      `json:"sku"`
+	Name         string      `json:"name"`
+	Quantity     int         `json:"quantity"`
+	UnitPrice    int64       `json:"unit_price"`
+	Subtotal     int64       `json:"subtotal"`
+	Discount     int64       `json:"discount"`
+	Total        int64       `json:"total"`
+	PromotionIDs []uuid.UUID `json:"promotion_ids"`
+}
+
+type AppliedPromotion struct {
+	PromotionID uuid.UUID `json:"promotion_id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Discount    int64     `json:"discount"`
+}
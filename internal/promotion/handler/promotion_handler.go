@@ -0,0 +1,225 @@
+package promotion
+
+import (
+	dto "candyshop/internal/promotion/dto"
+	service "candyshop/internal/promotion/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PromotionHandler struct {
+	service service.PromotionService
+}
+
+func NewPromotionHandler(service service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service}
+}
+
+func (h *PromotionHandler) GetAllPromotion(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	var storeID *uuid.UUID
+	if c.Query("store_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("store_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "store_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		storeID = &parseID
+	}
+
+	promotions, errPromotion := h.service.GetAllPromotion(storeID, offset, limit)
+	if errPromotion != nil {
+		return c.Status(errPromotion.StatusCode).JSON(fiber.Map{
+			"status_code": errPromotion.StatusCode,
+			"message":     "failed to fetch promotions",
+			"error":       errPromotion.Error.Error(),
+		})
+	}
+
+	if len(promotions) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch promotions",
+			"error":       "promotion not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data promotions",
+		"data":        promotions,
+	})
+}
+
+func (h *PromotionHandler) GetPromotionByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	promotion, errPromotion := h.service.GetPromotionByID(parseID)
+	if errPromotion != nil {
+		return c.Status(errPromotion.StatusCode).JSON(fiber.Map{
+			"status_code": errPromotion.StatusCode,
+			"message":     "failed to fetch promotion",
+			"error":       errPromotion.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data promotion",
+		"data":        promotion,
+	})
+}
+
+func (h *PromotionHandler) CreatePromotion(c *fiber.Ctx) error {
+	var req dto.CreatePromotionRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data promotion",
+			"error":       err.Error(),
+		})
+	}
+
+	promotion, errPromotion := h.service.CreatePromotion(auth.GetCaller(c), req)
+	if errPromotion != nil {
+		return c.Status(errPromotion.StatusCode).JSON(fiber.Map{
+			"status_code": errPromotion.StatusCode,
+			"message":     "failed to create promotion",
+			"error":       errPromotion.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create promotion",
+		"data":        promotion,
+	})
+}
+
+func (h *PromotionHandler) UpdatePromotion(c *fiber.Ctx) error {
+	var req dto.UpdatePromotionRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data promotion",
+			"error":       err.Error(),
+		})
+	}
+
+	errUpdate := h.service.UpdatePromotion(auth.GetCaller(c), req)
+	if errUpdate != nil {
+		if errUpdate.StatusCode == fiber.StatusConflict {
+			return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+				"status_code": errUpdate.StatusCode,
+				"message":     "failed to update promotion",
+				"error":       errUpdate.Message,
+			})
+		}
+
+		return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+			"status_code": errUpdate.StatusCode,
+			"message":     "failed to update promotion",
+			"error":       errUpdate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success update data promotion",
+		"data":        nil,
+	})
+}
+
+func (h *PromotionHandler) DeletePromotion(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errDelete := h.service.DeletePromotion(auth.GetCaller(c), parseID)
+	if errDelete != nil {
+		if errDelete.StatusCode == fiber.StatusConflict {
+			return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+				"status_code": errDelete.StatusCode,
+				"message":     "failed to delete promotion",
+				"error":       errDelete.Message,
+			})
+		}
+
+		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+			"status_code": errDelete.StatusCode,
+			"message":     "failed to delete promotion",
+			"error":       errDelete.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete data promotion",
+		"data":        nil,
+	})
+}
+
+func (h *PromotionHandler) EvaluateCart(c *fiber.Ctx) error {
+	var req dto.EvaluateCartRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data cart",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is required",
+			"error":       nil,
+		})
+	}
+
+	evaluation, errEvaluate := h.service.EvaluateCart(auth.GetCaller(c), req)
+	if errEvaluate != nil {
+		return c.Status(errEvaluate.StatusCode).JSON(fiber.Map{
+			"status_code": errEvaluate.StatusCode,
+			"message":     "failed to evaluate cart",
+			"error":       errEvaluate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success evaluate cart",
+		"data":        evaluation,
+	})
+}
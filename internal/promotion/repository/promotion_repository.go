@@ -0,0 +1,424 @@
+package promotion
+
+import (
+	entity "candyshop/internal/promotion/entity"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type PromotionRepository interface {
+	GetAllPromotion(storeID *uuid.UUID, offset, limit int) ([]entity.Promotion, *response.Error)
+	GetPromotionByID(id uuid.UUID) (*entity.Promotion, *response.Error)
+	GetActivePromotion(storeID uuid.UUID, at time.Time) ([]entity.Promotion, *response.Error)
+	CreatePromotion(data entity.Promotion) (*entity.Promotion, *response.Error)
+	UpdatePromotion(data entity.Promotion, replaceProducts bool) *response.Error
+	DeletePromotion(id uuid.UUID, deletedAt time.Time) *response.Error
+	GetCartProduct(productIDs []uuid.UUID) ([]entity.CartProduct, *response.Error)
+	GetCustomerMembership(customerID uuid.UUID) (bool, *response.Error)
+}
+
+type promotionRepository struct {
+	db *sqlx.DB
+}
+
+const promotionColumns = `id, name, description, type, discount_percent, discount_amount, buy_quantity, get_quantity, bundle_price,
+		member_only, store_id, product_type, brand, priority, starts_at, ends_at, status, created_at, updated_at, deleted_at`
+
+// GetAllPromotion implements PromotionRepository.
+func (p *promotionRepository) GetAllPromotion(storeID *uuid.UUID, offset int, limit int) ([]entity.Promotion, *response.Error) {
+	var promotions []entity.Promotion
+
+	// a store filter also returns the promotions that run in every store
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE deleted_at IS NULL AND ($3::uuid IS NULL OR store_id IS NULL OR store_id = $3)
+		ORDER BY priority DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := p.db.Select(&promotions, query, limit, offset, storeID)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get all promotion").Msg("failed to get all promotion")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch promotions",
+			Error:      err,
+		}
+	}
+
+	if errProducts := p.attachProducts(promotions); errProducts != nil {
+		return nil, errProducts
+	}
+
+	return promotions, nil
+}
+
+// GetPromotionByID implements PromotionRepository.
+func (p *promotionRepository) GetPromotionByID(id uuid.UUID) (*entity.Promotion, *response.Error) {
+	var promotion entity.Promotion
+
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE id = $1
+	`
+
+	err := p.db.Get(&promotion, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("status", 404).Str("function", "get promotion by id").Msg("failed to get promotion by id")
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch promotion",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get promotion by id").Msg("failed to get promotion by id")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch promotion",
+			Error:      err,
+		}
+	}
+
+	promotions := []entity.Promotion{promotion}
+	if errProducts := p.attachProducts(promotions); errProducts != nil {
+		return nil, errProducts
+	}
+
+	return &promotions[0], nil
+}
+
+// GetActivePromotion implements PromotionRepository.
+func (p *promotionRepository) GetActivePromotion(storeID uuid.UUID, at time.Time) ([]entity.Promotion, *response.Error) {
+	var promotions []entity.Promotion
+
+	// the order is the order promotions are applied in, keep it stable
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE deleted_at IS NULL AND status = true
+			AND (store_id IS NULL OR store_id = $1)
+			AND starts_at <= $2 AND (ends_at IS NULL OR ends_at > $2)
+		ORDER BY priority DESC, id
+	`
+
+	err := p.db.Select(&promotions, query, storeID, at)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get active promotion").Msg("failed to get active promotion")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch promotions",
+			Error:      err,
+		}
+	}
+
+	if errProducts := p.attachProducts(promotions); errProducts != nil {
+		return nil, errProducts
+	}
+
+	return promotions, nil
+}
+
+// CreatePromotion implements PromotionRepository.
+func (p *promotionRepository) CreatePromotion(data entity.Promotion) (*entity.Promotion, *response.Error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create promotion").Msg("failed to create promotion")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO promotions (id, name, description, type, discount_percent, discount_amount, buy_quantity, get_quantity, bundle_price,
+			member_only, store_id, product_type, brand, priority, starts_at, ends_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING ` + promotionColumns
+
+	var model entity.Promotion
+
+	errInsert := tx.QueryRowx(query,
+		data.ID,
+		data.Name,
+		data.Description,
+		data.Type,
+		data.DiscountPercent,
+		data.DiscountAmount,
+		data.BuyQuantity,
+		data.GetQuantity,
+		data.BundlePrice,
+		data.MemberOnly,
+		data.StoreID,
+		data.ProductType,
+		data.Brand,
+		data.Priority,
+		data.StartsAt,
+		data.EndsAt,
+		data.Status).StructScan(&model)
+
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "create promotion").Msg("failed to create promotion")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create promotion",
+			Error:      errInsert,
+		}
+	}
+
+	if errProducts := insertProducts(tx, model.ID, data.Products); errProducts != nil {
+		return nil, errProducts
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create promotion").Msg("failed to create promotion")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	model.Products = data.Products
+
+	return &model, nil
+}
+
+// UpdatePromotion implements PromotionRepository.
+func (p *promotionRepository) UpdatePromotion(data entity.Promotion, replaceProducts bool) *response.Error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update promotion").Msg("failed to update promotion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		UPDATE promotions SET name = $2, description = $3, type = $4, discount_percent = $5, discount_amount = $6, buy_quantity = $7,
+			get_quantity = $8, bundle_price = $9, member_only = $10, product_type = $11, brand = $12, priority = $13, starts_at = $14,
+			ends_at = $15, status = $16, updated_at = $17
+		WHERE id = $1
+	`
+
+	_, errExec := tx.Exec(query,
+		data.ID,
+		data.Name,
+		data.Description,
+		data.Type,
+		data.DiscountPercent,
+		data.DiscountAmount,
+		data.BuyQuantity,
+		data.GetQuantity,
+		data.BundlePrice,
+		data.MemberOnly,
+		data.ProductType,
+		data.Brand,
+		data.Priority,
+		data.StartsAt,
+		data.EndsAt,
+		data.Status,
+		data.UpdatedAt,
+	)
+
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "update promotion").Msg("failed to update promotion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update promotion",
+			Error:      errExec,
+		}
+	}
+
+	if replaceProducts {
+		_, errDelete := tx.Exec(`DELETE FROM promotion_products WHERE promotion_id = $1`, data.ID)
+		if errDelete != nil {
+			log.Error().Err(errDelete).Int("status", 500).Str("function", "update promotion").Msg("failed to delete promotion products")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to update promotion",
+				Error:      errDelete,
+			}
+		}
+
+		if errProducts := insertProducts(tx, data.ID, data.Products); errProducts != nil {
+			return errProducts
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update promotion").Msg("failed to update promotion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// DeletePromotion implements PromotionRepository.
+func (p *promotionRepository) DeletePromotion(id uuid.UUID, deletedAt time.Time) *response.Error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete promotion").Msg("failed to delete promotion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE promotions SET deleted_at = $2, status = false WHERE id = $1`
+
+	_, errExec := tx.Exec(query, id, deletedAt)
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "delete promotion").Msg("failed to delete promotion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete promotion",
+			Error:      errExec,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete promotion").Msg("failed to delete promotion")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// GetCartProduct implements PromotionRepository.
+func (p *promotionRepository) GetCartProduct(productIDs []uuid.UUID) ([]entity.CartProduct, *response.Error) {
+	var products []entity.CartProduct
+
+	query := `
+		SELECT id, sku, name, type, brand, price
+		FROM products
+		WHERE id = ANY($1) AND deleted_at IS NULL AND status = true
+	`
+
+	err := p.db.Select(&products, query, pq.Array(productIDs))
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get cart product").Msg("failed to get cart product")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch products",
+			Error:      err,
+		}
+	}
+
+	return products, nil
+}
+
+// GetCustomerMembership implements PromotionRepository.
+func (p *promotionRepository) GetCustomerMembership(customerID uuid.UUID) (bool, *response.Error) {
+	var isMember bool
+
+	err := p.db.Get(&isMember, `SELECT is_member FROM customers WHERE id = $1 AND deleted_at IS NULL`, customerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, &response.Error{
+				StatusCode: 404,
+				Message:    "customer not found",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get customer membership").Msg("failed to get customer membership")
+		return false, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch customer",
+			Error:      err,
+		}
+	}
+
+	return isMember, nil
+}
+
+// attachProducts loads the product scope of every promotion with one query.
+func (p *promotionRepository) attachProducts(promotions []entity.Promotion) *response.Error {
+	if len(promotions) == 0 {
+		return nil
+	}
+
+	promotionIDs := make([]uuid.UUID, len(promotions))
+	for i, promotion := range promotions {
+		promotionIDs[i] = promotion.ID
+	}
+
+	var products []entity.PromotionProduct
+
+	err := p.db.Select(&products, `
+		SELECT promotion_id, product_id, quantity
+		FROM promotion_products
+		WHERE promotion_id = ANY($1)
+		ORDER BY promotion_id, product_id
+	`, pq.Array(promotionIDs))
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "attach promotion products").Msg("failed to get promotion products")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch promotion products",
+			Error:      err,
+		}
+	}
+
+	index := map[uuid.UUID]int{}
+	for i, promotion := range promotions {
+		index[promotion.ID] = i
+	}
+
+	for _, product := range products {
+		i := index[product.PromotionID]
+		promotions[i].Products = append(promotions[i].Products, product)
+	}
+
+	return nil
+}
+
+func insertProducts(tx *sqlx.Tx, promotionID uuid.UUID, products []entity.PromotionProduct) *response.Error {
+	for _, product := range products {
+		_, errProduct := tx.Exec(`INSERT INTO promotion_products (promotion_id, product_id, quantity) VALUES ($1, $2, $3)`,
+			promotionID, product.ProductID, product.Quantity)
+		if errProduct != nil {
+			log.Error().Err(errProduct).Int("status", 500).Str("function", "insert promotion products").Msg("failed to insert promotion product")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to save promotion products",
+				Error:      errProduct,
+			}
+		}
+	}
+
+	return nil
+}
+
+func NewPromotionRepository(db *sqlx.DB) PromotionRepository {
+	return &promotionRepository{db}
+}
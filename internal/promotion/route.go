@@ -0,0 +1,26 @@
+package promotion
+
+import (
+	handler "candyshop/internal/promotion/handler"
+	repository "candyshop/internal/promotion/repository"
+	service "candyshop/internal/promotion/service"
+	staff "candyshop/internal/staff"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewPromotionRepository(db)
+	service := service.NewPromotionService(repo)
+	handler := handler.NewPromotionHandler(service)
+
+	promotionRoute := router.Group("api/v1/promotions", staff.Authenticate(db))
+
+	promotionRoute.Get("", handler.GetAllPromotion)
+	promotionRoute.Post("", handler.CreatePromotion)
+	promotionRoute.Post("/evaluate", handler.EvaluateCart)
+	promotionRoute.Get("/:id", handler.GetPromotionByID)
+	promotionRoute.Patch("", handler.UpdatePromotion)
+	promotionRoute.Patch("/delete/:id", handler.DeletePromotion)
+}
@@ -0,0 +1,259 @@
+package promotion
+
+import (
+	entity "candyshop/internal/promotion/entity"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// cartLine is one product of the cart while promotions are applied.
+type cartLine struct {
+	product  entity.CartProduct
+	quantity int
+	// free counts the units that no promotion has taken yet
+	free         int
+	discount     int64
+	promotionIDs []uuid.UUID
+}
+
+// cartUnits are the free units of a line, used by promotions that pick units across lines.
+type cartUnits struct {
+	line  int
+	price int64
+	count int
+}
+
+// evaluateCart applies the promotions in the given order, the repository sorts them by priority and id.
+// Promotions don't stack, a unit that is taken by one promotion is not seen by the next,
+// so the same cart and promotions always give the same result.
+func evaluateCart(lines []*cartLine, promotions []entity.Promotion, isMember bool) ([]entity.EvaluatedLine, []entity.AppliedPromotion) {
+	var applied []entity.AppliedPromotion
+
+	for _, promotion := range promotions {
+		if promotion.MemberOnly && !isMember {
+			continue
+		}
+
+		var discount int64
+		switch promotion.Type {
+		case entity.TypePercentage, entity.TypeFixed:
+			discount = applyUnitDiscount(lines, promotion)
+		case entity.TypeBuyXGetY:
+			discount = applyBuyXGetY(lines, promotion)
+		case entity.TypeBundle:
+			discount = applyBundle(lines, promotion)
+		}
+
+		if discount > 0 {
+			applied = append(applied, entity.AppliedPromotion{
+				PromotionID: promotion.ID,
+				Name:        promotion.Name,
+				Type:        promotion.Type,
+				Discount:    discount,
+			})
+		}
+	}
+
+	evaluated := make([]entity.EvaluatedLine, len(lines))
+	for i, line := range lines {
+		subtotal := line.product.Price * int64(line.quantity)
+		evaluated[i] = entity.EvaluatedLine{
+			ProductID:    line.product.ID,
+			SKU:          line.product.SKU,
+			Name:         line.product.Name,
+			Quantity:     line.quantity,
+			UnitPrice:    line.product.Price,
+			Subtotal:     subtotal,
+			Discount:     line.discount,
+			Total:        subtotal - line.discount,
+			PromotionIDs: line.promotionIDs,
+		}
+
+		if evaluated[i].PromotionIDs == nil {
+			evaluated[i].PromotionIDs = []uuid.UUID{}
+		}
+	}
+
+	return evaluated, applied
+}
+
+// matchScope reports whether a product is inside the store independent scope of a promotion.
+func matchScope(promotion entity.Promotion, product entity.CartProduct) bool {
+	if promotion.ProductType != nil && !strings.EqualFold(*promotion.ProductType, product.Type) {
+		return false
+	}
+
+	if promotion.Brand != nil && !strings.EqualFold(*promotion.Brand, product.Brand) {
+		return false
+	}
+
+	if len(promotion.Products) == 0 {
+		return true
+	}
+
+	for _, scoped := range promotion.Products {
+		if scoped.ProductID == product.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyUnitDiscount takes a percentage or a fixed amount off every free unit in scope.
+func applyUnitDiscount(lines []*cartLine, promotion entity.Promotion) int64 {
+	var total int64
+
+	for _, line := range lines {
+		if line.free == 0 || !matchScope(promotion, line.product) {
+			continue
+		}
+
+		units := int64(line.free)
+
+		var discount int64
+		if promotion.Type == entity.TypePercentage {
+			if promotion.DiscountPercent == nil {
+				continue
+			}
+
+			// the percentage is kept in basis points and rounded half up on the line, not per unit
+			basisPoints := int64(math.Round(*promotion.DiscountPercent * 100))
+			discount = (line.product.Price*units*basisPoints + 5000) / 10000
+		} else {
+			if promotion.DiscountAmount == nil {
+				continue
+			}
+
+			discount = min(*promotion.DiscountAmount, line.product.Price) * units
+		}
+
+		if discount <= 0 {
+			continue
+		}
+
+		line.free = 0
+		line.addDiscount(promotion.ID, discount)
+		total += discount
+	}
+
+	return total
+}
+
+// applyBuyXGetY sorts the free units in scope from the most expensive and splits them into groups
+// of buy + get units, the last get units of every group are free. Leftover units stay untaken.
+// The units of a line are counted, not listed one by one, so a large quantity costs no more than a small one.
+func applyBuyXGetY(lines []*cartLine, promotion entity.Promotion) int64 {
+	if promotion.BuyQuantity == nil || promotion.GetQuantity == nil {
+		return 0
+	}
+
+	buy, get := *promotion.BuyQuantity, *promotion.GetQuantity
+	size := buy + get
+
+	var units []cartUnits
+	count := 0
+	for i, line := range lines {
+		if line.free == 0 || !matchScope(promotion, line.product) {
+			continue
+		}
+
+		units = append(units, cartUnits{line: i, price: line.product.Price, count: line.free})
+		count += line.free
+	}
+
+	groups := count / size
+	if groups == 0 {
+		return 0
+	}
+
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].price != units[j].price {
+			return units[i].price > units[j].price
+		}
+
+		return units[i].line < units[j].line
+	})
+
+	// freeBefore counts the free positions before the nth unit of the sorted units
+	freeBefore := func(n int) int {
+		return n/size*get + max(n%size-buy, 0)
+	}
+
+	var total int64
+	start, taken := 0, groups*size
+	for _, unit := range units {
+		if start >= taken {
+			break
+		}
+
+		end := min(start+unit.count, taken)
+		line := lines[unit.line]
+		line.free -= end - start
+
+		if free := freeBefore(end) - freeBefore(start); free > 0 {
+			discount := unit.price * int64(free)
+			line.addDiscount(promotion.ID, discount)
+			total += discount
+		}
+
+		start = end
+	}
+
+	return total
+}
+
+// applyBundle sells as many complete bundles as the free units allow. The bundle discount is shared
+// by the components in proportion to their price, the rounding remainder goes to the first component.
+func applyBundle(lines []*cartLine, promotion entity.Promotion) int64 {
+	if promotion.BundlePrice == nil || len(promotion.Products) == 0 {
+		return 0
+	}
+
+	index := map[uuid.UUID]int{}
+	for i, line := range lines {
+		index[line.product.ID] = i
+	}
+
+	bundles := math.MaxInt
+	var value int64
+	for _, component := range promotion.Products {
+		i, ok := index[component.ProductID]
+		if !ok {
+			return 0
+		}
+
+		bundles = min(bundles, lines[i].free/component.Quantity)
+		value += lines[i].product.Price * int64(component.Quantity)
+	}
+
+	discount := value - *promotion.BundlePrice
+	if bundles == 0 || discount <= 0 {
+		return 0
+	}
+
+	shares := make([]int64, len(promotion.Products))
+	remainder := discount
+	for n, component := range promotion.Products {
+		line := lines[index[component.ProductID]]
+		shares[n] = discount * line.product.Price * int64(component.Quantity) / value
+		remainder -= shares[n]
+	}
+	shares[0] += remainder
+
+	for n, component := range promotion.Products {
+		line := lines[index[component.ProductID]]
+		line.free -= bundles * component.Quantity
+		line.addDiscount(promotion.ID, shares[n]*int64(bundles))
+	}
+
+	return discount * int64(bundles)
+}
+
+func (l *cartLine) addDiscount(promotionID uuid.UUID, discount int64) {
+	l.discount += discount
+	l.promotionIDs = append(l.promotionIDs, promotionID)
+}
@@ -0,0 +1,459 @@
+package promotion
+
+import (
+	dto "candyshop/internal/promotion/dto"
+	entity "candyshop/internal/promotion/entity"
+	repository "candyshop/internal/promotion/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MaxLineQuantity limits the units of one product in a cart or a bundle, MaxCartQuantity the units of a whole cart
+const (
+	MaxLineQuantity = 10000
+	MaxCartQuantity = 100000
+)
+
+type PromotionService interface {
+	GetAllPromotion(storeID *uuid.UUID, offset, limit int) ([]entity.Promotion, *response.Error)
+	GetPromotionByID(id uuid.UUID) (*entity.Promotion, *response.Error)
+	CreatePromotion(caller *auth.Caller, data dto.CreatePromotionRequest) (*entity.Promotion, *response.Error)
+	UpdatePromotion(caller *auth.Caller, data dto.UpdatePromotionRequest) *response.Error
+	DeletePromotion(caller *auth.Caller, id uuid.UUID) *response.Error
+	EvaluateCart(caller *auth.Caller, data dto.EvaluateCartRequest) (*entity.Evaluation, *response.Error)
+}
+
+type promotionService struct {
+	repository repository.PromotionRepository
+}
+
+// GetAllPromotion implements PromotionService.
+func (p *promotionService) GetAllPromotion(storeID *uuid.UUID, offset int, limit int) ([]entity.Promotion, *response.Error) {
+	return p.repository.GetAllPromotion(storeID, offset, limit)
+}
+
+// GetPromotionByID implements PromotionService.
+func (p *promotionService) GetPromotionByID(id uuid.UUID) (*entity.Promotion, *response.Error) {
+	return p.repository.GetPromotionByID(id)
+}
+
+// CreatePromotion implements PromotionService.
+func (p *promotionService) CreatePromotion(caller *auth.Caller, data dto.CreatePromotionRequest) (*entity.Promotion, *response.Error) {
+	if errAccess := canManagePromotion(caller, data.StoreID); errAccess != nil {
+		return nil, errAccess
+	}
+
+	startsAt := time.Now()
+	if data.StartsAt != nil {
+		startsAt = *data.StartsAt
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataPromotion := &entity.Promotion{
+		ID:              newUUID,
+		Name:            data.Name,
+		Description:     data.Description,
+		Type:            data.Type,
+		DiscountPercent: data.DiscountPercent,
+		DiscountAmount:  data.DiscountAmount,
+		BuyQuantity:     data.BuyQuantity,
+		GetQuantity:     data.GetQuantity,
+		BundlePrice:     data.BundlePrice,
+		MemberOnly:      data.MemberOnly,
+		StoreID:         data.StoreID,
+		ProductType:     data.ProductType,
+		Brand:           data.Brand,
+		Priority:        data.Priority,
+		StartsAt:        startsAt,
+		EndsAt:          data.EndsAt,
+		Status:          true,
+		Products:        promotionProducts(data.Products),
+	}
+
+	if errValidate := p.validatePromotion(dataPromotion); errValidate != nil {
+		return nil, errValidate
+	}
+
+	return p.repository.CreatePromotion(*dataPromotion)
+}
+
+// UpdatePromotion implements PromotionService.
+func (p *promotionService) UpdatePromotion(caller *auth.Caller, data dto.UpdatePromotionRequest) *response.Error {
+	// check if promotion is exist
+	promotion, errPromotion := p.repository.GetPromotionByID(data.ID)
+	if errPromotion != nil {
+		return errPromotion
+	}
+
+	if promotion.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "promotion is not active",
+			Error:      nil,
+		}
+	}
+
+	if errAccess := canManagePromotion(caller, promotion.StoreID); errAccess != nil {
+		return errAccess
+	}
+
+	if data.Name != "" {
+		promotion.Name = data.Name
+	}
+
+	if data.Description != "" {
+		promotion.Description = data.Description
+	}
+
+	if data.Type != "" {
+		promotion.Type = data.Type
+	}
+
+	if data.DiscountPercent != nil {
+		promotion.DiscountPercent = data.DiscountPercent
+	}
+
+	if data.DiscountAmount != nil {
+		promotion.DiscountAmount = data.DiscountAmount
+	}
+
+	if data.BuyQuantity != nil {
+		promotion.BuyQuantity = data.BuyQuantity
+	}
+
+	if data.GetQuantity != nil {
+		promotion.GetQuantity = data.GetQuantity
+	}
+
+	if data.BundlePrice != nil {
+		promotion.BundlePrice = data.BundlePrice
+	}
+
+	if data.MemberOnly != nil {
+		promotion.MemberOnly = *data.MemberOnly
+	}
+
+	if data.ProductType != nil {
+		promotion.ProductType = data.ProductType
+	}
+
+	if data.Brand != nil {
+		promotion.Brand = data.Brand
+	}
+
+	if data.Priority != nil {
+		promotion.Priority = *data.Priority
+	}
+
+	if data.StartsAt != nil {
+		promotion.StartsAt = *data.StartsAt
+	}
+
+	if data.EndsAt != nil {
+		promotion.EndsAt = data.EndsAt
+	}
+
+	if data.Status != nil {
+		promotion.Status = *data.Status
+	}
+
+	replaceProducts := data.Products != nil
+	if replaceProducts {
+		promotion.Products = promotionProducts(data.Products)
+	}
+
+	if errValidate := p.validatePromotion(promotion); errValidate != nil {
+		return errValidate
+	}
+
+	currentTime := time.Now()
+	promotion.UpdatedAt = &currentTime
+
+	return p.repository.UpdatePromotion(*promotion, replaceProducts)
+}
+
+// DeletePromotion implements PromotionService.
+func (p *promotionService) DeletePromotion(caller *auth.Caller, id uuid.UUID) *response.Error {
+	promotion, errPromotion := p.repository.GetPromotionByID(id)
+	if errPromotion != nil {
+		return errPromotion
+	}
+
+	if errAccess := canManagePromotion(caller, promotion.StoreID); errAccess != nil {
+		return errAccess
+	}
+
+	// check if promotion is deleted
+	if promotion.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "promotion is not active",
+			Error:      nil,
+		}
+	}
+
+	currentTime := time.Now()
+
+	return p.repository.DeletePromotion(id, currentTime)
+}
+
+// EvaluateCart implements PromotionService.
+func (p *promotionService) EvaluateCart(caller *auth.Caller, data dto.EvaluateCartRequest) (*entity.Evaluation, *response.Error) {
+	if !caller.CanAccessStore(data.StoreID) {
+		return nil, auth.ForbiddenStore(data.StoreID)
+	}
+
+	if len(data.Items) == 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "items is required",
+			Error:      errors.New("items is required"),
+		}
+	}
+
+	// lines of the same product are merged and keep the position of the first one
+	var productIDs []uuid.UUID
+	quantities := map[uuid.UUID]int{}
+	units := 0
+	for _, item := range data.Items {
+		if item.ProductID == uuid.Nil || item.Quantity <= 0 || item.Quantity > MaxLineQuantity {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("item quantity must be between 1 and %d", MaxLineQuantity),
+				Error:      fmt.Errorf("product %s with quantity %d is invalid", item.ProductID, item.Quantity),
+			}
+		}
+
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+
+		quantities[item.ProductID] += item.Quantity
+		units += item.Quantity
+
+		// checked on every item so the sum can't overflow
+		if quantities[item.ProductID] > MaxLineQuantity || units > MaxCartQuantity {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("cart can have at most %d units of a product and %d units in total", MaxLineQuantity, MaxCartQuantity),
+				Error:      fmt.Errorf("cart can have at most %d units of a product and %d units in total", MaxLineQuantity, MaxCartQuantity),
+			}
+		}
+	}
+
+	products, errProducts := p.cartProducts(productIDs)
+	if errProducts != nil {
+		return nil, errProducts
+	}
+
+	isMember := false
+	if data.CustomerID != nil {
+		var errCustomer *response.Error
+		isMember, errCustomer = p.repository.GetCustomerMembership(*data.CustomerID)
+		if errCustomer != nil {
+			return nil, errCustomer
+		}
+	}
+
+	evaluatedAt := time.Now()
+	if data.At != nil {
+		evaluatedAt = *data.At
+	}
+
+	promotions, errPromotions := p.repository.GetActivePromotion(data.StoreID, evaluatedAt)
+	if errPromotions != nil {
+		return nil, errPromotions
+	}
+
+	lines := make([]*cartLine, len(productIDs))
+	for i, productID := range productIDs {
+		lines[i] = &cartLine{product: products[productID], quantity: quantities[productID], free: quantities[productID]}
+	}
+
+	evaluatedLines, applied := evaluateCart(lines, promotions, isMember)
+
+	evaluation := &entity.Evaluation{
+		StoreID:     data.StoreID,
+		CustomerID:  data.CustomerID,
+		IsMember:    isMember,
+		EvaluatedAt: evaluatedAt,
+		Lines:       evaluatedLines,
+		Promotions:  applied,
+	}
+
+	if evaluation.Promotions == nil {
+		evaluation.Promotions = []entity.AppliedPromotion{}
+	}
+
+	for _, line := range evaluatedLines {
+		evaluation.Subtotal += line.Subtotal
+		evaluation.Discount += line.Discount
+		evaluation.Total += line.Total
+	}
+
+	return evaluation, nil
+}
+
+// cartProducts loads the active products by id and fails when one of them is unknown.
+func (p *promotionService) cartProducts(productIDs []uuid.UUID) (map[uuid.UUID]entity.CartProduct, *response.Error) {
+	products, errProducts := p.repository.GetCartProduct(productIDs)
+	if errProducts != nil {
+		return nil, errProducts
+	}
+
+	found := map[uuid.UUID]entity.CartProduct{}
+	for _, product := range products {
+		found[product.ID] = product
+	}
+
+	for _, productID := range productIDs {
+		if _, ok := found[productID]; !ok {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusNotFound,
+				Message:    fmt.Sprintf("product %s not found", productID),
+				Error:      fmt.Errorf("product %s not found", productID),
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// validatePromotion checks the rule of the promotion type and clears the fields the type doesn't use.
+func (p *promotionService) validatePromotion(promotion *entity.Promotion) *response.Error {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if strings.TrimSpace(promotion.Name) == "" {
+		return invalid("name is required")
+	}
+
+	if promotion.EndsAt != nil && !promotion.EndsAt.After(promotion.StartsAt) {
+		return invalid("ends_at must be after starts_at")
+	}
+
+	percent, amount, buy, get, bundle := promotion.DiscountPercent, promotion.DiscountAmount, promotion.BuyQuantity, promotion.GetQuantity, promotion.BundlePrice
+	promotion.DiscountPercent, promotion.DiscountAmount, promotion.BuyQuantity, promotion.GetQuantity, promotion.BundlePrice = nil, nil, nil, nil, nil
+
+	switch promotion.Type {
+	case entity.TypePercentage:
+		if percent == nil || *percent <= 0 || *percent > 100 {
+			return invalid("discount_percent must be more than 0 and at most 100")
+		}
+
+		promotion.DiscountPercent = percent
+	case entity.TypeFixed:
+		if amount == nil || *amount <= 0 {
+			return invalid("discount_amount must be more than 0")
+		}
+
+		promotion.DiscountAmount = amount
+	case entity.TypeBuyXGetY:
+		if buy == nil || get == nil || *buy <= 0 || *get <= 0 {
+			return invalid("buy_quantity and get_quantity must be more than 0")
+		}
+
+		promotion.BuyQuantity, promotion.GetQuantity = buy, get
+	case entity.TypeBundle:
+		if bundle == nil || *bundle < 0 {
+			return invalid("bundle_price is required")
+		}
+
+		// a bundle is scoped by its components only
+		if promotion.ProductType != nil || promotion.Brand != nil {
+			return invalid("bundle can not be scoped by product_type or brand")
+		}
+
+		units := 0
+		for _, product := range promotion.Products {
+			units += product.Quantity
+		}
+
+		if units < 2 {
+			return invalid("bundle needs at least 2 units of products")
+		}
+
+		promotion.BundlePrice = bundle
+	default:
+		return invalid(fmt.Sprintf("type must be %s, %s, %s or %s", entity.TypePercentage, entity.TypeFixed, entity.TypeBuyXGetY, entity.TypeBundle))
+	}
+
+	for _, product := range promotion.Products {
+		if product.ProductID == uuid.Nil || product.Quantity <= 0 || product.Quantity > MaxLineQuantity {
+			return invalid("product of promotion is invalid")
+		}
+	}
+
+	if len(promotion.Products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uuid.UUID, len(promotion.Products))
+	for i, product := range promotion.Products {
+		productIDs[i] = product.ProductID
+	}
+
+	_, errProducts := p.cartProducts(productIDs)
+
+	return errProducts
+}
+
+// promotionProducts merges products listed twice, the quantity only matters for bundles.
+func promotionProducts(data []dto.PromotionProductRequest) []entity.PromotionProduct {
+	products := []entity.PromotionProduct{}
+	index := map[uuid.UUID]int{}
+	for _, product := range data {
+		quantity := product.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+
+		if i, ok := index[product.ProductID]; ok {
+			products[i].Quantity += quantity
+			continue
+		}
+
+		index[product.ProductID] = len(products)
+		products = append(products, entity.PromotionProduct{ProductID: product.ProductID, Quantity: quantity})
+	}
+
+	return products
+}
+
+// canManagePromotion allows owners to manage every promotion and managers the promotions of their store.
+func canManagePromotion(caller *auth.Caller, storeID *uuid.UUID) *response.Error {
+	if storeID == nil {
+		if caller.IsOwner() {
+			return nil
+		}
+
+		return &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "only owner can manage promotion for every store",
+			Error:      errors.New("only owner can manage promotion for every store"),
+		}
+	}
+
+	if !caller.CanManageStore(*storeID) {
+		return auth.ForbiddenStore(*storeID)
+	}
+
+	return nil
+}
+
+func NewPromotionService(repository repository.PromotionRepository) PromotionService {
+	return &promotionService{repository}
+}
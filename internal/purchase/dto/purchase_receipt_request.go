@@ -0,0 +1,19 @@
+package purchase
+
+import "github.com/google/uuid"
+
+// CreatePurchaseReceiptRequest receives goods into a store, reference is the delivery note or invoice of the supplier
+type CreatePurchaseReceiptRequest struct {
+	StoreID    uuid.UUID                    `json:"store_id"`
+	SupplierID *uuid.UUID                   `json:"supplier_id"`
+	Reference  string                       `json:"reference"`
+	Note       string                       `json:"note"`
+	Items      []PurchaseReceiptItemRequest `json:"items"`
+}
+
+// PurchaseReceiptItemRequest is a received product, unit cost is in the smallest currency unit
+type PurchaseReceiptItemRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	UnitCost  int64     `json:"unit_cost"`
+}
@@ -0,0 +1,33 @@
+package purchase
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PurchaseReceipt is a delivery of goods into a store, its items are in stock as soon as it is created.
+// Total cost is what the items cost together.
+type PurchaseReceipt struct {
+	ID         uuid.UUID             `json:"id" db:"id"`
+	StoreID    uuid.UUID             `json:"store_id" db:"store_id"`
+	SupplierID *uuid.UUID            `json:"supplier_id" db:"supplier_id"`
+	Reference  string                `json:"reference" db:"reference"`
+	Note       string                `json:"note" db:"note"`
+	ReceivedBy *uuid.UUID            `json:"received_by" db:"received_by"`
+	ReceivedAt time.Time             `json:"received_at" db:"received_at"`
+	TotalCost  int64                 `json:"total_cost" db:"total_cost"`
+	CreatedAt  *time.Time            `json:"created_at" db:"created_at"`
+	Items      []PurchaseReceiptItem `json:"items,omitempty" db:"-"`
+}
+
+// PurchaseReceiptItem is a product of a delivery with what one unit of it cost.
+type PurchaseReceiptItem struct {
+	ReceiptID   uuid.UUID `json:"-" db:"receipt_id"`
+	ProductID   uuid.UUID `json:"product_id" db:"product_id"`
+	ProductSKU  string    `json:"product_sku" db:"product_sku"`
+	ProductName string    `json:"product_name" db:"product_name"`
+	Quantity    int       `json:"quantity" db:"quantity"`
+	UnitCost    int64     `json:"unit_cost" db:"unit_cost"`
+	TotalCost   int64     `json:"total_cost" db:"total_cost"`
+}
@@ -0,0 +1,143 @@
+package purchase
+
+import (
+	dto "candyshop/internal/purchase/dto"
+	service "candyshop/internal/purchase/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PurchaseReceiptHandler struct {
+	service service.PurchaseReceiptService
+}
+
+func NewPurchaseReceiptHandler(service service.PurchaseReceiptService) *PurchaseReceiptHandler {
+	return &PurchaseReceiptHandler{service}
+}
+
+func (h *PurchaseReceiptHandler) GetAllPurchaseReceipt(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	var storeID *uuid.UUID
+	if c.Query("store_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("store_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "store_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		storeID = &parseID
+	}
+
+	var supplierID *uuid.UUID
+	if c.Query("supplier_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("supplier_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "supplier_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		supplierID = &parseID
+	}
+
+	receipts, errReceipt := h.service.GetAllPurchaseReceipt(auth.GetCaller(c), storeID, supplierID, offset, limit)
+	if errReceipt != nil {
+		return c.Status(errReceipt.StatusCode).JSON(fiber.Map{
+			"status_code": errReceipt.StatusCode,
+			"message":     "failed to fetch purchase receipts",
+			"error":       errReceipt.Error.Error(),
+		})
+	}
+
+	if len(receipts) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch purchase receipts",
+			"error":       "purchase receipt not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data purchase receipts",
+		"data":        receipts,
+	})
+}
+
+func (h *PurchaseReceiptHandler) GetPurchaseReceiptByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	receipt, errReceipt := h.service.GetPurchaseReceiptByID(auth.GetCaller(c), parseID)
+	if errReceipt != nil {
+		return c.Status(errReceipt.StatusCode).JSON(fiber.Map{
+			"status_code": errReceipt.StatusCode,
+			"message":     "failed to fetch purchase receipt",
+			"error":       errReceipt.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data purchase receipt",
+		"data":        receipt,
+	})
+}
+
+func (h *PurchaseReceiptHandler) CreatePurchaseReceipt(c *fiber.Ctx) error {
+	var req dto.CreatePurchaseReceiptRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data purchase receipt",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is required",
+			"error":       nil,
+		})
+	}
+
+	receipt, errReceipt := h.service.CreatePurchaseReceipt(auth.GetCaller(c), req)
+	if errReceipt != nil {
+		return c.Status(errReceipt.StatusCode).JSON(fiber.Map{
+			"status_code": errReceipt.StatusCode,
+			"message":     "failed to create purchase receipt",
+			"error":       errReceipt.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data purchase receipt",
+		"data":        receipt,
+	})
+}
@@ -0,0 +1,180 @@
+package purchase
+
+import (
+	entity "candyshop/internal/purchase/entity"
+	"candyshop/pkg/ledger"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type PurchaseReceiptRepository interface {
+	GetAllPurchaseReceipt(storeIDs []uuid.UUID, supplierID *uuid.UUID, offset, limit int) ([]entity.PurchaseReceipt, *response.Error)
+	GetPurchaseReceiptByID(id uuid.UUID) (*entity.PurchaseReceipt, *response.Error)
+	CreatePurchaseReceipt(data entity.PurchaseReceipt) *response.Error
+}
+
+type purchaseReceiptRepository struct {
+	db *sqlx.DB
+}
+
+const receiptColumns = `r.id, r.store_id, r.supplier_id, r.reference, r.note, r.received_by, r.received_at, r.created_at,
+	(SELECT COALESCE(SUM(ri.quantity * ri.unit_cost), 0) FROM purchase_receipt_items ri WHERE ri.receipt_id = r.id) AS total_cost`
+
+// GetAllPurchaseReceipt implements PurchaseReceiptRepository.
+func (p *purchaseReceiptRepository) GetAllPurchaseReceipt(storeIDs []uuid.UUID, supplierID *uuid.UUID, offset int, limit int) ([]entity.PurchaseReceipt, *response.Error) {
+	var receipts []entity.PurchaseReceipt
+
+	// nil store ids means the caller is not limited to some stores
+	query := `
+		SELECT ` + receiptColumns + `
+		FROM purchase_receipts r
+		WHERE ($3::uuid[] IS NULL OR r.store_id = ANY($3)) AND ($4::uuid IS NULL OR r.supplier_id = $4)
+		ORDER BY r.received_at DESC, r.id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := p.db.Select(&receipts, query, limit, offset, pq.Array(storeIDs), supplierID)
+	if err != nil {
+		return nil, purchaseError("get all purchase receipt", err)
+	}
+
+	return receipts, nil
+}
+
+// GetPurchaseReceiptByID implements PurchaseReceiptRepository.
+func (p *purchaseReceiptRepository) GetPurchaseReceiptByID(id uuid.UUID) (*entity.PurchaseReceipt, *response.Error) {
+	var receipt entity.PurchaseReceipt
+
+	err := p.db.Get(&receipt, `SELECT `+receiptColumns+` FROM purchase_receipts r WHERE r.id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch purchase receipt",
+				Error:      err,
+			}
+		}
+
+		return nil, purchaseError("get purchase receipt by id", err)
+	}
+
+	errItems := p.db.Select(&receipt.Items, `
+		SELECT i.receipt_id, i.product_id, p.sku AS product_sku, p.name AS product_name, i.quantity, i.unit_cost,
+			i.quantity * i.unit_cost AS total_cost
+		FROM purchase_receipt_items i
+		JOIN products p ON p.id = i.product_id
+		WHERE i.receipt_id = $1
+		ORDER BY p.name, p.id
+	`, id)
+	if errItems != nil {
+		return nil, purchaseError("get purchase receipt items", errItems)
+	}
+
+	return &receipt, nil
+}
+
+// CreatePurchaseReceipt implements PurchaseReceiptRepository.
+func (p *purchaseReceiptRepository) CreatePurchaseReceipt(data entity.PurchaseReceipt) *response.Error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create purchase receipt").Msg("failed to create purchase receipt")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	_, errInsert := tx.Exec(`
+		INSERT INTO purchase_receipts (id, store_id, supplier_id, reference, note, received_by, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, data.ID, data.StoreID, data.SupplierID, data.Reference, data.Note, data.ReceivedBy, data.ReceivedAt)
+	if errInsert != nil {
+		if isForeignKeyError(errInsert) {
+			return &response.Error{
+				StatusCode: 404,
+				Message:    "store or supplier not found",
+				Error:      errors.New("store or supplier not found"),
+			}
+		}
+
+		return purchaseError("create purchase receipt", errInsert)
+	}
+
+	// stock comes in product order so concurrent movements can't deadlock
+	items := slices.Clone(data.Items)
+	slices.SortFunc(items, func(a, b entity.PurchaseReceiptItem) int {
+		return strings.Compare(a.ProductID.String(), b.ProductID.String())
+	})
+
+	for _, item := range items {
+		_, errItem := tx.Exec(`INSERT INTO purchase_receipt_items (receipt_id, product_id, quantity, unit_cost) VALUES ($1, $2, $3, $4)`,
+			data.ID, item.ProductID, item.Quantity, item.UnitCost)
+		if errItem != nil {
+			if isForeignKeyError(errItem) {
+				return &response.Error{
+					StatusCode: 404,
+					Message:    "product not found",
+					Error:      errors.New("product " + item.ProductID.String() + " not found"),
+				}
+			}
+
+			return purchaseError("create purchase receipt item", errItem)
+		}
+
+		errMove := ledger.Move(tx, ledger.Movement{
+			StoreID:       data.StoreID,
+			ProductID:     item.ProductID,
+			Type:          ledger.TypeReceipt,
+			Quantity:      item.Quantity,
+			ReferenceType: ledger.ReferencePurchase,
+			ReferenceID:   &data.ID,
+			CreatedBy:     data.ReceivedBy,
+			Note:          data.Reference,
+			MovedAt:       data.ReceivedAt,
+			UnitCost:      &item.UnitCost,
+		})
+		if errMove != nil {
+			return purchaseError("receive stock", errMove)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create purchase receipt").Msg("failed to create purchase receipt")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+func isForeignKeyError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+func purchaseError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewPurchaseReceiptRepository(db *sqlx.DB) PurchaseReceiptRepository {
+	return &purchaseReceiptRepository{db}
+}
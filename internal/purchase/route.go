@@ -0,0 +1,23 @@
+package purchase
+
+import (
+	handler "candyshop/internal/purchase/handler"
+	repository "candyshop/internal/purchase/repository"
+	service "candyshop/internal/purchase/service"
+	staff "candyshop/internal/staff"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewPurchaseReceiptRepository(db)
+	service := service.NewPurchaseReceiptService(repo)
+	handler := handler.NewPurchaseReceiptHandler(service)
+
+	purchaseRoute := router.Group("api/v1/purchase-receipts", staff.Authenticate(db))
+
+	purchaseRoute.Get("", handler.GetAllPurchaseReceipt)
+	purchaseRoute.Post("", handler.CreatePurchaseReceipt)
+	purchaseRoute.Get("/:id", handler.GetPurchaseReceiptByID)
+}
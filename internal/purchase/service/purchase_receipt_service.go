@@ -0,0 +1,126 @@
+package purchase
+
+import (
+	dto "candyshop/internal/purchase/dto"
+	entity "candyshop/internal/purchase/entity"
+	repository "candyshop/internal/purchase/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PurchaseReceiptService interface {
+	GetAllPurchaseReceipt(caller *auth.Caller, storeID, supplierID *uuid.UUID, offset, limit int) ([]entity.PurchaseReceipt, *response.Error)
+	GetPurchaseReceiptByID(caller *auth.Caller, id uuid.UUID) (*entity.PurchaseReceipt, *response.Error)
+	CreatePurchaseReceipt(caller *auth.Caller, data dto.CreatePurchaseReceiptRequest) (*entity.PurchaseReceipt, *response.Error)
+}
+
+type purchaseReceiptService struct {
+	repository repository.PurchaseReceiptRepository
+}
+
+// GetAllPurchaseReceipt implements PurchaseReceiptService.
+func (p *purchaseReceiptService) GetAllPurchaseReceipt(caller *auth.Caller, storeID *uuid.UUID, supplierID *uuid.UUID, offset int, limit int) ([]entity.PurchaseReceipt, *response.Error) {
+	// costs are only read by those who manage the store
+	storeIDs := caller.ManagedStores()
+	if storeID != nil {
+		if !caller.CanManageStore(*storeID) {
+			return nil, auth.ForbiddenStore(*storeID)
+		}
+
+		storeIDs = []uuid.UUID{*storeID}
+	}
+
+	return p.repository.GetAllPurchaseReceipt(storeIDs, supplierID, offset, limit)
+}
+
+// GetPurchaseReceiptByID implements PurchaseReceiptService.
+func (p *purchaseReceiptService) GetPurchaseReceiptByID(caller *auth.Caller, id uuid.UUID) (*entity.PurchaseReceipt, *response.Error) {
+	receipt, errReceipt := p.repository.GetPurchaseReceiptByID(id)
+	if errReceipt != nil {
+		return nil, errReceipt
+	}
+
+	if !caller.CanManageStore(receipt.StoreID) {
+		return nil, auth.ForbiddenStore(receipt.StoreID)
+	}
+
+	return receipt, nil
+}
+
+// CreatePurchaseReceipt implements PurchaseReceiptService.
+func (p *purchaseReceiptService) CreatePurchaseReceipt(caller *auth.Caller, data dto.CreatePurchaseReceiptRequest) (*entity.PurchaseReceipt, *response.Error) {
+	if !caller.CanManageStore(data.StoreID) {
+		return nil, auth.ForbiddenStore(data.StoreID)
+	}
+
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if len(data.Items) == 0 {
+		return nil, invalid("items is required")
+	}
+
+	reference := strings.TrimSpace(data.Reference)
+	note := strings.TrimSpace(data.Note)
+	if len(reference) > 100 || len(note) > 255 {
+		return nil, invalid("reference must be up to 100 and note up to 255 characters")
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataReceipt := entity.PurchaseReceipt{
+		ID:         newUUID,
+		StoreID:    data.StoreID,
+		SupplierID: data.SupplierID,
+		Reference:  reference,
+		Note:       note,
+		ReceivedBy: &caller.UserID,
+		ReceivedAt: time.Now(),
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, item := range data.Items {
+		if item.ProductID == uuid.Nil {
+			return nil, invalid("product_id is required")
+		}
+
+		if seen[item.ProductID] {
+			return nil, invalid(fmt.Sprintf("product %s is listed twice", item.ProductID))
+		}
+
+		seen[item.ProductID] = true
+
+		if item.Quantity <= 0 || item.UnitCost < 0 {
+			return nil, invalid("quantity must be positive and unit_cost can not be negative")
+		}
+
+		dataReceipt.Items = append(dataReceipt.Items, entity.PurchaseReceiptItem{
+			ReceiptID: newUUID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitCost:  item.UnitCost,
+		})
+	}
+
+	if errCreate := p.repository.CreatePurchaseReceipt(dataReceipt); errCreate != nil {
+		return nil, errCreate
+	}
+
+	return p.repository.GetPurchaseReceiptByID(newUUID)
+}
+
+func NewPurchaseReceiptService(repository repository.PurchaseReceiptRepository) PurchaseReceiptService {
+	return &purchaseReceiptService{repository}
+}
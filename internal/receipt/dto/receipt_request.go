@@ -0,0 +1,10 @@
+package receipt
+
+type TemplateRequest struct {
+	PaperWidth       int    `json:"paper_width"`
+	Header           string `json:"header"`
+	Footer           string `json:"footer"`
+	ShowCashier      bool   `json:"show_cashier"`
+	ShowTaxBreakdown bool   `json:"show_tax_breakdown"`
+	ShowLoyalty      bool   `json:"show_loyalty"`
+}
@@ -0,0 +1,33 @@
+package receipt
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Template is how the receipts of a store are printed. Header and footer are free texts printed centered
+// above and below the sale, PaperWidth is the number of characters on a line of the printer.
+type Template struct {
+	StoreID          uuid.UUID  `json:"store_id" db:"store_id"`
+	PaperWidth       int        `json:"paper_width" db:"paper_width"`
+	Header           string     `json:"header" db:"header"`
+	Footer           string     `json:"footer" db:"footer"`
+	ShowCashier      bool       `json:"show_cashier" db:"show_cashier"`
+	ShowTaxBreakdown bool       `json:"show_tax_breakdown" db:"show_tax_breakdown"`
+	ShowLoyalty      bool       `json:"show_loyalty" db:"show_loyalty"`
+	UpdatedAt        *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Heading is what a receipt prints about the store, the cashier and the customer of a sale.
+// The loyalty points are the balance of the customer when the receipt is printed.
+type Heading struct {
+	StoreName     string  `db:"store_name"`
+	StoreAddress  string  `db:"store_address"`
+	StorePhone    string  `db:"store_phone"`
+	Timezone      string  `db:"timezone"`
+	CashierName   *string `db:"cashier_name"`
+	CustomerName  *string `db:"customer_name"`
+	IsMember      *bool   `db:"is_member"`
+	LoyaltyPoints *int    `db:"loyalty_points"`
+}
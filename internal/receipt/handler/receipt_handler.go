@@ -0,0 +1,116 @@
+package receipt
+
+import (
+	dto "candyshop/internal/receipt/dto"
+	service "candyshop/internal/receipt/service"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/receipt"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReceiptHandler struct {
+	service service.ReceiptService
+}
+
+func NewReceiptHandler(service service.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{service}
+}
+
+func (h *ReceiptHandler) GetTemplate(c *fiber.Ctx) error {
+	storeID, errParse := uuid.Parse(c.Params("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	template, errTemplate := h.service.GetTemplate(auth.GetCaller(c), storeID)
+	if errTemplate != nil {
+		return c.Status(errTemplate.StatusCode).JSON(fiber.Map{
+			"status_code": errTemplate.StatusCode,
+			"message":     "failed to fetch receipt template",
+			"error":       errTemplate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get receipt template",
+		"data":        template,
+	})
+}
+
+func (h *ReceiptHandler) SaveTemplate(c *fiber.Ctx) error {
+	storeID, errParse := uuid.Parse(c.Params("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.TemplateRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data receipt template",
+			"error":       err.Error(),
+		})
+	}
+
+	template, errTemplate := h.service.SaveTemplate(auth.GetCaller(c), storeID, req)
+	if errTemplate != nil {
+		return c.Status(errTemplate.StatusCode).JSON(fiber.Map{
+			"status_code": errTemplate.StatusCode,
+			"message":     "failed to save receipt template",
+			"error":       errTemplate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success save receipt template",
+		"data":        template,
+	})
+}
+
+func (h *ReceiptHandler) RenderReceipt(c *fiber.Ctx) error {
+	saleID, errParse := uuid.Parse(c.Params("sale_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "sale_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	format, errFormat := receipt.ParseFormat(c.Query("format"))
+	if errFormat != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "format is invalid",
+			"error":       errFormat.Error(),
+		})
+	}
+
+	document, errRender := h.service.RenderReceipt(auth.GetCaller(c), saleID, format)
+	if errRender != nil {
+		return c.Status(errRender.StatusCode).JSON(fiber.Map{
+			"status_code": errRender.StatusCode,
+			"message":     "failed to render receipt",
+			"error":       errRender.Error.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, format.Filename("receipt-"+saleID.String())))
+
+	return c.Status(fiber.StatusOK).Send(document)
+}
@@ -0,0 +1,121 @@
+package receipt
+
+import (
+	"database/sql"
+	"errors"
+
+	entity "candyshop/internal/receipt/entity"
+	"candyshop/pkg/receipt"
+	"candyshop/pkg/response"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type ReceiptRepository interface {
+	GetTemplate(storeID uuid.UUID) (*entity.Template, *response.Error)
+	SaveTemplate(data entity.Template) *response.Error
+	GetHeading(saleID uuid.UUID) (*entity.Heading, *response.Error)
+}
+
+type receiptRepository struct {
+	db *sqlx.DB
+}
+
+// GetTemplate implements ReceiptRepository.
+func (r *receiptRepository) GetTemplate(storeID uuid.UUID) (*entity.Template, *response.Error) {
+	var templates []entity.Template
+
+	err := r.db.Select(&templates, `
+		SELECT store_id, paper_width, header, footer, show_cashier, show_tax_breakdown, show_loyalty, updated_at
+		FROM receipt_templates
+		WHERE store_id = $1
+	`, storeID)
+	if err != nil {
+		return nil, receiptError("get receipt template", err)
+	}
+
+	if len(templates) == 0 {
+		return &entity.Template{
+			StoreID:          storeID,
+			PaperWidth:       receipt.DefaultWidth,
+			ShowCashier:      true,
+			ShowTaxBreakdown: true,
+			ShowLoyalty:      true,
+		}, nil
+	}
+
+	return &templates[0], nil
+}
+
+// SaveTemplate implements ReceiptRepository.
+func (r *receiptRepository) SaveTemplate(data entity.Template) *response.Error {
+	query := `
+		INSERT INTO receipt_templates (store_id, paper_width, header, footer, show_cashier, show_tax_breakdown, show_loyalty, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (store_id) DO UPDATE SET paper_width = $2, header = $3, footer = $4,
+			show_cashier = $5, show_tax_breakdown = $6, show_loyalty = $7, updated_at = $8
+	`
+
+	_, err := r.db.Exec(query, data.StoreID, data.PaperWidth, data.Header, data.Footer,
+		data.ShowCashier, data.ShowTaxBreakdown, data.ShowLoyalty, data.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return &response.Error{
+				StatusCode: 404,
+				Message:    "store not found",
+				Error:      errors.New("store not found"),
+			}
+		}
+
+		return receiptError("save receipt template", err)
+	}
+
+	return nil
+}
+
+// GetHeading implements ReceiptRepository.
+func (r *receiptRepository) GetHeading(saleID uuid.UUID) (*entity.Heading, *response.Error) {
+	var model entity.Heading
+
+	query := `
+		SELECT st.name AS store_name, st.address AS store_address, st.phone_number AS store_phone, st.timezone,
+			u.name AS cashier_name, c.name AS customer_name, c.is_member, c.loyalty_points
+		FROM sales s
+		JOIN stores st ON st.id = s.store_id
+		LEFT JOIN users u ON u.id = s.cashier_id
+		LEFT JOIN customers c ON c.id = s.customer_id
+		WHERE s.id = $1
+	`
+
+	err := r.db.Get(&model, query, saleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch sale",
+				Error:      err,
+			}
+		}
+
+		return nil, receiptError("get receipt heading", err)
+	}
+
+	return &model, nil
+}
+
+func receiptError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewReceiptRepository(db *sqlx.DB) ReceiptRepository {
+	return &receiptRepository{db}
+}
@@ -0,0 +1,34 @@
+package receipt
+
+import (
+	promotionRepository "candyshop/internal/promotion/repository"
+	promotionService "candyshop/internal/promotion/service"
+	handler "candyshop/internal/receipt/handler"
+	repository "candyshop/internal/receipt/repository"
+	service "candyshop/internal/receipt/service"
+	saleRepository "candyshop/internal/sale/repository"
+	saleService "candyshop/internal/sale/service"
+	staff "candyshop/internal/staff"
+	taxRepository "candyshop/internal/tax/repository"
+	taxService "candyshop/internal/tax/service"
+	"candyshop/pkg/payment"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB, payments payment.Provider) {
+	promotion := promotionService.NewPromotionService(promotionRepository.NewPromotionRepository(db))
+	tax := taxService.NewTaxService(taxRepository.NewTaxRepository(db))
+	sale := saleService.NewSaleService(saleRepository.NewSaleRepository(db), promotion, tax, payments)
+
+	repo := repository.NewReceiptRepository(db)
+	service := service.NewReceiptService(repo, sale)
+	handler := handler.NewReceiptHandler(service)
+
+	receiptRoute := router.Group("api/v1/receipts", staff.Authenticate(db))
+
+	receiptRoute.Get("/templates/:store_id", handler.GetTemplate)
+	receiptRoute.Patch("/templates/:store_id", handler.SaveTemplate)
+	receiptRoute.Get("/sales/:sale_id", handler.RenderReceipt)
+}
@@ -0,0 +1,226 @@
+package receipt
+
+import (
+	dto "candyshop/internal/receipt/dto"
+	entity "candyshop/internal/receipt/entity"
+	repository "candyshop/internal/receipt/repository"
+	saleEntity "candyshop/internal/sale/entity"
+	saleService "candyshop/internal/sale/service"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/payment"
+	"candyshop/pkg/receipt"
+	"candyshop/pkg/response"
+	"candyshop/pkg/tax"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReceiptService interface {
+	GetTemplate(caller *auth.Caller, storeID uuid.UUID) (*entity.Template, *response.Error)
+	SaveTemplate(caller *auth.Caller, storeID uuid.UUID, data dto.TemplateRequest) (*entity.Template, *response.Error)
+	RenderReceipt(caller *auth.Caller, saleID uuid.UUID, format receipt.Format) ([]byte, *response.Error)
+}
+
+type receiptService struct {
+	repository repository.ReceiptRepository
+	sale       saleService.SaleService
+}
+
+// names of the payment methods as the customer reads them
+var paymentLabels = map[string]string{
+	saleEntity.PaymentCash: "Cash",
+	saleEntity.PaymentCard: "Card",
+	saleEntity.PaymentQRIS: "QRIS",
+}
+
+// GetTemplate implements ReceiptService.
+func (r *receiptService) GetTemplate(caller *auth.Caller, storeID uuid.UUID) (*entity.Template, *response.Error) {
+	if !caller.CanAccessStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	return r.repository.GetTemplate(storeID)
+}
+
+// SaveTemplate implements ReceiptService.
+func (r *receiptService) SaveTemplate(caller *auth.Caller, storeID uuid.UUID, data dto.TemplateRequest) (*entity.Template, *response.Error) {
+	if !caller.CanManageStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if data.PaperWidth == 0 {
+		data.PaperWidth = receipt.DefaultWidth
+	}
+
+	if data.PaperWidth < receipt.MinWidth || data.PaperWidth > receipt.MaxWidth {
+		return nil, invalid(fmt.Sprintf("paper_width must be between %d and %d characters", receipt.MinWidth, receipt.MaxWidth))
+	}
+
+	if len(data.Header) > 1000 || len(data.Footer) > 1000 {
+		return nil, invalid("header and footer must be up to 1000 characters")
+	}
+
+	currentTime := time.Now()
+
+	errSave := r.repository.SaveTemplate(entity.Template{
+		StoreID:          storeID,
+		PaperWidth:       data.PaperWidth,
+		Header:           strings.TrimSpace(data.Header),
+		Footer:           strings.TrimSpace(data.Footer),
+		ShowCashier:      data.ShowCashier,
+		ShowTaxBreakdown: data.ShowTaxBreakdown,
+		ShowLoyalty:      data.ShowLoyalty,
+		UpdatedAt:        &currentTime,
+	})
+	if errSave != nil {
+		return nil, errSave
+	}
+
+	return r.repository.GetTemplate(storeID)
+}
+
+// RenderReceipt implements ReceiptService.
+func (r *receiptService) RenderReceipt(caller *auth.Caller, saleID uuid.UUID, format receipt.Format) ([]byte, *response.Error) {
+	sale, errSale := r.sale.GetSaleByID(caller, saleID)
+	if errSale != nil {
+		return nil, errSale
+	}
+
+	template, errTemplate := r.repository.GetTemplate(sale.StoreID)
+	if errTemplate != nil {
+		return nil, errTemplate
+	}
+
+	heading, errHeading := r.repository.GetHeading(saleID)
+	if errHeading != nil {
+		return nil, errHeading
+	}
+
+	return format.Render(buildReceipt(sale, template, heading)), nil
+}
+
+// buildReceipt lays out a sale the way the template of its store prints it.
+func buildReceipt(sale *saleEntity.Sale, template *entity.Template, heading *entity.Heading) *receipt.Document {
+	document := receipt.New(template.PaperWidth)
+
+	document.Title(heading.StoreName)
+	document.Text(heading.StoreAddress, true)
+	if heading.StorePhone != "" {
+		document.Text(heading.StorePhone, true)
+	}
+	if template.Header != "" {
+		document.Blank()
+		document.Text(template.Header, true)
+	}
+
+	document.Separator()
+	document.Row("Sale", sale.ID.String()[:8])
+	document.Row("Date", sale.SoldAt.In(storeLocation(heading.Timezone)).Format("02 Jan 2006 15:04"))
+	if template.ShowCashier && heading.CashierName != nil {
+		document.Row("Cashier", *heading.CashierName)
+	}
+	if heading.CustomerName != nil {
+		document.Row("Customer", *heading.CustomerName)
+	}
+	if sale.Status == saleEntity.StatusPending {
+		document.Row("Status", "PAYMENT PENDING")
+	}
+	if sale.Status == saleEntity.StatusCancelled {
+		document.Row("Status", "CANCELLED")
+	}
+
+	document.Separator()
+	for _, item := range sale.Items {
+		document.Text(item.Name, false)
+		document.Row(fmt.Sprintf("  %d x %s", item.Quantity, receipt.Amount(item.UnitPrice)), receipt.Amount(item.Subtotal))
+	}
+
+	document.Separator()
+	document.Row("Subtotal", receipt.Amount(sale.Subtotal))
+	// the discount is printed once, by the promotions that gave it when they are known
+	if len(sale.Promotions) > 0 {
+		for _, promotion := range sale.Promotions {
+			document.Row(promotion.Name, receipt.Amount(-promotion.Discount))
+		}
+	} else if sale.Discount > 0 {
+		document.Row("Discount", receipt.Amount(-sale.Discount))
+	}
+	if sale.TaxMode == tax.ModeExclusive && sale.TaxAmount > 0 {
+		document.Row("Tax", receipt.Amount(sale.TaxAmount))
+	}
+	document.BoldRow("TOTAL", receipt.Amount(sale.Total))
+
+	for _, salePayment := range sale.Payments {
+		if salePayment.Status == payment.StatusFailed {
+			continue
+		}
+
+		label := paymentLabels[salePayment.Method]
+		if salePayment.Status == payment.StatusPending {
+			label += " (pending)"
+		}
+
+		if salePayment.Tendered > salePayment.Amount {
+			document.Row(label, receipt.Amount(salePayment.Tendered))
+			document.Row("Change", receipt.Amount(salePayment.Change))
+		} else {
+			document.Row(label, receipt.Amount(salePayment.Amount))
+		}
+	}
+	if sale.RefundedAmount > 0 {
+		document.Row("Refunded", receipt.Amount(-sale.RefundedAmount))
+	}
+
+	if template.ShowTaxBreakdown && len(sale.Taxes) > 0 {
+		document.Separator()
+		if sale.TaxMode == tax.ModeInclusive {
+			document.Text("Prices include tax", false)
+		}
+		for _, saleTax := range sale.Taxes {
+			document.Row(fmt.Sprintf("%s %s of %s", saleTax.Code, receipt.Rate(saleTax.Rate), receipt.Amount(saleTax.TaxableAmount)),
+				receipt.Amount(saleTax.TaxAmount))
+		}
+	}
+
+	if template.ShowLoyalty && heading.IsMember != nil && *heading.IsMember {
+		document.Separator()
+		document.Row("Points earned", fmt.Sprintf("%d", sale.LoyaltyPoints-sale.ReversedPoints))
+		if heading.LoyaltyPoints != nil {
+			document.Row("Points balance", fmt.Sprintf("%d", *heading.LoyaltyPoints))
+		}
+	}
+
+	if template.Footer != "" {
+		document.Separator()
+		document.Text(template.Footer, true)
+	}
+
+	return document
+}
+
+// storeLocation falls back to UTC for an unknown timezone so a receipt can still be printed.
+func storeLocation(timezone string) *time.Location {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func NewReceiptService(repository repository.ReceiptRepository, sale saleService.SaleService) ReceiptService {
+	return &receiptService{repository, sale}
+}
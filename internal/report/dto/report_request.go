@@ -0,0 +1,25 @@
+package report
+
+import "github.com/google/uuid"
+
+// ReportRequest is read from the query, from and to are dates in the timezone and both are included.
+// The timezone defaults to the one of the stores, or UTC when they are in several timezones.
+type ReportRequest struct {
+	StoreID  *uuid.UUID
+	From     string
+	To       string
+	Timezone string
+	GroupBy  string
+	Compare  bool
+	Limit    int
+}
+
+type RankingRequest struct {
+	StoreID  *uuid.UUID
+	From     string
+	To       string
+	Timezone string
+	Rank     string
+	Metric   string
+	Limit    int
+}
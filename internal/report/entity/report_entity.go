@@ -0,0 +1,118 @@
+package report
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// what a sales report is grouped by, the last four are time buckets in the timezone of the report
+const (
+	GroupStore    = "store"
+	GroupProduct  = "product"
+	GroupBrand    = "brand"
+	GroupCategory = "category"
+	GroupCashier  = "cashier"
+	GroupHour     = "hour"
+	GroupDay      = "day"
+	GroupWeek     = "week"
+	GroupMonth    = "month"
+)
+
+// metrics products are ranked by
+const (
+	MetricNetSales = "net_sales"
+	MetricQuantity = "quantity"
+	MetricMargin   = "margin"
+)
+
+// the end of the ranking products are taken from
+const (
+	RankTop    = "top"
+	RankBottom = "bottom"
+)
+
+func IsValidGroup(group string) bool {
+	switch group {
+	case GroupStore, GroupProduct, GroupBrand, GroupCategory, GroupCashier:
+		return true
+	}
+
+	return IsTimeBucket(group)
+}
+
+func IsTimeBucket(group string) bool {
+	return group == GroupHour || group == GroupDay || group == GroupWeek || group == GroupMonth
+}
+
+func IsValidMetric(metric string) bool {
+	return metric == MetricNetSales || metric == MetricQuantity || metric == MetricMargin
+}
+
+// Filter selects the completed sales sold from From up to but not including To in the stores,
+// nil store ids means every store. Time buckets are cut in Timezone.
+// Daily reads the daily summaries, which are cut in the timezone of the stores, instead of the sales.
+// The worker refreshes the summaries every minute, a sale made in the last minute may not be counted yet.
+type Filter struct {
+	StoreIDs []uuid.UUID
+	From     time.Time
+	To       time.Time
+	Timezone string
+	GroupBy  string
+	Limit    int
+	Daily    bool
+}
+
+// Metrics sums the sold lines, amounts are in the smallest currency unit.
+// Net sales are the line totals without their tax, refunded is what was refunded for the lines since.
+// Cost is what the sold goods cost the store when they were sold and margin is the net sales over it.
+type Metrics struct {
+	SalesCount       int64 `json:"sales_count" db:"sales_count"`
+	Quantity         int64 `json:"quantity" db:"quantity"`
+	ReturnedQuantity int64 `json:"returned_quantity" db:"returned_quantity"`
+	GrossSales       int64 `json:"gross_sales" db:"gross_sales"`
+	Discount         int64 `json:"discount" db:"discount"`
+	Tax              int64 `json:"tax" db:"tax"`
+	NetSales         int64 `json:"net_sales" db:"net_sales"`
+	Refunded         int64 `json:"refunded" db:"refunded"`
+	Cost             int64 `json:"cost" db:"cost"`
+	Margin           int64 `json:"margin" db:"margin"`
+}
+
+// Row is one group of a report. Key identifies the group, e.g. a store id, a brand or the start of a bucket.
+// Previous holds the same group in the previous period when the report is compared, change is in percent.
+type Row struct {
+	Key   string `json:"key" db:"key"`
+	Label string `json:"label" db:"label"`
+	Metrics
+	Previous       *Metrics `json:"previous,omitempty" db:"-"`
+	NetSalesChange *float64 `json:"net_sales_change,omitempty" db:"-"`
+}
+
+type Period struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Totals Metrics   `json:"totals"`
+}
+
+// SalesReport is the sales of a period grouped one way, the previous period has the same length and ends where it starts.
+type SalesReport struct {
+	GroupBy        string    `json:"group_by"`
+	Timezone       string    `json:"timezone"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	Totals         Metrics   `json:"totals"`
+	Previous       *Period   `json:"previous,omitempty"`
+	NetSalesChange *float64  `json:"net_sales_change,omitempty"`
+	Rows           []Row     `json:"rows"`
+}
+
+// ProductRanking is the best or worst selling products of a period, the worst include stocked products that sold nothing.
+type ProductRanking struct {
+	Rank     string    `json:"rank"`
+	Metric   string    `json:"metric"`
+	Timezone string    `json:"timezone"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Rows     []Row     `json:"rows"`
+}
@@ -0,0 +1,100 @@
+package report
+
+import (
+	dto "candyshop/internal/report/dto"
+	service "candyshop/internal/report/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReportHandler struct {
+	service service.ReportService
+}
+
+func NewReportHandler(service service.ReportService) *ReportHandler {
+	return &ReportHandler{service}
+}
+
+func (h *ReportHandler) GetSalesReport(c *fiber.Ctx) error {
+	storeID, errStore := queryStoreID(c)
+	if errStore != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errStore.Error(),
+		})
+	}
+
+	report, errReport := h.service.GetSalesReport(auth.GetCaller(c), dto.ReportRequest{
+		StoreID:  storeID,
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Timezone: c.Query("timezone"),
+		GroupBy:  c.Query("group_by"),
+		Compare:  c.QueryBool("compare"),
+		Limit:    c.QueryInt("limit"),
+	})
+	if errReport != nil {
+		return c.Status(errReport.StatusCode).JSON(fiber.Map{
+			"status_code": errReport.StatusCode,
+			"message":     "failed to fetch sales report",
+			"error":       errReport.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get sales report",
+		"data":        report,
+	})
+}
+
+func (h *ReportHandler) GetProductRanking(c *fiber.Ctx) error {
+	storeID, errStore := queryStoreID(c)
+	if errStore != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errStore.Error(),
+		})
+	}
+
+	ranking, errRanking := h.service.GetProductRanking(auth.GetCaller(c), dto.RankingRequest{
+		StoreID:  storeID,
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Timezone: c.Query("timezone"),
+		Rank:     c.Query("rank"),
+		Metric:   c.Query("metric"),
+		Limit:    c.QueryInt("limit"),
+	})
+	if errRanking != nil {
+		return c.Status(errRanking.StatusCode).JSON(fiber.Map{
+			"status_code": errRanking.StatusCode,
+			"message":     "failed to fetch product ranking",
+			"error":       errRanking.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get product ranking",
+		"data":        ranking,
+	})
+}
+
+// queryStoreID reads the optional store_id query parameter
+func queryStoreID(c *fiber.Ctx) (*uuid.UUID, error) {
+	if c.Query("store_id") == "" {
+		return nil, nil
+	}
+
+	storeID, err := uuid.Parse(c.Query("store_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &storeID, nil
+}
@@ -0,0 +1,284 @@
+package report
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	entity "candyshop/internal/report/entity"
+	"candyshop/pkg/response"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type ReportRepository interface {
+	GetStoreTimezone(storeID uuid.UUID) (string, *response.Error)
+	GetSharedTimezone(storeIDs []uuid.UUID) (string, *response.Error)
+	GetSalesTotals(filter entity.Filter) (*entity.Metrics, *response.Error)
+	GetSalesRows(filter entity.Filter, keys []string) ([]entity.Row, *response.Error)
+	GetProductRanking(filter entity.Filter, metric string, rank string) ([]entity.Row, *response.Error)
+}
+
+type reportRepository struct {
+	db *sqlx.DB
+}
+
+// saleLines are the lines of the completed sales in a filter with what has been refunded for them,
+// $1 and $2 bound the time they were sold, $3 are the stores and $4 is the timezone of the buckets.
+const saleLines = `
+	WITH lines AS (
+		SELECT s.id AS sale_id, s.store_id, s.cashier_id, s.sold_at AT TIME ZONE $4::text AS local_sold_at,
+			i.product_id, i.quantity, i.returned_quantity, i.subtotal, i.discount, i.tax_amount, i.total, i.cost,
+			COALESCE((SELECT SUM(ri.refund_amount) FROM sale_return_items ri WHERE ri.sale_item_id = i.id), 0) AS refunded
+		FROM sales s
+		JOIN sale_items i ON i.sale_id = s.id
+		WHERE s.status = 'completed' AND s.sold_at >= $1 AND s.sold_at < $2 AND ($3::uuid[] IS NULL OR s.store_id = ANY($3))
+	)`
+
+const metricColumns = `
+	COUNT(DISTINCT l.sale_id) AS sales_count,
+	COALESCE(SUM(l.quantity), 0) AS quantity,
+	COALESCE(SUM(l.returned_quantity), 0) AS returned_quantity,
+	COALESCE(SUM(l.subtotal), 0) AS gross_sales,
+	COALESCE(SUM(l.discount), 0) AS discount,
+	COALESCE(SUM(l.tax_amount), 0) AS tax,
+	COALESCE(SUM(l.total - l.tax_amount), 0) AS net_sales,
+	COALESCE(SUM(l.refunded), 0) AS refunded,
+	COALESCE(SUM(l.cost), 0) AS cost,
+	COALESCE(SUM(l.total - l.tax_amount - l.cost), 0) AS margin`
+
+// grouping is how the rows are grouped, from is the source of the rows with the tables the key and the label are read from.
+type grouping struct {
+	key   string
+	label string
+	from  string
+}
+
+var groupings = map[string]grouping{
+	entity.GroupStore:    {"l.store_id::text", "st.name", "lines l JOIN stores st ON st.id = l.store_id"},
+	entity.GroupProduct:  {"l.product_id::text", "p.name", "lines l JOIN products p ON p.id = l.product_id"},
+	entity.GroupBrand:    {"p.brand", "p.brand", "lines l JOIN products p ON p.id = l.product_id"},
+	entity.GroupCategory: {"COALESCE(p.category_id::text, '')", "COALESCE(c.name, 'Uncategorized')", "lines l JOIN products p ON p.id = l.product_id LEFT JOIN categories c ON c.id = p.category_id"},
+	entity.GroupCashier:  {"COALESCE(l.cashier_id::text, '')", "COALESCE(u.name, 'Unknown')", "lines l LEFT JOIN users u ON u.id = l.cashier_id"},
+	entity.GroupHour:     {`to_char(date_trunc('hour', l.local_sold_at), 'YYYY-MM-DD"T"HH24:00')`, "", "lines l"},
+	entity.GroupDay:      {`to_char(date_trunc('day', l.local_sold_at), 'YYYY-MM-DD')`, "", "lines l"},
+	entity.GroupWeek:     {`to_char(date_trunc('week', l.local_sold_at), 'YYYY-MM-DD')`, "", "lines l"},
+	entity.GroupMonth:    {`to_char(date_trunc('month', l.local_sold_at), 'YYYY-MM')`, "", "lines l"},
+}
+
+// dailyWhere selects the summaries of the days from $1 up to but not including $2 of the stores $3.
+const dailyWhere = `d.day >= $1::date AND d.day < $2::date AND ($3::uuid[] IS NULL OR d.store_id = ANY($3))`
+
+const dailyMetricColumns = `
+	COALESCE(SUM(d.sales_count), 0) AS sales_count,
+	COALESCE(SUM(d.quantity), 0) AS quantity,
+	COALESCE(SUM(d.returned_quantity), 0) AS returned_quantity,
+	COALESCE(SUM(d.gross_sales), 0) AS gross_sales,
+	COALESCE(SUM(d.discount), 0) AS discount,
+	COALESCE(SUM(d.tax), 0) AS tax,
+	COALESCE(SUM(d.net_sales), 0) AS net_sales,
+	COALESCE(SUM(d.refunded), 0) AS refunded,
+	COALESCE(SUM(d.cost), 0) AS cost,
+	COALESCE(SUM(d.margin), 0) AS margin`
+
+// dailyGroupings read the store summaries when a group is a store or a time bucket and the product summaries otherwise,
+// a brand or a category read from them counts a sale once per product it sold. There is no summary by hour or cashier.
+var dailyGroupings = map[string]grouping{
+	entity.GroupStore:    {"d.store_id::text", "st.name", "daily_store_sales d JOIN stores st ON st.id = d.store_id"},
+	entity.GroupProduct:  {"d.product_id::text", "p.name", "daily_product_sales d JOIN products p ON p.id = d.product_id"},
+	entity.GroupBrand:    {"p.brand", "p.brand", "daily_product_sales d JOIN products p ON p.id = d.product_id"},
+	entity.GroupCategory: {"COALESCE(p.category_id::text, '')", "COALESCE(c.name, 'Uncategorized')", "daily_product_sales d JOIN products p ON p.id = d.product_id LEFT JOIN categories c ON c.id = p.category_id"},
+	entity.GroupDay:      {`to_char(d.day, 'YYYY-MM-DD')`, "", "daily_store_sales d"},
+	entity.GroupWeek:     {`to_char(date_trunc('week', d.day), 'YYYY-MM-DD')`, "", "daily_store_sales d"},
+	entity.GroupMonth:    {`to_char(d.day, 'YYYY-MM')`, "", "daily_store_sales d"},
+}
+
+// HasDailySummary reports whether a group can be read from the daily summaries.
+func HasDailySummary(group string) bool {
+	_, ok := dailyGroupings[group]
+	return ok
+}
+
+// GetStoreTimezone implements ReportRepository.
+func (r *reportRepository) GetStoreTimezone(storeID uuid.UUID) (string, *response.Error) {
+	var timezone string
+
+	err := r.db.Get(&timezone, `SELECT timezone FROM stores WHERE id = $1 AND deleted_at IS NULL`, storeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", &response.Error{
+				StatusCode: 404,
+				Message:    "store not found",
+				Error:      err,
+			}
+		}
+
+		return "", reportError("get store timezone", err)
+	}
+
+	return timezone, nil
+}
+
+// GetSharedTimezone implements ReportRepository.
+func (r *reportRepository) GetSharedTimezone(storeIDs []uuid.UUID) (string, *response.Error) {
+	var timezones []string
+
+	err := r.db.Select(&timezones, `SELECT DISTINCT timezone FROM stores WHERE ($1::uuid[] IS NULL OR id = ANY($1))`, pq.Array(storeIDs))
+	if err != nil {
+		return "", reportError("get shared timezone", err)
+	}
+
+	// stores in several timezones share none
+	if len(timezones) != 1 {
+		return "", nil
+	}
+
+	return timezones[0], nil
+}
+
+// GetSalesTotals implements ReportRepository.
+func (r *reportRepository) GetSalesTotals(filter entity.Filter) (*entity.Metrics, *response.Error) {
+	var totals entity.Metrics
+
+	query := saleLines + `
+		SELECT ` + metricColumns + `
+		FROM lines l
+	`
+	args := []any{filter.From, filter.To, pq.Array(filter.StoreIDs), filter.Timezone}
+
+	if filter.Daily {
+		query = `SELECT ` + dailyMetricColumns + ` FROM daily_store_sales d WHERE ` + dailyWhere
+		args = dailyArgs(filter)
+	}
+
+	err := r.db.Get(&totals, query, args...)
+	if err != nil {
+		return nil, reportError("get sales totals", err)
+	}
+
+	return &totals, nil
+}
+
+// GetSalesRows implements ReportRepository.
+func (r *reportRepository) GetSalesRows(filter entity.Filter, keys []string) ([]entity.Row, *response.Error) {
+	var rows []entity.Row
+
+	group, metrics, with, where := groupings[filter.GroupBy], metricColumns, saleLines, ""
+	args := []any{filter.From, filter.To, pq.Array(filter.StoreIDs), filter.Timezone}
+
+	if filter.Daily {
+		group, metrics, with, where = dailyGroupings[filter.GroupBy], dailyMetricColumns, "", "WHERE "+dailyWhere
+		args = dailyArgs(filter)
+	}
+
+	// time buckets are labelled by their start and read in order, other groups from the best selling
+	label, order := group.label, "g.net_sales DESC, g.key"
+	if entity.IsTimeBucket(filter.GroupBy) {
+		label, order = group.key, "g.key"
+	}
+
+	// no keys reads every group, a limit of zero reads all of them
+	query := with + `
+		SELECT * FROM (
+			SELECT ` + group.key + ` AS key, ` + label + ` AS label, ` + metrics + `
+			FROM ` + group.from + `
+			` + where + `
+			GROUP BY 1, 2
+		) g
+		WHERE (` + placeholder(len(args)+1) + `::text[] IS NULL OR g.key = ANY(` + placeholder(len(args)+1) + `))
+		ORDER BY ` + order + `
+		LIMIT NULLIF(` + placeholder(len(args)+2) + `, 0)
+	`
+
+	err := r.db.Select(&rows, query, append(args, pq.Array(keys), filter.Limit)...)
+	if err != nil {
+		return nil, reportError("get sales rows", err)
+	}
+
+	return rows, nil
+}
+
+// GetProductRanking implements ReportRepository.
+func (r *reportRepository) GetProductRanking(filter entity.Filter, metric string, rank string) ([]entity.Row, *response.Error) {
+	var rows []entity.Row
+
+	direction := "DESC"
+	if rank == entity.RankBottom {
+		direction = "ASC"
+	}
+
+	sold := saleLines + `,
+		sold AS (
+			SELECT l.product_id, ` + metricColumns + `
+			FROM lines l
+			GROUP BY l.product_id
+		)`
+	args := []any{filter.From, filter.To, pq.Array(filter.StoreIDs), filter.Timezone}
+
+	if filter.Daily {
+		sold = `
+		WITH sold AS (
+			SELECT d.product_id, ` + dailyMetricColumns + `
+			FROM daily_product_sales d
+			WHERE ` + dailyWhere + `
+			GROUP BY d.product_id
+		)`
+		args = dailyArgs(filter)
+	}
+
+	// products stocked in the stores that sold nothing rank at the bottom with zero
+	query := sold + `
+		SELECT p.id::text AS key, p.name AS label,
+			COALESCE(sold.sales_count, 0) AS sales_count,
+			COALESCE(sold.quantity, 0) AS quantity,
+			COALESCE(sold.returned_quantity, 0) AS returned_quantity,
+			COALESCE(sold.gross_sales, 0) AS gross_sales,
+			COALESCE(sold.discount, 0) AS discount,
+			COALESCE(sold.tax, 0) AS tax,
+			COALESCE(sold.net_sales, 0) AS net_sales,
+			COALESCE(sold.refunded, 0) AS refunded,
+			COALESCE(sold.cost, 0) AS cost,
+			COALESCE(sold.margin, 0) AS margin
+		FROM products p
+		LEFT JOIN sold ON sold.product_id = p.id
+		WHERE sold.product_id IS NOT NULL
+			OR (p.deleted_at IS NULL AND EXISTS (
+				SELECT 1 FROM inventories inv WHERE inv.product_id = p.id AND ($3::uuid[] IS NULL OR inv.store_id = ANY($3))
+			))
+		ORDER BY ` + metric + ` ` + direction + `, p.name, p.id
+		LIMIT ` + placeholder(len(args)+1) + `
+	`
+
+	err := r.db.Select(&rows, query, append(args, filter.Limit)...)
+	if err != nil {
+		return nil, reportError("get product ranking", err)
+	}
+
+	return rows, nil
+}
+
+// dailyArgs are the first day, the day after the last and the stores of a filter read from the daily summaries.
+func dailyArgs(filter entity.Filter) []any {
+	return []any{filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly), pq.Array(filter.StoreIDs)}
+}
+
+func placeholder(position int) string {
+	return fmt.Sprintf("$%d", position)
+}
+
+func reportError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewReportRepository(db *sqlx.DB) ReportRepository {
+	return &reportRepository{db}
+}
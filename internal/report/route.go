@@ -0,0 +1,22 @@
+package report
+
+import (
+	handler "candyshop/internal/report/handler"
+	repository "candyshop/internal/report/repository"
+	service "candyshop/internal/report/service"
+	staff "candyshop/internal/staff"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewReportRepository(db)
+	service := service.NewReportService(repo)
+	handler := handler.NewReportHandler(service)
+
+	reportRoute := router.Group("api/v1/reports", staff.Authenticate(db))
+
+	reportRoute.Get("/sales", handler.GetSalesReport)
+	reportRoute.Get("/products/ranking", handler.GetProductRanking)
+}
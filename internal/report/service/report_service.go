@@ -0,0 +1,325 @@
+package report
+
+import (
+	dto "candyshop/internal/report/dto"
+	entity "candyshop/internal/report/entity"
+	repository "candyshop/internal/report/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// limits that keep a report to a size the database reads quickly
+const (
+	MaxReportDays     = 366
+	MaxHourReportDays = 31
+	DefaultRowLimit   = 100
+	MaxRowLimit       = 1000
+	DefaultRankLimit  = 10
+	MaxRankLimit      = 100
+)
+
+type ReportService interface {
+	GetSalesReport(caller *auth.Caller, data dto.ReportRequest) (*entity.SalesReport, *response.Error)
+	GetProductRanking(caller *auth.Caller, data dto.RankingRequest) (*entity.ProductRanking, *response.Error)
+}
+
+type reportService struct {
+	repository repository.ReportRepository
+}
+
+// GetSalesReport implements ReportService.
+func (r *reportService) GetSalesReport(caller *auth.Caller, data dto.ReportRequest) (*entity.SalesReport, *response.Error) {
+	if data.GroupBy == "" {
+		data.GroupBy = entity.GroupDay
+	}
+
+	if !entity.IsValidGroup(data.GroupBy) {
+		return nil, invalid("group_by must be store, product, brand, category, cashier, hour, day, week or month")
+	}
+
+	if data.Limit < 0 || data.Limit > MaxRowLimit {
+		return nil, invalid(fmt.Sprintf("limit must be up to %d", MaxRowLimit))
+	}
+
+	if data.Limit == 0 {
+		data.Limit = DefaultRowLimit
+	}
+
+	filter, location, errFilter := r.filter(caller, data.StoreID, data.From, data.To, data.Timezone)
+	if errFilter != nil {
+		return nil, errFilter
+	}
+
+	filter.GroupBy = data.GroupBy
+	filter.Limit = data.Limit
+	filter.Daily = filter.Daily && repository.HasDailySummary(data.GroupBy)
+
+	// every bucket of the period is listed, so buckets are not limited
+	if entity.IsTimeBucket(data.GroupBy) {
+		filter.Limit = 0
+
+		if data.GroupBy == entity.GroupHour && filter.To.Sub(filter.From) > MaxHourReportDays*24*time.Hour {
+			return nil, invalid(fmt.Sprintf("an hourly report covers up to %d days", MaxHourReportDays))
+		}
+	}
+
+	totals, errTotals := r.repository.GetSalesTotals(filter)
+	if errTotals != nil {
+		return nil, errTotals
+	}
+
+	rows, errRows := r.repository.GetSalesRows(filter, nil)
+	if errRows != nil {
+		return nil, errRows
+	}
+
+	if entity.IsTimeBucket(data.GroupBy) {
+		rows = fillBuckets(rows, data.GroupBy, filter.From, filter.To, location)
+	}
+
+	report := &entity.SalesReport{
+		GroupBy:  data.GroupBy,
+		Timezone: filter.Timezone,
+		From:     filter.From,
+		To:       filter.To,
+		Totals:   *totals,
+		Rows:     rows,
+	}
+
+	if !data.Compare {
+		return report, nil
+	}
+
+	// the previous period ends where the report starts and has as many days
+	previous := filter
+	previous.To = filter.From
+	previous.From = filter.From.AddDate(0, 0, -int(math.Round(filter.To.Sub(filter.From).Hours()/24)))
+
+	previousTotals, errPrevious := r.repository.GetSalesTotals(previous)
+	if errPrevious != nil {
+		return nil, errPrevious
+	}
+
+	report.Previous = &entity.Period{From: previous.From, To: previous.To, Totals: *previousTotals}
+	report.NetSalesChange = change(totals.NetSales, previousTotals.NetSales)
+
+	// buckets of two periods do not share keys, they are only compared by their totals
+	if entity.IsTimeBucket(data.GroupBy) || len(rows) == 0 {
+		return report, nil
+	}
+
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i] = row.Key
+	}
+
+	previous.Limit = 0
+	previousRows, errPreviousRows := r.repository.GetSalesRows(previous, keys)
+	if errPreviousRows != nil {
+		return nil, errPreviousRows
+	}
+
+	byKey := map[string]entity.Metrics{}
+	for _, row := range previousRows {
+		byKey[row.Key] = row.Metrics
+	}
+
+	for i := range report.Rows {
+		metrics := byKey[report.Rows[i].Key]
+		report.Rows[i].Previous = &metrics
+		report.Rows[i].NetSalesChange = change(report.Rows[i].NetSales, metrics.NetSales)
+	}
+
+	return report, nil
+}
+
+// GetProductRanking implements ReportService.
+func (r *reportService) GetProductRanking(caller *auth.Caller, data dto.RankingRequest) (*entity.ProductRanking, *response.Error) {
+	if data.Rank == "" {
+		data.Rank = entity.RankTop
+	}
+
+	if data.Metric == "" {
+		data.Metric = entity.MetricNetSales
+	}
+
+	if data.Rank != entity.RankTop && data.Rank != entity.RankBottom {
+		return nil, invalid("rank must be top or bottom")
+	}
+
+	if !entity.IsValidMetric(data.Metric) {
+		return nil, invalid("metric must be net_sales, quantity or margin")
+	}
+
+	if data.Limit < 0 || data.Limit > MaxRankLimit {
+		return nil, invalid(fmt.Sprintf("limit must be up to %d", MaxRankLimit))
+	}
+
+	if data.Limit == 0 {
+		data.Limit = DefaultRankLimit
+	}
+
+	filter, _, errFilter := r.filter(caller, data.StoreID, data.From, data.To, data.Timezone)
+	if errFilter != nil {
+		return nil, errFilter
+	}
+
+	filter.Limit = data.Limit
+
+	rows, errRows := r.repository.GetProductRanking(filter, data.Metric, data.Rank)
+	if errRows != nil {
+		return nil, errRows
+	}
+
+	return &entity.ProductRanking{
+		Rank:     data.Rank,
+		Metric:   data.Metric,
+		Timezone: filter.Timezone,
+		From:     filter.From,
+		To:       filter.To,
+		Rows:     rows,
+	}, nil
+}
+
+// filter limits a report to the stores the caller manages and turns the dates into the start of the first day
+// and the end of the last day in the timezone. The timezone defaults to the one the stores share, and when it is
+// theirs the report can be read from the daily summaries, whose days are cut in the timezone of the stores.
+func (r *reportService) filter(caller *auth.Caller, storeID *uuid.UUID, from, to, timezone string) (entity.Filter, *time.Location, *response.Error) {
+	var filter entity.Filter
+
+	if storeID != nil {
+		if !caller.CanManageStore(*storeID) {
+			return filter, nil, auth.ForbiddenStore(*storeID)
+		}
+
+		if _, errStore := r.repository.GetStoreTimezone(*storeID); errStore != nil {
+			return filter, nil, errStore
+		}
+
+		filter.StoreIDs = []uuid.UUID{*storeID}
+	} else if !caller.IsOwner() {
+		filter.StoreIDs = caller.ManagedStores()
+		if len(filter.StoreIDs) == 0 {
+			return filter, nil, &response.Error{
+				StatusCode: fiber.StatusForbidden,
+				Message:    "only owner and store managers can read reports",
+				Error:      errors.New("only owner and store managers can read reports"),
+			}
+		}
+	}
+
+	sharedTimezone, errShared := r.repository.GetSharedTimezone(filter.StoreIDs)
+	if errShared != nil {
+		return filter, nil, errShared
+	}
+
+	if timezone == "" {
+		timezone = sharedTimezone
+	}
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	filter.Daily = timezone == sharedTimezone
+
+	location, errLocation := time.LoadLocation(timezone)
+	if errLocation != nil {
+		return filter, nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    fmt.Sprintf("timezone %s is invalid", timezone),
+			Error:      errLocation,
+		}
+	}
+
+	fromDate, errFrom := time.ParseInLocation(time.DateOnly, from, location)
+	toDate, errTo := time.ParseInLocation(time.DateOnly, to, location)
+	if errFrom != nil || errTo != nil {
+		return filter, nil, invalid("from and to must be dates formatted as YYYY-MM-DD")
+	}
+
+	if toDate.Before(fromDate) {
+		return filter, nil, invalid("to must not be before from")
+	}
+
+	if toDate.Sub(fromDate) >= MaxReportDays*24*time.Hour {
+		return filter, nil, invalid(fmt.Sprintf("a report covers up to %d days", MaxReportDays))
+	}
+
+	filter.From = fromDate
+	filter.To = toDate.AddDate(0, 0, 1)
+	filter.Timezone = timezone
+
+	return filter, location, nil
+}
+
+// fillBuckets lists every bucket of the period in order, a bucket without sales has zero metrics.
+// The keys are formatted like the database formats them.
+func fillBuckets(rows []entity.Row, group string, from, to time.Time, location *time.Location) []entity.Row {
+	byKey := map[string]entity.Row{}
+	for _, row := range rows {
+		byKey[row.Key] = row
+	}
+
+	start := from.In(location)
+	layout := time.DateOnly
+	next := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+
+	switch group {
+	case entity.GroupHour:
+		layout = "2006-01-02T15:00"
+		next = func(t time.Time) time.Time { return t.Add(time.Hour) }
+	case entity.GroupWeek:
+		// weeks start on monday
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case entity.GroupMonth:
+		layout = "2006-01"
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, location)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	}
+
+	filled := []entity.Row{}
+	for t := start; t.Before(to); t = next(t) {
+		key := t.Format(layout)
+
+		row, ok := byKey[key]
+		if !ok {
+			row = entity.Row{Key: key, Label: key}
+		}
+
+		filled = append(filled, row)
+	}
+
+	return filled
+}
+
+// change is how much an amount changed from the previous one in percent, nothing compares to zero.
+func change(current, previous int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+
+	percent := math.Round(float64(current-previous)/float64(previous)*10000) / 100
+
+	return &percent
+}
+
+func invalid(message string) *response.Error {
+	return &response.Error{
+		StatusCode: fiber.StatusBadRequest,
+		Message:    message,
+		Error:      errors.New(message),
+	}
+}
+
+func NewReportService(repository repository.ReportRepository) ReportService {
+	return &reportService{repository}
+}
@@ -0,0 +1,28 @@
+package sale
+
+import "github.com/google/uuid"
+
+type SaleItemRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// PaymentRequest is a tender of a sale. A cash tender without an amount pays what the other tenders leave,
+// the tendered cash defaults to the amount. Token identifies the card or wallet of other methods.
+type PaymentRequest struct {
+	Method   string `json:"method"`
+	Amount   int64  `json:"amount"`
+	Tendered int64  `json:"tendered"`
+	Token    string `json:"token"`
+}
+
+// CreateSaleRequest is recorded on the given shift, or on the open shift of the cashier in the store when it is empty.
+// Without payments the whole total is paid in cash.
+type CreateSaleRequest struct {
+	StoreID    uuid.UUID         `json:"store_id"`
+	CustomerID *uuid.UUID        `json:"customer_id"`
+	ShiftID    *uuid.UUID        `json:"shift_id"`
+	Note       string            `json:"note"`
+	Items      []SaleItemRequest `json:"items"`
+	Payments   []PaymentRequest  `json:"payments"`
+}
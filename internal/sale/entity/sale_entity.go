@@ -0,0 +1,117 @@
+package sale
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// statuses of a sale, pending and completed sales took their items out of the store stock
+const (
+	// StatusPending waits for a card or e-wallet payment the provider has not captured yet
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	// StatusCancelled is a pending sale that was called off, its items went back to the stock and its payments were voided
+	StatusCancelled = "cancelled"
+)
+
+// payment methods a sale is paid and refunded with
+const (
+	PaymentCash = "cash"
+	PaymentCard = "card"
+	PaymentQRIS = "qris"
+)
+
+func IsValidPaymentMethod(method string) bool {
+	return method == PaymentCash || method == PaymentCard || method == PaymentQRIS
+}
+
+// Sale is a checkout in a store, amounts are in the smallest currency unit.
+// A sale made while the cashier works a shift is recorded on it and its cash payments count towards the drawer.
+// The total is what the customer pays, with exclusive tax it is the discounted subtotal plus the tax.
+// Loyalty points are earned by a member customer, refunds reverse them in proportion to the refunded amount.
+// Cost is what the goods cost the store and margin is the total without tax over it, both nil when they are hidden.
+type Sale struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	StoreID        uuid.UUID       `json:"store_id" db:"store_id"`
+	CustomerID     *uuid.UUID      `json:"customer_id" db:"customer_id"`
+	CashierID      *uuid.UUID      `json:"cashier_id" db:"cashier_id"`
+	ShiftID        *uuid.UUID      `json:"shift_id" db:"shift_id"`
+	Status         string          `json:"status" db:"status"`
+	Subtotal       int64           `json:"subtotal" db:"subtotal"`
+	Discount       int64           `json:"discount" db:"discount"`
+	TaxMode        string          `json:"tax_mode" db:"tax_mode"`
+	TaxAmount      int64           `json:"tax_amount" db:"tax_amount"`
+	Total          int64           `json:"total" db:"total"`
+	LoyaltyPoints  int             `json:"loyalty_points" db:"loyalty_points"`
+	RefundedAmount int64           `json:"refunded_amount" db:"refunded_amount"`
+	ReversedPoints int             `json:"reversed_points" db:"reversed_points"`
+	Cost           *int64          `json:"cost" db:"cost"`
+	Margin         *int64          `json:"margin" db:"margin"`
+	Note           string          `json:"note" db:"note"`
+	SoldAt         time.Time       `json:"sold_at" db:"sold_at"`
+	CreatedAt      *time.Time      `json:"created_at" db:"created_at"`
+	Items          []SaleItem      `json:"items,omitempty" db:"-"`
+	Promotions     []SalePromotion `json:"promotions,omitempty" db:"-"`
+	Payments       []Payment       `json:"payments,omitempty" db:"-"`
+	Taxes          []SaleTax       `json:"taxes,omitempty" db:"-"`
+}
+
+// SaleItem is a line of a sale, sku and name are kept as they were when it was sold.
+// Its total includes the tax of the line whether the store adds the tax on top or not.
+// Unit cost is the average cost of the product in the store when it was sold.
+type SaleItem struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	SaleID           uuid.UUID  `json:"-" db:"sale_id"`
+	LineNo           int        `json:"line_no" db:"line_no"`
+	ProductID        uuid.UUID  `json:"product_id" db:"product_id"`
+	SKU              string     `json:"sku" db:"sku"`
+	Name             string     `json:"name" db:"name"`
+	Quantity         int        `json:"quantity" db:"quantity"`
+	ReturnedQuantity int        `json:"returned_quantity" db:"returned_quantity"`
+	UnitPrice        int64      `json:"unit_price" db:"unit_price"`
+	Subtotal         int64      `json:"subtotal" db:"subtotal"`
+	Discount         int64      `json:"discount" db:"discount"`
+	TaxID            *uuid.UUID `json:"tax_id" db:"tax_id"`
+	TaxRate          int        `json:"tax_rate" db:"tax_rate"`
+	TaxAmount        int64      `json:"tax_amount" db:"tax_amount"`
+	Total            int64      `json:"total" db:"total"`
+	UnitCost         *int64     `json:"unit_cost" db:"unit_cost"`
+	Cost             *int64     `json:"cost" db:"cost"`
+}
+
+// SaleTax is the tax of the lines of a sale under one tax, Taxable is their amount without the tax.
+type SaleTax struct {
+	SaleID        uuid.UUID `json:"-" db:"sale_id"`
+	TaxID         uuid.UUID `json:"tax_id" db:"tax_id"`
+	Code          string    `json:"code" db:"code"`
+	Name          string    `json:"name" db:"name"`
+	Rate          int       `json:"rate" db:"rate"`
+	TaxableAmount int64     `json:"taxable_amount" db:"taxable_amount"`
+	TaxAmount     int64     `json:"tax_amount" db:"tax_amount"`
+}
+
+type SalePromotion struct {
+	SaleID      uuid.UUID `json:"-" db:"sale_id"`
+	PromotionID uuid.UUID `json:"promotion_id" db:"promotion_id"`
+	Name        string    `json:"name" db:"name"`
+	Discount    int64     `json:"discount" db:"discount"`
+}
+
+// Payment is one tender of a sale. Only cash has a tendered amount above the amount and gives change,
+// card and e-wallet payments go through the provider under the reference. Token is only passed on to the provider.
+type Payment struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	SaleID         uuid.UUID  `json:"-" db:"sale_id"`
+	Method         string     `json:"method" db:"method"`
+	Status         string     `json:"status" db:"status"`
+	Amount         int64      `json:"amount" db:"amount"`
+	Tendered       int64      `json:"tendered" db:"tendered"`
+	Change         int64      `json:"change" db:"change_amount"`
+	RefundedAmount int64      `json:"refunded_amount" db:"refunded_amount"`
+	Provider       string     `json:"provider" db:"provider"`
+	Reference      string     `json:"reference" db:"reference"`
+	CreatedAt      *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at" db:"updated_at"`
+	Token          string     `json:"-" db:"-"`
+}
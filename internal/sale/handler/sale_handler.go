@@ -0,0 +1,250 @@
+package sale
+
+import (
+	dto "candyshop/internal/sale/dto"
+	service "candyshop/internal/sale/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SaleHandler struct {
+	service service.SaleService
+}
+
+func NewSaleHandler(service service.SaleService) *SaleHandler {
+	return &SaleHandler{service}
+}
+
+func (h *SaleHandler) GetAllSale(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	var storeID *uuid.UUID
+	if c.Query("store_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("store_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "store_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		storeID = &parseID
+	}
+
+	sales, errSale := h.service.GetAllSale(auth.GetCaller(c), storeID, offset, limit)
+	if errSale != nil {
+		return c.Status(errSale.StatusCode).JSON(fiber.Map{
+			"status_code": errSale.StatusCode,
+			"message":     "failed to fetch sales",
+			"error":       errSale.Error.Error(),
+		})
+	}
+
+	if len(sales) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch sales",
+			"error":       "sale not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data sales",
+		"data":        sales,
+	})
+}
+
+func (h *SaleHandler) GetSaleByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	sale, errSale := h.service.GetSaleByID(auth.GetCaller(c), parseID)
+	if errSale != nil {
+		return c.Status(errSale.StatusCode).JSON(fiber.Map{
+			"status_code": errSale.StatusCode,
+			"message":     "failed to fetch sale",
+			"error":       errSale.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data sale",
+		"data":        sale,
+	})
+}
+
+func (h *SaleHandler) CreateSale(c *fiber.Ctx) error {
+	var req dto.CreateSaleRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data sale",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is required",
+			"error":       nil,
+		})
+	}
+
+	sale, errSale := h.service.CreateSale(auth.GetCaller(c), req)
+	if errSale != nil {
+		if errSale.StatusCode == fiber.StatusConflict {
+			return c.Status(errSale.StatusCode).JSON(fiber.Map{
+				"status_code": errSale.StatusCode,
+				"message":     "failed to create sale",
+				"error":       errSale.Message,
+			})
+		}
+
+		return c.Status(errSale.StatusCode).JSON(fiber.Map{
+			"status_code": errSale.StatusCode,
+			"message":     "failed to create sale",
+			"error":       errSale.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data sale",
+		"data":        sale,
+	})
+}
+
+func (h *SaleHandler) AddPayment(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.PaymentRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data payment",
+			"error":       err.Error(),
+		})
+	}
+
+	sale, errSale := h.service.AddPayment(auth.GetCaller(c), parseID, req)
+	if errSale != nil {
+		if errSale.StatusCode == fiber.StatusConflict {
+			return c.Status(errSale.StatusCode).JSON(fiber.Map{
+				"status_code": errSale.StatusCode,
+				"message":     "failed to add payment",
+				"error":       errSale.Message,
+			})
+		}
+
+		return c.Status(errSale.StatusCode).JSON(fiber.Map{
+			"status_code": errSale.StatusCode,
+			"message":     "failed to add payment",
+			"error":       errSale.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success add payment",
+		"data":        sale,
+	})
+}
+
+func (h *SaleHandler) RefreshPayment(c *fiber.Ctx) error {
+	saleID, errParse := uuid.Parse(c.Params("id"))
+	paymentID, errParsePayment := uuid.Parse(c.Params("payment_id"))
+	if errParse != nil || errParsePayment != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       "id is invalid",
+		})
+	}
+
+	sale, errSale := h.service.RefreshPayment(auth.GetCaller(c), saleID, paymentID)
+	if errSale != nil {
+		if errSale.StatusCode == fiber.StatusConflict {
+			return c.Status(errSale.StatusCode).JSON(fiber.Map{
+				"status_code": errSale.StatusCode,
+				"message":     "failed to refresh payment",
+				"error":       errSale.Message,
+			})
+		}
+
+		return c.Status(errSale.StatusCode).JSON(fiber.Map{
+			"status_code": errSale.StatusCode,
+			"message":     "failed to refresh payment",
+			"error":       errSale.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success refresh payment",
+		"data":        sale,
+	})
+}
+
+func (h *SaleHandler) CancelSale(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	sale, errSale := h.service.CancelSale(auth.GetCaller(c), parseID)
+	if errSale != nil {
+		if errSale.StatusCode == fiber.StatusConflict {
+			return c.Status(errSale.StatusCode).JSON(fiber.Map{
+				"status_code": errSale.StatusCode,
+				"message":     "failed to cancel sale",
+				"error":       errSale.Message,
+			})
+		}
+
+		return c.Status(errSale.StatusCode).JSON(fiber.Map{
+			"status_code": errSale.StatusCode,
+			"message":     "failed to cancel sale",
+			"error":       errSale.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success cancel sale",
+		"data":        sale,
+	})
+}
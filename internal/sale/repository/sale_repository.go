@@ -0,0 +1,699 @@
+package sale
+
+import (
+	entity "candyshop/internal/sale/entity"
+	shiftRepository "candyshop/internal/shift/repository"
+	"candyshop/pkg/event"
+	"candyshop/pkg/ledger"
+	"candyshop/pkg/payment"
+	"candyshop/pkg/response"
+	"candyshop/pkg/summary"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type SaleRepository interface {
+	GetAllSale(storeIDs []uuid.UUID, offset, limit int) ([]entity.Sale, *response.Error)
+	GetSaleByID(id uuid.UUID) (*entity.Sale, *response.Error)
+	CreateSale(data entity.Sale) (*entity.Sale, *response.Error)
+	AddPayment(data entity.Payment) *response.Error
+	UpdatePayment(data entity.Payment, previousStatus string) *response.Error
+	CancelSale(id uuid.UUID, cancelledBy *uuid.UUID, cancelledAt time.Time) *response.Error
+}
+
+type saleRepository struct {
+	db *sqlx.DB
+}
+
+const paymentColumns = `id, sale_id, method, status, amount, tendered, change_amount, refunded_amount, provider, reference, created_at, updated_at`
+
+const saleColumns = `id, store_id, customer_id, cashier_id, shift_id, status, subtotal, discount, tax_mode, tax_amount, total, loyalty_points, refunded_amount, reversed_points, note, sold_at, created_at,
+	cost, total - tax_amount - cost AS margin`
+
+// GetAllSale implements SaleRepository.
+func (s *saleRepository) GetAllSale(storeIDs []uuid.UUID, offset int, limit int) ([]entity.Sale, *response.Error) {
+	var sales []entity.Sale
+
+	// nil store ids means the caller is not limited to some stores
+	query := `
+		SELECT ` + saleColumns + `
+		FROM sales
+		WHERE ($3::uuid[] IS NULL OR store_id = ANY($3))
+		ORDER BY sold_at DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := s.db.Select(&sales, query, limit, offset, pq.Array(storeIDs))
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get all sale").Msg("failed to get all sale")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch sales",
+			Error:      err,
+		}
+	}
+
+	return sales, nil
+}
+
+// GetSaleByID implements SaleRepository.
+func (s *saleRepository) GetSaleByID(id uuid.UUID) (*entity.Sale, *response.Error) {
+	var sale entity.Sale
+
+	err := s.db.Get(&sale, `SELECT `+saleColumns+` FROM sales WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch sale",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get sale by id").Msg("failed to get sale by id")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch sale",
+			Error:      err,
+		}
+	}
+
+	errItems := s.db.Select(&sale.Items, `
+		SELECT id, sale_id, line_no, product_id, sku, name, quantity, returned_quantity, unit_price, subtotal, discount,
+			tax_id, tax_rate, tax_amount, total, unit_cost, cost
+		FROM sale_items
+		WHERE sale_id = $1
+		ORDER BY line_no
+	`, id)
+	if errItems != nil {
+		return nil, saleError("get sale items", errItems)
+	}
+
+	errPromotions := s.db.Select(&sale.Promotions, `
+		SELECT sale_id, promotion_id, name, discount
+		FROM sale_promotions
+		WHERE sale_id = $1
+		ORDER BY name
+	`, id)
+	if errPromotions != nil {
+		return nil, saleError("get sale promotions", errPromotions)
+	}
+
+	errTaxes := s.db.Select(&sale.Taxes, `
+		SELECT sale_id, tax_id, code, name, rate, taxable_amount, tax_amount
+		FROM sale_taxes
+		WHERE sale_id = $1
+		ORDER BY code
+	`, id)
+	if errTaxes != nil {
+		return nil, saleError("get sale taxes", errTaxes)
+	}
+
+	errPayments := s.db.Select(&sale.Payments, `SELECT `+paymentColumns+` FROM sale_payments WHERE sale_id = $1 ORDER BY created_at, id`, id)
+	if errPayments != nil {
+		return nil, saleError("get sale payments", errPayments)
+	}
+
+	return &sale, nil
+}
+
+// CreateSale implements SaleRepository.
+func (s *saleRepository) CreateSale(data entity.Sale) (*entity.Sale, *response.Error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create sale").Msg("failed to create sale")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	var cashierID uuid.UUID
+	if data.CashierID != nil {
+		cashierID = *data.CashierID
+	}
+
+	// the shift stays open until the sale is committed
+	shiftID, errShift := shiftRepository.LockOpenShift(tx, data.StoreID, data.ShiftID, cashierID)
+	if errShift != nil {
+		return nil, errShift
+	}
+
+	data.ShiftID = shiftID
+
+	query := `
+		INSERT INTO sales (id, store_id, customer_id, cashier_id, shift_id, status, subtotal, discount, tax_mode, tax_amount, total, loyalty_points, note, sold_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING ` + saleColumns
+
+	model := entity.Sale{Items: data.Items, Promotions: data.Promotions, Payments: data.Payments, Taxes: data.Taxes}
+
+	errInsert := tx.QueryRowx(query,
+		data.ID,
+		data.StoreID,
+		data.CustomerID,
+		data.CashierID,
+		data.ShiftID,
+		data.Status,
+		data.Subtotal,
+		data.Discount,
+		data.TaxMode,
+		data.TaxAmount,
+		data.Total,
+		data.LoyaltyPoints,
+		data.Note,
+		data.SoldAt).StructScan(&model)
+
+	if errInsert != nil {
+		return nil, saleError("create sale", errInsert)
+	}
+
+	for _, item := range data.Items {
+		_, errItem := tx.Exec(`
+			INSERT INTO sale_items (id, sale_id, line_no, product_id, sku, name, quantity, unit_price, subtotal, discount, tax_id, tax_rate, tax_amount, total)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, item.ID, data.ID, item.LineNo, item.ProductID, item.SKU, item.Name, item.Quantity, item.UnitPrice, item.Subtotal, item.Discount,
+			item.TaxID, item.TaxRate, item.TaxAmount, item.Total)
+		if errItem != nil {
+			return nil, saleError("create sale item", errItem)
+		}
+	}
+
+	for _, promotion := range data.Promotions {
+		_, errPromotion := tx.Exec(`INSERT INTO sale_promotions (sale_id, promotion_id, name, discount) VALUES ($1, $2, $3, $4)`,
+			data.ID, promotion.PromotionID, promotion.Name, promotion.Discount)
+		if errPromotion != nil {
+			return nil, saleError("create sale promotion", errPromotion)
+		}
+	}
+
+	for _, saleTax := range data.Taxes {
+		_, errTax := tx.Exec(`
+			INSERT INTO sale_taxes (sale_id, tax_id, code, name, rate, taxable_amount, tax_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, data.ID, saleTax.TaxID, saleTax.Code, saleTax.Name, saleTax.Rate, saleTax.TaxableAmount, saleTax.TaxAmount)
+		if errTax != nil {
+			return nil, saleError("create sale tax", errTax)
+		}
+	}
+
+	for _, salePayment := range data.Payments {
+		if errPayment := insertPayment(tx, salePayment); errPayment != nil {
+			return nil, errPayment
+		}
+	}
+
+	if errStock := takeStock(tx, data); errStock != nil {
+		return nil, errStock
+	}
+
+	if errCost := stampCost(tx, &model); errCost != nil {
+		return nil, errCost
+	}
+
+	if errQueue := summary.Queue(tx, data.ID); errQueue != nil {
+		return nil, saleError("queue sales summary", errQueue)
+	}
+
+	// a sale waiting for its payments completes later in completeSale
+	if model.Status == entity.StatusCompleted {
+		if errPoints := earnPoints(tx, data.ID); errPoints != nil {
+			return nil, errPoints
+		}
+
+		if errEvent := emitSaleCompleted(tx, data.ID); errEvent != nil {
+			return nil, errEvent
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create sale").Msg("failed to create sale")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// AddPayment implements SaleRepository.
+func (s *saleRepository) AddPayment(data entity.Payment) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "add payment").Msg("failed to add payment")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	sale, errSale := lockSale(tx, data.SaleID)
+	if errSale != nil {
+		return errSale
+	}
+
+	if sale.Status != entity.StatusPending {
+		return &response.Error{
+			StatusCode: 409,
+			Message:    fmt.Sprintf("sale is %s", sale.Status),
+			Error:      nil,
+		}
+	}
+
+	var covered int64
+	errCovered := tx.Get(&covered, `SELECT COALESCE(SUM(amount), 0) FROM sale_payments WHERE sale_id = $1 AND status IN ($2, $3)`,
+		data.SaleID, payment.StatusPending, payment.StatusCaptured)
+	if errCovered != nil {
+		return saleError("get sale paid amount", errCovered)
+	}
+
+	if covered+data.Amount > sale.Total {
+		return &response.Error{
+			StatusCode: 409,
+			Message:    fmt.Sprintf("payment is more than the %d left to pay", sale.Total-covered),
+			Error:      nil,
+		}
+	}
+
+	// cash goes into the drawer of the shift the sale was made on
+	if data.Method == entity.PaymentCash && sale.ShiftID != nil {
+		if _, errShift := shiftRepository.LockOpenShift(tx, sale.StoreID, sale.ShiftID, uuid.Nil); errShift != nil {
+			return errShift
+		}
+	}
+
+	if errPayment := insertPayment(tx, data); errPayment != nil {
+		return errPayment
+	}
+
+	if errComplete := completeSale(tx, data.SaleID); errComplete != nil {
+		return errComplete
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "add payment").Msg("failed to add payment")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// UpdatePayment implements SaleRepository.
+func (s *saleRepository) UpdatePayment(data entity.Payment, previousStatus string) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update payment").Msg("failed to update payment")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	if _, errSale := lockSale(tx, data.SaleID); errSale != nil {
+		return errSale
+	}
+
+	result, errUpdate := tx.Exec(`
+		UPDATE sale_payments SET status = $3, updated_at = $4
+		WHERE id = $1 AND sale_id = $2 AND status = $5
+	`, data.ID, data.SaleID, data.Status, data.UpdatedAt, previousStatus)
+	if errUpdate != nil {
+		return saleError("update payment", errUpdate)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &response.Error{
+			StatusCode: 409,
+			Message:    "payment was changed, try again",
+			Error:      nil,
+		}
+	}
+
+	if errComplete := completeSale(tx, data.SaleID); errComplete != nil {
+		return errComplete
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update payment").Msg("failed to update payment")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// CancelSale implements SaleRepository.
+func (s *saleRepository) CancelSale(id uuid.UUID, cancelledBy *uuid.UUID, cancelledAt time.Time) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "cancel sale").Msg("failed to cancel sale")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	sale, errSale := lockSale(tx, id)
+	if errSale != nil {
+		return errSale
+	}
+
+	if sale.Status != entity.StatusPending {
+		return &response.Error{
+			StatusCode: 409,
+			Message:    fmt.Sprintf("sale is %s", sale.Status),
+			Error:      nil,
+		}
+	}
+
+	errItems := tx.Select(&sale.Items, `SELECT id, product_id, sku, quantity FROM sale_items WHERE sale_id = $1`, id)
+	if errItems != nil {
+		return saleError("get sale items", errItems)
+	}
+
+	// rows are updated in product order like takeStock so a cancel and a sale of the same products can't deadlock
+	slices.SortFunc(sale.Items, func(a, b entity.SaleItem) int { return strings.Compare(a.ProductID.String(), b.ProductID.String()) })
+
+	for _, item := range sale.Items {
+		errMove := ledger.Move(tx, ledger.Movement{
+			StoreID:       sale.StoreID,
+			ProductID:     item.ProductID,
+			Type:          ledger.TypeCancel,
+			Quantity:      item.Quantity,
+			ReferenceType: ledger.ReferenceSale,
+			ReferenceID:   &sale.ID,
+			CreatedBy:     cancelledBy,
+			MovedAt:       cancelledAt,
+		})
+		if errMove != nil {
+			return saleError("put back stock", errMove)
+		}
+	}
+
+	// the cash goes back to the customer and the charges are voided at the provider after the commit,
+	// a voided card or e-wallet payment is refunded and cash that left the drawer again has failed
+	_, errPayments := tx.Exec(`
+		UPDATE sale_payments SET status = CASE WHEN method = $2 THEN $3 ELSE $4 END, updated_at = $5
+		WHERE sale_id = $1 AND status IN ($6, $7)
+	`, id, entity.PaymentCash, payment.StatusFailed, payment.StatusRefunded, cancelledAt, payment.StatusPending, payment.StatusCaptured)
+	if errPayments != nil {
+		return saleError("void sale payments", errPayments)
+	}
+
+	_, errCancel := tx.Exec(`UPDATE sales SET status = $2 WHERE id = $1`, id, entity.StatusCancelled)
+	if errCancel != nil {
+		return saleError("cancel sale", errCancel)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "cancel sale").Msg("failed to cancel sale")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// lockSale keeps payments of a sale from being added or changed at the same time.
+func lockSale(tx *sqlx.Tx, id uuid.UUID) (*entity.Sale, *response.Error) {
+	var sale entity.Sale
+
+	err := tx.Get(&sale, `SELECT `+saleColumns+` FROM sales WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "sale not found",
+				Error:      err,
+			}
+		}
+
+		return nil, saleError("lock sale", err)
+	}
+
+	return &sale, nil
+}
+
+func insertPayment(tx *sqlx.Tx, data entity.Payment) *response.Error {
+	_, err := tx.Exec(`
+		INSERT INTO sale_payments (id, sale_id, method, status, amount, tendered, change_amount, provider, reference)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, data.ID, data.SaleID, data.Method, data.Status, data.Amount, data.Tendered, data.Change, data.Provider, data.Reference)
+	if err != nil {
+		return saleError("create sale payment", err)
+	}
+
+	return nil
+}
+
+// completeSale completes a pending sale once its captured payments cover the total.
+// A completed sale counts in the sales summary of its day from then on.
+func completeSale(tx *sqlx.Tx, id uuid.UUID) *response.Error {
+	result, err := tx.Exec(`
+		UPDATE sales SET status = $2
+		WHERE id = $1 AND status = $3
+			AND total = (SELECT COALESCE(SUM(amount), 0) FROM sale_payments WHERE sale_id = $1 AND status = $4)
+	`, id, entity.StatusCompleted, entity.StatusPending, payment.StatusCaptured)
+	if err != nil {
+		return saleError("complete sale", err)
+	}
+
+	if completed, _ := result.RowsAffected(); completed > 0 {
+		if errQueue := summary.Queue(tx, id); errQueue != nil {
+			return saleError("queue sales summary", errQueue)
+		}
+
+		if errPoints := earnPoints(tx, id); errPoints != nil {
+			return errPoints
+		}
+
+		if errEvent := emitSaleCompleted(tx, id); errEvent != nil {
+			return errEvent
+		}
+	}
+
+	return nil
+}
+
+// earnPoints credits the loyalty points of a sale to its customer once the sale completes.
+func earnPoints(tx *sqlx.Tx, id uuid.UUID) *response.Error {
+	_, err := tx.Exec(`
+		UPDATE customers c SET loyalty_points = c.loyalty_points + s.loyalty_points
+		FROM sales s
+		WHERE s.id = $1 AND c.id = s.customer_id AND s.loyalty_points > 0
+	`, id)
+	if err != nil {
+		return saleError("earn loyalty points", err)
+	}
+
+	return nil
+}
+
+// completedSale is the sale.completed payload, subscribers outside the store never see what the goods cost.
+type completedSale struct {
+	ID             uuid.UUID           `json:"id" db:"id"`
+	StoreID        uuid.UUID           `json:"store_id" db:"store_id"`
+	CustomerID     *uuid.UUID          `json:"customer_id" db:"customer_id"`
+	CashierID      *uuid.UUID          `json:"cashier_id" db:"cashier_id"`
+	ShiftID        *uuid.UUID          `json:"shift_id" db:"shift_id"`
+	Status         string              `json:"status" db:"status"`
+	Subtotal       int64               `json:"subtotal" db:"subtotal"`
+	Discount       int64               `json:"discount" db:"discount"`
+	TaxMode        string              `json:"tax_mode" db:"tax_mode"`
+	TaxAmount      int64               `json:"tax_amount" db:"tax_amount"`
+	Total          int64               `json:"total" db:"total"`
+	LoyaltyPoints  int                 `json:"loyalty_points" db:"loyalty_points"`
+	RefundedAmount int64               `json:"refunded_amount" db:"refunded_amount"`
+	ReversedPoints int                 `json:"reversed_points" db:"reversed_points"`
+	Note           string              `json:"note" db:"note"`
+	SoldAt         time.Time           `json:"sold_at" db:"sold_at"`
+	CreatedAt      *time.Time          `json:"created_at" db:"created_at"`
+	Items          []completedSaleItem `json:"items" db:"-"`
+	Taxes          []entity.SaleTax    `json:"taxes" db:"-"`
+	Payments       []entity.Payment    `json:"payments" db:"-"`
+}
+
+type completedSaleItem struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	LineNo           int        `json:"line_no" db:"line_no"`
+	ProductID        uuid.UUID  `json:"product_id" db:"product_id"`
+	SKU              string     `json:"sku" db:"sku"`
+	Name             string     `json:"name" db:"name"`
+	Quantity         int        `json:"quantity" db:"quantity"`
+	ReturnedQuantity int        `json:"returned_quantity" db:"returned_quantity"`
+	UnitPrice        int64      `json:"unit_price" db:"unit_price"`
+	Subtotal         int64      `json:"subtotal" db:"subtotal"`
+	Discount         int64      `json:"discount" db:"discount"`
+	TaxID            *uuid.UUID `json:"tax_id" db:"tax_id"`
+	TaxRate          int        `json:"tax_rate" db:"tax_rate"`
+	TaxAmount        int64      `json:"tax_amount" db:"tax_amount"`
+	Total            int64      `json:"total" db:"total"`
+}
+
+// emitSaleCompleted emits the completed sale with its items, taxes and payments as stored in the transaction.
+func emitSaleCompleted(tx *sqlx.Tx, id uuid.UUID) *response.Error {
+	var sale completedSale
+
+	err := tx.Get(&sale, `
+		SELECT id, store_id, customer_id, cashier_id, shift_id, status, subtotal, discount, tax_mode, tax_amount, total,
+			loyalty_points, refunded_amount, reversed_points, note, sold_at, created_at
+		FROM sales
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return saleError("emit sale completed", err)
+	}
+
+	errItems := tx.Select(&sale.Items, `
+		SELECT id, line_no, product_id, sku, name, quantity, returned_quantity, unit_price, subtotal, discount,
+			tax_id, tax_rate, tax_amount, total
+		FROM sale_items
+		WHERE sale_id = $1
+		ORDER BY line_no
+	`, id)
+	if errItems != nil {
+		return saleError("emit sale completed", errItems)
+	}
+
+	errTaxes := tx.Select(&sale.Taxes, `
+		SELECT sale_id, tax_id, code, name, rate, taxable_amount, tax_amount
+		FROM sale_taxes
+		WHERE sale_id = $1
+		ORDER BY code
+	`, id)
+	if errTaxes != nil {
+		return saleError("emit sale completed", errTaxes)
+	}
+
+	errPayments := tx.Select(&sale.Payments, `SELECT `+paymentColumns+` FROM sale_payments WHERE sale_id = $1 ORDER BY created_at, id`, id)
+	if errPayments != nil {
+		return saleError("emit sale completed", errPayments)
+	}
+
+	if err := event.Emit(tx, event.SaleCompleted, id, sale); err != nil {
+		return saleError("emit sale completed", err)
+	}
+
+	return nil
+}
+
+// takeStock removes the sold quantities from the store, the whole sale fails when one product is short.
+// Rows are updated in product order so two sales of the same products can't deadlock.
+func takeStock(tx *sqlx.Tx, sale entity.Sale) *response.Error {
+	items := slices.Clone(sale.Items)
+	slices.SortFunc(items, func(a, b entity.SaleItem) int { return strings.Compare(a.ProductID.String(), b.ProductID.String()) })
+
+	for _, item := range items {
+		errMove := ledger.Move(tx, ledger.Movement{
+			StoreID:       sale.StoreID,
+			ProductID:     item.ProductID,
+			Type:          ledger.TypeSale,
+			Quantity:      -item.Quantity,
+			ReferenceType: ledger.ReferenceSale,
+			ReferenceID:   &sale.ID,
+			CreatedBy:     sale.CashierID,
+			MovedAt:       sale.SoldAt,
+		})
+		if errors.Is(errMove, ledger.ErrInsufficientStock) {
+			return &response.Error{
+				StatusCode: 409,
+				Message:    fmt.Sprintf("insufficient stock of product %s", item.SKU),
+				Error:      nil,
+			}
+		}
+
+		if errMove != nil {
+			return saleError("take stock", errMove)
+		}
+	}
+
+	return nil
+}
+
+// stampCost prices the goods of a sale at the average cost of the store once their stock is taken.
+func stampCost(tx *sqlx.Tx, sale *entity.Sale) *response.Error {
+	var costs []entity.SaleItem
+
+	err := tx.Select(&costs, `
+		UPDATE sale_items i SET unit_cost = inv.average_cost, cost = inv.average_cost * i.quantity
+		FROM inventories inv
+		WHERE i.sale_id = $1 AND inv.store_id = $2 AND inv.product_id = i.product_id
+		RETURNING i.id, i.unit_cost, i.cost
+	`, sale.ID, sale.StoreID)
+	if err != nil {
+		return saleError("stamp sale cost", err)
+	}
+
+	byID := map[uuid.UUID]entity.SaleItem{}
+	for _, cost := range costs {
+		byID[cost.ID] = cost
+	}
+
+	var total int64
+	for i := range sale.Items {
+		item := byID[sale.Items[i].ID]
+		sale.Items[i].UnitCost = item.UnitCost
+		sale.Items[i].Cost = item.Cost
+
+		if item.Cost != nil {
+			total += *item.Cost
+		}
+	}
+
+	_, errSale := tx.Exec(`UPDATE sales SET cost = $2 WHERE id = $1`, sale.ID, total)
+	if errSale != nil {
+		return saleError("stamp sale cost", errSale)
+	}
+
+	margin := sale.Total - sale.TaxAmount - total
+	sale.Cost = &total
+	sale.Margin = &margin
+
+	return nil
+}
+
+func saleError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewSaleRepository(db *sqlx.DB) SaleRepository {
+	return &saleRepository{db}
+}
@@ -0,0 +1,34 @@
+package sale
+
+import (
+	promotionRepository "candyshop/internal/promotion/repository"
+	promotionService "candyshop/internal/promotion/service"
+	handler "candyshop/internal/sale/handler"
+	repository "candyshop/internal/sale/repository"
+	service "candyshop/internal/sale/service"
+	staff "candyshop/internal/staff"
+	taxRepository "candyshop/internal/tax/repository"
+	taxService "candyshop/internal/tax/service"
+	"candyshop/pkg/payment"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB, payments payment.Provider) {
+	promotion := promotionService.NewPromotionService(promotionRepository.NewPromotionRepository(db))
+	tax := taxService.NewTaxService(taxRepository.NewTaxRepository(db))
+
+	repo := repository.NewSaleRepository(db)
+	service := service.NewSaleService(repo, promotion, tax, payments)
+	handler := handler.NewSaleHandler(service)
+
+	saleRoute := router.Group("api/v1/sales", staff.Authenticate(db))
+
+	saleRoute.Get("", handler.GetAllSale)
+	saleRoute.Post("", handler.CreateSale)
+	saleRoute.Get("/:id", handler.GetSaleByID)
+	saleRoute.Post("/:id/payments", handler.AddPayment)
+	saleRoute.Patch("/:id/payments/:payment_id/refresh", handler.RefreshPayment)
+	saleRoute.Post("/:id/cancel", handler.CancelSale)
+}
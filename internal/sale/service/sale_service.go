@@ -0,0 +1,510 @@
+package sale
+
+import (
+	promotionDto "candyshop/internal/promotion/dto"
+	promotion "candyshop/internal/promotion/service"
+	dto "candyshop/internal/sale/dto"
+	entity "candyshop/internal/sale/entity"
+	repository "candyshop/internal/sale/repository"
+	taxEntity "candyshop/internal/tax/entity"
+	taxService "candyshop/internal/tax/service"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/payment"
+	"candyshop/pkg/response"
+	"candyshop/pkg/tax"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// LoyaltyPointAmount is the part of the sale total a member earns one loyalty point for
+const LoyaltyPointAmount = 10000
+
+type SaleService interface {
+	GetAllSale(caller *auth.Caller, storeID *uuid.UUID, offset, limit int) ([]entity.Sale, *response.Error)
+	GetSaleByID(caller *auth.Caller, id uuid.UUID) (*entity.Sale, *response.Error)
+	CreateSale(caller *auth.Caller, data dto.CreateSaleRequest) (*entity.Sale, *response.Error)
+	AddPayment(caller *auth.Caller, saleID uuid.UUID, data dto.PaymentRequest) (*entity.Sale, *response.Error)
+	RefreshPayment(caller *auth.Caller, saleID, paymentID uuid.UUID) (*entity.Sale, *response.Error)
+	CancelSale(caller *auth.Caller, id uuid.UUID) (*entity.Sale, *response.Error)
+}
+
+type saleService struct {
+	repository repository.SaleRepository
+	promotion  promotion.PromotionService
+	tax        taxService.TaxService
+	payments   payment.Provider
+}
+
+// GetAllSale implements SaleService.
+func (s *saleService) GetAllSale(caller *auth.Caller, storeID *uuid.UUID, offset int, limit int) ([]entity.Sale, *response.Error) {
+	storeIDs := caller.StoreScope()
+	if storeID != nil {
+		if !caller.CanAccessStore(*storeID) {
+			return nil, auth.ForbiddenStore(*storeID)
+		}
+
+		storeIDs = []uuid.UUID{*storeID}
+	}
+
+	sales, errSale := s.repository.GetAllSale(storeIDs, offset, limit)
+	if errSale != nil {
+		return nil, errSale
+	}
+
+	for i := range sales {
+		hideCost(caller, &sales[i])
+	}
+
+	return sales, nil
+}
+
+// GetSaleByID implements SaleService.
+func (s *saleService) GetSaleByID(caller *auth.Caller, id uuid.UUID) (*entity.Sale, *response.Error) {
+	sale, errSale := s.repository.GetSaleByID(id)
+	if errSale != nil {
+		return nil, errSale
+	}
+
+	if !caller.CanAccessStore(sale.StoreID) {
+		return nil, auth.ForbiddenStore(sale.StoreID)
+	}
+
+	hideCost(caller, sale)
+
+	return sale, nil
+}
+
+// CreateSale implements SaleService.
+func (s *saleService) CreateSale(caller *auth.Caller, data dto.CreateSaleRequest) (*entity.Sale, *response.Error) {
+	items := make([]promotionDto.CartItemRequest, len(data.Items))
+	for i, item := range data.Items {
+		items[i] = promotionDto.CartItemRequest{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	// the cart is priced the same way the register previews it, store access is checked there too
+	evaluation, errEvaluate := s.promotion.EvaluateCart(caller, promotionDto.EvaluateCartRequest{
+		StoreID:    data.StoreID,
+		CustomerID: data.CustomerID,
+		Items:      items,
+	})
+	if errEvaluate != nil {
+		return nil, errEvaluate
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataSale := &entity.Sale{
+		ID:         newUUID,
+		StoreID:    data.StoreID,
+		CustomerID: data.CustomerID,
+		CashierID:  &caller.UserID,
+		ShiftID:    data.ShiftID,
+		Status:     entity.StatusCompleted,
+		Subtotal:   evaluation.Subtotal,
+		Discount:   evaluation.Discount,
+		Total:      evaluation.Total,
+		Note:       strings.TrimSpace(data.Note),
+		SoldAt:     evaluation.EvaluatedAt,
+	}
+
+	for i, line := range evaluation.Lines {
+		itemUUID, _ := uuid.NewV7()
+
+		dataSale.Items = append(dataSale.Items, entity.SaleItem{
+			ID:        itemUUID,
+			SaleID:    newUUID,
+			LineNo:    i + 1,
+			ProductID: line.ProductID,
+			SKU:       line.SKU,
+			Name:      line.Name,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+			Subtotal:  line.Subtotal,
+			Discount:  line.Discount,
+			Total:     line.Total,
+		})
+	}
+
+	if errTax := s.addTax(dataSale); errTax != nil {
+		return nil, errTax
+	}
+
+	if evaluation.IsMember {
+		dataSale.LoyaltyPoints = int(dataSale.Total / LoyaltyPointAmount)
+	}
+
+	for _, applied := range evaluation.Promotions {
+		dataSale.Promotions = append(dataSale.Promotions, entity.SalePromotion{
+			SaleID:      newUUID,
+			PromotionID: applied.PromotionID,
+			Name:        applied.Name,
+			Discount:    applied.Discount,
+		})
+	}
+
+	payments, errPayment := buildPayments(data.Payments, newUUID, dataSale.Total)
+	if errPayment != nil {
+		return nil, errPayment
+	}
+
+	for i := range payments {
+		if payments[i].Method == entity.PaymentCash {
+			continue
+		}
+
+		if errCharge := s.charge(&payments[i]); errCharge != nil {
+			s.voidPayments(payments[:i])
+			return nil, errCharge
+		}
+
+		// the goods are taken now, the sale completes when the provider captures the payment
+		if payments[i].Status == payment.StatusPending {
+			dataSale.Status = entity.StatusPending
+		}
+	}
+
+	dataSale.Payments = payments
+
+	sale, errCreate := s.repository.CreateSale(*dataSale)
+	if errCreate != nil {
+		s.voidPayments(payments)
+		return nil, errCreate
+	}
+
+	hideCost(caller, sale)
+
+	return sale, nil
+}
+
+// addTax prices the tax of the sale lines as the store is set up on the day of the sale.
+// Exclusive tax is added to the lines and the total, inclusive tax is already part of them.
+func (s *saleService) addTax(sale *entity.Sale) *response.Error {
+	lines := make([]taxEntity.QuoteLine, len(sale.Items))
+	for i, item := range sale.Items {
+		lines[i] = taxEntity.QuoteLine{ProductID: item.ProductID, Net: item.Total}
+	}
+
+	quote, errQuote := s.tax.Quote(sale.StoreID, sale.SoldAt, lines)
+	if errQuote != nil {
+		return errQuote
+	}
+
+	sale.TaxMode = quote.Mode
+	sale.TaxAmount = quote.Tax
+
+	for i, line := range quote.Lines {
+		sale.Items[i].TaxID = line.TaxID
+		sale.Items[i].TaxRate = line.Rate
+		sale.Items[i].TaxAmount = line.Tax
+
+		if quote.Mode == tax.ModeExclusive {
+			sale.Items[i].Total += line.Tax
+		}
+	}
+
+	if quote.Mode == tax.ModeExclusive {
+		sale.Total += quote.Tax
+	}
+
+	for _, quoteTax := range quote.Taxes {
+		sale.Taxes = append(sale.Taxes, entity.SaleTax{
+			SaleID:        sale.ID,
+			TaxID:         quoteTax.TaxID,
+			Code:          quoteTax.Code,
+			Name:          quoteTax.Name,
+			Rate:          quoteTax.Rate,
+			TaxableAmount: quoteTax.Taxable,
+			TaxAmount:     quoteTax.Tax,
+		})
+	}
+
+	return nil
+}
+
+// AddPayment implements SaleService.
+func (s *saleService) AddPayment(caller *auth.Caller, saleID uuid.UUID, data dto.PaymentRequest) (*entity.Sale, *response.Error) {
+	sale, errSale := s.GetSaleByID(caller, saleID)
+	if errSale != nil {
+		return nil, errSale
+	}
+
+	if sale.Status != entity.StatusPending {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("sale is %s", sale.Status),
+			Error:      nil,
+		}
+	}
+
+	// a payment replaces one that failed, it pays what the other payments leave
+	outstanding := sale.Total
+	for _, salePayment := range sale.Payments {
+		if salePayment.Status == payment.StatusPending || salePayment.Status == payment.StatusCaptured {
+			outstanding -= salePayment.Amount
+		}
+	}
+
+	payments, errPayment := buildPayments([]dto.PaymentRequest{data}, saleID, outstanding)
+	if errPayment != nil {
+		return nil, errPayment
+	}
+
+	newPayment := payments[0]
+	if newPayment.Method != entity.PaymentCash {
+		if errCharge := s.charge(&newPayment); errCharge != nil {
+			return nil, errCharge
+		}
+	}
+
+	if errAdd := s.repository.AddPayment(newPayment); errAdd != nil {
+		s.voidPayments([]entity.Payment{newPayment})
+		return nil, errAdd
+	}
+
+	return s.GetSaleByID(caller, saleID)
+}
+
+// RefreshPayment implements SaleService.
+func (s *saleService) RefreshPayment(caller *auth.Caller, saleID uuid.UUID, paymentID uuid.UUID) (*entity.Sale, *response.Error) {
+	sale, errSale := s.GetSaleByID(caller, saleID)
+	if errSale != nil {
+		return nil, errSale
+	}
+
+	index := slices.IndexFunc(sale.Payments, func(salePayment entity.Payment) bool { return salePayment.ID == paymentID })
+	if index < 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusNotFound,
+			Message:    "payment not found",
+			Error:      errors.New("payment not found"),
+		}
+	}
+
+	salePayment := sale.Payments[index]
+	if salePayment.Status != payment.StatusPending {
+		return sale, nil
+	}
+
+	result, err := s.payments.Status(salePayment.Reference)
+	if err != nil {
+		return nil, providerError(err)
+	}
+
+	if result.Status == payment.StatusPending {
+		return sale, nil
+	}
+
+	currentTime := time.Now()
+	salePayment.Status = result.Status
+	salePayment.UpdatedAt = &currentTime
+
+	if errUpdate := s.repository.UpdatePayment(salePayment, payment.StatusPending); errUpdate != nil {
+		return nil, errUpdate
+	}
+
+	return s.GetSaleByID(caller, saleID)
+}
+
+// CancelSale implements SaleService.
+func (s *saleService) CancelSale(caller *auth.Caller, id uuid.UUID) (*entity.Sale, *response.Error) {
+	sale, errSale := s.GetSaleByID(caller, id)
+	if errSale != nil {
+		return nil, errSale
+	}
+
+	// a cancelled sale is cancelled again to retry the voids the provider refused
+	if sale.Status != entity.StatusCancelled {
+		if errCancel := s.repository.CancelSale(id, &caller.UserID, time.Now()); errCancel != nil {
+			return nil, errCancel
+		}
+
+		sale, errSale = s.GetSaleByID(caller, id)
+		if errSale != nil {
+			return nil, errSale
+		}
+	}
+
+	// the charges are voided once the sale is cancelled, a void repeated with the same payment id is not made twice
+	for _, salePayment := range sale.Payments {
+		if salePayment.Reference == "" || salePayment.Status != payment.StatusRefunded {
+			continue
+		}
+
+		err := s.payments.Refund(payment.Refund{ID: salePayment.ID, Reference: salePayment.Reference, Amount: salePayment.Amount})
+		if err != nil {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadGateway,
+				Message:    "sale is cancelled but the payment provider refused to void a payment, cancel it again to retry",
+				Error:      err,
+			}
+		}
+	}
+
+	return sale, nil
+}
+
+// charge sends a card or e-wallet payment to the provider, a declined payment fails the request.
+func (s *saleService) charge(salePayment *entity.Payment) *response.Error {
+	result, err := s.payments.Charge(payment.Charge{
+		PaymentID: salePayment.ID,
+		Method:    salePayment.Method,
+		Amount:    salePayment.Amount,
+		Token:     salePayment.Token,
+	})
+	if err != nil {
+		return providerError(err)
+	}
+
+	salePayment.Provider = s.payments.Name()
+	salePayment.Reference = result.Reference
+	salePayment.Status = result.Status
+
+	if result.Status == payment.StatusFailed {
+		return &response.Error{
+			StatusCode: fiber.StatusPaymentRequired,
+			Message:    fmt.Sprintf("%s payment failed: %s", salePayment.Method, result.Message),
+			Error:      fmt.Errorf("%s payment failed: %s", salePayment.Method, result.Message),
+		}
+	}
+
+	return nil
+}
+
+// voidPayments gives back the charges of a sale that could not be recorded, failures are only logged.
+func (s *saleService) voidPayments(payments []entity.Payment) {
+	for _, salePayment := range payments {
+		if salePayment.Reference == "" || salePayment.Status == payment.StatusFailed {
+			continue
+		}
+
+		err := s.payments.Refund(payment.Refund{ID: salePayment.ID, Reference: salePayment.Reference, Amount: salePayment.Amount})
+		if err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "void payment").Str("reference", salePayment.Reference).Msg("failed to void payment")
+		}
+	}
+}
+
+// buildPayments checks the payments add up to the amount to pay and works out the change of the cash payment.
+// Without payments the amount is paid in cash.
+func buildPayments(requests []dto.PaymentRequest, saleID uuid.UUID, amount int64) ([]entity.Payment, *response.Error) {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if len(requests) == 0 {
+		if amount == 0 {
+			return nil, nil
+		}
+
+		requests = []dto.PaymentRequest{{Method: entity.PaymentCash}}
+	}
+
+	var payments []entity.Payment
+	var paid int64
+
+	cashIndex := -1
+	for _, request := range requests {
+		if !entity.IsValidPaymentMethod(request.Method) {
+			return nil, invalid(fmt.Sprintf("payment method %s is invalid", request.Method))
+		}
+
+		if request.Amount < 0 || request.Tendered < 0 {
+			return nil, invalid("payment amount and tendered can not be negative")
+		}
+
+		if request.Method == entity.PaymentCash {
+			if cashIndex >= 0 {
+				return nil, invalid("a sale can only have one cash payment")
+			}
+
+			cashIndex = len(payments)
+		} else if request.Amount == 0 {
+			return nil, invalid(fmt.Sprintf("amount of the %s payment is required", request.Method))
+		}
+
+		paymentUUID, _ := uuid.NewV7()
+
+		payments = append(payments, entity.Payment{
+			ID:       paymentUUID,
+			SaleID:   saleID,
+			Method:   request.Method,
+			Status:   payment.StatusPending,
+			Amount:   request.Amount,
+			Tendered: request.Tendered,
+			Token:    strings.TrimSpace(request.Token),
+		})
+
+		paid += request.Amount
+	}
+
+	if cashIndex >= 0 && payments[cashIndex].Amount == 0 {
+		payments[cashIndex].Amount = amount - paid
+		paid = amount
+	}
+
+	if paid != amount {
+		return nil, invalid(fmt.Sprintf("payments add up to %d but %d is to be paid", paid, amount))
+	}
+
+	for i := range payments {
+		if payments[i].Amount <= 0 {
+			return nil, invalid("payment amount must be more than zero")
+		}
+
+		if payments[i].Method != entity.PaymentCash {
+			payments[i].Tendered = payments[i].Amount
+			continue
+		}
+
+		if payments[i].Tendered == 0 {
+			payments[i].Tendered = payments[i].Amount
+		}
+
+		if payments[i].Tendered < payments[i].Amount {
+			return nil, invalid(fmt.Sprintf("tendered cash %d is less than the %d to pay", payments[i].Tendered, payments[i].Amount))
+		}
+
+		payments[i].Change = payments[i].Tendered - payments[i].Amount
+		payments[i].Status = payment.StatusCaptured
+	}
+
+	return payments, nil
+}
+
+func providerError(err error) *response.Error {
+	log.Error().Err(err).Int("status", 502).Str("function", "payment provider").Msg("failed to reach payment provider")
+	return &response.Error{
+		StatusCode: fiber.StatusBadGateway,
+		Message:    "payment provider is not available",
+		Error:      err,
+	}
+}
+
+// hideCost leaves what the goods of a sale cost to those who manage its store.
+func hideCost(caller *auth.Caller, sale *entity.Sale) {
+	if caller.CanManageStore(sale.StoreID) {
+		return
+	}
+
+	sale.Cost = nil
+	sale.Margin = nil
+	for i := range sale.Items {
+		sale.Items[i].UnitCost = nil
+		sale.Items[i].Cost = nil
+	}
+}
+
+func NewSaleService(repository repository.SaleRepository, promotion promotion.PromotionService, tax taxService.TaxService, payments payment.Provider) SaleService {
+	return &saleService{repository, promotion, tax, payments}
+}
@@ -0,0 +1,27 @@
+package salereturn
+
+import "github.com/google/uuid"
+
+type ReturnItemRequest struct {
+	SaleItemID uuid.UUID `json:"sale_item_id"`
+	Quantity   int       `json:"quantity"`
+	// Disposition is restock or write_off
+	Disposition string `json:"disposition"`
+}
+
+type RefundRequest struct {
+	Method string `json:"method"`
+	Amount int64  `json:"amount"`
+}
+
+// CreateReturnRequest returns lines of a sale, without refunds the whole amount is refunded in cash.
+// It is recorded on the given shift, or on the open shift of the cashier in the store when it is empty.
+type CreateReturnRequest struct {
+	SaleID  uuid.UUID           `json:"sale_id"`
+	ShiftID *uuid.UUID          `json:"shift_id"`
+	Reason  string              `json:"reason"`
+	Items   []ReturnItemRequest `json:"items"`
+	Refunds []RefundRequest     `json:"refunds"`
+	// IdempotencyKey comes from the Idempotency-Key header, a retry with the key finishes the first return instead of making another
+	IdempotencyKey string `json:"-"`
+}
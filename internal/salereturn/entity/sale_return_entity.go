@@ -0,0 +1,72 @@
+package salereturn
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// what happens to a returned line
+const (
+	DispositionRestock  = "restock"
+	DispositionWriteOff = "write_off"
+)
+
+// statuses of a return, a pending return waits for some of its card or e-wallet refunds to go through the provider
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+)
+
+// SaleReturn is goods a customer brought back from a sale, amounts are in the smallest currency unit.
+// Cash refunded during a shift is taken out of the drawer of that shift, card and e-wallet refunds go back
+// through the provider to the payments of the sale.
+type SaleReturn struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	SaleID         uuid.UUID        `json:"sale_id" db:"sale_id"`
+	StoreID        uuid.UUID        `json:"store_id" db:"store_id"`
+	CustomerID     *uuid.UUID       `json:"customer_id" db:"customer_id"`
+	ProcessedBy    *uuid.UUID       `json:"processed_by" db:"processed_by"`
+	ShiftID        *uuid.UUID       `json:"shift_id" db:"shift_id"`
+	Reason         string           `json:"reason" db:"reason"`
+	Status         string           `json:"status" db:"status"`
+	IdempotencyKey *string          `json:"-" db:"idempotency_key"`
+	RefundAmount   int64            `json:"refund_amount" db:"refund_amount"`
+	ReversedPoints int              `json:"reversed_points" db:"reversed_points"`
+	ReturnedAt     time.Time        `json:"returned_at" db:"returned_at"`
+	CreatedAt      *time.Time       `json:"created_at" db:"created_at"`
+	Items          []SaleReturnItem `json:"items,omitempty" db:"-"`
+	Refunds        []Refund         `json:"refunds,omitempty" db:"-"`
+	PaymentRefunds []PaymentRefund  `json:"-" db:"-"`
+}
+
+// SaleReturnItem is a returned quantity of a sale line.
+type SaleReturnItem struct {
+	ReturnID     uuid.UUID `json:"-" db:"return_id"`
+	SaleItemID   uuid.UUID `json:"sale_item_id" db:"sale_item_id"`
+	ProductID    uuid.UUID `json:"product_id" db:"product_id"`
+	SKU          string    `json:"sku" db:"sku"`
+	Name         string    `json:"name" db:"name"`
+	Quantity     int       `json:"quantity" db:"quantity"`
+	Disposition  string    `json:"disposition" db:"disposition"`
+	RefundAmount int64     `json:"refund_amount" db:"refund_amount"`
+	// ReturnedBefore is the quantity of the line returned before this return, it guards against concurrent returns
+	ReturnedBefore int `json:"-" db:"-"`
+}
+
+// Refund is the part of a refund paid back with one payment method.
+type Refund struct {
+	ReturnID uuid.UUID `json:"-" db:"return_id"`
+	Method   string    `json:"method" db:"method"`
+	Amount   int64     `json:"amount" db:"amount"`
+}
+
+// PaymentRefund is the part of a card or e-wallet refund given back on one payment of the sale,
+// it is pending until the provider made it.
+type PaymentRefund struct {
+	ID        uuid.UUID `db:"id"`
+	PaymentID uuid.UUID `db:"payment_id"`
+	Reference string    `db:"reference"`
+	Amount    int64     `db:"amount"`
+	Status    string    `db:"status"`
+}
@@ -0,0 +1,179 @@
+package salereturn
+
+import (
+	dto "candyshop/internal/salereturn/dto"
+	service "candyshop/internal/salereturn/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SaleReturnHandler struct {
+	service service.SaleReturnService
+}
+
+func NewSaleReturnHandler(service service.SaleReturnService) *SaleReturnHandler {
+	return &SaleReturnHandler{service}
+}
+
+func (h *SaleReturnHandler) GetAllReturn(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	var storeID *uuid.UUID
+	if c.Query("store_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("store_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "store_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		storeID = &parseID
+	}
+
+	var saleID *uuid.UUID
+	if c.Query("sale_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("sale_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "sale_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		saleID = &parseID
+	}
+
+	saleReturns, errReturn := h.service.GetAllReturn(auth.GetCaller(c), storeID, saleID, offset, limit)
+	if errReturn != nil {
+		return c.Status(errReturn.StatusCode).JSON(fiber.Map{
+			"status_code": errReturn.StatusCode,
+			"message":     "failed to fetch returns",
+			"error":       errReturn.Error.Error(),
+		})
+	}
+
+	if len(saleReturns) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch returns",
+			"error":       "return not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data returns",
+		"data":        saleReturns,
+	})
+}
+
+func (h *SaleReturnHandler) GetReturnByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	saleReturn, errReturn := h.service.GetReturnByID(auth.GetCaller(c), parseID)
+	if errReturn != nil {
+		return c.Status(errReturn.StatusCode).JSON(fiber.Map{
+			"status_code": errReturn.StatusCode,
+			"message":     "failed to fetch return",
+			"error":       errReturn.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data return",
+		"data":        saleReturn,
+	})
+}
+
+func (h *SaleReturnHandler) CreateReturn(c *fiber.Ctx) error {
+	var req dto.CreateReturnRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data return",
+			"error":       err.Error(),
+		})
+	}
+
+	req.IdempotencyKey = c.Get("Idempotency-Key")
+
+	if req.SaleID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "sale_id is required",
+			"error":       nil,
+		})
+	}
+
+	saleReturn, errReturn := h.service.CreateReturn(auth.GetCaller(c), req)
+	if errReturn != nil {
+		if errReturn.StatusCode == fiber.StatusConflict {
+			return c.Status(errReturn.StatusCode).JSON(fiber.Map{
+				"status_code": errReturn.StatusCode,
+				"message":     "failed to create return",
+				"error":       errReturn.Message,
+			})
+		}
+
+		return c.Status(errReturn.StatusCode).JSON(fiber.Map{
+			"status_code": errReturn.StatusCode,
+			"message":     "failed to create return",
+			"error":       errReturn.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data return",
+		"data":        saleReturn,
+	})
+}
+
+func (h *SaleReturnHandler) FinishReturn(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	saleReturn, errReturn := h.service.FinishReturn(auth.GetCaller(c), parseID)
+	if errReturn != nil {
+		return c.Status(errReturn.StatusCode).JSON(fiber.Map{
+			"status_code": errReturn.StatusCode,
+			"message":     "failed to finish return",
+			"error":       errReturn.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success finish data return",
+		"data":        saleReturn,
+	})
+}
@@ -0,0 +1,379 @@
+package salereturn
+
+import (
+	entity "candyshop/internal/salereturn/entity"
+	shiftRepository "candyshop/internal/shift/repository"
+	"candyshop/pkg/ledger"
+	"candyshop/pkg/payment"
+	"candyshop/pkg/response"
+	"candyshop/pkg/summary"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type SaleReturnRepository interface {
+	GetAllReturn(storeIDs []uuid.UUID, saleID *uuid.UUID, offset, limit int) ([]entity.SaleReturn, *response.Error)
+	GetReturnByID(id uuid.UUID) (*entity.SaleReturn, *response.Error)
+	GetReturnByKey(saleID uuid.UUID, idempotencyKey string) (*entity.SaleReturn, *response.Error)
+	CreateReturn(data entity.SaleReturn, refundedBefore int64) *response.Error
+	CompletePaymentRefund(returnID, id uuid.UUID, refundedAt time.Time) *response.Error
+}
+
+type saleReturnRepository struct {
+	db *sqlx.DB
+}
+
+const returnColumns = `id, sale_id, store_id, customer_id, processed_by, shift_id, reason, status, idempotency_key, refund_amount, reversed_points, returned_at, created_at`
+
+// GetAllReturn implements SaleReturnRepository.
+func (s *saleReturnRepository) GetAllReturn(storeIDs []uuid.UUID, saleID *uuid.UUID, offset int, limit int) ([]entity.SaleReturn, *response.Error) {
+	var saleReturns []entity.SaleReturn
+
+	// nil store ids means the caller is not limited to some stores
+	query := `
+		SELECT ` + returnColumns + `
+		FROM sale_returns
+		WHERE ($3::uuid[] IS NULL OR store_id = ANY($3)) AND ($4::uuid IS NULL OR sale_id = $4)
+		ORDER BY returned_at DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := s.db.Select(&saleReturns, query, limit, offset, pq.Array(storeIDs), saleID)
+	if err != nil {
+		return nil, returnError("get all return", err)
+	}
+
+	return saleReturns, nil
+}
+
+// GetReturnByID implements SaleReturnRepository.
+func (s *saleReturnRepository) GetReturnByID(id uuid.UUID) (*entity.SaleReturn, *response.Error) {
+	var saleReturn entity.SaleReturn
+
+	err := s.db.Get(&saleReturn, `SELECT `+returnColumns+` FROM sale_returns WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch return",
+				Error:      err,
+			}
+		}
+
+		return nil, returnError("get return by id", err)
+	}
+
+	errItems := s.db.Select(&saleReturn.Items, `
+		SELECT return_id, sale_item_id, product_id, sku, name, quantity, disposition, refund_amount
+		FROM sale_return_items
+		WHERE return_id = $1
+		ORDER BY name, sale_item_id
+	`, id)
+	if errItems != nil {
+		return nil, returnError("get return items", errItems)
+	}
+
+	errRefunds := s.db.Select(&saleReturn.Refunds, `SELECT return_id, method, amount FROM sale_return_refunds WHERE return_id = $1 ORDER BY method`, id)
+	if errRefunds != nil {
+		return nil, returnError("get return refunds", errRefunds)
+	}
+
+	errPaymentRefunds := s.db.Select(&saleReturn.PaymentRefunds, `
+		SELECT r.id, r.payment_id, p.reference, r.amount, r.status
+		FROM sale_return_payment_refunds r
+		JOIN sale_payments p ON p.id = r.payment_id
+		WHERE r.return_id = $1
+		ORDER BY r.id
+	`, id)
+	if errPaymentRefunds != nil {
+		return nil, returnError("get return payment refunds", errPaymentRefunds)
+	}
+
+	return &saleReturn, nil
+}
+
+// GetReturnByKey implements SaleReturnRepository.
+func (s *saleReturnRepository) GetReturnByKey(saleID uuid.UUID, idempotencyKey string) (*entity.SaleReturn, *response.Error) {
+	var id uuid.UUID
+
+	err := s.db.Get(&id, `SELECT id FROM sale_returns WHERE sale_id = $1 AND idempotency_key = $2`, saleID, idempotencyKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch return",
+				Error:      err,
+			}
+		}
+
+		return nil, returnError("get return by key", err)
+	}
+
+	return s.GetReturnByID(id)
+}
+
+// CreateReturn implements SaleReturnRepository.
+func (s *saleReturnRepository) CreateReturn(data entity.SaleReturn, refundedBefore int64) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create return").Msg("failed to create return")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	var processedBy uuid.UUID
+	if data.ProcessedBy != nil {
+		processedBy = *data.ProcessedBy
+	}
+
+	// the shift stays open until the refund is committed
+	shiftID, errShift := shiftRepository.LockOpenShift(tx, data.StoreID, data.ShiftID, processedBy)
+	if errShift != nil {
+		return errShift
+	}
+
+	data.ShiftID = shiftID
+
+	// the amounts were calculated from what the sale looked like before, another return in between makes them wrong.
+	// The sale stays locked until the return is saved, so returns of a sale are reserved one after the other
+	// and nothing has gone to the provider yet when one of them has to try again.
+	changed := &response.Error{
+		StatusCode: 409,
+		Message:    "sale was changed by another return, try again",
+		Error:      nil,
+	}
+
+	var refundedAmount int64
+
+	errLock := tx.Get(&refundedAmount, `SELECT refunded_amount FROM sales WHERE id = $1 FOR UPDATE`, data.SaleID)
+	if errLock != nil {
+		return returnError("lock sale", errLock)
+	}
+
+	if refundedAmount != refundedBefore {
+		return changed
+	}
+
+	_, errSale := tx.Exec(`
+		UPDATE sales SET refunded_amount = refunded_amount + $2, reversed_points = reversed_points + $3 WHERE id = $1
+	`, data.SaleID, data.RefundAmount, data.ReversedPoints)
+	if errSale != nil {
+		return returnError("update sale refund", errSale)
+	}
+
+	for _, item := range data.Items {
+		result, errItem := tx.Exec(`
+			UPDATE sale_items SET returned_quantity = returned_quantity + $3
+			WHERE id = $1 AND sale_id = $2 AND returned_quantity = $4
+		`, item.SaleItemID, data.SaleID, item.Quantity, item.ReturnedBefore)
+		if errItem != nil {
+			return returnError("update sale item return", errItem)
+		}
+
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return changed
+		}
+	}
+
+	for _, paymentRefund := range data.PaymentRefunds {
+		result, errPayment := tx.Exec(`
+			UPDATE sale_payments
+			SET refunded_amount = refunded_amount + $2,
+				status = CASE WHEN refunded_amount + $2 = amount THEN $3 ELSE status END,
+				updated_at = $4
+			WHERE id = $1 AND sale_id = $5 AND refunded_amount + $2 <= amount
+		`, paymentRefund.PaymentID, paymentRefund.Amount, payment.StatusRefunded, data.ReturnedAt, data.SaleID)
+		if errPayment != nil {
+			return returnError("update payment refund", errPayment)
+		}
+
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return changed
+		}
+	}
+
+	_, errInsert := tx.Exec(`
+		INSERT INTO sale_returns (id, sale_id, store_id, customer_id, processed_by, shift_id, reason, status, idempotency_key, refund_amount, reversed_points, returned_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, data.ID,
+		data.SaleID,
+		data.StoreID,
+		data.CustomerID,
+		data.ProcessedBy,
+		data.ShiftID,
+		data.Reason,
+		data.Status,
+		data.IdempotencyKey,
+		data.RefundAmount,
+		data.ReversedPoints,
+		data.ReturnedAt)
+	if errInsert != nil {
+		var pqErr *pq.Error
+		if errors.As(errInsert, &pqErr) && pqErr.Code == "23505" {
+			return &response.Error{
+				StatusCode: 409,
+				Message:    "a return with the same idempotency key is being processed",
+				Error:      nil,
+			}
+		}
+
+		return returnError("create return", errInsert)
+	}
+
+	for _, paymentRefund := range data.PaymentRefunds {
+		_, errPaymentRefund := tx.Exec(`
+			INSERT INTO sale_return_payment_refunds (id, return_id, payment_id, amount, status) VALUES ($1, $2, $3, $4, $5)
+		`, paymentRefund.ID, data.ID, paymentRefund.PaymentID, paymentRefund.Amount, paymentRefund.Status)
+		if errPaymentRefund != nil {
+			return returnError("create return payment refund", errPaymentRefund)
+		}
+	}
+
+	for _, item := range data.Items {
+		_, errItem := tx.Exec(`
+			INSERT INTO sale_return_items (return_id, sale_item_id, product_id, sku, name, quantity, disposition, refund_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, data.ID, item.SaleItemID, item.ProductID, item.SKU, item.Name, item.Quantity, item.Disposition, item.RefundAmount)
+		if errItem != nil {
+			return returnError("create return item", errItem)
+		}
+	}
+
+	for _, refund := range data.Refunds {
+		_, errRefund := tx.Exec(`INSERT INTO sale_return_refunds (return_id, method, amount) VALUES ($1, $2, $3)`,
+			data.ID, refund.Method, refund.Amount)
+		if errRefund != nil {
+			return returnError("create return refund", errRefund)
+		}
+	}
+
+	// restocked products go back to the store in product order so concurrent movements can't deadlock
+	items := slices.Clone(data.Items)
+	slices.SortFunc(items, func(a, b entity.SaleReturnItem) int {
+		return strings.Compare(a.ProductID.String(), b.ProductID.String())
+	})
+
+	for _, item := range items {
+		if item.Disposition != entity.DispositionRestock {
+			continue
+		}
+
+		// the goods come back at the cost they were sold at, or at the average cost when the sale has none
+		var unitCost *int64
+
+		errCost := tx.Get(&unitCost, `SELECT NULLIF(unit_cost, 0) FROM sale_items WHERE id = $1`, item.SaleItemID)
+		if errCost != nil {
+			return returnError("restock return", errCost)
+		}
+
+		errMove := ledger.Move(tx, ledger.Movement{
+			StoreID:       data.StoreID,
+			ProductID:     item.ProductID,
+			Type:          ledger.TypeReturn,
+			Quantity:      item.Quantity,
+			ReferenceType: ledger.ReferenceReturn,
+			ReferenceID:   &data.ID,
+			CreatedBy:     data.ProcessedBy,
+			MovedAt:       data.ReturnedAt,
+			UnitCost:      unitCost,
+		})
+		if errMove != nil {
+			return returnError("restock return", errMove)
+		}
+	}
+
+	// the balance of the customer never goes below zero
+	if data.CustomerID != nil && data.ReversedPoints > 0 {
+		_, errPoints := tx.Exec(`UPDATE customers SET loyalty_points = GREATEST(loyalty_points - $2, 0) WHERE id = $1`,
+			data.CustomerID, data.ReversedPoints)
+		if errPoints != nil {
+			return returnError("reverse loyalty points", errPoints)
+		}
+	}
+
+	// refunds count on the day of the sale
+	if errQueue := summary.Queue(tx, data.SaleID); errQueue != nil {
+		return returnError("queue sales summary", errQueue)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create return").Msg("failed to create return")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// CompletePaymentRefund implements SaleReturnRepository.
+func (s *saleReturnRepository) CompletePaymentRefund(returnID uuid.UUID, id uuid.UUID, refundedAt time.Time) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "complete payment refund").Msg("failed to complete payment refund")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	_, errRefund := tx.Exec(`
+		UPDATE sale_return_payment_refunds SET status = $3, refunded_at = $4
+		WHERE id = $1 AND return_id = $2 AND status = $5
+	`, id, returnID, payment.StatusRefunded, refundedAt, payment.StatusPending)
+	if errRefund != nil {
+		return returnError("complete payment refund", errRefund)
+	}
+
+	// the return completes with its last refund
+	_, errReturn := tx.Exec(`
+		UPDATE sale_returns SET status = $2
+		WHERE id = $1 AND status = $3
+			AND NOT EXISTS (SELECT 1 FROM sale_return_payment_refunds WHERE return_id = $1 AND status = $4)
+	`, returnID, entity.StatusCompleted, entity.StatusPending, payment.StatusPending)
+	if errReturn != nil {
+		return returnError("complete return", errReturn)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "complete payment refund").Msg("failed to complete payment refund")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+func returnError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewSaleReturnRepository(db *sqlx.DB) SaleReturnRepository {
+	return &saleReturnRepository{db}
+}
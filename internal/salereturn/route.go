@@ -0,0 +1,35 @@
+package salereturn
+
+import (
+	promotionRepository "candyshop/internal/promotion/repository"
+	promotionService "candyshop/internal/promotion/service"
+	saleRepository "candyshop/internal/sale/repository"
+	saleService "candyshop/internal/sale/service"
+	handler "candyshop/internal/salereturn/handler"
+	repository "candyshop/internal/salereturn/repository"
+	service "candyshop/internal/salereturn/service"
+	staff "candyshop/internal/staff"
+	taxRepository "candyshop/internal/tax/repository"
+	taxService "candyshop/internal/tax/service"
+	"candyshop/pkg/payment"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB, payments payment.Provider) {
+	promotion := promotionService.NewPromotionService(promotionRepository.NewPromotionRepository(db))
+	tax := taxService.NewTaxService(taxRepository.NewTaxRepository(db))
+	sale := saleService.NewSaleService(saleRepository.NewSaleRepository(db), promotion, tax, payments)
+
+	repo := repository.NewSaleReturnRepository(db)
+	service := service.NewSaleReturnService(repo, sale, payments)
+	handler := handler.NewSaleReturnHandler(service)
+
+	returnRoute := router.Group("api/v1/returns", staff.Authenticate(db))
+
+	returnRoute.Get("", handler.GetAllReturn)
+	returnRoute.Post("", handler.CreateReturn)
+	returnRoute.Get("/:id", handler.GetReturnByID)
+	returnRoute.Post("/:id/refund", handler.FinishReturn)
+}
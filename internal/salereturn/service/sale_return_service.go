@@ -0,0 +1,370 @@
+package salereturn
+
+import (
+	saleEntity "candyshop/internal/sale/entity"
+	sale "candyshop/internal/sale/service"
+	dto "candyshop/internal/salereturn/dto"
+	entity "candyshop/internal/salereturn/entity"
+	repository "candyshop/internal/salereturn/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/payment"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SaleReturnService interface {
+	GetAllReturn(caller *auth.Caller, storeID, saleID *uuid.UUID, offset, limit int) ([]entity.SaleReturn, *response.Error)
+	GetReturnByID(caller *auth.Caller, id uuid.UUID) (*entity.SaleReturn, *response.Error)
+	CreateReturn(caller *auth.Caller, data dto.CreateReturnRequest) (*entity.SaleReturn, *response.Error)
+	FinishReturn(caller *auth.Caller, id uuid.UUID) (*entity.SaleReturn, *response.Error)
+}
+
+type saleReturnService struct {
+	repository repository.SaleReturnRepository
+	sale       sale.SaleService
+	payments   payment.Provider
+}
+
+// GetAllReturn implements SaleReturnService.
+func (s *saleReturnService) GetAllReturn(caller *auth.Caller, storeID *uuid.UUID, saleID *uuid.UUID, offset int, limit int) ([]entity.SaleReturn, *response.Error) {
+	storeIDs := caller.StoreScope()
+	if storeID != nil {
+		if !caller.CanAccessStore(*storeID) {
+			return nil, auth.ForbiddenStore(*storeID)
+		}
+
+		storeIDs = []uuid.UUID{*storeID}
+	}
+
+	return s.repository.GetAllReturn(storeIDs, saleID, offset, limit)
+}
+
+// GetReturnByID implements SaleReturnService.
+func (s *saleReturnService) GetReturnByID(caller *auth.Caller, id uuid.UUID) (*entity.SaleReturn, *response.Error) {
+	saleReturn, errReturn := s.repository.GetReturnByID(id)
+	if errReturn != nil {
+		return nil, errReturn
+	}
+
+	if !caller.CanAccessStore(saleReturn.StoreID) {
+		return nil, auth.ForbiddenStore(saleReturn.StoreID)
+	}
+
+	return saleReturn, nil
+}
+
+// CreateReturn implements SaleReturnService.
+func (s *saleReturnService) CreateReturn(caller *auth.Caller, data dto.CreateReturnRequest) (*entity.SaleReturn, *response.Error) {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if len(data.Items) == 0 {
+		return nil, invalid("items is required")
+	}
+
+	reason := strings.TrimSpace(data.Reason)
+	if len(reason) > 255 {
+		return nil, invalid("reason can not be longer than 255 characters")
+	}
+
+	idempotencyKey := strings.TrimSpace(data.IdempotencyKey)
+	if len(idempotencyKey) > 100 {
+		return nil, invalid("idempotency key can not be longer than 100 characters")
+	}
+
+	// a retry of a return that was saved finishes its refunds instead of returning the items again
+	if idempotencyKey != "" {
+		saleReturn, errReturn := s.repository.GetReturnByKey(data.SaleID, idempotencyKey)
+		if errReturn == nil {
+			if !caller.CanAccessStore(saleReturn.StoreID) {
+				return nil, auth.ForbiddenStore(saleReturn.StoreID)
+			}
+
+			return s.refund(saleReturn)
+		}
+
+		if errReturn.StatusCode != fiber.StatusNotFound {
+			return nil, errReturn
+		}
+	}
+
+	// store access is checked by the sale
+	dataSale, errSale := s.sale.GetSaleByID(caller, data.SaleID)
+	if errSale != nil {
+		return nil, errSale
+	}
+
+	if dataSale.Status != saleEntity.StatusCompleted {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("sale is %s", dataSale.Status),
+			Error:      nil,
+		}
+	}
+
+	saleItems := map[uuid.UUID]saleEntity.SaleItem{}
+	for _, item := range dataSale.Items {
+		saleItems[item.ID] = item
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataReturn := &entity.SaleReturn{
+		ID:          newUUID,
+		SaleID:      dataSale.ID,
+		StoreID:     dataSale.StoreID,
+		CustomerID:  dataSale.CustomerID,
+		ProcessedBy: &caller.UserID,
+		ShiftID:     data.ShiftID,
+		Reason:      reason,
+		Status:      entity.StatusCompleted,
+		ReturnedAt:  time.Now(),
+	}
+
+	if idempotencyKey != "" {
+		dataReturn.IdempotencyKey = &idempotencyKey
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, item := range data.Items {
+		saleItem, ok := saleItems[item.SaleItemID]
+		if !ok {
+			return nil, invalid(fmt.Sprintf("sale item %s is not part of the sale", item.SaleItemID))
+		}
+
+		if seen[item.SaleItemID] {
+			return nil, invalid(fmt.Sprintf("sale item %s is listed twice", item.SaleItemID))
+		}
+
+		seen[item.SaleItemID] = true
+
+		if item.Disposition != entity.DispositionRestock && item.Disposition != entity.DispositionWriteOff {
+			return nil, invalid("disposition must be restock or write_off")
+		}
+
+		remaining := saleItem.Quantity - saleItem.ReturnedQuantity
+		if item.Quantity <= 0 || item.Quantity > remaining {
+			return nil, invalid(fmt.Sprintf("quantity of %s must be between 1 and %d", saleItem.SKU, remaining))
+		}
+
+		refund := lineRefund(saleItem, item.Quantity)
+
+		dataReturn.Items = append(dataReturn.Items, entity.SaleReturnItem{
+			ReturnID:       newUUID,
+			SaleItemID:     saleItem.ID,
+			ProductID:      saleItem.ProductID,
+			SKU:            saleItem.SKU,
+			Name:           saleItem.Name,
+			Quantity:       item.Quantity,
+			Disposition:    item.Disposition,
+			RefundAmount:   refund,
+			ReturnedBefore: saleItem.ReturnedQuantity,
+		})
+
+		dataReturn.RefundAmount += refund
+	}
+
+	// the points are reversed in proportion to the refunded part of the sale total, rounded the same way on every return
+	if dataSale.Total > 0 {
+		refundedAfter := dataSale.RefundedAmount + dataReturn.RefundAmount
+		reversedAfter := int(int64(dataSale.LoyaltyPoints) * refundedAfter / dataSale.Total)
+		dataReturn.ReversedPoints = max(reversedAfter-dataSale.ReversedPoints, 0)
+	}
+
+	refunds, errRefund := refundsByMethod(data.Refunds, dataReturn.RefundAmount)
+	if errRefund != nil {
+		return nil, errRefund
+	}
+
+	for _, refund := range refunds {
+		refund.ReturnID = newUUID
+		dataReturn.Refunds = append(dataReturn.Refunds, refund)
+	}
+
+	paymentRefunds, errAllocate := allocateRefunds(dataSale.Payments, refunds)
+	if errAllocate != nil {
+		return nil, errAllocate
+	}
+
+	// the return is saved with its refunds pending before any money moves, so a return that fails
+	// to save has refunded nothing and a refund the provider made is never lost
+	for i := range paymentRefunds {
+		paymentRefunds[i].ID, _ = uuid.NewV7()
+		paymentRefunds[i].Status = payment.StatusPending
+	}
+
+	if len(paymentRefunds) > 0 {
+		dataReturn.Status = entity.StatusPending
+	}
+
+	dataReturn.PaymentRefunds = paymentRefunds
+
+	if errCreate := s.repository.CreateReturn(*dataReturn, dataSale.RefundedAmount); errCreate != nil {
+		return nil, errCreate
+	}
+
+	saleReturn, errReturn := s.repository.GetReturnByID(newUUID)
+	if errReturn != nil {
+		return nil, errReturn
+	}
+
+	return s.refund(saleReturn)
+}
+
+// FinishReturn implements SaleReturnService.
+func (s *saleReturnService) FinishReturn(caller *auth.Caller, id uuid.UUID) (*entity.SaleReturn, *response.Error) {
+	saleReturn, errReturn := s.GetReturnByID(caller, id)
+	if errReturn != nil {
+		return nil, errReturn
+	}
+
+	return s.refund(saleReturn)
+}
+
+// refund makes the pending refunds of a return at the provider. The id of a refund makes the provider answer
+// a retry with the first result, a refund that fails leaves the return pending to be finished later.
+func (s *saleReturnService) refund(saleReturn *entity.SaleReturn) (*entity.SaleReturn, *response.Error) {
+	if saleReturn.Status != entity.StatusPending {
+		return saleReturn, nil
+	}
+
+	for _, paymentRefund := range saleReturn.PaymentRefunds {
+		if paymentRefund.Status != payment.StatusPending {
+			continue
+		}
+
+		err := s.payments.Refund(payment.Refund{ID: paymentRefund.ID, Reference: paymentRefund.Reference, Amount: paymentRefund.Amount})
+		if err != nil {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadGateway,
+				Message:    "payment provider refused the refund, the return stays pending until it is finished",
+				Error:      err,
+			}
+		}
+
+		if errComplete := s.repository.CompletePaymentRefund(saleReturn.ID, paymentRefund.ID, time.Now()); errComplete != nil {
+			return nil, errComplete
+		}
+	}
+
+	return s.repository.GetReturnByID(saleReturn.ID)
+}
+
+// lineRefund is what the returned units paid after the discounts of their line. The refund of the units returned so far
+// is rounded down from the line total, so returning the whole line in parts refunds exactly the line total.
+func lineRefund(item saleEntity.SaleItem, quantity int) int64 {
+	quantityBefore := int64(item.ReturnedQuantity)
+	quantityAfter := quantityBefore + int64(quantity)
+
+	return item.Total*quantityAfter/int64(item.Quantity) - item.Total*quantityBefore/int64(item.Quantity)
+}
+
+// refundsByMethod checks the requested refunds add up to the refund amount, without refunds everything goes back in cash.
+func refundsByMethod(requests []dto.RefundRequest, amount int64) ([]entity.Refund, *response.Error) {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if len(requests) == 0 {
+		if amount == 0 {
+			return nil, nil
+		}
+
+		return []entity.Refund{{Method: saleEntity.PaymentCash, Amount: amount}}, nil
+	}
+
+	var refunds []entity.Refund
+	var total int64
+
+	index := map[string]int{}
+	for _, request := range requests {
+		if !saleEntity.IsValidPaymentMethod(request.Method) {
+			return nil, invalid(fmt.Sprintf("payment method %s is invalid", request.Method))
+		}
+
+		if request.Amount <= 0 {
+			return nil, invalid("refund amount must be more than zero")
+		}
+
+		total += request.Amount
+
+		if i, ok := index[request.Method]; ok {
+			refunds[i].Amount += request.Amount
+			continue
+		}
+
+		index[request.Method] = len(refunds)
+		refunds = append(refunds, entity.Refund{Method: request.Method, Amount: request.Amount})
+	}
+
+	if total != amount {
+		return nil, invalid(fmt.Sprintf("refunds add up to %d but the refund amount is %d", total, amount))
+	}
+
+	return refunds, nil
+}
+
+// allocateRefunds spreads card and e-wallet refunds over the captured payments of the same method,
+// they can't give back more than was paid with it. Cash comes out of the drawer and needs no payment.
+func allocateRefunds(payments []saleEntity.Payment, refunds []entity.Refund) ([]entity.PaymentRefund, *response.Error) {
+	var paymentRefunds []entity.PaymentRefund
+
+	for _, refund := range refunds {
+		if refund.Method == saleEntity.PaymentCash {
+			continue
+		}
+
+		remaining := refund.Amount
+		for _, salePayment := range payments {
+			if remaining == 0 {
+				break
+			}
+
+			if salePayment.Method != refund.Method || salePayment.Status != payment.StatusCaptured {
+				continue
+			}
+
+			amount := min(salePayment.Amount-salePayment.RefundedAmount, remaining)
+			if amount <= 0 {
+				continue
+			}
+
+			paymentRefunds = append(paymentRefunds, entity.PaymentRefund{
+				PaymentID: salePayment.ID,
+				Reference: salePayment.Reference,
+				Amount:    amount,
+			})
+
+			remaining -= amount
+		}
+
+		if remaining > 0 {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("%s refund is more than what is left of the %s payments", refund.Method, refund.Method),
+				Error:      fmt.Errorf("%s refund is more than what is left of the %s payments", refund.Method, refund.Method),
+			}
+		}
+	}
+
+	return paymentRefunds, nil
+}
+
+func NewSaleReturnService(repository repository.SaleReturnRepository, sale sale.SaleService, payments payment.Provider) SaleReturnService {
+	return &saleReturnService{repository, sale, payments}
+}
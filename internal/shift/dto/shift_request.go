@@ -0,0 +1,25 @@
+package shift
+
+import "github.com/google/uuid"
+
+type CreateRegisterRequest struct {
+	StoreID uuid.UUID `json:"store_id"`
+	Name    string    `json:"name"`
+}
+
+type OpenShiftRequest struct {
+	RegisterID   uuid.UUID `json:"register_id"`
+	OpeningFloat int64     `json:"opening_float"`
+}
+
+// CashMovementRequest is cash_in or cash_out
+type CashMovementRequest struct {
+	Type   string `json:"type"`
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash *int64 `json:"counted_cash"`
+	Note        string `json:"note"`
+}
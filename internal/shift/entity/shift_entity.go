@@ -0,0 +1,91 @@
+package shift
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// statuses of a shift, sales and cash movements are only recorded on an open shift
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// types of a cash movement
+const (
+	CashIn  = "cash_in"
+	CashOut = "cash_out"
+)
+
+// Register is a cash drawer of a store.
+type Register struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	StoreID   uuid.UUID  `json:"store_id" db:"store_id"`
+	Name      string     `json:"name" db:"name"`
+	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"-" db:"updated_at"`
+	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+}
+
+// Shift is the time a cashier works a register, amounts are in the smallest currency unit.
+// Expected cash, counted cash and variance are nil until the shift is closed.
+type Shift struct {
+	ID            uuid.UUID      `json:"id" db:"id"`
+	RegisterID    uuid.UUID      `json:"register_id" db:"register_id"`
+	StoreID       uuid.UUID      `json:"store_id" db:"store_id"`
+	Status        string         `json:"status" db:"status"`
+	OpenedBy      uuid.UUID      `json:"opened_by" db:"opened_by"`
+	ClosedBy      *uuid.UUID     `json:"closed_by" db:"closed_by"`
+	OpeningFloat  int64          `json:"opening_float" db:"opening_float"`
+	ExpectedCash  *int64         `json:"expected_cash" db:"expected_cash"`
+	CountedCash   *int64         `json:"counted_cash" db:"counted_cash"`
+	Variance      *int64         `json:"variance" db:"variance"`
+	Note          string         `json:"note" db:"note"`
+	OpenedAt      time.Time      `json:"opened_at" db:"opened_at"`
+	ClosedAt      *time.Time     `json:"closed_at" db:"closed_at"`
+	CashMovements []CashMovement `json:"cash_movements,omitempty" db:"-"`
+}
+
+type CashMovement struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	ShiftID   uuid.UUID `json:"shift_id" db:"shift_id"`
+	Type      string    `json:"type" db:"type"`
+	Amount    int64     `json:"amount" db:"amount"`
+	Reason    string    `json:"reason" db:"reason"`
+	CreatedBy uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CashSummary adds up the cash that went through the drawer of a shift.
+type CashSummary struct {
+	CashIn      int64 `json:"cash_in" db:"cash_in"`
+	CashOut     int64 `json:"cash_out" db:"cash_out"`
+	CashSales   int64 `json:"cash_sales" db:"cash_sales"`
+	CashRefunds int64 `json:"cash_refunds" db:"cash_refunds"`
+}
+
+// MethodTotal is the amount taken or refunded with one payment method.
+type MethodTotal struct {
+	Method string `json:"method" db:"method"`
+	Count  int    `json:"count" db:"count"`
+	Amount int64  `json:"amount" db:"amount"`
+}
+
+// ZReport summarises a shift, it reads as an X report while the shift is still open.
+type ZReport struct {
+	Shift        Shift         `json:"shift"`
+	SalesCount   int           `json:"sales_count" db:"sales_count"`
+	ItemsSold    int           `json:"items_sold" db:"items_sold"`
+	GrossSales   int64         `json:"gross_sales" db:"gross_sales"`
+	Discounts    int64         `json:"discounts" db:"discounts"`
+	NetSales     int64         `json:"net_sales" db:"net_sales"`
+	ReturnsCount int           `json:"returns_count" db:"returns_count"`
+	RefundTotal  int64         `json:"refund_total" db:"refund_total"`
+	Payments     []MethodTotal `json:"payments"`
+	Refunds      []MethodTotal `json:"refunds"`
+	Cash         CashSummary   `json:"cash"`
+	ExpectedCash int64         `json:"expected_cash"`
+	CountedCash  *int64        `json:"counted_cash"`
+	Variance     *int64        `json:"variance"`
+}
@@ -0,0 +1,367 @@
+package shift
+
+import (
+	dto "candyshop/internal/shift/dto"
+	service "candyshop/internal/shift/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShiftHandler struct {
+	service service.ShiftService
+}
+
+func NewShiftHandler(service service.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service}
+}
+
+func (h *ShiftHandler) GetAllRegister(c *fiber.Ctx) error {
+	var storeID *uuid.UUID
+	if c.Query("store_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("store_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "store_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		storeID = &parseID
+	}
+
+	registers, errRegister := h.service.GetAllRegister(auth.GetCaller(c), storeID)
+	if errRegister != nil {
+		return c.Status(errRegister.StatusCode).JSON(fiber.Map{
+			"status_code": errRegister.StatusCode,
+			"message":     "failed to fetch registers",
+			"error":       errRegister.Error.Error(),
+		})
+	}
+
+	if len(registers) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch registers",
+			"error":       "register not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data registers",
+		"data":        registers,
+	})
+}
+
+func (h *ShiftHandler) CreateRegister(c *fiber.Ctx) error {
+	var req dto.CreateRegisterRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data register",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is required",
+			"error":       nil,
+		})
+	}
+
+	register, errRegister := h.service.CreateRegister(auth.GetCaller(c), req)
+	if errRegister != nil {
+		if errRegister.StatusCode == fiber.StatusConflict {
+			return c.Status(errRegister.StatusCode).JSON(fiber.Map{
+				"status_code": errRegister.StatusCode,
+				"message":     "failed to create register",
+				"error":       errRegister.Message,
+			})
+		}
+
+		return c.Status(errRegister.StatusCode).JSON(fiber.Map{
+			"status_code": errRegister.StatusCode,
+			"message":     "failed to create register",
+			"error":       errRegister.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data register",
+		"data":        register,
+	})
+}
+
+func (h *ShiftHandler) DeleteRegister(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errDelete := h.service.DeleteRegister(auth.GetCaller(c), parseID)
+	if errDelete != nil {
+		if errDelete.StatusCode == fiber.StatusConflict {
+			return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+				"status_code": errDelete.StatusCode,
+				"message":     "failed to delete register",
+				"error":       errDelete.Message,
+			})
+		}
+
+		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+			"status_code": errDelete.StatusCode,
+			"message":     "failed to delete register",
+			"error":       errDelete.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete data register",
+		"data":        nil,
+	})
+}
+
+func (h *ShiftHandler) GetAllShift(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	var storeID *uuid.UUID
+	if c.Query("store_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("store_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "store_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		storeID = &parseID
+	}
+
+	shifts, errShift := h.service.GetAllShift(auth.GetCaller(c), storeID, offset, limit)
+	if errShift != nil {
+		return c.Status(errShift.StatusCode).JSON(fiber.Map{
+			"status_code": errShift.StatusCode,
+			"message":     "failed to fetch shifts",
+			"error":       errShift.Error.Error(),
+		})
+	}
+
+	if len(shifts) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch shifts",
+			"error":       "shift not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data shifts",
+		"data":        shifts,
+	})
+}
+
+func (h *ShiftHandler) GetShiftByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	shift, errShift := h.service.GetShiftByID(auth.GetCaller(c), parseID)
+	if errShift != nil {
+		return c.Status(errShift.StatusCode).JSON(fiber.Map{
+			"status_code": errShift.StatusCode,
+			"message":     "failed to fetch shift",
+			"error":       errShift.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data shift",
+		"data":        shift,
+	})
+}
+
+func (h *ShiftHandler) OpenShift(c *fiber.Ctx) error {
+	var req dto.OpenShiftRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data shift",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.RegisterID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "register_id is required",
+			"error":       nil,
+		})
+	}
+
+	shift, errShift := h.service.OpenShift(auth.GetCaller(c), req)
+	if errShift != nil {
+		if errShift.StatusCode == fiber.StatusConflict {
+			return c.Status(errShift.StatusCode).JSON(fiber.Map{
+				"status_code": errShift.StatusCode,
+				"message":     "failed to open shift",
+				"error":       errShift.Message,
+			})
+		}
+
+		return c.Status(errShift.StatusCode).JSON(fiber.Map{
+			"status_code": errShift.StatusCode,
+			"message":     "failed to open shift",
+			"error":       errShift.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success open shift",
+		"data":        shift,
+	})
+}
+
+func (h *ShiftHandler) AddCashMovement(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.CashMovementRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data cash movement",
+			"error":       err.Error(),
+		})
+	}
+
+	shift, errShift := h.service.AddCashMovement(auth.GetCaller(c), parseID, req)
+	if errShift != nil {
+		if errShift.StatusCode == fiber.StatusConflict {
+			return c.Status(errShift.StatusCode).JSON(fiber.Map{
+				"status_code": errShift.StatusCode,
+				"message":     "failed to record cash movement",
+				"error":       errShift.Message,
+			})
+		}
+
+		return c.Status(errShift.StatusCode).JSON(fiber.Map{
+			"status_code": errShift.StatusCode,
+			"message":     "failed to record cash movement",
+			"error":       errShift.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success record cash movement",
+		"data":        shift,
+	})
+}
+
+func (h *ShiftHandler) CloseShift(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.CloseShiftRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data shift",
+			"error":       err.Error(),
+		})
+	}
+
+	report, errClose := h.service.CloseShift(auth.GetCaller(c), parseID, req)
+	if errClose != nil {
+		if errClose.StatusCode == fiber.StatusConflict {
+			return c.Status(errClose.StatusCode).JSON(fiber.Map{
+				"status_code": errClose.StatusCode,
+				"message":     "failed to close shift",
+				"error":       errClose.Message,
+			})
+		}
+
+		return c.Status(errClose.StatusCode).JSON(fiber.Map{
+			"status_code": errClose.StatusCode,
+			"message":     "failed to close shift",
+			"error":       errClose.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success close shift",
+		"data":        report,
+	})
+}
+
+func (h *ShiftHandler) GetZReport(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	report, errReport := h.service.GetZReport(auth.GetCaller(c), parseID)
+	if errReport != nil {
+		return c.Status(errReport.StatusCode).JSON(fiber.Map{
+			"status_code": errReport.StatusCode,
+			"message":     "failed to fetch shift report",
+			"error":       errReport.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get shift report",
+		"data":        report,
+	})
+}
@@ -0,0 +1,449 @@
+package shift
+
+import (
+	saleEntity "candyshop/internal/sale/entity"
+	entity "candyshop/internal/shift/entity"
+	"candyshop/pkg/payment"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type ShiftRepository interface {
+	GetAllRegister(storeIDs []uuid.UUID) ([]entity.Register, *response.Error)
+	GetRegisterByID(id uuid.UUID) (*entity.Register, *response.Error)
+	CreateRegister(data entity.Register) (*entity.Register, *response.Error)
+	DeleteRegister(id uuid.UUID, deletedAt time.Time) *response.Error
+	GetAllShift(storeIDs []uuid.UUID, offset, limit int) ([]entity.Shift, *response.Error)
+	GetShiftByID(id uuid.UUID) (*entity.Shift, *response.Error)
+	OpenShift(data entity.Shift) *response.Error
+	AddCashMovement(data entity.CashMovement) *response.Error
+	CloseShift(data entity.Shift) (*entity.Shift, *response.Error)
+	GetCashSummary(id uuid.UUID) (*entity.CashSummary, *response.Error)
+	GetZReport(id uuid.UUID) (*entity.ZReport, *response.Error)
+}
+
+type shiftRepository struct {
+	db *sqlx.DB
+}
+
+const shiftColumns = `id, register_id, store_id, status, opened_by, closed_by, opening_float, expected_cash, counted_cash, variance, note, opened_at, closed_at`
+
+// GetAllRegister implements ShiftRepository.
+func (s *shiftRepository) GetAllRegister(storeIDs []uuid.UUID) ([]entity.Register, *response.Error) {
+	var registers []entity.Register
+
+	// nil store ids means the caller is not limited to some stores
+	query := `
+		SELECT id, store_id, name, created_at, updated_at, deleted_at
+		FROM registers
+		WHERE deleted_at IS NULL AND ($1::uuid[] IS NULL OR store_id = ANY($1))
+		ORDER BY store_id, name
+	`
+
+	err := s.db.Select(&registers, query, pq.Array(storeIDs))
+	if err != nil {
+		return nil, shiftError("get all register", err)
+	}
+
+	return registers, nil
+}
+
+// GetRegisterByID implements ShiftRepository.
+func (s *shiftRepository) GetRegisterByID(id uuid.UUID) (*entity.Register, *response.Error) {
+	var register entity.Register
+
+	err := s.db.Get(&register, `SELECT id, store_id, name, created_at, updated_at, deleted_at FROM registers WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch register",
+				Error:      err,
+			}
+		}
+
+		return nil, shiftError("get register by id", err)
+	}
+
+	return &register, nil
+}
+
+// CreateRegister implements ShiftRepository.
+func (s *shiftRepository) CreateRegister(data entity.Register) (*entity.Register, *response.Error) {
+	var model entity.Register
+
+	query := `
+		INSERT INTO registers (id, store_id, name) VALUES ($1, $2, $3)
+		RETURNING id, store_id, name, created_at
+	`
+
+	err := s.db.QueryRowx(query, data.ID, data.StoreID, data.Name).StructScan(&model)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, &response.Error{
+				StatusCode: 409,
+				Message:    "register name already exists in the store",
+				Error:      nil,
+			}
+		}
+
+		return nil, shiftError("create register", err)
+	}
+
+	return &model, nil
+}
+
+// DeleteRegister implements ShiftRepository.
+func (s *shiftRepository) DeleteRegister(id uuid.UUID, deletedAt time.Time) *response.Error {
+	// a register with an open shift has cash in its drawer
+	result, err := s.db.Exec(`
+		UPDATE registers SET deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM shifts WHERE register_id = $1 AND status = $3)
+	`, id, deletedAt, entity.StatusOpen)
+	if err != nil {
+		return shiftError("delete register", err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &response.Error{
+			StatusCode: 409,
+			Message:    "register is deleted or has an open shift",
+			Error:      nil,
+		}
+	}
+
+	return nil
+}
+
+// GetAllShift implements ShiftRepository.
+func (s *shiftRepository) GetAllShift(storeIDs []uuid.UUID, offset int, limit int) ([]entity.Shift, *response.Error) {
+	var shifts []entity.Shift
+
+	query := `
+		SELECT ` + shiftColumns + `
+		FROM shifts
+		WHERE ($3::uuid[] IS NULL OR store_id = ANY($3))
+		ORDER BY opened_at DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := s.db.Select(&shifts, query, limit, offset, pq.Array(storeIDs))
+	if err != nil {
+		return nil, shiftError("get all shift", err)
+	}
+
+	return shifts, nil
+}
+
+// GetShiftByID implements ShiftRepository.
+func (s *shiftRepository) GetShiftByID(id uuid.UUID) (*entity.Shift, *response.Error) {
+	var shift entity.Shift
+
+	err := s.db.Get(&shift, `SELECT `+shiftColumns+` FROM shifts WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch shift",
+				Error:      err,
+			}
+		}
+
+		return nil, shiftError("get shift by id", err)
+	}
+
+	errMovements := s.db.Select(&shift.CashMovements, `
+		SELECT id, shift_id, type, amount, reason, created_by, created_at
+		FROM shift_cash_movements
+		WHERE shift_id = $1
+		ORDER BY created_at, id
+	`, id)
+	if errMovements != nil {
+		return nil, shiftError("get shift cash movements", errMovements)
+	}
+
+	return &shift, nil
+}
+
+// OpenShift implements ShiftRepository.
+func (s *shiftRepository) OpenShift(data entity.Shift) *response.Error {
+	_, err := s.db.Exec(`
+		INSERT INTO shifts (id, register_id, store_id, status, opened_by, opening_float, opened_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, data.ID, data.RegisterID, data.StoreID, data.Status, data.OpenedBy, data.OpeningFloat, data.OpenedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return &response.Error{
+				StatusCode: 409,
+				Message:    "register already has an open shift",
+				Error:      nil,
+			}
+		}
+
+		return shiftError("open shift", err)
+	}
+
+	return nil
+}
+
+// AddCashMovement implements ShiftRepository.
+func (s *shiftRepository) AddCashMovement(data entity.CashMovement) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "add cash movement").Msg("failed to add cash movement")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	if _, errLock := lockShift(tx, data.ShiftID, "FOR SHARE"); errLock != nil {
+		return errLock
+	}
+
+	_, errInsert := tx.Exec(`
+		INSERT INTO shift_cash_movements (id, shift_id, type, amount, reason, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, data.ID, data.ShiftID, data.Type, data.Amount, data.Reason, data.CreatedBy, data.CreatedAt)
+	if errInsert != nil {
+		return shiftError("add cash movement", errInsert)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "add cash movement").Msg("failed to add cash movement")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// CloseShift implements ShiftRepository.
+func (s *shiftRepository) CloseShift(data entity.Shift) (*entity.Shift, *response.Error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "close shift").Msg("failed to close shift")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the update lock waits for sales and refunds still being recorded on the shift
+	shift, errLock := lockShift(tx, data.ID, "FOR UPDATE")
+	if errLock != nil {
+		return nil, errLock
+	}
+
+	summary, errSummary := cashSummary(tx, data.ID)
+	if errSummary != nil {
+		return nil, errSummary
+	}
+
+	expected := ExpectedCash(shift.OpeningFloat, *summary)
+	variance := *data.CountedCash - expected
+
+	var model entity.Shift
+
+	errUpdate := tx.QueryRowx(`
+		UPDATE shifts SET status = $2, closed_by = $3, expected_cash = $4, counted_cash = $5, variance = $6, note = $7, closed_at = $8
+		WHERE id = $1
+		RETURNING `+shiftColumns,
+		data.ID, entity.StatusClosed, data.ClosedBy, expected, data.CountedCash, variance, data.Note, data.ClosedAt).StructScan(&model)
+	if errUpdate != nil {
+		return nil, shiftError("close shift", errUpdate)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "close shift").Msg("failed to close shift")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// GetCashSummary implements ShiftRepository.
+func (s *shiftRepository) GetCashSummary(id uuid.UUID) (*entity.CashSummary, *response.Error) {
+	return cashSummary(s.db, id)
+}
+
+// GetZReport implements ShiftRepository.
+func (s *shiftRepository) GetZReport(id uuid.UUID) (*entity.ZReport, *response.Error) {
+	var report entity.ZReport
+
+	errSales := s.db.Get(&report, `
+		SELECT
+			(SELECT COUNT(*) FROM sales WHERE shift_id = $1) AS sales_count,
+			(SELECT COALESCE(SUM(si.quantity), 0) FROM sale_items si JOIN sales sa ON sa.id = si.sale_id WHERE sa.shift_id = $1) AS items_sold,
+			(SELECT COALESCE(SUM(subtotal), 0) FROM sales WHERE shift_id = $1) AS gross_sales,
+			(SELECT COALESCE(SUM(discount), 0) FROM sales WHERE shift_id = $1) AS discounts,
+			(SELECT COALESCE(SUM(total), 0) FROM sales WHERE shift_id = $1) AS net_sales,
+			(SELECT COUNT(*) FROM sale_returns WHERE shift_id = $1) AS returns_count,
+			(SELECT COALESCE(SUM(refund_amount), 0) FROM sale_returns WHERE shift_id = $1) AS refund_total
+	`, id)
+	if errSales != nil {
+		return nil, shiftError("get z report", errSales)
+	}
+
+	// payments refunded later were still taken, the refunds are listed on their own
+	errPayments := s.db.Select(&report.Payments, `
+		SELECT p.method, COUNT(*) AS count, SUM(p.amount) AS amount
+		FROM sale_payments p
+		JOIN sales sa ON sa.id = p.sale_id
+		WHERE sa.shift_id = $1 AND p.status IN ($2, $3)
+		GROUP BY p.method
+		ORDER BY p.method
+	`, id, payment.StatusCaptured, payment.StatusRefunded)
+	if errPayments != nil {
+		return nil, shiftError("get z report payments", errPayments)
+	}
+
+	errRefunds := s.db.Select(&report.Refunds, `
+		SELECT rf.method, COUNT(*) AS count, SUM(rf.amount) AS amount
+		FROM sale_return_refunds rf
+		JOIN sale_returns r ON r.id = rf.return_id
+		WHERE r.shift_id = $1
+		GROUP BY rf.method
+		ORDER BY rf.method
+	`, id)
+	if errRefunds != nil {
+		return nil, shiftError("get z report refunds", errRefunds)
+	}
+
+	summary, errSummary := cashSummary(s.db, id)
+	if errSummary != nil {
+		return nil, errSummary
+	}
+
+	report.Cash = *summary
+
+	return &report, nil
+}
+
+// ExpectedCash is what the drawer should hold: the opening float, cash put in and taken out, cash sales and cash refunds.
+func ExpectedCash(openingFloat int64, summary entity.CashSummary) int64 {
+	return openingFloat + summary.CashIn - summary.CashOut + summary.CashSales - summary.CashRefunds
+}
+
+// LockOpenShift returns the open shift a sale or a refund in the store is recorded on and locks it until the transaction
+// ends, so the shift can't close in between. Without a shift id it is the shift the user opened in the store,
+// nil when the user has no open shift there.
+func LockOpenShift(tx *sqlx.Tx, storeID uuid.UUID, shiftID *uuid.UUID, userID uuid.UUID) (*uuid.UUID, *response.Error) {
+	if shiftID == nil {
+		var ids []uuid.UUID
+
+		errOpen := tx.Select(&ids, `
+			SELECT id FROM shifts
+			WHERE store_id = $1 AND opened_by = $2 AND status = $3
+			ORDER BY opened_at DESC
+			LIMIT 1
+			FOR SHARE
+		`, storeID, userID, entity.StatusOpen)
+		if errOpen != nil {
+			return nil, shiftError("find open shift", errOpen)
+		}
+
+		if len(ids) == 0 {
+			return nil, nil
+		}
+
+		return &ids[0], nil
+	}
+
+	shift, errLock := lockShift(tx, *shiftID, "FOR SHARE")
+	if errLock != nil {
+		return nil, errLock
+	}
+
+	if shift.StoreID != storeID {
+		return nil, &response.Error{
+			StatusCode: 409,
+			Message:    "shift belongs to another store",
+			Error:      nil,
+		}
+	}
+
+	return &shift.ID, nil
+}
+
+// lockShift locks a shift and fails when it is not open.
+func lockShift(tx *sqlx.Tx, id uuid.UUID, lock string) (*entity.Shift, *response.Error) {
+	var shift entity.Shift
+
+	err := tx.Get(&shift, `SELECT `+shiftColumns+` FROM shifts WHERE id = $1 `+lock, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "shift not found",
+				Error:      err,
+			}
+		}
+
+		return nil, shiftError("lock shift", err)
+	}
+
+	if shift.Status != entity.StatusOpen {
+		return nil, &response.Error{
+			StatusCode: 409,
+			Message:    "shift is closed",
+			Error:      nil,
+		}
+	}
+
+	return &shift, nil
+}
+
+func cashSummary(q sqlx.Queryer, id uuid.UUID) (*entity.CashSummary, *response.Error) {
+	var summary entity.CashSummary
+
+	err := sqlx.Get(q, &summary, `
+		SELECT
+			(SELECT COALESCE(SUM(amount), 0) FROM shift_cash_movements WHERE shift_id = $1 AND type = $2) AS cash_in,
+			(SELECT COALESCE(SUM(amount), 0) FROM shift_cash_movements WHERE shift_id = $1 AND type = $3) AS cash_out,
+			(SELECT COALESCE(SUM(p.amount), 0) FROM sale_payments p JOIN sales sa ON sa.id = p.sale_id
+				WHERE sa.shift_id = $1 AND p.method = $4 AND p.status IN ($5, $6)) AS cash_sales,
+			(SELECT COALESCE(SUM(rf.amount), 0) FROM sale_return_refunds rf JOIN sale_returns r ON r.id = rf.return_id
+				WHERE r.shift_id = $1 AND rf.method = $4) AS cash_refunds
+	`, id, entity.CashIn, entity.CashOut, saleEntity.PaymentCash, payment.StatusCaptured, payment.StatusRefunded)
+	if err != nil {
+		return nil, shiftError("get cash summary", err)
+	}
+
+	return &summary, nil
+}
+
+func shiftError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewShiftRepository(db *sqlx.DB) ShiftRepository {
+	return &shiftRepository{db}
+}
@@ -0,0 +1,32 @@
+package shift
+
+import (
+	handler "candyshop/internal/shift/handler"
+	repository "candyshop/internal/shift/repository"
+	service "candyshop/internal/shift/service"
+	staff "candyshop/internal/staff"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewShiftRepository(db)
+	service := service.NewShiftService(repo)
+	handler := handler.NewShiftHandler(service)
+
+	registerRoute := router.Group("api/v1/registers", staff.Authenticate(db))
+
+	registerRoute.Get("", handler.GetAllRegister)
+	registerRoute.Post("", handler.CreateRegister)
+	registerRoute.Patch("/delete/:id", handler.DeleteRegister)
+
+	shiftRoute := router.Group("api/v1/shifts", staff.Authenticate(db))
+
+	shiftRoute.Get("", handler.GetAllShift)
+	shiftRoute.Post("", handler.OpenShift)
+	shiftRoute.Get("/:id", handler.GetShiftByID)
+	shiftRoute.Get("/:id/report", handler.GetZReport)
+	shiftRoute.Post("/:id/cash", handler.AddCashMovement)
+	shiftRoute.Patch("/:id/close", handler.CloseShift)
+}
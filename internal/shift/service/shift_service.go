@@ -0,0 +1,284 @@
+package shift
+
+import (
+	dto "candyshop/internal/shift/dto"
+	entity "candyshop/internal/shift/entity"
+	repository "candyshop/internal/shift/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShiftService interface {
+	GetAllRegister(caller *auth.Caller, storeID *uuid.UUID) ([]entity.Register, *response.Error)
+	CreateRegister(caller *auth.Caller, data dto.CreateRegisterRequest) (*entity.Register, *response.Error)
+	DeleteRegister(caller *auth.Caller, id uuid.UUID) *response.Error
+	GetAllShift(caller *auth.Caller, storeID *uuid.UUID, offset, limit int) ([]entity.Shift, *response.Error)
+	GetShiftByID(caller *auth.Caller, id uuid.UUID) (*entity.Shift, *response.Error)
+	OpenShift(caller *auth.Caller, data dto.OpenShiftRequest) (*entity.Shift, *response.Error)
+	AddCashMovement(caller *auth.Caller, id uuid.UUID, data dto.CashMovementRequest) (*entity.Shift, *response.Error)
+	CloseShift(caller *auth.Caller, id uuid.UUID, data dto.CloseShiftRequest) (*entity.ZReport, *response.Error)
+	GetZReport(caller *auth.Caller, id uuid.UUID) (*entity.ZReport, *response.Error)
+}
+
+type shiftService struct {
+	repository repository.ShiftRepository
+}
+
+// GetAllRegister implements ShiftService.
+func (s *shiftService) GetAllRegister(caller *auth.Caller, storeID *uuid.UUID) ([]entity.Register, *response.Error) {
+	storeIDs := caller.StoreScope()
+	if storeID != nil {
+		if !caller.CanAccessStore(*storeID) {
+			return nil, auth.ForbiddenStore(*storeID)
+		}
+
+		storeIDs = []uuid.UUID{*storeID}
+	}
+
+	return s.repository.GetAllRegister(storeIDs)
+}
+
+// CreateRegister implements ShiftService.
+func (s *shiftService) CreateRegister(caller *auth.Caller, data dto.CreateRegisterRequest) (*entity.Register, *response.Error) {
+	if !caller.CanManageStore(data.StoreID) {
+		return nil, auth.ForbiddenStore(data.StoreID)
+	}
+
+	name := strings.TrimSpace(data.Name)
+	if name == "" || len(name) > 100 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "name is required and can not be longer than 100 characters",
+			Error:      errors.New("name is required and can not be longer than 100 characters"),
+		}
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	return s.repository.CreateRegister(entity.Register{
+		ID:      newUUID,
+		StoreID: data.StoreID,
+		Name:    name,
+	})
+}
+
+// DeleteRegister implements ShiftService.
+func (s *shiftService) DeleteRegister(caller *auth.Caller, id uuid.UUID) *response.Error {
+	register, errRegister := s.repository.GetRegisterByID(id)
+	if errRegister != nil {
+		return errRegister
+	}
+
+	if !caller.CanManageStore(register.StoreID) {
+		return auth.ForbiddenStore(register.StoreID)
+	}
+
+	return s.repository.DeleteRegister(id, time.Now())
+}
+
+// GetAllShift implements ShiftService.
+func (s *shiftService) GetAllShift(caller *auth.Caller, storeID *uuid.UUID, offset int, limit int) ([]entity.Shift, *response.Error) {
+	storeIDs := caller.StoreScope()
+	if storeID != nil {
+		if !caller.CanAccessStore(*storeID) {
+			return nil, auth.ForbiddenStore(*storeID)
+		}
+
+		storeIDs = []uuid.UUID{*storeID}
+	}
+
+	return s.repository.GetAllShift(storeIDs, offset, limit)
+}
+
+// GetShiftByID implements ShiftService.
+func (s *shiftService) GetShiftByID(caller *auth.Caller, id uuid.UUID) (*entity.Shift, *response.Error) {
+	shift, errShift := s.repository.GetShiftByID(id)
+	if errShift != nil {
+		return nil, errShift
+	}
+
+	if !caller.CanAccessStore(shift.StoreID) {
+		return nil, auth.ForbiddenStore(shift.StoreID)
+	}
+
+	return shift, nil
+}
+
+// OpenShift implements ShiftService.
+func (s *shiftService) OpenShift(caller *auth.Caller, data dto.OpenShiftRequest) (*entity.Shift, *response.Error) {
+	if data.OpeningFloat < 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "opening_float can not be negative",
+			Error:      errors.New("opening_float can not be negative"),
+		}
+	}
+
+	register, errRegister := s.repository.GetRegisterByID(data.RegisterID)
+	if errRegister != nil {
+		return nil, errRegister
+	}
+
+	if !caller.CanAccessStore(register.StoreID) {
+		return nil, auth.ForbiddenStore(register.StoreID)
+	}
+
+	if register.DeletedAt != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "register is not active",
+			Error:      nil,
+		}
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	errOpen := s.repository.OpenShift(entity.Shift{
+		ID:           newUUID,
+		RegisterID:   register.ID,
+		StoreID:      register.StoreID,
+		Status:       entity.StatusOpen,
+		OpenedBy:     caller.UserID,
+		OpeningFloat: data.OpeningFloat,
+		OpenedAt:     time.Now(),
+	})
+	if errOpen != nil {
+		return nil, errOpen
+	}
+
+	return s.repository.GetShiftByID(newUUID)
+}
+
+// AddCashMovement implements ShiftService.
+func (s *shiftService) AddCashMovement(caller *auth.Caller, id uuid.UUID, data dto.CashMovementRequest) (*entity.Shift, *response.Error) {
+	invalid := func(message string) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      errors.New(message),
+		}
+	}
+
+	if data.Type != entity.CashIn && data.Type != entity.CashOut {
+		return nil, invalid("type must be cash_in or cash_out")
+	}
+
+	if data.Amount <= 0 {
+		return nil, invalid("amount must be greater than zero")
+	}
+
+	reason := strings.TrimSpace(data.Reason)
+	if len(reason) > 255 {
+		return nil, invalid("reason can not be longer than 255 characters")
+	}
+
+	shift, errShift := s.GetShiftByID(caller, id)
+	if errShift != nil {
+		return nil, errShift
+	}
+
+	if errAccess := canWorkShift(caller, shift); errAccess != nil {
+		return nil, errAccess
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	errMovement := s.repository.AddCashMovement(entity.CashMovement{
+		ID:        newUUID,
+		ShiftID:   id,
+		Type:      data.Type,
+		Amount:    data.Amount,
+		Reason:    reason,
+		CreatedBy: caller.UserID,
+		CreatedAt: time.Now(),
+	})
+	if errMovement != nil {
+		return nil, errMovement
+	}
+
+	return s.repository.GetShiftByID(id)
+}
+
+// CloseShift implements ShiftService.
+func (s *shiftService) CloseShift(caller *auth.Caller, id uuid.UUID, data dto.CloseShiftRequest) (*entity.ZReport, *response.Error) {
+	if data.CountedCash == nil || *data.CountedCash < 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "counted_cash is required and can not be negative",
+			Error:      errors.New("counted_cash is required and can not be negative"),
+		}
+	}
+
+	shift, errShift := s.GetShiftByID(caller, id)
+	if errShift != nil {
+		return nil, errShift
+	}
+
+	if errAccess := canWorkShift(caller, shift); errAccess != nil {
+		return nil, errAccess
+	}
+
+	currentTime := time.Now()
+
+	_, errClose := s.repository.CloseShift(entity.Shift{
+		ID:          id,
+		ClosedBy:    &caller.UserID,
+		CountedCash: data.CountedCash,
+		Note:        strings.TrimSpace(data.Note),
+		ClosedAt:    &currentTime,
+	})
+	if errClose != nil {
+		return nil, errClose
+	}
+
+	return s.GetZReport(caller, id)
+}
+
+// GetZReport implements ShiftService.
+func (s *shiftService) GetZReport(caller *auth.Caller, id uuid.UUID) (*entity.ZReport, *response.Error) {
+	shift, errShift := s.GetShiftByID(caller, id)
+	if errShift != nil {
+		return nil, errShift
+	}
+
+	report, errReport := s.repository.GetZReport(id)
+	if errReport != nil {
+		return nil, errReport
+	}
+
+	report.Shift = *shift
+	report.CountedCash = shift.CountedCash
+	report.Variance = shift.Variance
+
+	// a closed shift keeps the expected cash it was closed with
+	if shift.ExpectedCash != nil {
+		report.ExpectedCash = *shift.ExpectedCash
+	} else {
+		report.ExpectedCash = repository.ExpectedCash(shift.OpeningFloat, report.Cash)
+	}
+
+	return report, nil
+}
+
+// canWorkShift lets the cashier who opened the shift and the managers of the store handle its drawer.
+func canWorkShift(caller *auth.Caller, shift *entity.Shift) *response.Error {
+	if shift.OpenedBy == caller.UserID || caller.CanManageStore(shift.StoreID) {
+		return nil
+	}
+
+	return &response.Error{
+		StatusCode: fiber.StatusForbidden,
+		Message:    "only the cashier of the shift or a store manager can handle its cash",
+		Error:      errors.New("only the cashier of the shift or a store manager can handle its cash"),
+	}
+}
+
+func NewShiftService(repository repository.ShiftRepository) ShiftService {
+	return &shiftService{repository}
+}
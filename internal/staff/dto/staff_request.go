@@ -0,0 +1,9 @@
+package staff
+
+import "github.com/google/uuid"
+
+type AssignStaffRequest struct {
+	StoreID uuid.UUID `json:"store_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Role    string    `json:"role"`
+}
@@ -0,0 +1,20 @@
+package staff
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Staff assigns a user to a store with a role inside that store.
+type Staff struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	StoreID   uuid.UUID  `json:"store_id" db:"store_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	UserName  string     `json:"user_name" db:"user_name"`
+	UserEmail string     `json:"user_email" db:"user_email"`
+	Role      string     `json:"role" db:"role"`
+	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"-" db:"updated_at"`
+	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+}
@@ -0,0 +1,173 @@
+package staff
+
+import (
+	dto "candyshop/internal/staff/dto"
+	service "candyshop/internal/staff/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type StaffHandler struct {
+	service service.StaffService
+}
+
+func NewStaffHandler(service service.StaffService) *StaffHandler {
+	return &StaffHandler{service}
+}
+
+// Authenticate loads the calling user and the stores assigned to the user.
+func (h *StaffHandler) Authenticate(c *fiber.Ctx) error {
+	userID, errParse := uuid.Parse(c.Get(auth.HeaderUserID))
+	if errParse != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status_code": fiber.StatusUnauthorized,
+			"message":     "user is not authenticated",
+			"error":       "header " + auth.HeaderUserID + " is invalid",
+		})
+	}
+
+	caller, errCaller := h.service.GetCaller(userID)
+	if errCaller != nil {
+		if errCaller.StatusCode == fiber.StatusNotFound {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status_code": fiber.StatusUnauthorized,
+				"message":     "user is not authenticated",
+				"error":       errCaller.Message,
+			})
+		}
+
+		return c.Status(errCaller.StatusCode).JSON(fiber.Map{
+			"status_code": errCaller.StatusCode,
+			"message":     "failed to authenticate user",
+			"error":       errCaller.Error.Error(),
+		})
+	}
+
+	auth.SetCaller(c, caller)
+
+	return c.Next()
+}
+
+func (h *StaffHandler) GetStaffByStore(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	storeID, errParse := uuid.Parse(c.Query("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	staff, errStaff := h.service.GetStaffByStore(auth.GetCaller(c), storeID, offset, limit)
+	if errStaff != nil {
+		return c.Status(errStaff.StatusCode).JSON(fiber.Map{
+			"status_code": errStaff.StatusCode,
+			"message":     "failed to fetch staff",
+			"error":       errStaff.Error.Error(),
+		})
+	}
+
+	if len(staff) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch staff",
+			"error":       "staff not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data staff",
+		"data":        staff,
+	})
+}
+
+func (h *StaffHandler) AssignStaff(c *fiber.Ctx) error {
+	var req dto.AssignStaffRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data staff",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil || req.UserID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id and user_id is required",
+			"error":       nil,
+		})
+	}
+
+	staff, errStaff := h.service.AssignStaff(auth.GetCaller(c), req)
+	if errStaff != nil {
+		if errStaff.StatusCode == fiber.StatusConflict {
+			return c.Status(errStaff.StatusCode).JSON(fiber.Map{
+				"status_code": errStaff.StatusCode,
+				"message":     "failed to assign staff",
+				"error":       errStaff.Message,
+			})
+		}
+
+		return c.Status(errStaff.StatusCode).JSON(fiber.Map{
+			"status_code": errStaff.StatusCode,
+			"message":     "failed to assign staff",
+			"error":       errStaff.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success assign staff",
+		"data":        staff,
+	})
+}
+
+func (h *StaffHandler) UnassignStaff(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errStaff := h.service.UnassignStaff(auth.GetCaller(c), parseID)
+	if errStaff != nil {
+		if errStaff.StatusCode == fiber.StatusConflict {
+			return c.Status(errStaff.StatusCode).JSON(fiber.Map{
+				"status_code": errStaff.StatusCode,
+				"message":     "failed to unassign staff",
+				"error":       errStaff.Message,
+			})
+		}
+
+		return c.Status(errStaff.StatusCode).JSON(fiber.Map{
+			"status_code": errStaff.StatusCode,
+			"message":     "failed to unassign staff",
+			"error":       errStaff.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success unassign staff",
+		"data":        nil,
+	})
+}
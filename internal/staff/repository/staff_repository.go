@@ -0,0 +1,275 @@
+package staff
+
+import (
+	entity "candyshop/internal/staff/entity"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+type StaffRepository interface {
+	GetStaffByStore(storeID uuid.UUID, offset, limit int) ([]entity.Staff, *response.Error)
+	GetStaffByID(id uuid.UUID) (*entity.Staff, *response.Error)
+	GetActiveStaff(storeID, userID uuid.UUID) (*entity.Staff, *response.Error)
+	CreateStaff(data entity.Staff) (*entity.Staff, *response.Error)
+	DeleteStaff(id uuid.UUID, deletedAt time.Time) *response.Error
+	GetCaller(userID uuid.UUID) (*auth.Caller, *response.Error)
+}
+
+type staffRepository struct {
+	db *sqlx.DB
+}
+
+// GetStaffByStore implements StaffRepository.
+func (s *staffRepository) GetStaffByStore(storeID uuid.UUID, offset int, limit int) ([]entity.Staff, *response.Error) {
+	var staff []entity.Staff
+
+	query := `
+		SELECT ss.id, ss.store_id, ss.user_id, u.name AS user_name, u.email AS user_email, ss.role, ss.created_at, ss.updated_at, ss.deleted_at
+		FROM store_staff ss
+		JOIN users u ON u.id = ss.user_id
+		WHERE ss.store_id = $1 AND ss.deleted_at IS NULL
+		ORDER BY ss.created_at
+		LIMIT $2 OFFSET $3
+	`
+
+	err := s.db.Select(&staff, query, storeID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get staff by store").Msg("failed to get staff by store")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch staff",
+			Error:      err,
+		}
+	}
+
+	return staff, nil
+}
+
+// GetStaffByID implements StaffRepository.
+func (s *staffRepository) GetStaffByID(id uuid.UUID) (*entity.Staff, *response.Error) {
+	var staff entity.Staff
+
+	query := `
+		SELECT ss.id, ss.store_id, ss.user_id, u.name AS user_name, u.email AS user_email, ss.role, ss.created_at, ss.updated_at, ss.deleted_at
+		FROM store_staff ss
+		JOIN users u ON u.id = ss.user_id
+		WHERE ss.id = $1
+	`
+
+	err := s.db.Get(&staff, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("status", 404).Str("function", "get staff by id").Msg("failed to get staff by id")
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch staff",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get staff by id").Msg("failed to get staff by id")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch staff",
+			Error:      err,
+		}
+	}
+
+	return &staff, nil
+}
+
+// GetActiveStaff implements StaffRepository.
+func (s *staffRepository) GetActiveStaff(storeID uuid.UUID, userID uuid.UUID) (*entity.Staff, *response.Error) {
+	var staff entity.Staff
+
+	query := `
+		SELECT id, store_id, user_id, role, created_at, updated_at, deleted_at
+		FROM store_staff
+		WHERE store_id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`
+
+	err := s.db.Get(&staff, query, storeID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch staff",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get active staff").Msg("failed to get active staff")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch staff",
+			Error:      err,
+		}
+	}
+
+	return &staff, nil
+}
+
+// CreateStaff implements StaffRepository.
+func (s *staffRepository) CreateStaff(data entity.Staff) (*entity.Staff, *response.Error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create staff").Msg("failed to create staff")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// only active stores and users can be assigned
+	query := `
+		INSERT INTO store_staff (id, store_id, user_id, role)
+		SELECT $1, $2, $3, $4
+		WHERE EXISTS (SELECT 1 FROM stores WHERE id = $2 AND deleted_at IS NULL)
+			AND EXISTS (SELECT 1 FROM users WHERE id = $3 AND deleted_at IS NULL AND status = true)
+		RETURNING id, store_id, user_id, role, created_at
+	`
+
+	var model entity.Staff
+
+	errInsert := tx.QueryRowx(query,
+		data.ID,
+		data.StoreID,
+		data.UserID,
+		data.Role).Scan(&model.ID,
+		&model.StoreID,
+		&model.UserID,
+		&model.Role,
+		&model.CreatedAt)
+
+	if errInsert != nil {
+		if errors.Is(errInsert, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "store or user not found",
+				Error:      errors.New("store or user not found"),
+			}
+		}
+
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "create staff").Msg("failed to create staff")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create staff",
+			Error:      errInsert,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create staff").Msg("failed to create staff")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// DeleteStaff implements StaffRepository.
+func (s *staffRepository) DeleteStaff(id uuid.UUID, deletedAt time.Time) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete staff").Msg("failed to delete staff")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE store_staff SET deleted_at = $2, updated_at = $2 WHERE id = $1`
+
+	_, errExec := tx.Exec(query, id, deletedAt)
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "delete staff").Msg("failed to delete staff")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete staff",
+			Error:      errExec,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete staff").Msg("failed to delete staff")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// GetCaller implements StaffRepository.
+func (s *staffRepository) GetCaller(userID uuid.UUID) (*auth.Caller, *response.Error) {
+	var role string
+
+	errUser := s.db.Get(&role, `SELECT role FROM users WHERE id = $1 AND deleted_at IS NULL AND status = true`, userID)
+	if errUser != nil {
+		if errors.Is(errUser, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "user not found",
+				Error:      errUser,
+			}
+		}
+
+		log.Error().Err(errUser).Int("status", 500).Str("function", "get caller").Msg("failed to get caller")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch user",
+			Error:      errUser,
+		}
+	}
+
+	var assignments []entity.Staff
+
+	errStaff := s.db.Select(&assignments, `
+		SELECT ss.store_id, ss.role
+		FROM store_staff ss
+		JOIN stores st ON st.id = ss.store_id
+		WHERE ss.user_id = $1 AND ss.deleted_at IS NULL AND st.deleted_at IS NULL
+	`, userID)
+	if errStaff != nil {
+		log.Error().Err(errStaff).Int("status", 500).Str("function", "get caller").Msg("failed to get caller")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch staff",
+			Error:      errStaff,
+		}
+	}
+
+	caller := &auth.Caller{
+		UserID: userID,
+		Role:   role,
+		Stores: map[uuid.UUID]string{},
+	}
+
+	for _, assignment := range assignments {
+		caller.Stores[assignment.StoreID] = assignment.Role
+	}
+
+	return caller, nil
+}
+
+func NewStaffRepository(db *sqlx.DB) StaffRepository {
+	return &staffRepository{db}
+}
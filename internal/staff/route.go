@@ -0,0 +1,32 @@
+package staff
+
+import (
+	handler "candyshop/internal/staff/handler"
+	repository "candyshop/internal/staff/repository"
+	service "candyshop/internal/staff/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	handler := newHandler(db)
+
+	staffRoute := router.Group("api/v1/staff", handler.Authenticate)
+
+	staffRoute.Get("", handler.GetStaffByStore)
+	staffRoute.Post("", handler.AssignStaff)
+	staffRoute.Patch("/delete/:id", handler.UnassignStaff)
+}
+
+// Authenticate returns the middleware that puts the caller and the caller's stores in the request context,
+// store scoped modules use it in front of their routes.
+func Authenticate(db *sqlx.DB) fiber.Handler {
+	return newHandler(db).Authenticate
+}
+
+func newHandler(db *sqlx.DB) *handler.StaffHandler {
+	repo := repository.NewStaffRepository(db)
+	service := service.NewStaffService(repo)
+	return handler.NewStaffHandler(service)
+}
@@ -0,0 +1,124 @@
+package staff
+
+import (
+	dto "candyshop/internal/staff/dto"
+	entity "candyshop/internal/staff/entity"
+	repository "candyshop/internal/staff/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type StaffService interface {
+	GetStaffByStore(caller *auth.Caller, storeID uuid.UUID, offset, limit int) ([]entity.Staff, *response.Error)
+	AssignStaff(caller *auth.Caller, data dto.AssignStaffRequest) (*entity.Staff, *response.Error)
+	UnassignStaff(caller *auth.Caller, id uuid.UUID) *response.Error
+	GetCaller(userID uuid.UUID) (*auth.Caller, *response.Error)
+}
+
+type staffService struct {
+	repository repository.StaffRepository
+}
+
+// GetStaffByStore implements StaffService.
+func (s *staffService) GetStaffByStore(caller *auth.Caller, storeID uuid.UUID, offset int, limit int) ([]entity.Staff, *response.Error) {
+	if !caller.CanAccessStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	return s.repository.GetStaffByStore(storeID, offset, limit)
+}
+
+// AssignStaff implements StaffService.
+func (s *staffService) AssignStaff(caller *auth.Caller, data dto.AssignStaffRequest) (*entity.Staff, *response.Error) {
+	if !caller.CanManageStore(data.StoreID) {
+		return nil, auth.ForbiddenStore(data.StoreID)
+	}
+
+	if !auth.IsValidStoreRole(data.Role) {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "role is invalid",
+			Error:      fmt.Errorf("role %s is invalid, use %s, %s or %s", data.Role, auth.StoreRoleManager, auth.StoreRoleCashier, auth.StoreRoleStaff),
+		}
+	}
+
+	// only owners can hand out the manager role
+	if data.Role == auth.StoreRoleManager && !caller.IsOwner() {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "only owner can assign manager",
+			Error:      errors.New("only owner can assign manager"),
+		}
+	}
+
+	checkStaff, errStaff := s.repository.GetActiveStaff(data.StoreID, data.UserID)
+	if errStaff != nil && errStaff.StatusCode != 404 {
+		return nil, errStaff
+	}
+
+	if checkStaff != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "user already assigned to store",
+			Error:      nil,
+		}
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataStaff := &entity.Staff{
+		ID:      newUUID,
+		StoreID: data.StoreID,
+		UserID:  data.UserID,
+		Role:    data.Role,
+	}
+
+	return s.repository.CreateStaff(*dataStaff)
+}
+
+// UnassignStaff implements StaffService.
+func (s *staffService) UnassignStaff(caller *auth.Caller, id uuid.UUID) *response.Error {
+	checkStaff, errStaff := s.repository.GetStaffByID(id)
+	if errStaff != nil {
+		return errStaff
+	}
+
+	if !caller.CanManageStore(checkStaff.StoreID) {
+		return auth.ForbiddenStore(checkStaff.StoreID)
+	}
+
+	if checkStaff.Role == auth.StoreRoleManager && !caller.IsOwner() {
+		return &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "only owner can unassign manager",
+			Error:      errors.New("only owner can unassign manager"),
+		}
+	}
+
+	if checkStaff.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "staff already unassigned",
+			Error:      nil,
+		}
+	}
+
+	currentTime := time.Now()
+
+	return s.repository.DeleteStaff(id, currentTime)
+}
+
+// GetCaller implements StaffService.
+func (s *staffService) GetCaller(userID uuid.UUID) (*auth.Caller, *response.Error) {
+	return s.repository.GetCaller(userID)
+}
+
+func NewStaffService(repository repository.StaffRepository) StaffService {
+	return &staffService{repository}
+}
@@ -0,0 +1,22 @@
+package stocktake
+
+import "github.com/google/uuid"
+
+// CreateStockTakeRequest starts a stock-take, without product ids every product stocked by the store is counted
+type CreateStockTakeRequest struct {
+	StoreID    uuid.UUID   `json:"store_id"`
+	Blind      bool        `json:"blind"`
+	Note       string      `json:"note"`
+	ProductIDs []uuid.UUID `json:"product_ids"`
+}
+
+type CountRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Location  string    `json:"location"`
+	Quantity  int       `json:"quantity"`
+}
+
+// ApproveStockTakeRequest posts the variances, uncounted products are left alone unless they count as zero
+type ApproveStockTakeRequest struct {
+	UncountedAsZero bool `json:"uncounted_as_zero"`
+}
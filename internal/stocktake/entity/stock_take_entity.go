@@ -0,0 +1,70 @@
+package stocktake
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// statuses of a stock-take, only an open stock-take accepts counts
+const (
+	StatusOpen      = "open"
+	StatusApproved  = "approved"
+	StatusCancelled = "cancelled"
+)
+
+// StockTake is a physical count of the products of a store.
+type StockTake struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	StoreID     uuid.UUID       `json:"store_id" db:"store_id"`
+	Status      string          `json:"status" db:"status"`
+	Blind       bool            `json:"blind" db:"blind"`
+	Note        string          `json:"note" db:"note"`
+	CreatedBy   *uuid.UUID      `json:"created_by" db:"created_by"`
+	ApprovedBy  *uuid.UUID      `json:"approved_by" db:"approved_by"`
+	CreatedAt   *time.Time      `json:"created_at" db:"created_at"`
+	ApprovedAt  *time.Time      `json:"approved_at" db:"approved_at"`
+	CancelledAt *time.Time      `json:"cancelled_at" db:"cancelled_at"`
+	Items       []StockTakeItem `json:"items,omitempty" db:"-"`
+}
+
+// StockTakeItem is a product of a stock-take. Counted quantity is the sum of the latest count of every location,
+// nil while nobody counted the product. Expected quantity and variance are nil when they are hidden from the caller.
+type StockTakeItem struct {
+	StockTakeID      uuid.UUID `json:"-" db:"stock_take_id"`
+	ProductID        uuid.UUID `json:"product_id" db:"product_id"`
+	ProductSKU       string    `json:"product_sku" db:"product_sku"`
+	ProductName      string    `json:"product_name" db:"product_name"`
+	ExpectedQuantity *int      `json:"expected_quantity" db:"expected_quantity"`
+	CountedQuantity  *int      `json:"counted_quantity" db:"counted_quantity"`
+	UnitPrice        int64     `json:"unit_price" db:"unit_price"`
+	AdjustedQuantity *int      `json:"adjusted_quantity" db:"adjusted_quantity"`
+	Variance         *int      `json:"variance" db:"-"`
+	VarianceValue    *int64    `json:"variance_value" db:"-"`
+	Counts           []Count   `json:"counts,omitempty" db:"-"`
+}
+
+// Count is what one staff counted of a product in one location.
+type Count struct {
+	StockTakeID uuid.UUID `json:"-" db:"stock_take_id"`
+	ProductID   uuid.UUID `json:"product_id" db:"product_id"`
+	Location    string    `json:"location" db:"location"`
+	CountedBy   uuid.UUID `json:"counted_by" db:"counted_by"`
+	Quantity    int       `json:"quantity" db:"quantity"`
+	CountedAt   time.Time `json:"counted_at" db:"counted_at"`
+}
+
+// VarianceReport sums the variances of a stock-take, values use the unit price of the snapshot.
+type VarianceReport struct {
+	StockTakeID       uuid.UUID       `json:"stock_take_id"`
+	StoreID           uuid.UUID       `json:"store_id"`
+	Status            string          `json:"status"`
+	CountedProducts   int             `json:"counted_products"`
+	UncountedProducts int             `json:"uncounted_products"`
+	ShortageQuantity  int             `json:"shortage_quantity"`
+	ShortageValue     int64           `json:"shortage_value"`
+	SurplusQuantity   int             `json:"surplus_quantity"`
+	SurplusValue      int64           `json:"surplus_value"`
+	NetValue          int64           `json:"net_value"`
+	Items             []StockTakeItem `json:"items"`
+}
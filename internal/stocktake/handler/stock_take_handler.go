@@ -0,0 +1,287 @@
+package stocktake
+
+import (
+	dto "candyshop/internal/stocktake/dto"
+	service "candyshop/internal/stocktake/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type StockTakeHandler struct {
+	service service.StockTakeService
+}
+
+func NewStockTakeHandler(service service.StockTakeService) *StockTakeHandler {
+	return &StockTakeHandler{service}
+}
+
+func (h *StockTakeHandler) GetAllStockTake(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	var storeID *uuid.UUID
+	if c.Query("store_id") != "" {
+		parseID, errParse := uuid.Parse(c.Query("store_id"))
+		if errParse != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "store_id is invalid",
+				"error":       errParse.Error(),
+			})
+		}
+
+		storeID = &parseID
+	}
+
+	stockTakes, errStockTake := h.service.GetAllStockTake(auth.GetCaller(c), storeID, offset, limit)
+	if errStockTake != nil {
+		return c.Status(errStockTake.StatusCode).JSON(fiber.Map{
+			"status_code": errStockTake.StatusCode,
+			"message":     "failed to fetch stock takes",
+			"error":       errStockTake.Error.Error(),
+		})
+	}
+
+	if len(stockTakes) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch stock takes",
+			"error":       "stock take not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data stock takes",
+		"data":        stockTakes,
+	})
+}
+
+func (h *StockTakeHandler) GetStockTakeByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	stockTake, errStockTake := h.service.GetStockTakeByID(auth.GetCaller(c), parseID)
+	if errStockTake != nil {
+		return c.Status(errStockTake.StatusCode).JSON(fiber.Map{
+			"status_code": errStockTake.StatusCode,
+			"message":     "failed to fetch stock take",
+			"error":       errStockTake.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data stock take",
+		"data":        stockTake,
+	})
+}
+
+func (h *StockTakeHandler) CreateStockTake(c *fiber.Ctx) error {
+	var req dto.CreateStockTakeRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data stock take",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.StoreID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is required",
+			"error":       nil,
+		})
+	}
+
+	stockTake, errStockTake := h.service.CreateStockTake(auth.GetCaller(c), req)
+	if errStockTake != nil {
+		if errStockTake.StatusCode == fiber.StatusConflict {
+			return c.Status(errStockTake.StatusCode).JSON(fiber.Map{
+				"status_code": errStockTake.StatusCode,
+				"message":     "failed to create stock take",
+				"error":       errStockTake.Message,
+			})
+		}
+
+		return c.Status(errStockTake.StatusCode).JSON(fiber.Map{
+			"status_code": errStockTake.StatusCode,
+			"message":     "failed to create stock take",
+			"error":       errStockTake.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data stock take",
+		"data":        stockTake,
+	})
+}
+
+func (h *StockTakeHandler) SaveCount(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.CountRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data count",
+			"error":       err.Error(),
+		})
+	}
+
+	stockTake, errCount := h.service.SaveCount(auth.GetCaller(c), parseID, req)
+	if errCount != nil {
+		if errCount.StatusCode == fiber.StatusConflict {
+			return c.Status(errCount.StatusCode).JSON(fiber.Map{
+				"status_code": errCount.StatusCode,
+				"message":     "failed to save count",
+				"error":       errCount.Message,
+			})
+		}
+
+		return c.Status(errCount.StatusCode).JSON(fiber.Map{
+			"status_code": errCount.StatusCode,
+			"message":     "failed to save count",
+			"error":       errCount.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success save count",
+		"data":        stockTake,
+	})
+}
+
+func (h *StockTakeHandler) ApproveStockTake(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.ApproveStockTakeRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status_code": fiber.StatusBadRequest,
+				"message":     "failed to input data stock take",
+				"error":       err.Error(),
+			})
+		}
+	}
+
+	report, errApprove := h.service.ApproveStockTake(auth.GetCaller(c), parseID, req)
+	if errApprove != nil {
+		if errApprove.StatusCode == fiber.StatusConflict {
+			return c.Status(errApprove.StatusCode).JSON(fiber.Map{
+				"status_code": errApprove.StatusCode,
+				"message":     "failed to approve stock take",
+				"error":       errApprove.Message,
+			})
+		}
+
+		return c.Status(errApprove.StatusCode).JSON(fiber.Map{
+			"status_code": errApprove.StatusCode,
+			"message":     "failed to approve stock take",
+			"error":       errApprove.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success approve stock take",
+		"data":        report,
+	})
+}
+
+func (h *StockTakeHandler) CancelStockTake(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errCancel := h.service.CancelStockTake(auth.GetCaller(c), parseID)
+	if errCancel != nil {
+		if errCancel.StatusCode == fiber.StatusConflict {
+			return c.Status(errCancel.StatusCode).JSON(fiber.Map{
+				"status_code": errCancel.StatusCode,
+				"message":     "failed to cancel stock take",
+				"error":       errCancel.Message,
+			})
+		}
+
+		return c.Status(errCancel.StatusCode).JSON(fiber.Map{
+			"status_code": errCancel.StatusCode,
+			"message":     "failed to cancel stock take",
+			"error":       errCancel.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success cancel stock take",
+		"data":        nil,
+	})
+}
+
+func (h *StockTakeHandler) GetVarianceReport(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	report, errReport := h.service.GetVarianceReport(auth.GetCaller(c), parseID)
+	if errReport != nil {
+		return c.Status(errReport.StatusCode).JSON(fiber.Map{
+			"status_code": errReport.StatusCode,
+			"message":     "failed to fetch variance report",
+			"error":       errReport.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get variance report",
+		"data":        report,
+	})
+}
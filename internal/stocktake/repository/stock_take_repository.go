@@ -0,0 +1,384 @@
+package stocktake
+
+import (
+	entity "candyshop/internal/stocktake/entity"
+	"candyshop/pkg/ledger"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type StockTakeRepository interface {
+	GetAllStockTake(storeIDs []uuid.UUID, offset, limit int) ([]entity.StockTake, *response.Error)
+	GetStockTakeByID(id uuid.UUID) (*entity.StockTake, *response.Error)
+	CreateStockTake(data entity.StockTake, productIDs []uuid.UUID) *response.Error
+	SaveCount(data entity.Count) *response.Error
+	ApproveStockTake(id, approvedBy uuid.UUID, uncountedAsZero bool, approvedAt time.Time) *response.Error
+	CancelStockTake(id uuid.UUID, cancelledAt time.Time) *response.Error
+}
+
+type stockTakeRepository struct {
+	db *sqlx.DB
+}
+
+const stockTakeColumns = `id, store_id, status, blind, note, created_by, approved_by, created_at, approved_at, cancelled_at`
+
+// itemQuery reads the items of a stock-take, the counted quantity adds up the latest count of every location
+const itemQuery = `
+	SELECT i.stock_take_id, i.product_id, p.sku AS product_sku, p.name AS product_name, i.expected_quantity,
+		c.quantity AS counted_quantity, i.unit_price, i.adjusted_quantity
+	FROM stock_take_items i
+	JOIN products p ON p.id = i.product_id
+	LEFT JOIN LATERAL (
+		SELECT SUM(latest.quantity) AS quantity
+		FROM (
+			SELECT DISTINCT ON (sc.location) sc.quantity
+			FROM stock_take_counts sc
+			WHERE sc.stock_take_id = i.stock_take_id AND sc.product_id = i.product_id
+			ORDER BY sc.location, sc.counted_at DESC
+		) latest
+	) c ON TRUE
+	WHERE i.stock_take_id = $1
+`
+
+// GetAllStockTake implements StockTakeRepository.
+func (s *stockTakeRepository) GetAllStockTake(storeIDs []uuid.UUID, offset int, limit int) ([]entity.StockTake, *response.Error) {
+	var stockTakes []entity.StockTake
+
+	// nil store ids means the caller is not limited to some stores
+	query := `
+		SELECT ` + stockTakeColumns + `
+		FROM stock_takes
+		WHERE ($3::uuid[] IS NULL OR store_id = ANY($3))
+		ORDER BY created_at DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := s.db.Select(&stockTakes, query, limit, offset, pq.Array(storeIDs))
+	if err != nil {
+		return nil, stockTakeError("get all stock take", err)
+	}
+
+	return stockTakes, nil
+}
+
+// GetStockTakeByID implements StockTakeRepository.
+func (s *stockTakeRepository) GetStockTakeByID(id uuid.UUID) (*entity.StockTake, *response.Error) {
+	var stockTake entity.StockTake
+
+	err := s.db.Get(&stockTake, `SELECT `+stockTakeColumns+` FROM stock_takes WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch stock take",
+				Error:      err,
+			}
+		}
+
+		return nil, stockTakeError("get stock take by id", err)
+	}
+
+	errItems := s.db.Select(&stockTake.Items, itemQuery+` ORDER BY p.name, p.id`, id)
+	if errItems != nil {
+		return nil, stockTakeError("get stock take items", errItems)
+	}
+
+	var counts []entity.Count
+
+	errCounts := s.db.Select(&counts, `
+		SELECT stock_take_id, product_id, location, counted_by, quantity, counted_at
+		FROM stock_take_counts
+		WHERE stock_take_id = $1
+		ORDER BY location, counted_at
+	`, id)
+	if errCounts != nil {
+		return nil, stockTakeError("get stock take counts", errCounts)
+	}
+
+	itemIndex := map[uuid.UUID]int{}
+	for i, item := range stockTake.Items {
+		itemIndex[item.ProductID] = i
+	}
+
+	for _, count := range counts {
+		i := itemIndex[count.ProductID]
+		stockTake.Items[i].Counts = append(stockTake.Items[i].Counts, count)
+	}
+
+	return &stockTake, nil
+}
+
+// CreateStockTake implements StockTakeRepository.
+func (s *stockTakeRepository) CreateStockTake(data entity.StockTake, productIDs []uuid.UUID) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create stock take").Msg("failed to create stock take")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	_, errInsert := tx.Exec(`INSERT INTO stock_takes (id, store_id, status, blind, note, created_by) VALUES ($1, $2, $3, $4, $5, $6)`,
+		data.ID, data.StoreID, data.Status, data.Blind, data.Note, data.CreatedBy)
+	if errInsert != nil {
+		var pqErr *pq.Error
+		if errors.As(errInsert, &pqErr) && pqErr.Code == "23505" {
+			return &response.Error{
+				StatusCode: 409,
+				Message:    "store already has an open stock take",
+				Error:      nil,
+			}
+		}
+
+		return stockTakeError("create stock take", errInsert)
+	}
+
+	// without product ids the snapshot is every product the store has an inventory of,
+	// listed products the store never stocked are expected at zero
+	_, errSnapshot := tx.Exec(`
+		INSERT INTO stock_take_items (stock_take_id, product_id, expected_quantity, unit_price)
+		SELECT $1, p.id, COALESCE(i.quantity, 0), p.price
+		FROM products p
+		LEFT JOIN inventories i ON i.product_id = p.id AND i.store_id = $2
+		WHERE p.deleted_at IS NULL AND (($3::uuid[] IS NULL AND i.store_id IS NOT NULL) OR p.id = ANY($3))
+	`, data.ID, data.StoreID, pq.Array(productIDs))
+	if errSnapshot != nil {
+		return stockTakeError("snapshot stock take", errSnapshot)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create stock take").Msg("failed to create stock take")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// SaveCount implements StockTakeRepository.
+func (s *stockTakeRepository) SaveCount(data entity.Count) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "save count").Msg("failed to save count")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the share lock lets staff count at the same time but waits for an approval in progress
+	storeID, errLock := lockOpenStockTake(tx, data.StockTakeID, "FOR SHARE")
+	if errLock != nil {
+		return errLock
+	}
+
+	// a product found during the count joins the stock-take with the stock the store expects right now
+	_, errItem := tx.Exec(`
+		INSERT INTO stock_take_items (stock_take_id, product_id, expected_quantity, unit_price)
+		SELECT $1, p.id, COALESCE(i.quantity, 0), p.price
+		FROM products p
+		LEFT JOIN inventories i ON i.product_id = p.id AND i.store_id = $3
+		WHERE p.id = $2 AND p.deleted_at IS NULL
+		ON CONFLICT DO NOTHING
+	`, data.StockTakeID, data.ProductID, storeID)
+	if errItem != nil {
+		return stockTakeError("save count item", errItem)
+	}
+
+	_, errCount := tx.Exec(`
+		INSERT INTO stock_take_counts (stock_take_id, product_id, location, counted_by, quantity, counted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (stock_take_id, product_id, location, counted_by) DO UPDATE SET quantity = $5, counted_at = $6
+	`, data.StockTakeID, data.ProductID, data.Location, data.CountedBy, data.Quantity, data.CountedAt)
+	if errCount != nil {
+		var pqErr *pq.Error
+		if errors.As(errCount, &pqErr) && pqErr.Code == "23503" {
+			return &response.Error{
+				StatusCode: 404,
+				Message:    "product not found",
+				Error:      errors.New("product not found"),
+			}
+		}
+
+		return stockTakeError("save count", errCount)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "save count").Msg("failed to save count")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// ApproveStockTake implements StockTakeRepository.
+func (s *stockTakeRepository) ApproveStockTake(id uuid.UUID, approvedBy uuid.UUID, uncountedAsZero bool, approvedAt time.Time) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "approve stock take").Msg("failed to approve stock take")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	storeID, errLock := lockOpenStockTake(tx, id, "FOR UPDATE")
+	if errLock != nil {
+		return errLock
+	}
+
+	var items []entity.StockTakeItem
+
+	// inventory rows are updated in product order so two approvals can't deadlock
+	errItems := tx.Select(&items, itemQuery+` ORDER BY i.product_id`, id)
+	if errItems != nil {
+		return stockTakeError("get stock take items", errItems)
+	}
+
+	for _, item := range items {
+		counted := item.CountedQuantity
+		if counted == nil {
+			if !uncountedAsZero {
+				continue
+			}
+
+			counted = new(int)
+		}
+
+		// the variance is applied to the current stock, what was sold during the count stays sold
+		variance := *counted - *item.ExpectedQuantity
+
+		if variance < 0 {
+			var current int
+
+			errCurrent := tx.Get(&current, `SELECT quantity FROM inventories WHERE store_id = $1 AND product_id = $2 FOR UPDATE`, storeID, item.ProductID)
+			if errCurrent != nil && !errors.Is(errCurrent, sql.ErrNoRows) {
+				return stockTakeError("post stock take adjustment", errCurrent)
+			}
+
+			// the stock can't go below zero when more was sold during the count than was missing
+			variance = max(variance, -current)
+		}
+
+		errMove := ledger.Move(tx, ledger.Movement{
+			StoreID:       storeID,
+			ProductID:     item.ProductID,
+			Type:          ledger.TypeStockTake,
+			Quantity:      variance,
+			ReferenceType: ledger.ReferenceStockTake,
+			ReferenceID:   &id,
+			CreatedBy:     &approvedBy,
+			MovedAt:       approvedAt,
+		})
+		if errMove != nil {
+			return stockTakeError("post stock take adjustment", errMove)
+		}
+
+		_, errItem := tx.Exec(`UPDATE stock_take_items SET adjusted_quantity = $3 WHERE stock_take_id = $1 AND product_id = $2`,
+			id, item.ProductID, variance)
+		if errItem != nil {
+			return stockTakeError("post stock take adjustment", errItem)
+		}
+	}
+
+	_, errApprove := tx.Exec(`UPDATE stock_takes SET status = $2, approved_by = $3, approved_at = $4 WHERE id = $1`,
+		id, entity.StatusApproved, approvedBy, approvedAt)
+	if errApprove != nil {
+		return stockTakeError("approve stock take", errApprove)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "approve stock take").Msg("failed to approve stock take")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// CancelStockTake implements StockTakeRepository.
+func (s *stockTakeRepository) CancelStockTake(id uuid.UUID, cancelledAt time.Time) *response.Error {
+	result, err := s.db.Exec(`UPDATE stock_takes SET status = $2, cancelled_at = $3 WHERE id = $1 AND status = $4`,
+		id, entity.StatusCancelled, cancelledAt, entity.StatusOpen)
+	if err != nil {
+		return stockTakeError("cancel stock take", err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &response.Error{
+			StatusCode: 409,
+			Message:    "stock take is not open",
+			Error:      nil,
+		}
+	}
+
+	return nil
+}
+
+// lockOpenStockTake locks a stock-take and returns its store, it fails when the stock-take is not open.
+func lockOpenStockTake(tx *sqlx.Tx, id uuid.UUID, lock string) (uuid.UUID, *response.Error) {
+	var stockTake entity.StockTake
+
+	err := tx.Get(&stockTake, `SELECT id, store_id, status FROM stock_takes WHERE id = $1 `+lock, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, &response.Error{
+				StatusCode: 404,
+				Message:    "stock take not found",
+				Error:      err,
+			}
+		}
+
+		return uuid.Nil, stockTakeError("lock stock take", err)
+	}
+
+	if stockTake.Status != entity.StatusOpen {
+		return uuid.Nil, &response.Error{
+			StatusCode: 409,
+			Message:    "stock take is not open",
+			Error:      nil,
+		}
+	}
+
+	return stockTake.StoreID, nil
+}
+
+func stockTakeError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewStockTakeRepository(db *sqlx.DB) StockTakeRepository {
+	return &stockTakeRepository{db}
+}
@@ -0,0 +1,27 @@
+package stocktake
+
+import (
+	staff "candyshop/internal/staff"
+	handler "candyshop/internal/stocktake/handler"
+	repository "candyshop/internal/stocktake/repository"
+	service "candyshop/internal/stocktake/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewStockTakeRepository(db)
+	service := service.NewStockTakeService(repo)
+	handler := handler.NewStockTakeHandler(service)
+
+	stockTakeRoute := router.Group("api/v1/stock-takes", staff.Authenticate(db))
+
+	stockTakeRoute.Get("", handler.GetAllStockTake)
+	stockTakeRoute.Post("", handler.CreateStockTake)
+	stockTakeRoute.Get("/:id", handler.GetStockTakeByID)
+	stockTakeRoute.Get("/:id/report", handler.GetVarianceReport)
+	stockTakeRoute.Post("/:id/counts", handler.SaveCount)
+	stockTakeRoute.Patch("/:id/approve", handler.ApproveStockTake)
+	stockTakeRoute.Patch("/:id/cancel", handler.CancelStockTake)
+}
@@ -0,0 +1,272 @@
+package stocktake
+
+import (
+	dto "candyshop/internal/stocktake/dto"
+	entity "candyshop/internal/stocktake/entity"
+	repository "candyshop/internal/stocktake/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type StockTakeService interface {
+	GetAllStockTake(caller *auth.Caller, storeID *uuid.UUID, offset, limit int) ([]entity.StockTake, *response.Error)
+	GetStockTakeByID(caller *auth.Caller, id uuid.UUID) (*entity.StockTake, *response.Error)
+	CreateStockTake(caller *auth.Caller, data dto.CreateStockTakeRequest) (*entity.StockTake, *response.Error)
+	SaveCount(caller *auth.Caller, id uuid.UUID, data dto.CountRequest) (*entity.StockTake, *response.Error)
+	ApproveStockTake(caller *auth.Caller, id uuid.UUID, data dto.ApproveStockTakeRequest) (*entity.VarianceReport, *response.Error)
+	CancelStockTake(caller *auth.Caller, id uuid.UUID) *response.Error
+	GetVarianceReport(caller *auth.Caller, id uuid.UUID) (*entity.VarianceReport, *response.Error)
+}
+
+type stockTakeService struct {
+	repository repository.StockTakeRepository
+}
+
+// GetAllStockTake implements StockTakeService.
+func (s *stockTakeService) GetAllStockTake(caller *auth.Caller, storeID *uuid.UUID, offset int, limit int) ([]entity.StockTake, *response.Error) {
+	storeIDs := caller.StoreScope()
+	if storeID != nil {
+		if !caller.CanAccessStore(*storeID) {
+			return nil, auth.ForbiddenStore(*storeID)
+		}
+
+		storeIDs = []uuid.UUID{*storeID}
+	}
+
+	return s.repository.GetAllStockTake(storeIDs, offset, limit)
+}
+
+// GetStockTakeByID implements StockTakeService.
+func (s *stockTakeService) GetStockTakeByID(caller *auth.Caller, id uuid.UUID) (*entity.StockTake, *response.Error) {
+	stockTake, errStockTake := s.repository.GetStockTakeByID(id)
+	if errStockTake != nil {
+		return nil, errStockTake
+	}
+
+	if !caller.CanAccessStore(stockTake.StoreID) {
+		return nil, auth.ForbiddenStore(stockTake.StoreID)
+	}
+
+	if hideExpected(caller, stockTake) {
+		for i := range stockTake.Items {
+			stockTake.Items[i].ExpectedQuantity = nil
+		}
+	} else {
+		for i := range stockTake.Items {
+			setVariance(&stockTake.Items[i])
+		}
+	}
+
+	return stockTake, nil
+}
+
+// CreateStockTake implements StockTakeService.
+func (s *stockTakeService) CreateStockTake(caller *auth.Caller, data dto.CreateStockTakeRequest) (*entity.StockTake, *response.Error) {
+	if !caller.CanManageStore(data.StoreID) {
+		return nil, auth.ForbiddenStore(data.StoreID)
+	}
+
+	var productIDs []uuid.UUID
+	if len(data.ProductIDs) > 0 {
+		productIDs = slices.Clone(data.ProductIDs)
+		slices.SortFunc(productIDs, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+		productIDs = slices.Compact(productIDs)
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataStockTake := entity.StockTake{
+		ID:        newUUID,
+		StoreID:   data.StoreID,
+		Status:    entity.StatusOpen,
+		Blind:     data.Blind,
+		Note:      strings.TrimSpace(data.Note),
+		CreatedBy: &caller.UserID,
+	}
+
+	if errCreate := s.repository.CreateStockTake(dataStockTake, productIDs); errCreate != nil {
+		return nil, errCreate
+	}
+
+	return s.GetStockTakeByID(caller, newUUID)
+}
+
+// SaveCount implements StockTakeService.
+func (s *stockTakeService) SaveCount(caller *auth.Caller, id uuid.UUID, data dto.CountRequest) (*entity.StockTake, *response.Error) {
+	if data.ProductID == uuid.Nil || data.Quantity < 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "product_id is required and quantity can not be negative",
+			Error:      errors.New("product_id is required and quantity can not be negative"),
+		}
+	}
+
+	location := strings.TrimSpace(data.Location)
+	if len(location) > 100 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "location can not be longer than 100 characters",
+			Error:      errors.New("location can not be longer than 100 characters"),
+		}
+	}
+
+	stockTake, errStockTake := s.repository.GetStockTakeByID(id)
+	if errStockTake != nil {
+		return nil, errStockTake
+	}
+
+	if !caller.CanAccessStore(stockTake.StoreID) {
+		return nil, auth.ForbiddenStore(stockTake.StoreID)
+	}
+
+	errCount := s.repository.SaveCount(entity.Count{
+		StockTakeID: id,
+		ProductID:   data.ProductID,
+		Location:    location,
+		CountedBy:   caller.UserID,
+		Quantity:    data.Quantity,
+		CountedAt:   time.Now(),
+	})
+	if errCount != nil {
+		return nil, errCount
+	}
+
+	return s.GetStockTakeByID(caller, id)
+}
+
+// ApproveStockTake implements StockTakeService.
+func (s *stockTakeService) ApproveStockTake(caller *auth.Caller, id uuid.UUID, data dto.ApproveStockTakeRequest) (*entity.VarianceReport, *response.Error) {
+	stockTake, errStockTake := s.repository.GetStockTakeByID(id)
+	if errStockTake != nil {
+		return nil, errStockTake
+	}
+
+	if !caller.CanManageStore(stockTake.StoreID) {
+		return nil, auth.ForbiddenStore(stockTake.StoreID)
+	}
+
+	if errApprove := s.repository.ApproveStockTake(id, caller.UserID, data.UncountedAsZero, time.Now()); errApprove != nil {
+		return nil, errApprove
+	}
+
+	return s.GetVarianceReport(caller, id)
+}
+
+// CancelStockTake implements StockTakeService.
+func (s *stockTakeService) CancelStockTake(caller *auth.Caller, id uuid.UUID) *response.Error {
+	stockTake, errStockTake := s.repository.GetStockTakeByID(id)
+	if errStockTake != nil {
+		return errStockTake
+	}
+
+	if !caller.CanManageStore(stockTake.StoreID) {
+		return auth.ForbiddenStore(stockTake.StoreID)
+	}
+
+	return s.repository.CancelStockTake(id, time.Now())
+}
+
+// GetVarianceReport implements StockTakeService.
+func (s *stockTakeService) GetVarianceReport(caller *auth.Caller, id uuid.UUID) (*entity.VarianceReport, *response.Error) {
+	stockTake, errStockTake := s.repository.GetStockTakeByID(id)
+	if errStockTake != nil {
+		return nil, errStockTake
+	}
+
+	if !caller.CanAccessStore(stockTake.StoreID) {
+		return nil, auth.ForbiddenStore(stockTake.StoreID)
+	}
+
+	if hideExpected(caller, stockTake) {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "variances of a blind stock take are hidden until it is approved",
+			Error:      errors.New("variances of a blind stock take are hidden until it is approved"),
+		}
+	}
+
+	report := &entity.VarianceReport{
+		StockTakeID: stockTake.ID,
+		StoreID:     stockTake.StoreID,
+		Status:      stockTake.Status,
+		Items:       []entity.StockTakeItem{},
+	}
+
+	for _, item := range stockTake.Items {
+		setVariance(&item)
+		item.Counts = nil
+
+		if item.Variance == nil {
+			report.UncountedProducts++
+			continue
+		}
+
+		report.CountedProducts++
+
+		if *item.Variance == 0 {
+			continue
+		}
+
+		if *item.Variance < 0 {
+			report.ShortageQuantity -= *item.Variance
+			report.ShortageValue -= *item.VarianceValue
+		} else {
+			report.SurplusQuantity += *item.Variance
+			report.SurplusValue += *item.VarianceValue
+		}
+
+		report.NetValue += *item.VarianceValue
+		report.Items = append(report.Items, item)
+	}
+
+	// the largest differences by value come first
+	slices.SortStableFunc(report.Items, func(a, b entity.StockTakeItem) int {
+		return cmp.Compare(abs(*b.VarianceValue), abs(*a.VarianceValue))
+	})
+
+	return report, nil
+}
+
+// setVariance fills the variance of a counted item. After approval the posted adjustment is the variance,
+// so products that were treated as counted zero keep their variance.
+func setVariance(item *entity.StockTakeItem) {
+	var variance int
+
+	switch {
+	case item.AdjustedQuantity != nil:
+		variance = *item.AdjustedQuantity
+	case item.CountedQuantity != nil:
+		variance = *item.CountedQuantity - *item.ExpectedQuantity
+	default:
+		return
+	}
+
+	value := int64(variance) * item.UnitPrice
+	item.Variance = &variance
+	item.VarianceValue = &value
+}
+
+// hideExpected reports whether the caller counts blind, only managers see the expected quantities before approval.
+func hideExpected(caller *auth.Caller, stockTake *entity.StockTake) bool {
+	return stockTake.Blind && stockTake.Status == entity.StatusOpen && !caller.CanManageStore(stockTake.StoreID)
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func NewStockTakeService(repository repository.StockTakeRepository) StockTakeService {
+	return &stockTakeService{repository}
+}
@@ -3,12 +3,41 @@ package store
 import "github.com/google/uuid"
 
 type CreateStoreRequest struct {
-	Name    string `json:"name" binding:"required"`
-	Address string `json:"address" binding:"required"`
+	Name        string     `json:"name" binding:"required"`
+	Address     string     `json:"address" binding:"required"`
+	Latitude    *float64   `json:"latitude"`
+	Longitude   *float64   `json:"longitude"`
+	Timezone    string     `json:"timezone"`
+	PhoneNumber string     `json:"phone_number"`
+	ManagerID   *uuid.UUID `json:"manager_id"`
 }
 
 type UpdateStoreRequest struct {
-	ID      uuid.UUID `json:"id" binding:"required"`
-	Name    string    `json:"name" binding:"required"`
-	Address string    `json:"address" binding:"required"`
+	ID          uuid.UUID  `json:"id" binding:"required"`
+	Name        string     `json:"name" binding:"required"`
+	Address     string     `json:"address" binding:"required"`
+	Latitude    *float64   `json:"latitude"`
+	Longitude   *float64   `json:"longitude"`
+	Timezone    string     `json:"timezone"`
+	PhoneNumber string     `json:"phone_number"`
+	ManagerID   *uuid.UUID `json:"manager_id"`
+}
+
+type OpeningHourRequest struct {
+	Weekday   int     `json:"weekday"`
+	OpenTime  *string `json:"open_time"`
+	CloseTime *string `json:"close_time"`
+	IsClosed  bool    `json:"is_closed"`
+}
+
+type UpdateOpeningHoursRequest struct {
+	OpeningHours []OpeningHourRequest `json:"opening_hours"`
+}
+
+type HolidayRequest struct {
+	Date        string  `json:"date"`
+	OpenTime    *string `json:"open_time"`
+	CloseTime   *string `json:"close_time"`
+	IsClosed    bool    `json:"is_closed"`
+	Description string  `json:"description"`
 }
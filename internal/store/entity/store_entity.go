@@ -7,11 +7,41 @@ import (
 )
 
 type Store struct {
-	ID        uuid.UUID  `json:"id" db:"id"`
-	Name      string     `json:"name" db:"name"`
-	Address   string     `json:"address" db:"address"`
-	Status    bool       `json:"status" db:"status"`
-	CreatedAt *time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt *time.Time `json:"-" db:"updated_at"`
-	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Address     string     `json:"address" db:"address"`
+	Latitude    *float64   `json:"latitude" db:"latitude"`
+	Longitude   *float64   `json:"longitude" db:"longitude"`
+	Timezone    string     `json:"timezone" db:"timezone"`
+	PhoneNumber string     `json:"phone_number" db:"phone_number"`
+	ManagerID   *uuid.UUID `json:"manager_id" db:"manager_id"`
+	Status      bool       `json:"status" db:"status"`
+	CreatedAt   *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"-" db:"updated_at"`
+	DeletedAt   *time.Time `json:"-" db:"deleted_at"`
+}
+
+// OpeningHour is the regular opening time of a store on a weekday, times are HH:MM in the store timezone.
+type OpeningHour struct {
+	StoreID   uuid.UUID    `json:"-" db:"store_id"`
+	Weekday   time.Weekday `json:"weekday" db:"weekday"`
+	OpenTime  *string      `json:"open_time" db:"open_time"`
+	CloseTime *string      `json:"close_time" db:"close_time"`
+	IsClosed  bool         `json:"is_closed" db:"is_closed"`
+}
+
+// Holiday overrides the opening hours of a store on a single date.
+type Holiday struct {
+	StoreID     uuid.UUID `json:"-" db:"store_id"`
+	Date        string    `json:"date" db:"date"`
+	OpenTime    *string   `json:"open_time" db:"open_time"`
+	CloseTime   *string   `json:"close_time" db:"close_time"`
+	IsClosed    bool      `json:"is_closed" db:"is_closed"`
+	Description string    `json:"description" db:"description"`
+}
+
+type NearbyStore struct {
+	Store
+	DistanceKm float64 `json:"distance_km" db:"distance_km"`
+	IsOpen     bool    `json:"is_open" db:"-"`
 }
@@ -1,11 +1,17 @@
 package store
 
 import (
+	"bufio"
 	dto "candyshop/internal/store/dto"
 	service "candyshop/internal/store/service"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/export"
+	"fmt"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 type StoreHandler struct {
@@ -28,7 +34,7 @@ func (h *StoreHandler) GetAllStore(c *fiber.Ctx) error {
 		})
 	}
 
-	stores, errStore := h.service.GetAllStore(offset, limit)
+	stores, errStore := h.service.GetAllStore(auth.GetCaller(c), offset, limit)
 	if errStore != nil {
 		return c.Status(errStore.StatusCode).JSON(fiber.Map{
 			"status_code": errStore.StatusCode,
@@ -79,7 +85,7 @@ func (h *StoreHandler) CreateStore(c *fiber.Ctx) error {
 		})
 	}
 
-	product, errProduct := h.service.CreateStore(req)
+	product, errProduct := h.service.CreateStore(auth.GetCaller(c), req)
 	if errProduct != nil {
 		return c.Status(errProduct.StatusCode).JSON(fiber.Map{
 			"status_code": errProduct.StatusCode,
@@ -135,7 +141,7 @@ func (h *StoreHandler) UpdateStore(c *fiber.Ctx) error {
 		})
 	}
 
-	errUpdate := h.service.UpdateStore(req)
+	errUpdate := h.service.UpdateStore(auth.GetCaller(c), req)
 	if errUpdate != nil {
 		return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
 			"status_code": errUpdate.StatusCode,
@@ -164,7 +170,7 @@ func (h *StoreHandler) DeleteStore(c *fiber.Ctx) error {
 
 	parseID, _ := uuid.Parse(id)
 
-	errDelete := h.service.DeleteStore(parseID)
+	errDelete := h.service.DeleteStore(auth.GetCaller(c), parseID)
 	if errDelete != nil {
 		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
 			"status_code": errDelete.StatusCode,
@@ -179,3 +185,242 @@ func (h *StoreHandler) DeleteStore(c *fiber.Ctx) error {
 		"data":        nil,
 	})
 }
+
+func (h *StoreHandler) GetNearbyStore(c *fiber.Ctx) error {
+	latitude, errLatitude := strconv.ParseFloat(c.Query("lat"), 64)
+	longitude, errLongitude := strconv.ParseFloat(c.Query("lng"), 64)
+
+	if errLatitude != nil || errLongitude != nil || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "lat or lng is invalid",
+			"error":       nil,
+		})
+	}
+
+	// radius in km
+	radius := c.QueryFloat("radius", 5)
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if radius <= 0 || offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "radius, offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	stores, errStore := h.service.GetNearbyStore(latitude, longitude, radius, offset, limit)
+	if errStore != nil {
+		return c.Status(errStore.StatusCode).JSON(fiber.Map{
+			"status_code": errStore.StatusCode,
+			"message":     "failed to fetch nearby stores",
+			"error":       errStore.Error.Error(),
+		})
+	}
+
+	if len(stores) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch nearby stores",
+			"error":       "store not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get nearby stores",
+		"data":        stores,
+	})
+}
+
+func (h *StoreHandler) GetOpeningHours(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	hours, holidays, errStore := h.service.GetOpeningHours(parseID)
+	if errStore != nil {
+		return c.Status(errStore.StatusCode).JSON(fiber.Map{
+			"status_code": errStore.StatusCode,
+			"message":     "failed to fetch opening hours",
+			"error":       errStore.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get opening hours",
+		"data": fiber.Map{
+			"opening_hours": hours,
+			"holidays":      holidays,
+		},
+	})
+}
+
+func (h *StoreHandler) UpdateOpeningHours(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.UpdateOpeningHoursRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input opening hours",
+			"error":       err.Error(),
+		})
+	}
+
+	errUpdate := h.service.UpdateOpeningHours(auth.GetCaller(c), parseID, req)
+	if errUpdate != nil {
+		if errUpdate.StatusCode == fiber.StatusConflict {
+			return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+				"status_code": errUpdate.StatusCode,
+				"message":     "failed to update opening hours",
+				"error":       errUpdate.Message,
+			})
+		}
+
+		return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+			"status_code": errUpdate.StatusCode,
+			"message":     "failed to update opening hours",
+			"error":       errUpdate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success update opening hours",
+		"data":        nil,
+	})
+}
+
+func (h *StoreHandler) SaveHoliday(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.HolidayRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input holiday",
+			"error":       err.Error(),
+		})
+	}
+
+	errSave := h.service.SaveHoliday(auth.GetCaller(c), parseID, req)
+	if errSave != nil {
+		if errSave.StatusCode == fiber.StatusConflict {
+			return c.Status(errSave.StatusCode).JSON(fiber.Map{
+				"status_code": errSave.StatusCode,
+				"message":     "failed to save holiday",
+				"error":       errSave.Message,
+			})
+		}
+
+		return c.Status(errSave.StatusCode).JSON(fiber.Map{
+			"status_code": errSave.StatusCode,
+			"message":     "failed to save holiday",
+			"error":       errSave.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success save holiday",
+		"data":        nil,
+	})
+}
+
+func (h *StoreHandler) DeleteHoliday(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errDelete := h.service.DeleteHoliday(auth.GetCaller(c), parseID, c.Params("date"))
+	if errDelete != nil {
+		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+			"status_code": errDelete.StatusCode,
+			"message":     "failed to delete holiday",
+			"error":       errDelete.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete holiday",
+		"data":        nil,
+	})
+}
+
+func (h *StoreHandler) ExportStore(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit")
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	format, errFormat := export.ParseFormat(c.Query("format"))
+	if errFormat != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "format is invalid",
+			"error":       errFormat.Error(),
+		})
+	}
+
+	columns, errColumns := h.service.ExportColumns(c.Query("columns"))
+	if errColumns != nil {
+		return c.Status(errColumns.StatusCode).JSON(fiber.Map{
+			"status_code": errColumns.StatusCode,
+			"message":     errColumns.Message,
+			"error":       errColumns.Error.Error(),
+		})
+	}
+
+	// the context is released when the handler returns, read the caller before streaming
+	caller := auth.GetCaller(c)
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, format.Filename("stores")))
+
+	// rows are written while they are read from database, the status is already sent so errors can only be logged
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		errExport := h.service.ExportStore(caller, offset, limit, columns, export.NewWriter(format, w))
+		if errExport != nil {
+			log.Error().Err(errExport.Error).Int("status", errExport.StatusCode).Str("function", "export store").Msg(errExport.Message)
+		}
+	})
+
+	return nil
+}
@@ -9,15 +9,23 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
 type StoreRepository interface {
-	GetAllStore(offset, limit int) ([]entity.Store, *response.Error)
+	GetAllStore(storeIDs []uuid.UUID, offset, limit int) ([]entity.Store, *response.Error)
 	GetStoreByID(id uuid.UUID) (*entity.Store, *response.Error)
 	CreateStore(data entity.Store) (*entity.Store, *response.Error)
 	UpdateStore(data entity.Store) *response.Error
 	DeleteStore(id uuid.UUID, deletedAt time.Time) *response.Error
+	GetNearbyStore(latitude, longitude, radiusKm float64, offset, limit int) ([]entity.NearbyStore, *response.Error)
+	GetOpeningHours(storeIDs []uuid.UUID) ([]entity.OpeningHour, *response.Error)
+	ReplaceOpeningHours(storeID uuid.UUID, hours []entity.OpeningHour) *response.Error
+	GetHolidays(storeIDs []uuid.UUID, from, to string) ([]entity.Holiday, *response.Error)
+	UpsertHoliday(data entity.Holiday) *response.Error
+	DeleteHoliday(storeID uuid.UUID, date string) *response.Error
+	StreamStore(storeIDs []uuid.UUID, offset, limit int, fn func(entity.Store) error) *response.Error
 }
 
 type storeRepository struct {
@@ -41,17 +49,27 @@ func (s *storeRepository) CreateStore(data entity.Store) (*entity.Store, *respon
 	var model entity.Store
 
 	query := `
-		INSERT INTO stores (id, name, address, status) VALUES ($1, $2, $3, $4)
-		RETURNING id, name, address, status, created_at
+		INSERT INTO stores (id, name, address, latitude, longitude, timezone, phone_number, manager_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, name, address, latitude, longitude, timezone, phone_number, manager_id, status, created_at
 	`
 
 	errInsert := tx.QueryRowx(query,
 		data.ID,
 		data.Name,
 		data.Address,
+		data.Latitude,
+		data.Longitude,
+		data.Timezone,
+		data.PhoneNumber,
+		data.ManagerID,
 		data.Status).Scan(&model.ID,
 		&model.Name,
 		&model.Address,
+		&model.Latitude,
+		&model.Longitude,
+		&model.Timezone,
+		&model.PhoneNumber,
+		&model.ManagerID,
 		&model.Status,
 		&model.CreatedAt)
 
@@ -115,16 +133,18 @@ func (s *storeRepository) DeleteStore(id uuid.UUID, deletedAt time.Time) *respon
 }
 
 // GetAllStore implements StoreRepository.
-func (s *storeRepository) GetAllStore(offset int, limit int) ([]entity.Store, *response.Error) {
+func (s *storeRepository) GetAllStore(storeIDs []uuid.UUID, offset int, limit int) ([]entity.Store, *response.Error) {
 	var stores []entity.Store
 
 	query := `
-		SELECT id, name, address, status, created_at, updated_at, deleted_at 
+		SELECT id, name, address, latitude, longitude, timezone, phone_number, manager_id, status, created_at, updated_at, deleted_at
 		FROM stores
+		WHERE $3::uuid[] IS NULL OR id = ANY($3)
 		LIMIT $1 OFFSET $2
 	`
 
-	err := s.db.Select(&stores, query, limit, offset)
+	// nil store ids means the caller is not limited to some stores
+	err := s.db.Select(&stores, query, limit, offset, pq.Array(storeIDs))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Error().Err(err).Int("status", 404).Str("function", "get all store").Msg("failed to get all store")
@@ -151,7 +171,7 @@ func (s *storeRepository) GetStoreByID(id uuid.UUID) (*entity.Store, *response.E
 	var store entity.Store
 
 	query := `
-		SELECT id, name, address, status, created_at, updated_at, deleted_at 
+		SELECT id, name, address, latitude, longitude, timezone, phone_number, manager_id, status, created_at, updated_at, deleted_at
 		FROM stores
 		WHERE id = $1
 	`
@@ -193,10 +213,19 @@ func (s *storeRepository) UpdateStore(data entity.Store) *response.Error {
 	defer tx.Rollback()
 
 	query := `
-		UPDATE stores SET name = $2, address = $3, updated_at = $4 WHERE id = $1
+		UPDATE stores SET name = $2, address = $3, latitude = $4, longitude = $5, timezone = $6, phone_number = $7, manager_id = $8, updated_at = $9 WHERE id = $1
 	`
 
-	_, errExec := tx.Exec(query, data.ID, data.Name, data.Address, data.UpdatedAt)
+	_, errExec := tx.Exec(query,
+		data.ID,
+		data.Name,
+		data.Address,
+		data.Latitude,
+		data.Longitude,
+		data.Timezone,
+		data.PhoneNumber,
+		data.ManagerID,
+		data.UpdatedAt)
 	if errExec != nil {
 		log.Error().Err(errExec).Int("status", 500).Str("function", "update store").Msg("failed to update store")
 		return &response.Error{
@@ -218,6 +247,247 @@ func (s *storeRepository) UpdateStore(data entity.Store) *response.Error {
 	return nil
 }
 
+// StreamStore implements StoreRepository.
+func (s *storeRepository) StreamStore(storeIDs []uuid.UUID, offset int, limit int, fn func(entity.Store) error) *response.Error {
+	// limit 0 means no limit for export
+	var queryLimit *int
+	if limit > 0 {
+		queryLimit = &limit
+	}
+
+	query := `
+		SELECT id, name, address, latitude, longitude, timezone, phone_number, manager_id, status, created_at
+		FROM stores
+		WHERE $3::uuid[] IS NULL OR id = ANY($3)
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := s.db.Queryx(query, queryLimit, offset, pq.Array(storeIDs))
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "stream store").Msg("failed to stream store")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch stores",
+			Error:      err,
+		}
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var store entity.Store
+		if err := rows.StructScan(&store); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "stream store").Msg("failed to stream store")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to fetch stores",
+				Error:      err,
+			}
+		}
+
+		if err := fn(store); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "stream store").Msg("failed to write store")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to write stores",
+				Error:      err,
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "stream store").Msg("failed to stream store")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch stores",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// GetNearbyStore implements StoreRepository.
+func (s *storeRepository) GetNearbyStore(latitude float64, longitude float64, radiusKm float64, offset int, limit int) ([]entity.NearbyStore, *response.Error) {
+	var stores []entity.NearbyStore
+
+	// haversine distance in km, the latitude range lets the location index skip far away stores
+	query := `
+		SELECT * FROM (
+			SELECT id, name, address, latitude, longitude, timezone, phone_number, manager_id, status, created_at, updated_at, deleted_at,
+				6371 * 2 * ASIN(SQRT(
+					POWER(SIN(RADIANS(latitude - $1::float8) / 2), 2) +
+					COS(RADIANS($1::float8)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2::float8) / 2), 2)
+				)) AS distance_km
+			FROM stores
+			WHERE deleted_at IS NULL AND status = true
+				AND latitude IS NOT NULL AND longitude IS NOT NULL
+				AND latitude BETWEEN $1::float8 - $3::float8 / 111.045 AND $1::float8 + $3::float8 / 111.045
+		) nearby
+		WHERE distance_km <= $3::float8
+		ORDER BY distance_km
+		LIMIT $4 OFFSET $5
+	`
+
+	err := s.db.Select(&stores, query, latitude, longitude, radiusKm, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get nearby store").Msg("failed to get nearby store")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch stores",
+			Error:      err,
+		}
+	}
+
+	return stores, nil
+}
+
+// GetOpeningHours implements StoreRepository.
+func (s *storeRepository) GetOpeningHours(storeIDs []uuid.UUID) ([]entity.OpeningHour, *response.Error) {
+	var hours []entity.OpeningHour
+
+	query := `
+		SELECT store_id, weekday, to_char(open_time, 'HH24:MI') AS open_time, to_char(close_time, 'HH24:MI') AS close_time, is_closed
+		FROM store_opening_hours
+		WHERE store_id = ANY($1)
+		ORDER BY store_id, weekday
+	`
+
+	err := s.db.Select(&hours, query, pq.Array(storeIDs))
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get opening hours").Msg("failed to get opening hours")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch opening hours",
+			Error:      err,
+		}
+	}
+
+	return hours, nil
+}
+
+// ReplaceOpeningHours implements StoreRepository.
+func (s *storeRepository) ReplaceOpeningHours(storeID uuid.UUID, hours []entity.OpeningHour) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "replace opening hours").Msg("failed to replace opening hours")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	_, errDelete := tx.Exec(`DELETE FROM store_opening_hours WHERE store_id = $1`, storeID)
+	if errDelete != nil {
+		log.Error().Err(errDelete).Int("status", 500).Str("function", "replace opening hours").Msg("failed to replace opening hours")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update opening hours",
+			Error:      errDelete,
+		}
+	}
+
+	query := `
+		INSERT INTO store_opening_hours (store_id, weekday, open_time, close_time, is_closed) VALUES ($1, $2, $3, $4, $5)
+	`
+
+	for _, hour := range hours {
+		_, errInsert := tx.Exec(query, storeID, hour.Weekday, hour.OpenTime, hour.CloseTime, hour.IsClosed)
+		if errInsert != nil {
+			log.Error().Err(errInsert).Int("status", 500).Str("function", "replace opening hours").Msg("failed to replace opening hours")
+			return &response.Error{
+				StatusCode: 500,
+				Message:    "failed to update opening hours",
+				Error:      errInsert,
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "replace opening hours").Msg("failed to replace opening hours")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// GetHolidays implements StoreRepository.
+func (s *storeRepository) GetHolidays(storeIDs []uuid.UUID, from string, to string) ([]entity.Holiday, *response.Error) {
+	var holidays []entity.Holiday
+
+	query := `
+		SELECT store_id, to_char(date, 'YYYY-MM-DD') AS date, to_char(open_time, 'HH24:MI') AS open_time,
+			to_char(close_time, 'HH24:MI') AS close_time, is_closed, description
+		FROM store_holidays
+		WHERE store_id = ANY($1) AND date BETWEEN $2 AND $3
+		ORDER BY store_id, date
+	`
+
+	err := s.db.Select(&holidays, query, pq.Array(storeIDs), from, to)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get holidays").Msg("failed to get holidays")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch holidays",
+			Error:      err,
+		}
+	}
+
+	return holidays, nil
+}
+
+// UpsertHoliday implements StoreRepository.
+func (s *storeRepository) UpsertHoliday(data entity.Holiday) *response.Error {
+	query := `
+		INSERT INTO store_holidays (store_id, date, open_time, close_time, is_closed, description) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (store_id, date) DO UPDATE
+		SET open_time = EXCLUDED.open_time, close_time = EXCLUDED.close_time, is_closed = EXCLUDED.is_closed, description = EXCLUDED.description
+	`
+
+	_, err := s.db.Exec(query, data.StoreID, data.Date, data.OpenTime, data.CloseTime, data.IsClosed, data.Description)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "upsert holiday").Msg("failed to save holiday")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to save holiday",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// DeleteHoliday implements StoreRepository.
+func (s *storeRepository) DeleteHoliday(storeID uuid.UUID, date string) *response.Error {
+	result, err := s.db.Exec(`DELETE FROM store_holidays WHERE store_id = $1 AND date = $2`, storeID, date)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete holiday").Msg("failed to delete holiday")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete holiday",
+			Error:      err,
+		}
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &response.Error{
+			StatusCode: 404,
+			Message:    "holiday not found",
+			Error:      sql.ErrNoRows,
+		}
+	}
+
+	return nil
+}
+
 func NewStoreRepository(db *sqlx.DB) StoreRepository {
 	return &storeRepository{db}
 }
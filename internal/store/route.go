@@ -1,6 +1,7 @@
 package store
 
 import (
+	staff "candyshop/internal/staff"
 	handler "candyshop/internal/store/handler"
 	repository "candyshop/internal/store/repository"
 	service "candyshop/internal/store/service"
@@ -13,12 +14,19 @@ func Init(router fiber.Router, db *sqlx.DB) {
 	repo := repository.NewStoreRepository(db)
 	service := service.NewStoreService(repo)
 	handler := handler.NewStoreHandler(service)
+	authenticate := staff.Authenticate(db)
 
 	storeRoute := router.Group("api/v1/stores")
 
-	storeRoute.Get("", handler.GetAllStore)
+	storeRoute.Get("", authenticate, handler.GetAllStore)
+	storeRoute.Get("export", authenticate, handler.ExportStore)
+	storeRoute.Get("nearby", handler.GetNearbyStore)
 	storeRoute.Get(":id", handler.GetStoreByID)
-	storeRoute.Post("", handler.CreateStore)
-	storeRoute.Patch("", handler.UpdateStore)
-	storeRoute.Patch("/delete/:id", handler.DeleteStore)
+	storeRoute.Post("", authenticate, handler.CreateStore)
+	storeRoute.Patch("", authenticate, handler.UpdateStore)
+	storeRoute.Patch("/delete/:id", authenticate, handler.DeleteStore)
+	storeRoute.Get("/:id/opening-hours", handler.GetOpeningHours)
+	storeRoute.Put("/:id/opening-hours", authenticate, handler.UpdateOpeningHours)
+	storeRoute.Post("/:id/holidays", authenticate, handler.SaveHoliday)
+	storeRoute.Delete("/:id/holidays/:date", authenticate, handler.DeleteHoliday)
 }
@@ -0,0 +1,163 @@
+package store
+
+import (
+	entity "candyshop/internal/store/entity"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DefaultTimezone is used when a store is created without timezone.
+const DefaultTimezone = "Asia/Jakarta"
+
+const clockLayout = "15:04"
+
+func validateStoreDetail(latitude, longitude *float64, timezone string) *response.Error {
+	if (latitude == nil) != (longitude == nil) {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "latitude and longitude must be filled together",
+			Error:      errors.New("latitude and longitude must be filled together"),
+		}
+	}
+
+	if latitude != nil && (*latitude < -90 || *latitude > 90 || *longitude < -180 || *longitude > 180) {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "latitude or longitude is invalid",
+			Error:      fmt.Errorf("location %f,%f is out of range", *latitude, *longitude),
+		}
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    fmt.Sprintf("timezone %s is invalid", timezone),
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+func validateOpeningTime(openTime, closeTime *string, isClosed bool) *response.Error {
+	if isClosed {
+		return nil
+	}
+
+	if openTime == nil || closeTime == nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "open_time and close_time is required when store is open",
+			Error:      errors.New("open_time and close_time is required when store is open"),
+		}
+	}
+
+	for _, value := range []string{*openTime, *closeTime} {
+		if _, err := time.Parse(clockLayout, value); err != nil {
+			return &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("time %s is invalid, use HH:MM", value),
+				Error:      err,
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatCoordinate(coordinate *float64) string {
+	if coordinate == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*coordinate, 'f', -1, 64)
+}
+
+// storeLocation falls back to UTC for an unknown timezone so one bad row can't break a listing.
+func storeLocation(timezone string) *time.Location {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// storeSchedule holds the opening hours and holiday exceptions of one store.
+type storeSchedule struct {
+	hours    map[time.Weekday]entity.OpeningHour
+	holidays map[string]entity.Holiday
+}
+
+func newStoreSchedule(storeID uuid.UUID, hours []entity.OpeningHour, holidays []entity.Holiday) storeSchedule {
+	schedule := storeSchedule{
+		hours:    map[time.Weekday]entity.OpeningHour{},
+		holidays: map[string]entity.Holiday{},
+	}
+
+	for _, hour := range hours {
+		if hour.StoreID == storeID {
+			schedule.hours[hour.Weekday] = hour
+		}
+	}
+
+	for _, holiday := range holidays {
+		if holiday.StoreID == storeID {
+			schedule.holidays[holiday.Date] = holiday
+		}
+	}
+
+	return schedule
+}
+
+// openingMinutes returns the open and close time of a day in minutes after midnight,
+// a holiday exception replaces the weekday hours.
+func (s storeSchedule) openingMinutes(day time.Time) (int, int, bool) {
+	openTime, closeTime, isClosed := (*string)(nil), (*string)(nil), true
+
+	if holiday, ok := s.holidays[day.Format(time.DateOnly)]; ok {
+		openTime, closeTime, isClosed = holiday.OpenTime, holiday.CloseTime, holiday.IsClosed
+	} else if hour, ok := s.hours[day.Weekday()]; ok {
+		openTime, closeTime, isClosed = hour.OpenTime, hour.CloseTime, hour.IsClosed
+	}
+
+	if isClosed || openTime == nil || closeTime == nil {
+		return 0, 0, false
+	}
+
+	openAt, errOpen := time.Parse(clockLayout, *openTime)
+	closeAt, errClose := time.Parse(clockLayout, *closeTime)
+	if errOpen != nil || errClose != nil {
+		return 0, 0, false
+	}
+
+	return openAt.Hour()*60 + openAt.Minute(), closeAt.Hour()*60 + closeAt.Minute(), true
+}
+
+// isOpen reports whether the store is open at the given moment in its own timezone.
+// A close time before the open time means the store closes after midnight.
+func (s storeSchedule) isOpen(timezone string, now time.Time) bool {
+	local := now.In(storeLocation(timezone))
+	minutes := local.Hour()*60 + local.Minute()
+
+	if openAt, closeAt, ok := s.openingMinutes(local.AddDate(0, 0, -1)); ok && closeAt <= openAt && minutes < closeAt {
+		return true
+	}
+
+	openAt, closeAt, ok := s.openingMinutes(local)
+	if !ok {
+		return false
+	}
+
+	if closeAt <= openAt {
+		return minutes >= openAt
+	}
+
+	return minutes >= openAt && minutes < closeAt
+}
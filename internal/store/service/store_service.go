@@ -4,40 +4,166 @@ import (
 	dto "candyshop/internal/store/dto"
 	entity "candyshop/internal/store/entity"
 	repository "candyshop/internal/store/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/export"
+	"candyshop/pkg/phone"
 	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 type StoreService interface {
-	GetAllStore(offset, limit int) ([]entity.Store, *response.Error)
+	GetAllStore(caller *auth.Caller, offset, limit int) ([]entity.Store, *response.Error)
 	GetStoreByID(id uuid.UUID) (*entity.Store, *response.Error)
-	CreateStore(data dto.CreateStoreRequest) (*entity.Store, *response.Error)
-	UpdateStore(data dto.UpdateStoreRequest) *response.Error
-	DeleteStore(id uuid.UUID) *response.Error
+	CreateStore(caller *auth.Caller, data dto.CreateStoreRequest) (*entity.Store, *response.Error)
+	UpdateStore(caller *auth.Caller, data dto.UpdateStoreRequest) *response.Error
+	DeleteStore(caller *auth.Caller, id uuid.UUID) *response.Error
+	GetNearbyStore(latitude, longitude, radiusKm float64, offset, limit int) ([]entity.NearbyStore, *response.Error)
+	GetOpeningHours(id uuid.UUID) ([]entity.OpeningHour, []entity.Holiday, *response.Error)
+	UpdateOpeningHours(caller *auth.Caller, id uuid.UUID, data dto.UpdateOpeningHoursRequest) *response.Error
+	SaveHoliday(caller *auth.Caller, id uuid.UUID, data dto.HolidayRequest) *response.Error
+	DeleteHoliday(caller *auth.Caller, id uuid.UUID, date string) *response.Error
+	ExportColumns(columns string) ([]string, *response.Error)
+	ExportStore(caller *auth.Caller, offset, limit int, columns []string, writer export.Writer) *response.Error
 }
 
 type storeService struct {
 	repository repository.StoreRepository
 }
 
+// storeColumns lists the columns available for store export.
+var storeColumns = export.Columns[entity.Store]{
+	{Name: "id", Value: func(s entity.Store) string { return s.ID.String() }},
+	{Name: "name", Value: func(s entity.Store) string { return s.Name }},
+	{Name: "address", Value: func(s entity.Store) string { return s.Address }},
+	{Name: "latitude", Value: func(s entity.Store) string { return formatCoordinate(s.Latitude) }},
+	{Name: "longitude", Value: func(s entity.Store) string { return formatCoordinate(s.Longitude) }},
+	{Name: "timezone", Value: func(s entity.Store) string { return s.Timezone }},
+	{Name: "phone_number", Value: func(s entity.Store) string { return s.PhoneNumber }},
+	{Name: "manager_id", Value: func(s entity.Store) string {
+		if s.ManagerID == nil {
+			return ""
+		}
+		return s.ManagerID.String()
+	}},
+	{Name: "status", Value: func(s entity.Store) string { return strconv.FormatBool(s.Status) }},
+	{Name: "created_at", Value: func(s entity.Store) string { return export.FormatTime(s.CreatedAt) }},
+}
+
+// ExportColumns implements StoreService.
+func (p *storeService) ExportColumns(columns string) ([]string, *response.Error) {
+	selected, err := storeColumns.Select(export.SplitColumns(columns))
+	if err != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "columns is invalid",
+			Error:      err,
+		}
+	}
+
+	return selected.Names(), nil
+}
+
+// ExportStore implements StoreService.
+func (p *storeService) ExportStore(caller *auth.Caller, offset int, limit int, columns []string, writer export.Writer) *response.Error {
+	selected, err := storeColumns.Select(columns)
+	if err != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "columns is invalid",
+			Error:      err,
+		}
+	}
+
+	if err := writer.WriteHeader(selected.Names()); err != nil {
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to write stores",
+			Error:      err,
+		}
+	}
+
+	errStream := p.repository.StreamStore(caller.StoreScope(), offset, limit, func(store entity.Store) error {
+		return writer.WriteRow(selected.Values(store))
+	})
+	if errStream != nil {
+		return errStream
+	}
+
+	if err := writer.Close(); err != nil {
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to write stores",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
 // CreateStore implements StoreService.
-func (p *storeService) CreateStore(data dto.CreateStoreRequest) (*entity.Store, *response.Error) {
+func (p *storeService) CreateStore(caller *auth.Caller, data dto.CreateStoreRequest) (*entity.Store, *response.Error) {
+	if !caller.IsOwner() {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "only owner can create store",
+			Error:      errors.New("only owner can create store"),
+		}
+	}
+
+	if data.Timezone == "" {
+		data.Timezone = DefaultTimezone
+	}
+
+	if errDetail := validateStoreDetail(data.Latitude, data.Longitude, data.Timezone); errDetail != nil {
+		return nil, errDetail
+	}
+
+	if data.PhoneNumber != "" {
+		phoneNumber, err := phone.Normalize(data.PhoneNumber)
+		if err != nil {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("phone number %s is invalid", data.PhoneNumber),
+				Error:      err,
+			}
+		}
+
+		data.PhoneNumber = phoneNumber
+	}
+
 	newUUID, _ := uuid.NewV7()
 
 	dataStore := &entity.Store{
-		ID:      newUUID,
-		Name:    data.Name,
-		Address: data.Address,
-		Status:  true,
+		ID:          newUUID,
+		Name:        data.Name,
+		Address:     data.Address,
+		Latitude:    data.Latitude,
+		Longitude:   data.Longitude,
+		Timezone:    data.Timezone,
+		PhoneNumber: data.PhoneNumber,
+		ManagerID:   data.ManagerID,
+		Status:      true,
 	}
 
 	return p.repository.CreateStore(*dataStore)
 }
 
 // DeleteStore implements storeService.
-func (p *storeService) DeleteStore(id uuid.UUID) *response.Error {
+func (p *storeService) DeleteStore(caller *auth.Caller, id uuid.UUID) *response.Error {
+	if !caller.IsOwner() {
+		return &response.Error{
+			StatusCode: fiber.StatusForbidden,
+			Message:    "only owner can delete store",
+			Error:      errors.New("only owner can delete store"),
+		}
+	}
+
 	checkStore, errStore := p.repository.GetStoreByID(id)
 	if errStore != nil {
 		return errStore
@@ -57,8 +183,8 @@ func (p *storeService) DeleteStore(id uuid.UUID) *response.Error {
 }
 
 // GetAllStore implements storeService.
-func (p *storeService) GetAllStore(offset int, limit int) ([]entity.Store, *response.Error) {
-	return p.repository.GetAllStore(offset, limit)
+func (p *storeService) GetAllStore(caller *auth.Caller, offset int, limit int) ([]entity.Store, *response.Error) {
+	return p.repository.GetAllStore(caller.StoreScope(), offset, limit)
 }
 
 // GetStoreByID implements storeService.
@@ -67,7 +193,11 @@ func (p *storeService) GetStoreByID(id uuid.UUID) (*entity.Store, *response.Erro
 }
 
 // UpdateStore implements storeService.
-func (p *storeService) UpdateStore(data dto.UpdateStoreRequest) *response.Error {
+func (p *storeService) UpdateStore(caller *auth.Caller, data dto.UpdateStoreRequest) *response.Error {
+	if !caller.CanManageStore(data.ID) {
+		return auth.ForbiddenStore(data.ID)
+	}
+
 	checkStore, errStore := p.repository.GetStoreByID(data.ID)
 	if errStore != nil {
 		return errStore
@@ -89,19 +219,219 @@ func (p *storeService) UpdateStore(data dto.UpdateStoreRequest) *response.Error
 		data.Address = checkStore.Address
 	}
 
+	if data.Latitude == nil {
+		data.Latitude = checkStore.Latitude
+	}
+
+	if data.Longitude == nil {
+		data.Longitude = checkStore.Longitude
+	}
+
+	if data.Timezone == "" {
+		data.Timezone = checkStore.Timezone
+	}
+
+	if data.ManagerID == nil {
+		data.ManagerID = checkStore.ManagerID
+	}
+
+	if errDetail := validateStoreDetail(data.Latitude, data.Longitude, data.Timezone); errDetail != nil {
+		return errDetail
+	}
+
+	if data.PhoneNumber == "" {
+		data.PhoneNumber = checkStore.PhoneNumber
+	} else {
+		phoneNumber, err := phone.Normalize(data.PhoneNumber)
+		if err != nil {
+			return &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    fmt.Sprintf("phone number %s is invalid", data.PhoneNumber),
+				Error:      err,
+			}
+		}
+
+		data.PhoneNumber = phoneNumber
+	}
+
 	currentTime := time.Now()
 
 	dataStore := &entity.Store{
-		ID:      checkStore.ID,
-		Name:    data.Name,
-		Address: data.Address,
-		Status:  true,
-		UpdatedAt: &currentTime,
+		ID:          checkStore.ID,
+		Name:        data.Name,
+		Address:     data.Address,
+		Latitude:    data.Latitude,
+		Longitude:   data.Longitude,
+		Timezone:    data.Timezone,
+		PhoneNumber: data.PhoneNumber,
+		ManagerID:   data.ManagerID,
+		Status:      true,
+		UpdatedAt:   &currentTime,
 	}
 
 	return p.repository.UpdateStore(*dataStore)
 }
 
+// GetNearbyStore implements StoreService.
+func (p *storeService) GetNearbyStore(latitude float64, longitude float64, radiusKm float64, offset int, limit int) ([]entity.NearbyStore, *response.Error) {
+	stores, errStore := p.repository.GetNearbyStore(latitude, longitude, radiusKm, offset, limit)
+	if errStore != nil || len(stores) == 0 {
+		return stores, errStore
+	}
+
+	storeIDs := make([]uuid.UUID, len(stores))
+	for i, store := range stores {
+		storeIDs[i] = store.ID
+	}
+
+	now := time.Now()
+
+	hours, errHours := p.repository.GetOpeningHours(storeIDs)
+	if errHours != nil {
+		return nil, errHours
+	}
+
+	// two days around today covers the local date of every timezone
+	holidays, errHolidays := p.repository.GetHolidays(storeIDs, now.AddDate(0, 0, -2).Format(time.DateOnly), now.AddDate(0, 0, 2).Format(time.DateOnly))
+	if errHolidays != nil {
+		return nil, errHolidays
+	}
+
+	for i := range stores {
+		schedule := newStoreSchedule(stores[i].ID, hours, holidays)
+		stores[i].IsOpen = schedule.isOpen(stores[i].Timezone, now)
+	}
+
+	return stores, nil
+}
+
+// GetOpeningHours implements StoreService.
+func (p *storeService) GetOpeningHours(id uuid.UUID) ([]entity.OpeningHour, []entity.Holiday, *response.Error) {
+	store, errStore := p.repository.GetStoreByID(id)
+	if errStore != nil {
+		return nil, nil, errStore
+	}
+
+	hours, errHours := p.repository.GetOpeningHours([]uuid.UUID{store.ID})
+	if errHours != nil {
+		return nil, nil, errHours
+	}
+
+	// only upcoming holidays are relevant
+	today := time.Now().In(storeLocation(store.Timezone))
+	holidays, errHolidays := p.repository.GetHolidays([]uuid.UUID{store.ID}, today.Format(time.DateOnly), "infinity")
+	if errHolidays != nil {
+		return nil, nil, errHolidays
+	}
+
+	return hours, holidays, nil
+}
+
+// UpdateOpeningHours implements StoreService.
+func (p *storeService) UpdateOpeningHours(caller *auth.Caller, id uuid.UUID, data dto.UpdateOpeningHoursRequest) *response.Error {
+	if !caller.CanManageStore(id) {
+		return auth.ForbiddenStore(id)
+	}
+
+	store, errStore := p.repository.GetStoreByID(id)
+	if errStore != nil {
+		return errStore
+	}
+
+	if store.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "store is deleted",
+			Error:      nil,
+		}
+	}
+
+	var hours []entity.OpeningHour
+	seen := map[int]bool{}
+	for _, hour := range data.OpeningHours {
+		if hour.Weekday < 0 || hour.Weekday > 6 || seen[hour.Weekday] {
+			return &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    "weekday is invalid",
+				Error:      fmt.Errorf("weekday %d is invalid or duplicated", hour.Weekday),
+			}
+		}
+		seen[hour.Weekday] = true
+
+		if errTime := validateOpeningTime(hour.OpenTime, hour.CloseTime, hour.IsClosed); errTime != nil {
+			return errTime
+		}
+
+		hours = append(hours, entity.OpeningHour{
+			StoreID:   store.ID,
+			Weekday:   time.Weekday(hour.Weekday),
+			OpenTime:  hour.OpenTime,
+			CloseTime: hour.CloseTime,
+			IsClosed:  hour.IsClosed,
+		})
+	}
+
+	return p.repository.ReplaceOpeningHours(store.ID, hours)
+}
+
+// SaveHoliday implements StoreService.
+func (p *storeService) SaveHoliday(caller *auth.Caller, id uuid.UUID, data dto.HolidayRequest) *response.Error {
+	if !caller.CanManageStore(id) {
+		return auth.ForbiddenStore(id)
+	}
+
+	store, errStore := p.repository.GetStoreByID(id)
+	if errStore != nil {
+		return errStore
+	}
+
+	if store.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "store is deleted",
+			Error:      nil,
+		}
+	}
+
+	if _, err := time.Parse(time.DateOnly, data.Date); err != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "date is invalid",
+			Error:      err,
+		}
+	}
+
+	if errTime := validateOpeningTime(data.OpenTime, data.CloseTime, data.IsClosed); errTime != nil {
+		return errTime
+	}
+
+	return p.repository.UpsertHoliday(entity.Holiday{
+		StoreID:     store.ID,
+		Date:        data.Date,
+		OpenTime:    data.OpenTime,
+		CloseTime:   data.CloseTime,
+		IsClosed:    data.IsClosed,
+		Description: data.Description,
+	})
+}
+
+// DeleteHoliday implements StoreService.
+func (p *storeService) DeleteHoliday(caller *auth.Caller, id uuid.UUID, date string) *response.Error {
+	if !caller.CanManageStore(id) {
+		return auth.ForbiddenStore(id)
+	}
+
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "date is invalid",
+			Error:      err,
+		}
+	}
+
+	return p.repository.DeleteHoliday(id, date)
+}
+
 func NewStoreService(repository repository.StoreRepository) StoreService {
 	return &storeService{repository}
 }
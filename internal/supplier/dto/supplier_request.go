@@ -0,0 +1,27 @@
+package supplier
+
+import "github.com/google/uuid"
+
+type CreateSupplierRequest struct {
+	Name         string `json:"name"`
+	ContactName  string `json:"contact_name"`
+	PhoneNumber  string `json:"phone_number"`
+	Email        string `json:"email"`
+	LeadTimeDays *int   `json:"lead_time_days"`
+}
+
+type UpdateSupplierRequest struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	ContactName  string    `json:"contact_name"`
+	PhoneNumber  string    `json:"phone_number"`
+	Email        string    `json:"email"`
+	LeadTimeDays *int      `json:"lead_time_days"`
+}
+
+// SupplierProductRequest links a product to the supplier, a product bought elsewhere before moves here.
+type SupplierProductRequest struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	SupplierSKU string    `json:"supplier_sku"`
+	PackSize    int       `json:"pack_size"`
+}
@@ -0,0 +1,30 @@
+package supplier
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Supplier struct {
+	ID           uuid.UUID         `json:"id" db:"id"`
+	Name         string            `json:"name" db:"name"`
+	ContactName  string            `json:"contact_name" db:"contact_name"`
+	PhoneNumber  string            `json:"phone_number" db:"phone_number"`
+	Email        string            `json:"email" db:"email"`
+	LeadTimeDays int               `json:"lead_time_days" db:"lead_time_days"`
+	CreatedAt    *time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt    *time.Time        `json:"-" db:"updated_at"`
+	DeletedAt    *time.Time        `json:"-" db:"deleted_at"`
+	Products     []SupplierProduct `json:"products,omitempty" db:"-"`
+}
+
+// SupplierProduct links a product to the supplier it is bought from.
+type SupplierProduct struct {
+	ProductID   uuid.UUID `json:"product_id" db:"product_id"`
+	SupplierID  uuid.UUID `json:"supplier_id" db:"supplier_id"`
+	ProductSKU  string    `json:"product_sku" db:"product_sku"`
+	ProductName string    `json:"product_name" db:"product_name"`
+	SupplierSKU string    `json:"supplier_sku" db:"supplier_sku"`
+	PackSize    int       `json:"pack_size" db:"pack_size"`
+}
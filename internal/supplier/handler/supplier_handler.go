@@ -0,0 +1,231 @@
+package supplier
+
+import (
+	dto "candyshop/internal/supplier/dto"
+	service "candyshop/internal/supplier/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SupplierHandler struct {
+	service service.SupplierService
+}
+
+func NewSupplierHandler(service service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service}
+}
+
+func (h *SupplierHandler) GetAllSupplier(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	suppliers, errSupplier := h.service.GetAllSupplier(offset, limit)
+	if errSupplier != nil {
+		return c.Status(errSupplier.StatusCode).JSON(fiber.Map{
+			"status_code": errSupplier.StatusCode,
+			"message":     "failed to fetch suppliers",
+			"error":       errSupplier.Error.Error(),
+		})
+	}
+
+	if len(suppliers) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch suppliers",
+			"error":       "supplier not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data suppliers",
+		"data":        suppliers,
+	})
+}
+
+func (h *SupplierHandler) GetSupplierByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	supplier, errSupplier := h.service.GetSupplierByID(parseID)
+	if errSupplier != nil {
+		return c.Status(errSupplier.StatusCode).JSON(fiber.Map{
+			"status_code": errSupplier.StatusCode,
+			"message":     "failed to fetch supplier",
+			"error":       errSupplier.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data supplier",
+		"data":        supplier,
+	})
+}
+
+func (h *SupplierHandler) CreateSupplier(c *fiber.Ctx) error {
+	var req dto.CreateSupplierRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data supplier",
+			"error":       err.Error(),
+		})
+	}
+
+	supplier, errSupplier := h.service.CreateSupplier(auth.GetCaller(c), req)
+	if errSupplier != nil {
+		return c.Status(errSupplier.StatusCode).JSON(fiber.Map{
+			"status_code": errSupplier.StatusCode,
+			"message":     "failed to create supplier",
+			"error":       errSupplier.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data supplier",
+		"data":        supplier,
+	})
+}
+
+func (h *SupplierHandler) UpdateSupplier(c *fiber.Ctx) error {
+	var req dto.UpdateSupplierRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data supplier",
+			"error":       err.Error(),
+		})
+	}
+
+	errUpdate := h.service.UpdateSupplier(auth.GetCaller(c), req)
+	if errUpdate != nil {
+		return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+			"status_code": errUpdate.StatusCode,
+			"message":     "failed to update supplier",
+			"error":       errUpdate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success update data supplier",
+		"data":        nil,
+	})
+}
+
+func (h *SupplierHandler) DeleteSupplier(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errDelete := h.service.DeleteSupplier(auth.GetCaller(c), parseID)
+	if errDelete != nil {
+		if errDelete.StatusCode == fiber.StatusConflict {
+			return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+				"status_code": errDelete.StatusCode,
+				"message":     "failed to delete supplier",
+				"error":       errDelete.Message,
+			})
+		}
+
+		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+			"status_code": errDelete.StatusCode,
+			"message":     "failed to delete supplier",
+			"error":       errDelete.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete data supplier",
+		"data":        nil,
+	})
+}
+
+func (h *SupplierHandler) SetSupplierProduct(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.SupplierProductRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data supplier product",
+			"error":       err.Error(),
+		})
+	}
+
+	errProduct := h.service.SetSupplierProduct(auth.GetCaller(c), parseID, req)
+	if errProduct != nil {
+		return c.Status(errProduct.StatusCode).JSON(fiber.Map{
+			"status_code": errProduct.StatusCode,
+			"message":     "failed to save supplier product",
+			"error":       errProduct.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success save supplier product",
+		"data":        nil,
+	})
+}
+
+func (h *SupplierHandler) DeleteSupplierProduct(c *fiber.Ctx) error {
+	supplierID, errParse := uuid.Parse(c.Params("id"))
+	productID, errParseProduct := uuid.Parse(c.Params("product_id"))
+	if errParse != nil || errParseProduct != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       "id is invalid",
+		})
+	}
+
+	errProduct := h.service.DeleteSupplierProduct(auth.GetCaller(c), supplierID, productID)
+	if errProduct != nil {
+		return c.Status(errProduct.StatusCode).JSON(fiber.Map{
+			"status_code": errProduct.StatusCode,
+			"message":     "failed to delete supplier product",
+			"error":       errProduct.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete supplier product",
+		"data":        nil,
+	})
+}
@@ -0,0 +1,261 @@
+package supplier
+
+import (
+	entity "candyshop/internal/supplier/entity"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type SupplierRepository interface {
+	GetAllSupplier(offset, limit int) ([]entity.Supplier, *response.Error)
+	GetSupplierByID(id uuid.UUID) (*entity.Supplier, *response.Error)
+	CreateSupplier(data entity.Supplier) (*entity.Supplier, *response.Error)
+	UpdateSupplier(data entity.Supplier) *response.Error
+	DeleteSupplier(id uuid.UUID, deletedAt time.Time) *response.Error
+	SetSupplierProduct(data entity.SupplierProduct) *response.Error
+	DeleteSupplierProduct(supplierID, productID uuid.UUID) *response.Error
+}
+
+type supplierRepository struct {
+	db *sqlx.DB
+}
+
+// GetAllSupplier implements SupplierRepository.
+func (s *supplierRepository) GetAllSupplier(offset int, limit int) ([]entity.Supplier, *response.Error) {
+	var suppliers []entity.Supplier
+
+	query := `
+		SELECT id, name, contact_name, phone_number, email, lead_time_days, created_at, updated_at, deleted_at
+		FROM suppliers
+		WHERE deleted_at IS NULL
+		ORDER BY name, id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := s.db.Select(&suppliers, query, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get all supplier").Msg("failed to get all supplier")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch suppliers",
+			Error:      err,
+		}
+	}
+
+	return suppliers, nil
+}
+
+// GetSupplierByID implements SupplierRepository.
+func (s *supplierRepository) GetSupplierByID(id uuid.UUID) (*entity.Supplier, *response.Error) {
+	var supplier entity.Supplier
+
+	query := `
+		SELECT id, name, contact_name, phone_number, email, lead_time_days, created_at, updated_at, deleted_at
+		FROM suppliers
+		WHERE id = $1
+	`
+
+	err := s.db.Get(&supplier, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch supplier",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get supplier by id").Msg("failed to get supplier by id")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch supplier",
+			Error:      err,
+		}
+	}
+
+	errProducts := s.db.Select(&supplier.Products, `
+		SELECT ps.product_id, ps.supplier_id, p.sku AS product_sku, p.name AS product_name, ps.supplier_sku, ps.pack_size
+		FROM product_suppliers ps
+		JOIN products p ON p.id = ps.product_id
+		WHERE ps.supplier_id = $1 AND p.deleted_at IS NULL
+		ORDER BY p.name, p.id
+	`, id)
+	if errProducts != nil {
+		log.Error().Err(errProducts).Int("status", 500).Str("function", "get supplier by id").Msg("failed to get supplier products")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch supplier",
+			Error:      errProducts,
+		}
+	}
+
+	return &supplier, nil
+}
+
+// CreateSupplier implements SupplierRepository.
+func (s *supplierRepository) CreateSupplier(data entity.Supplier) (*entity.Supplier, *response.Error) {
+	var model entity.Supplier
+
+	query := `
+		INSERT INTO suppliers (id, name, contact_name, phone_number, email, lead_time_days) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, contact_name, phone_number, email, lead_time_days, created_at
+	`
+
+	err := s.db.QueryRowx(query,
+		data.ID,
+		data.Name,
+		data.ContactName,
+		data.PhoneNumber,
+		data.Email,
+		data.LeadTimeDays).StructScan(&model)
+
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create supplier").Msg("failed to create supplier")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create supplier",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// UpdateSupplier implements SupplierRepository.
+func (s *supplierRepository) UpdateSupplier(data entity.Supplier) *response.Error {
+	query := `
+		UPDATE suppliers SET name = $2, contact_name = $3, phone_number = $4, email = $5, lead_time_days = $6, updated_at = $7
+		WHERE id = $1
+	`
+
+	_, err := s.db.Exec(query,
+		data.ID,
+		data.Name,
+		data.ContactName,
+		data.PhoneNumber,
+		data.Email,
+		data.LeadTimeDays,
+		data.UpdatedAt,
+	)
+
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update supplier").Msg("failed to update supplier")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update supplier",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// DeleteSupplier implements SupplierRepository.
+func (s *supplierRepository) DeleteSupplier(id uuid.UUID, deletedAt time.Time) *response.Error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete supplier").Msg("failed to delete supplier")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the products of a deleted supplier have no supplier until they are linked again
+	_, errUnlink := tx.Exec(`DELETE FROM product_suppliers WHERE supplier_id = $1`, id)
+	if errUnlink != nil {
+		log.Error().Err(errUnlink).Int("status", 500).Str("function", "delete supplier").Msg("failed to delete supplier")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete supplier",
+			Error:      errUnlink,
+		}
+	}
+
+	_, errExec := tx.Exec(`UPDATE suppliers SET deleted_at = $2 WHERE id = $1`, id, deletedAt)
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "delete supplier").Msg("failed to delete supplier")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete supplier",
+			Error:      errExec,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete supplier").Msg("failed to delete supplier")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// SetSupplierProduct implements SupplierRepository.
+func (s *supplierRepository) SetSupplierProduct(data entity.SupplierProduct) *response.Error {
+	query := `
+		INSERT INTO product_suppliers (product_id, supplier_id, supplier_sku, pack_size) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id) DO UPDATE SET supplier_id = $2, supplier_sku = $3, pack_size = $4
+	`
+
+	_, err := s.db.Exec(query, data.ProductID, data.SupplierID, data.SupplierSKU, data.PackSize)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return &response.Error{
+				StatusCode: 404,
+				Message:    "product not found",
+				Error:      errors.New("product not found"),
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "set supplier product").Msg("failed to set supplier product")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to save supplier product",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// DeleteSupplierProduct implements SupplierRepository.
+func (s *supplierRepository) DeleteSupplierProduct(supplierID uuid.UUID, productID uuid.UUID) *response.Error {
+	result, err := s.db.Exec(`DELETE FROM product_suppliers WHERE supplier_id = $1 AND product_id = $2`, supplierID, productID)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete supplier product").Msg("failed to delete supplier product")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete supplier product",
+			Error:      err,
+		}
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &response.Error{
+			StatusCode: 404,
+			Message:    "supplier product not found",
+			Error:      errors.New("supplier product not found"),
+		}
+	}
+
+	return nil
+}
+
+func NewSupplierRepository(db *sqlx.DB) SupplierRepository {
+	return &supplierRepository{db}
+}
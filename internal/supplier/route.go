@@ -0,0 +1,27 @@
+package supplier
+
+import (
+	staff "candyshop/internal/staff"
+	handler "candyshop/internal/supplier/handler"
+	repository "candyshop/internal/supplier/repository"
+	service "candyshop/internal/supplier/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewSupplierRepository(db)
+	service := service.NewSupplierService(repo)
+	handler := handler.NewSupplierHandler(service)
+
+	supplierRoute := router.Group("api/v1/suppliers", staff.Authenticate(db))
+
+	supplierRoute.Get("", handler.GetAllSupplier)
+	supplierRoute.Post("", handler.CreateSupplier)
+	supplierRoute.Get("/:id", handler.GetSupplierByID)
+	supplierRoute.Patch("", handler.UpdateSupplier)
+	supplierRoute.Patch("/delete/:id", handler.DeleteSupplier)
+	supplierRoute.Post("/:id/products", handler.SetSupplierProduct)
+	supplierRoute.Delete("/:id/products/:product_id", handler.DeleteSupplierProduct)
+}
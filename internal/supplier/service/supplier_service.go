@@ -0,0 +1,244 @@
+package supplier
+
+import (
+	dto "candyshop/internal/supplier/dto"
+	entity "candyshop/internal/supplier/entity"
+	repository "candyshop/internal/supplier/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/phone"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DefaultLeadTimeDays is used when a supplier is created without a lead time
+const DefaultLeadTimeDays = 7
+
+type SupplierService interface {
+	GetAllSupplier(offset, limit int) ([]entity.Supplier, *response.Error)
+	GetSupplierByID(id uuid.UUID) (*entity.Supplier, *response.Error)
+	CreateSupplier(caller *auth.Caller, data dto.CreateSupplierRequest) (*entity.Supplier, *response.Error)
+	UpdateSupplier(caller *auth.Caller, data dto.UpdateSupplierRequest) *response.Error
+	DeleteSupplier(caller *auth.Caller, id uuid.UUID) *response.Error
+	SetSupplierProduct(caller *auth.Caller, supplierID uuid.UUID, data dto.SupplierProductRequest) *response.Error
+	DeleteSupplierProduct(caller *auth.Caller, supplierID, productID uuid.UUID) *response.Error
+}
+
+type supplierService struct {
+	repository repository.SupplierRepository
+}
+
+// GetAllSupplier implements SupplierService.
+func (s *supplierService) GetAllSupplier(offset int, limit int) ([]entity.Supplier, *response.Error) {
+	return s.repository.GetAllSupplier(offset, limit)
+}
+
+// GetSupplierByID implements SupplierService.
+func (s *supplierService) GetSupplierByID(id uuid.UUID) (*entity.Supplier, *response.Error) {
+	supplier, errSupplier := s.repository.GetSupplierByID(id)
+	if errSupplier != nil {
+		return nil, errSupplier
+	}
+
+	if supplier.DeletedAt != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusNotFound,
+			Message:    "supplier is not active",
+			Error:      errors.New("supplier is not active"),
+		}
+	}
+
+	return supplier, nil
+}
+
+// CreateSupplier implements SupplierService.
+func (s *supplierService) CreateSupplier(caller *auth.Caller, data dto.CreateSupplierRequest) (*entity.Supplier, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	leadTimeDays := DefaultLeadTimeDays
+	if data.LeadTimeDays != nil {
+		leadTimeDays = *data.LeadTimeDays
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataSupplier := &entity.Supplier{
+		ID:           newUUID,
+		Name:         strings.TrimSpace(data.Name),
+		ContactName:  strings.TrimSpace(data.ContactName),
+		PhoneNumber:  data.PhoneNumber,
+		Email:        strings.TrimSpace(data.Email),
+		LeadTimeDays: leadTimeDays,
+	}
+
+	if errValidate := validateSupplier(dataSupplier); errValidate != nil {
+		return nil, errValidate
+	}
+
+	return s.repository.CreateSupplier(*dataSupplier)
+}
+
+// UpdateSupplier implements SupplierService.
+func (s *supplierService) UpdateSupplier(caller *auth.Caller, data dto.UpdateSupplierRequest) *response.Error {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return errOwner
+	}
+
+	supplier, errSupplier := s.GetSupplierByID(data.ID)
+	if errSupplier != nil {
+		return errSupplier
+	}
+
+	if strings.TrimSpace(data.Name) != "" {
+		supplier.Name = strings.TrimSpace(data.Name)
+	}
+
+	if strings.TrimSpace(data.ContactName) != "" {
+		supplier.ContactName = strings.TrimSpace(data.ContactName)
+	}
+
+	if data.PhoneNumber != "" {
+		supplier.PhoneNumber = data.PhoneNumber
+	}
+
+	if strings.TrimSpace(data.Email) != "" {
+		supplier.Email = strings.TrimSpace(data.Email)
+	}
+
+	if data.LeadTimeDays != nil {
+		supplier.LeadTimeDays = *data.LeadTimeDays
+	}
+
+	if errValidate := validateSupplier(supplier); errValidate != nil {
+		return errValidate
+	}
+
+	currentTime := time.Now()
+	supplier.UpdatedAt = &currentTime
+
+	return s.repository.UpdateSupplier(*supplier)
+}
+
+// DeleteSupplier implements SupplierService.
+func (s *supplierService) DeleteSupplier(caller *auth.Caller, id uuid.UUID) *response.Error {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return errOwner
+	}
+
+	supplier, errSupplier := s.repository.GetSupplierByID(id)
+	if errSupplier != nil {
+		return errSupplier
+	}
+
+	// check if supplier is deleted
+	if supplier.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "supplier is not active",
+			Error:      nil,
+		}
+	}
+
+	currentTime := time.Now()
+
+	return s.repository.DeleteSupplier(id, currentTime)
+}
+
+// SetSupplierProduct implements SupplierService.
+func (s *supplierService) SetSupplierProduct(caller *auth.Caller, supplierID uuid.UUID, data dto.SupplierProductRequest) *response.Error {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return errOwner
+	}
+
+	if _, errSupplier := s.GetSupplierByID(supplierID); errSupplier != nil {
+		return errSupplier
+	}
+
+	if data.ProductID == uuid.Nil || data.PackSize < 0 {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "product_id is required and pack_size can not be negative",
+			Error:      errors.New("product_id is required and pack_size can not be negative"),
+		}
+	}
+
+	if data.PackSize == 0 {
+		data.PackSize = 1
+	}
+
+	return s.repository.SetSupplierProduct(entity.SupplierProduct{
+		ProductID:   data.ProductID,
+		SupplierID:  supplierID,
+		SupplierSKU: strings.TrimSpace(data.SupplierSKU),
+		PackSize:    data.PackSize,
+	})
+}
+
+// DeleteSupplierProduct implements SupplierService.
+func (s *supplierService) DeleteSupplierProduct(caller *auth.Caller, supplierID uuid.UUID, productID uuid.UUID) *response.Error {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return errOwner
+	}
+
+	return s.repository.DeleteSupplierProduct(supplierID, productID)
+}
+
+func validateSupplier(supplier *entity.Supplier) *response.Error {
+	invalid := func(message string, err error) *response.Error {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    message,
+			Error:      err,
+		}
+	}
+
+	if supplier.Name == "" {
+		return invalid("name is required", errors.New("name is required"))
+	}
+
+	if supplier.LeadTimeDays < 0 {
+		return invalid("lead_time_days can not be negative", errors.New("lead_time_days can not be negative"))
+	}
+
+	if supplier.PhoneNumber != "" {
+		phoneNumber, err := phone.Normalize(supplier.PhoneNumber)
+		if err != nil {
+			return invalid(fmt.Sprintf("phone number %s is invalid", supplier.PhoneNumber), err)
+		}
+
+		supplier.PhoneNumber = phoneNumber
+	}
+
+	if supplier.Email != "" {
+		if _, err := mail.ParseAddress(supplier.Email); err != nil {
+			return invalid(fmt.Sprintf("email %s is invalid", supplier.Email), err)
+		}
+	}
+
+	return nil
+}
+
+// onlyOwner keeps the supplier list in the hands of the owner, every store buys from the same suppliers.
+func onlyOwner(caller *auth.Caller) *response.Error {
+	if caller.IsOwner() {
+		return nil
+	}
+
+	return &response.Error{
+		StatusCode: fiber.StatusForbidden,
+		Message:    "only owner can manage suppliers",
+		Error:      errors.New("only owner can manage suppliers"),
+	}
+}
+
+func NewSupplierService(repository repository.SupplierRepository) SupplierService {
+	return &supplierService{repository}
+}
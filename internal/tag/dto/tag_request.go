@@ -0,0 +1,12 @@
+package tag
+
+import "github.com/google/uuid"
+
+type CreateTagRequest struct {
+	Name string `json:"name"`
+}
+
+type UpdateTagRequest struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
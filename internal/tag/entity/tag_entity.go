@@ -0,0 +1,14 @@
+package tag
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Tag struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	Name         string     `json:"name" db:"name"`
+	ProductCount int        `json:"product_count" db:"product_count"`
+	CreatedAt    *time.Time `json:"created_at" db:"created_at"`
+}
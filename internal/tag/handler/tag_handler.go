@@ -0,0 +1,146 @@
+package tag
+
+import (
+	dto "candyshop/internal/tag/dto"
+	service "candyshop/internal/tag/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TagHandler struct {
+	service service.TagService
+}
+
+func NewTagHandler(service service.TagService) *TagHandler {
+	return &TagHandler{service}
+}
+
+func (h *TagHandler) GetAllTag(c *fiber.Ctx) error {
+	tags, errTag := h.service.GetAllTag()
+	if errTag != nil {
+		return c.Status(errTag.StatusCode).JSON(fiber.Map{
+			"status_code": errTag.StatusCode,
+			"message":     "failed to fetch tags",
+			"error":       errTag.Error.Error(),
+		})
+	}
+
+	if len(tags) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch tags",
+			"error":       "tag not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data tags",
+		"data":        tags,
+	})
+}
+
+func (h *TagHandler) CreateTag(c *fiber.Ctx) error {
+	var req dto.CreateTagRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data tag",
+			"error":       err.Error(),
+		})
+	}
+
+	tag, errTag := h.service.CreateTag(req)
+	if errTag != nil {
+		if errTag.StatusCode == fiber.StatusConflict {
+			return c.Status(errTag.StatusCode).JSON(fiber.Map{
+				"status_code": errTag.StatusCode,
+				"message":     "failed to create tag",
+				"error":       errTag.Message,
+			})
+		}
+
+		return c.Status(errTag.StatusCode).JSON(fiber.Map{
+			"status_code": errTag.StatusCode,
+			"message":     "failed to create tag",
+			"error":       errTag.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data tag",
+		"data":        tag,
+	})
+}
+
+func (h *TagHandler) UpdateTag(c *fiber.Ctx) error {
+	var req dto.UpdateTagRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data tag",
+			"error":       err.Error(),
+		})
+	}
+
+	errUpdate := h.service.UpdateTag(req)
+	if errUpdate != nil {
+		if errUpdate.StatusCode == fiber.StatusConflict {
+			return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+				"status_code": errUpdate.StatusCode,
+				"message":     "failed to update tag",
+				"error":       errUpdate.Message,
+			})
+		}
+
+		return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+			"status_code": errUpdate.StatusCode,
+			"message":     "failed to update tag",
+			"error":       errUpdate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success update data tag",
+		"data":        nil,
+	})
+}
+
+func (h *TagHandler) DeleteTag(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errDelete := h.service.DeleteTag(parseID)
+	if errDelete != nil {
+		if errDelete.StatusCode == fiber.StatusConflict {
+			return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+				"status_code": errDelete.StatusCode,
+				"message":     "failed to delete tag",
+				"error":       errDelete.Message,
+			})
+		}
+
+		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+			"status_code": errDelete.StatusCode,
+			"message":     "failed to delete tag",
+			"error":       errDelete.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete data tag",
+		"data":        nil,
+	})
+}
@@ -0,0 +1,139 @@
+package tag
+
+import (
+	entity "candyshop/internal/tag/entity"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+type TagRepository interface {
+	GetAllTag() ([]entity.Tag, *response.Error)
+	GetTagByID(id uuid.UUID) (*entity.Tag, *response.Error)
+	GetTagByName(name string) (*entity.Tag, *response.Error)
+	CreateTag(data entity.Tag) (*entity.Tag, *response.Error)
+	UpdateTag(data entity.Tag) *response.Error
+	DeleteTag(id uuid.UUID) *response.Error
+}
+
+type tagRepository struct {
+	db *sqlx.DB
+}
+
+// GetAllTag implements TagRepository.
+func (t *tagRepository) GetAllTag() ([]entity.Tag, *response.Error) {
+	var tags []entity.Tag
+
+	query := `
+		SELECT t.id, t.name, t.created_at, COUNT(p.id) AS product_count
+		FROM tags t
+		LEFT JOIN product_tags pt ON pt.tag_id = t.id
+		LEFT JOIN products p ON p.id = pt.product_id AND p.deleted_at IS NULL
+		GROUP BY t.id
+		ORDER BY t.name
+	`
+
+	err := t.db.Select(&tags, query)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get all tag").Msg("failed to get all tag")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch tags",
+			Error:      err,
+		}
+	}
+
+	return tags, nil
+}
+
+// GetTagByID implements TagRepository.
+func (t *tagRepository) GetTagByID(id uuid.UUID) (*entity.Tag, *response.Error) {
+	return t.getTag("get tag by id", `WHERE id = $1`, id)
+}
+
+// GetTagByName implements TagRepository.
+func (t *tagRepository) GetTagByName(name string) (*entity.Tag, *response.Error) {
+	return t.getTag("get tag by name", `WHERE name = $1`, name)
+}
+
+func (t *tagRepository) getTag(function string, where string, arg any) (*entity.Tag, *response.Error) {
+	var tag entity.Tag
+
+	err := t.db.Get(&tag, `SELECT id, name, created_at FROM tags `+where, arg)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch tag",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch tag",
+			Error:      err,
+		}
+	}
+
+	return &tag, nil
+}
+
+// CreateTag implements TagRepository.
+func (t *tagRepository) CreateTag(data entity.Tag) (*entity.Tag, *response.Error) {
+	var model entity.Tag
+
+	query := `INSERT INTO tags (id, name) VALUES ($1, $2) RETURNING id, name, created_at`
+
+	errInsert := t.db.QueryRowx(query, data.ID, data.Name).Scan(&model.ID, &model.Name, &model.CreatedAt)
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "create tag").Msg("failed to create tag")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create tag",
+			Error:      errInsert,
+		}
+	}
+
+	return &model, nil
+}
+
+// UpdateTag implements TagRepository.
+func (t *tagRepository) UpdateTag(data entity.Tag) *response.Error {
+	_, errExec := t.db.Exec(`UPDATE tags SET name = $2 WHERE id = $1`, data.ID, data.Name)
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "update tag").Msg("failed to update tag")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to update tag",
+			Error:      errExec,
+		}
+	}
+
+	return nil
+}
+
+// DeleteTag implements TagRepository.
+func (t *tagRepository) DeleteTag(id uuid.UUID) *response.Error {
+	// tags have no history, the product links go with the tag
+	_, errExec := t.db.Exec(`DELETE FROM tags WHERE id = $1`, id)
+	if errExec != nil {
+		log.Error().Err(errExec).Int("status", 500).Str("function", "delete tag").Msg("failed to delete tag")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to delete tag",
+			Error:      errExec,
+		}
+	}
+
+	return nil
+}
+
+func NewTagRepository(db *sqlx.DB) TagRepository {
+	return &tagRepository{db}
+}
@@ -0,0 +1,23 @@
+package tag
+
+import (
+	handler "candyshop/internal/tag/handler"
+	repository "candyshop/internal/tag/repository"
+	service "candyshop/internal/tag/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewTagRepository(db)
+	service := service.NewTagService(repo)
+	handler := handler.NewTagHandler(service)
+
+	tagRoute := router.Group("api/v1/tags")
+
+	tagRoute.Get("", handler.GetAllTag)
+	tagRoute.Post("", handler.CreateTag)
+	tagRoute.Patch("", handler.UpdateTag)
+	tagRoute.Patch("/delete/:id", handler.DeleteTag)
+}
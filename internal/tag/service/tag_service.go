@@ -0,0 +1,105 @@
+package tag
+
+import (
+	dto "candyshop/internal/tag/dto"
+	entity "candyshop/internal/tag/entity"
+	repository "candyshop/internal/tag/repository"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TagService interface {
+	GetAllTag() ([]entity.Tag, *response.Error)
+	CreateTag(data dto.CreateTagRequest) (*entity.Tag, *response.Error)
+	UpdateTag(data dto.UpdateTagRequest) *response.Error
+	DeleteTag(id uuid.UUID) *response.Error
+}
+
+type tagService struct {
+	repository repository.TagRepository
+}
+
+// GetAllTag implements TagService.
+func (t *tagService) GetAllTag() ([]entity.Tag, *response.Error) {
+	return t.repository.GetAllTag()
+}
+
+// CreateTag implements TagService.
+func (t *tagService) CreateTag(data dto.CreateTagRequest) (*entity.Tag, *response.Error) {
+	name, errName := t.checkName(data.Name, uuid.Nil)
+	if errName != nil {
+		return nil, errName
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	return t.repository.CreateTag(entity.Tag{ID: newUUID, Name: name})
+}
+
+// UpdateTag implements TagService.
+func (t *tagService) UpdateTag(data dto.UpdateTagRequest) *response.Error {
+	// check if tag is exist
+	tag, errTag := t.repository.GetTagByID(data.ID)
+	if errTag != nil {
+		return errTag
+	}
+
+	name, errName := t.checkName(data.Name, tag.ID)
+	if errName != nil {
+		return errName
+	}
+
+	tag.Name = name
+
+	return t.repository.UpdateTag(*tag)
+}
+
+// DeleteTag implements TagService.
+func (t *tagService) DeleteTag(id uuid.UUID) *response.Error {
+	if _, errTag := t.repository.GetTagByID(id); errTag != nil {
+		return errTag
+	}
+
+	return t.repository.DeleteTag(id)
+}
+
+// checkName normalizes the name and checks that no other tag uses it.
+func (t *tagService) checkName(name string, id uuid.UUID) (string, *response.Error) {
+	name = NormalizeName(name)
+	if name == "" {
+		return "", &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "name is required",
+			Error:      errors.New("name is required"),
+		}
+	}
+
+	checkTag, errTag := t.repository.GetTagByName(name)
+	if errTag != nil && errTag.StatusCode != 404 {
+		return "", errTag
+	}
+
+	if checkTag != nil && checkTag.ID != id {
+		return "", &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    fmt.Sprintf("tag %s already registered", name),
+			Error:      nil,
+		}
+	}
+
+	return name, nil
+}
+
+// NormalizeName lower cases a tag and joins its words with dashes, Gluten Free becomes gluten-free.
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+func NewTagService(repository repository.TagRepository) TagService {
+	return &tagService{repository}
+}
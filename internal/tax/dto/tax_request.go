@@ -0,0 +1,38 @@
+package tax
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateTaxRequest struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+	// Rate is the first rate in basis points, it applies from now
+	Rate *int `json:"rate"`
+}
+
+type UpdateTaxRequest struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	IsDefault *bool     `json:"is_default"`
+}
+
+type RateRequest struct {
+	Rate          int       `json:"rate"`
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// AssignmentRequest names a category or a product, not both
+type AssignmentRequest struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+	ProductID  *uuid.UUID `json:"product_id"`
+}
+
+type StoreSettingRequest struct {
+	Mode     string `json:"mode"`
+	Rounding string `json:"rounding"`
+}
@@ -0,0 +1,83 @@
+package tax
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tax is a tax like VAT, its rate changes over time. Rates are in basis points, 1100 is 11%.
+// CurrentRate is the rate that applies now, nil before the first rate starts.
+type Tax struct {
+	ID          uuid.UUID    `json:"id" db:"id"`
+	Code        string       `json:"code" db:"code"`
+	Name        string       `json:"name" db:"name"`
+	IsDefault   bool         `json:"is_default" db:"is_default"`
+	CurrentRate *int         `json:"current_rate" db:"current_rate"`
+	CreatedAt   *time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time   `json:"-" db:"updated_at"`
+	DeletedAt   *time.Time   `json:"-" db:"deleted_at"`
+	Rates       []Rate       `json:"rates,omitempty" db:"-"`
+	Assignments []Assignment `json:"assignments,omitempty" db:"-"`
+}
+
+// Rate applies from its effective time until the next rate of the tax starts.
+type Rate struct {
+	TaxID         uuid.UUID  `json:"-" db:"tax_id"`
+	Rate          int        `json:"rate" db:"rate"`
+	EffectiveFrom time.Time  `json:"effective_from" db:"effective_from"`
+	CreatedAt     *time.Time `json:"created_at" db:"created_at"`
+}
+
+// Assignment puts a product or every product of a category under a tax.
+type Assignment struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	TaxID      uuid.UUID  `json:"tax_id" db:"tax_id"`
+	CategoryID *uuid.UUID `json:"category_id" db:"category_id"`
+	ProductID  *uuid.UUID `json:"product_id" db:"product_id"`
+	CreatedAt  *time.Time `json:"created_at" db:"created_at"`
+}
+
+// StoreSetting is how a store prices tax, see the modes and roundings of pkg/tax.
+type StoreSetting struct {
+	StoreID   uuid.UUID  `json:"store_id" db:"store_id"`
+	Mode      string     `json:"mode" db:"mode"`
+	Rounding  string     `json:"rounding" db:"rounding"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProductTax is the tax and rate a product is sold with at some time.
+type ProductTax struct {
+	ProductID uuid.UUID `db:"product_id"`
+	TaxID     uuid.UUID `db:"tax_id"`
+	Code      string    `db:"code"`
+	Name      string    `db:"name"`
+	Rate      int       `db:"rate"`
+}
+
+// Quote is the tax of priced lines in a store, Net is a line amount after its discounts.
+type Quote struct {
+	Mode     string      `json:"mode"`
+	Rounding string      `json:"rounding"`
+	Lines    []QuoteLine `json:"lines"`
+	Taxes    []QuoteTax  `json:"taxes"`
+	Tax      int64       `json:"tax"`
+}
+
+type QuoteLine struct {
+	ProductID uuid.UUID  `json:"product_id"`
+	Net       int64      `json:"net"`
+	TaxID     *uuid.UUID `json:"tax_id"`
+	Rate      int        `json:"rate"`
+	Tax       int64      `json:"tax"`
+}
+
+// QuoteTax is the tax of the lines under one tax, Taxable is their amount without the tax.
+type QuoteTax struct {
+	TaxID   uuid.UUID `json:"tax_id"`
+	Code    string    `json:"code"`
+	Name    string    `json:"name"`
+	Rate    int       `json:"rate"`
+	Taxable int64     `json:"taxable"`
+	Tax     int64     `json:"tax"`
+}
@@ -0,0 +1,353 @@
+package tax
+
+import (
+	dto "candyshop/internal/tax/dto"
+	service "candyshop/internal/tax/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TaxHandler struct {
+	service service.TaxService
+}
+
+func NewTaxHandler(service service.TaxService) *TaxHandler {
+	return &TaxHandler{service}
+}
+
+func (h *TaxHandler) GetAllTax(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	taxes, errTax := h.service.GetAllTax(offset, limit)
+	if errTax != nil {
+		return c.Status(errTax.StatusCode).JSON(fiber.Map{
+			"status_code": errTax.StatusCode,
+			"message":     "failed to fetch taxes",
+			"error":       errTax.Error.Error(),
+		})
+	}
+
+	if len(taxes) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch taxes",
+			"error":       "tax not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data taxes",
+		"data":        taxes,
+	})
+}
+
+func (h *TaxHandler) GetTaxByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	tax, errTax := h.service.GetTaxByID(parseID)
+	if errTax != nil {
+		return c.Status(errTax.StatusCode).JSON(fiber.Map{
+			"status_code": errTax.StatusCode,
+			"message":     "failed to fetch tax",
+			"error":       errTax.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data tax",
+		"data":        tax,
+	})
+}
+
+func (h *TaxHandler) CreateTax(c *fiber.Ctx) error {
+	var req dto.CreateTaxRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data tax",
+			"error":       err.Error(),
+		})
+	}
+
+	tax, errTax := h.service.CreateTax(auth.GetCaller(c), req)
+	if errTax != nil {
+		if errTax.StatusCode == fiber.StatusConflict {
+			return c.Status(errTax.StatusCode).JSON(fiber.Map{
+				"status_code": errTax.StatusCode,
+				"message":     "failed to create tax",
+				"error":       errTax.Message,
+			})
+		}
+
+		return c.Status(errTax.StatusCode).JSON(fiber.Map{
+			"status_code": errTax.StatusCode,
+			"message":     "failed to create tax",
+			"error":       errTax.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create data tax",
+		"data":        tax,
+	})
+}
+
+func (h *TaxHandler) UpdateTax(c *fiber.Ctx) error {
+	var req dto.UpdateTaxRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data tax",
+			"error":       err.Error(),
+		})
+	}
+
+	errUpdate := h.service.UpdateTax(auth.GetCaller(c), req)
+	if errUpdate != nil {
+		if errUpdate.StatusCode == fiber.StatusConflict {
+			return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+				"status_code": errUpdate.StatusCode,
+				"message":     "failed to update tax",
+				"error":       errUpdate.Message,
+			})
+		}
+
+		return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
+			"status_code": errUpdate.StatusCode,
+			"message":     "failed to update tax",
+			"error":       errUpdate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success update data tax",
+		"data":        nil,
+	})
+}
+
+func (h *TaxHandler) DeleteTax(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errDelete := h.service.DeleteTax(auth.GetCaller(c), parseID)
+	if errDelete != nil {
+		if errDelete.StatusCode == fiber.StatusConflict {
+			return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+				"status_code": errDelete.StatusCode,
+				"message":     "failed to delete tax",
+				"error":       errDelete.Message,
+			})
+		}
+
+		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
+			"status_code": errDelete.StatusCode,
+			"message":     "failed to delete tax",
+			"error":       errDelete.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete data tax",
+		"data":        nil,
+	})
+}
+
+func (h *TaxHandler) AddRate(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.RateRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data tax rate",
+			"error":       err.Error(),
+		})
+	}
+
+	tax, errRate := h.service.AddRate(auth.GetCaller(c), parseID, req)
+	if errRate != nil {
+		if errRate.StatusCode == fiber.StatusConflict {
+			return c.Status(errRate.StatusCode).JSON(fiber.Map{
+				"status_code": errRate.StatusCode,
+				"message":     "failed to add tax rate",
+				"error":       errRate.Message,
+			})
+		}
+
+		return c.Status(errRate.StatusCode).JSON(fiber.Map{
+			"status_code": errRate.StatusCode,
+			"message":     "failed to add tax rate",
+			"error":       errRate.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success add tax rate",
+		"data":        tax,
+	})
+}
+
+func (h *TaxHandler) SaveAssignment(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.AssignmentRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data tax assignment",
+			"error":       err.Error(),
+		})
+	}
+
+	tax, errAssignment := h.service.SaveAssignment(auth.GetCaller(c), parseID, req)
+	if errAssignment != nil {
+		return c.Status(errAssignment.StatusCode).JSON(fiber.Map{
+			"status_code": errAssignment.StatusCode,
+			"message":     "failed to save tax assignment",
+			"error":       errAssignment.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success save tax assignment",
+		"data":        tax,
+	})
+}
+
+func (h *TaxHandler) DeleteAssignment(c *fiber.Ctx) error {
+	taxID, errParse := uuid.Parse(c.Params("id"))
+	assignmentID, errParseAssignment := uuid.Parse(c.Params("assignment_id"))
+	if errParse != nil || errParseAssignment != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       "id is invalid",
+		})
+	}
+
+	errAssignment := h.service.DeleteAssignment(auth.GetCaller(c), taxID, assignmentID)
+	if errAssignment != nil {
+		return c.Status(errAssignment.StatusCode).JSON(fiber.Map{
+			"status_code": errAssignment.StatusCode,
+			"message":     "failed to delete tax assignment",
+			"error":       errAssignment.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success delete tax assignment",
+		"data":        nil,
+	})
+}
+
+func (h *TaxHandler) GetStoreSetting(c *fiber.Ctx) error {
+	storeID, errParse := uuid.Parse(c.Params("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	setting, errSetting := h.service.GetStoreSetting(auth.GetCaller(c), storeID)
+	if errSetting != nil {
+		return c.Status(errSetting.StatusCode).JSON(fiber.Map{
+			"status_code": errSetting.StatusCode,
+			"message":     "failed to fetch store tax setting",
+			"error":       errSetting.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get store tax setting",
+		"data":        setting,
+	})
+}
+
+func (h *TaxHandler) SaveStoreSetting(c *fiber.Ctx) error {
+	storeID, errParse := uuid.Parse(c.Params("store_id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "store_id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.StoreSettingRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data store tax setting",
+			"error":       err.Error(),
+		})
+	}
+
+	setting, errSetting := h.service.SaveStoreSetting(auth.GetCaller(c), storeID, req)
+	if errSetting != nil {
+		return c.Status(errSetting.StatusCode).JSON(fiber.Map{
+			"status_code": errSetting.StatusCode,
+			"message":     "failed to save store tax setting",
+			"error":       errSetting.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success save store tax setting",
+		"data":        setting,
+	})
+}
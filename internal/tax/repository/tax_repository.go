@@ -0,0 +1,437 @@
+package tax
+
+import (
+	entity "candyshop/internal/tax/entity"
+	"candyshop/pkg/response"
+	"candyshop/pkg/tax"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type TaxRepository interface {
+	GetAllTax(offset, limit int) ([]entity.Tax, *response.Error)
+	GetTaxByID(id uuid.UUID) (*entity.Tax, *response.Error)
+	CreateTax(data entity.Tax, rate *entity.Rate) *response.Error
+	UpdateTax(data entity.Tax) *response.Error
+	DeleteTax(id uuid.UUID, deletedAt time.Time) *response.Error
+	AddRate(data entity.Rate) *response.Error
+	SaveAssignment(data entity.Assignment) *response.Error
+	DeleteAssignment(taxID, id uuid.UUID) *response.Error
+	GetStoreSetting(storeID uuid.UUID) (*entity.StoreSetting, *response.Error)
+	SaveStoreSetting(data entity.StoreSetting) *response.Error
+	GetProductTax(productIDs []uuid.UUID, at time.Time) ([]entity.ProductTax, *response.Error)
+}
+
+type taxRepository struct {
+	db *sqlx.DB
+}
+
+const taxColumns = `id, code, name, is_default, created_at, updated_at, deleted_at,
+		(SELECT r.rate FROM tax_rates r WHERE r.tax_id = taxes.id AND r.effective_from <= now() ORDER BY r.effective_from DESC LIMIT 1) AS current_rate`
+
+// GetAllTax implements TaxRepository.
+func (t *taxRepository) GetAllTax(offset int, limit int) ([]entity.Tax, *response.Error) {
+	var taxes []entity.Tax
+
+	query := `
+		SELECT ` + taxColumns + `
+		FROM taxes
+		WHERE deleted_at IS NULL
+		ORDER BY code, id
+		LIMIT $1 OFFSET $2
+	`
+
+	err := t.db.Select(&taxes, query, limit, offset)
+	if err != nil {
+		return nil, taxError("get all tax", err)
+	}
+
+	return taxes, nil
+}
+
+// GetTaxByID implements TaxRepository.
+func (t *taxRepository) GetTaxByID(id uuid.UUID) (*entity.Tax, *response.Error) {
+	var model entity.Tax
+
+	err := t.db.Get(&model, `SELECT `+taxColumns+` FROM taxes WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch tax",
+				Error:      err,
+			}
+		}
+
+		return nil, taxError("get tax by id", err)
+	}
+
+	errRates := t.db.Select(&model.Rates, `
+		SELECT tax_id, rate, effective_from, created_at
+		FROM tax_rates
+		WHERE tax_id = $1
+		ORDER BY effective_from
+	`, id)
+	if errRates != nil {
+		return nil, taxError("get tax rates", errRates)
+	}
+
+	errAssignments := t.db.Select(&model.Assignments, `
+		SELECT id, tax_id, category_id, product_id, created_at
+		FROM tax_assignments
+		WHERE tax_id = $1
+		ORDER BY created_at, id
+	`, id)
+	if errAssignments != nil {
+		return nil, taxError("get tax assignments", errAssignments)
+	}
+
+	return &model, nil
+}
+
+// CreateTax implements TaxRepository.
+func (t *taxRepository) CreateTax(data entity.Tax, rate *entity.Rate) *response.Error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create tax").Msg("failed to create tax")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	if data.IsDefault {
+		if errDefault := unsetDefault(tx, data.ID); errDefault != nil {
+			return errDefault
+		}
+	}
+
+	_, errInsert := tx.Exec(`INSERT INTO taxes (id, code, name, is_default) VALUES ($1, $2, $3, $4)`,
+		data.ID, data.Code, data.Name, data.IsDefault)
+	if errInsert != nil {
+		return saveTaxError("create tax", errInsert)
+	}
+
+	if rate != nil {
+		if errRate := insertRate(tx, *rate); errRate != nil {
+			return errRate
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create tax").Msg("failed to create tax")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// UpdateTax implements TaxRepository.
+func (t *taxRepository) UpdateTax(data entity.Tax) *response.Error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update tax").Msg("failed to update tax")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	if data.IsDefault {
+		if errDefault := unsetDefault(tx, data.ID); errDefault != nil {
+			return errDefault
+		}
+	}
+
+	_, errUpdate := tx.Exec(`UPDATE taxes SET code = $2, name = $3, is_default = $4, updated_at = $5 WHERE id = $1`,
+		data.ID, data.Code, data.Name, data.IsDefault, data.UpdatedAt)
+	if errUpdate != nil {
+		return saveTaxError("update tax", errUpdate)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "update tax").Msg("failed to update tax")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// DeleteTax implements TaxRepository.
+func (t *taxRepository) DeleteTax(id uuid.UUID, deletedAt time.Time) *response.Error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete tax").Msg("failed to delete tax")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	// the products of a deleted tax fall back to their categories or the default tax
+	_, errUnassign := tx.Exec(`DELETE FROM tax_assignments WHERE tax_id = $1`, id)
+	if errUnassign != nil {
+		return taxError("delete tax assignments", errUnassign)
+	}
+
+	_, errDelete := tx.Exec(`UPDATE taxes SET is_default = false, deleted_at = $2 WHERE id = $1`, id, deletedAt)
+	if errDelete != nil {
+		return taxError("delete tax", errDelete)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "delete tax").Msg("failed to delete tax")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// AddRate implements TaxRepository.
+func (t *taxRepository) AddRate(data entity.Rate) *response.Error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "add tax rate").Msg("failed to add tax rate")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	if errRate := insertRate(tx, data); errRate != nil {
+		return errRate
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "add tax rate").Msg("failed to add tax rate")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// SaveAssignment implements TaxRepository.
+func (t *taxRepository) SaveAssignment(data entity.Assignment) *response.Error {
+	// a product or a category has one tax, assigning it again moves it
+	query := `
+		INSERT INTO tax_assignments (id, tax_id, product_id) VALUES ($1, $2, $3)
+		ON CONFLICT (product_id) WHERE product_id IS NOT NULL DO UPDATE SET tax_id = $2
+	`
+	target := data.ProductID
+	if data.CategoryID != nil {
+		query = `
+			INSERT INTO tax_assignments (id, tax_id, category_id) VALUES ($1, $2, $3)
+			ON CONFLICT (category_id) WHERE category_id IS NOT NULL DO UPDATE SET tax_id = $2
+		`
+		target = data.CategoryID
+	}
+
+	_, err := t.db.Exec(query, data.ID, data.TaxID, target)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return &response.Error{
+				StatusCode: 404,
+				Message:    "category or product not found",
+				Error:      errors.New("category or product not found"),
+			}
+		}
+
+		return taxError("save tax assignment", err)
+	}
+
+	return nil
+}
+
+// DeleteAssignment implements TaxRepository.
+func (t *taxRepository) DeleteAssignment(taxID uuid.UUID, id uuid.UUID) *response.Error {
+	result, err := t.db.Exec(`DELETE FROM tax_assignments WHERE id = $1 AND tax_id = $2`, id, taxID)
+	if err != nil {
+		return taxError("delete tax assignment", err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &response.Error{
+			StatusCode: 404,
+			Message:    "tax assignment not found",
+			Error:      errors.New("tax assignment not found"),
+		}
+	}
+
+	return nil
+}
+
+// GetStoreSetting implements TaxRepository.
+func (t *taxRepository) GetStoreSetting(storeID uuid.UUID) (*entity.StoreSetting, *response.Error) {
+	var settings []entity.StoreSetting
+
+	err := t.db.Select(&settings, `SELECT store_id, mode, rounding, updated_at FROM store_tax_settings WHERE store_id = $1`, storeID)
+	if err != nil {
+		return nil, taxError("get store tax setting", err)
+	}
+
+	if len(settings) == 0 {
+		return &entity.StoreSetting{StoreID: storeID, Mode: tax.ModeInclusive, Rounding: tax.RoundingLine}, nil
+	}
+
+	return &settings[0], nil
+}
+
+// SaveStoreSetting implements TaxRepository.
+func (t *taxRepository) SaveStoreSetting(data entity.StoreSetting) *response.Error {
+	query := `
+		INSERT INTO store_tax_settings (store_id, mode, rounding, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (store_id) DO UPDATE SET mode = $2, rounding = $3, updated_at = $4
+	`
+
+	_, err := t.db.Exec(query, data.StoreID, data.Mode, data.Rounding, data.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return &response.Error{
+				StatusCode: 404,
+				Message:    "store not found",
+				Error:      errors.New("store not found"),
+			}
+		}
+
+		return taxError("save store tax setting", err)
+	}
+
+	return nil
+}
+
+// GetProductTax implements TaxRepository.
+func (t *taxRepository) GetProductTax(productIDs []uuid.UUID, at time.Time) ([]entity.ProductTax, *response.Error) {
+	var taxes []entity.ProductTax
+
+	// candidates are ranked by how close they are to the product: its own tax, its categories from the nearest up,
+	// then the default tax. A tax without a rate yet taxes nothing.
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT p.id AS product_id, p.category_id, 0 AS depth
+			FROM products p
+			WHERE p.id = ANY($1) AND p.category_id IS NOT NULL
+			UNION ALL
+			SELECT a.product_id, c.parent_id, a.depth + 1
+			FROM ancestors a
+			JOIN categories c ON c.id = a.category_id
+			WHERE c.parent_id IS NOT NULL
+		),
+		candidates AS (
+			SELECT ta.tax_id, ta.product_id, -1 AS depth
+			FROM tax_assignments ta
+			WHERE ta.product_id = ANY($1)
+			UNION ALL
+			SELECT ta.tax_id, a.product_id, a.depth
+			FROM tax_assignments ta
+			JOIN ancestors a ON a.category_id = ta.category_id
+			UNION ALL
+			SELECT t.id, p.id, 2147483647
+			FROM taxes t
+			CROSS JOIN unnest($1::uuid[]) AS p(id)
+			WHERE t.is_default AND t.deleted_at IS NULL
+		)
+		SELECT DISTINCT ON (c.product_id) c.product_id, t.id AS tax_id, t.code, t.name, COALESCE(r.rate, 0) AS rate
+		FROM candidates c
+		JOIN taxes t ON t.id = c.tax_id AND t.deleted_at IS NULL
+		LEFT JOIN LATERAL (
+			SELECT rate FROM tax_rates WHERE tax_id = t.id AND effective_from <= $2 ORDER BY effective_from DESC LIMIT 1
+		) r ON true
+		ORDER BY c.product_id, c.depth
+	`
+
+	err := t.db.Select(&taxes, query, pq.Array(productIDs), at)
+	if err != nil {
+		return nil, taxError("get product tax", err)
+	}
+
+	return taxes, nil
+}
+
+func unsetDefault(tx *sqlx.Tx, id uuid.UUID) *response.Error {
+	_, err := tx.Exec(`UPDATE taxes SET is_default = false WHERE is_default AND id <> $1`, id)
+	if err != nil {
+		return taxError("unset default tax", err)
+	}
+
+	return nil
+}
+
+func insertRate(tx *sqlx.Tx, data entity.Rate) *response.Error {
+	_, err := tx.Exec(`INSERT INTO tax_rates (tax_id, rate, effective_from) VALUES ($1, $2, $3)`, data.TaxID, data.Rate, data.EffectiveFrom)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return &response.Error{
+				StatusCode: 409,
+				Message:    "tax already has a rate starting at that time",
+				Error:      nil,
+			}
+		}
+
+		return taxError("add tax rate", err)
+	}
+
+	return nil
+}
+
+func saveTaxError(function string, err error) *response.Error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return &response.Error{
+			StatusCode: 409,
+			Message:    "tax code already exists",
+			Error:      nil,
+		}
+	}
+
+	return taxError(function, err)
+}
+
+func taxError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewTaxRepository(db *sqlx.DB) TaxRepository {
+	return &taxRepository{db}
+}
@@ -0,0 +1,30 @@
+package tax
+
+import (
+	staff "candyshop/internal/staff"
+	handler "candyshop/internal/tax/handler"
+	repository "candyshop/internal/tax/repository"
+	service "candyshop/internal/tax/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewTaxRepository(db)
+	service := service.NewTaxService(repo)
+	handler := handler.NewTaxHandler(service)
+
+	taxRoute := router.Group("api/v1/taxes", staff.Authenticate(db))
+
+	taxRoute.Get("", handler.GetAllTax)
+	taxRoute.Post("", handler.CreateTax)
+	taxRoute.Patch("", handler.UpdateTax)
+	taxRoute.Get("/stores/:store_id", handler.GetStoreSetting)
+	taxRoute.Patch("/stores/:store_id", handler.SaveStoreSetting)
+	taxRoute.Patch("/delete/:id", handler.DeleteTax)
+	taxRoute.Get("/:id", handler.GetTaxByID)
+	taxRoute.Post("/:id/rates", handler.AddRate)
+	taxRoute.Post("/:id/assignments", handler.SaveAssignment)
+	taxRoute.Delete("/:id/assignments/:assignment_id", handler.DeleteAssignment)
+}
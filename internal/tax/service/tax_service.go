@@ -0,0 +1,369 @@
+package tax
+
+import (
+	dto "candyshop/internal/tax/dto"
+	entity "candyshop/internal/tax/entity"
+	repository "candyshop/internal/tax/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"candyshop/pkg/tax"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TaxService interface {
+	GetAllTax(offset, limit int) ([]entity.Tax, *response.Error)
+	GetTaxByID(id uuid.UUID) (*entity.Tax, *response.Error)
+	CreateTax(caller *auth.Caller, data dto.CreateTaxRequest) (*entity.Tax, *response.Error)
+	UpdateTax(caller *auth.Caller, data dto.UpdateTaxRequest) *response.Error
+	DeleteTax(caller *auth.Caller, id uuid.UUID) *response.Error
+	AddRate(caller *auth.Caller, id uuid.UUID, data dto.RateRequest) (*entity.Tax, *response.Error)
+	SaveAssignment(caller *auth.Caller, id uuid.UUID, data dto.AssignmentRequest) (*entity.Tax, *response.Error)
+	DeleteAssignment(caller *auth.Caller, id, assignmentID uuid.UUID) *response.Error
+	GetStoreSetting(caller *auth.Caller, storeID uuid.UUID) (*entity.StoreSetting, *response.Error)
+	SaveStoreSetting(caller *auth.Caller, storeID uuid.UUID, data dto.StoreSettingRequest) (*entity.StoreSetting, *response.Error)
+	Quote(storeID uuid.UUID, at time.Time, lines []entity.QuoteLine) (*entity.Quote, *response.Error)
+}
+
+type taxService struct {
+	repository repository.TaxRepository
+}
+
+// GetAllTax implements TaxService.
+func (t *taxService) GetAllTax(offset int, limit int) ([]entity.Tax, *response.Error) {
+	return t.repository.GetAllTax(offset, limit)
+}
+
+// GetTaxByID implements TaxService.
+func (t *taxService) GetTaxByID(id uuid.UUID) (*entity.Tax, *response.Error) {
+	model, errTax := t.repository.GetTaxByID(id)
+	if errTax != nil {
+		return nil, errTax
+	}
+
+	if model.DeletedAt != nil {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusNotFound,
+			Message:    "tax is not active",
+			Error:      errors.New("tax is not active"),
+		}
+	}
+
+	return model, nil
+}
+
+// CreateTax implements TaxService.
+func (t *taxService) CreateTax(caller *auth.Caller, data dto.CreateTaxRequest) (*entity.Tax, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataTax := &entity.Tax{
+		ID:        newUUID,
+		Code:      strings.ToUpper(strings.TrimSpace(data.Code)),
+		Name:      strings.TrimSpace(data.Name),
+		IsDefault: data.IsDefault,
+	}
+
+	if errValidate := validateTax(dataTax); errValidate != nil {
+		return nil, errValidate
+	}
+
+	var rate *entity.Rate
+	if data.Rate != nil {
+		if errRate := validateRate(*data.Rate); errRate != nil {
+			return nil, errRate
+		}
+
+		rate = &entity.Rate{TaxID: newUUID, Rate: *data.Rate, EffectiveFrom: time.Now()}
+	}
+
+	if errCreate := t.repository.CreateTax(*dataTax, rate); errCreate != nil {
+		return nil, errCreate
+	}
+
+	return t.repository.GetTaxByID(newUUID)
+}
+
+// UpdateTax implements TaxService.
+func (t *taxService) UpdateTax(caller *auth.Caller, data dto.UpdateTaxRequest) *response.Error {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return errOwner
+	}
+
+	model, errTax := t.GetTaxByID(data.ID)
+	if errTax != nil {
+		return errTax
+	}
+
+	if strings.TrimSpace(data.Code) != "" {
+		model.Code = strings.ToUpper(strings.TrimSpace(data.Code))
+	}
+
+	if strings.TrimSpace(data.Name) != "" {
+		model.Name = strings.TrimSpace(data.Name)
+	}
+
+	if data.IsDefault != nil {
+		model.IsDefault = *data.IsDefault
+	}
+
+	if errValidate := validateTax(model); errValidate != nil {
+		return errValidate
+	}
+
+	currentTime := time.Now()
+	model.UpdatedAt = &currentTime
+
+	return t.repository.UpdateTax(*model)
+}
+
+// DeleteTax implements TaxService.
+func (t *taxService) DeleteTax(caller *auth.Caller, id uuid.UUID) *response.Error {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return errOwner
+	}
+
+	model, errTax := t.repository.GetTaxByID(id)
+	if errTax != nil {
+		return errTax
+	}
+
+	if model.DeletedAt != nil {
+		return &response.Error{
+			StatusCode: fiber.StatusConflict,
+			Message:    "tax is not active",
+			Error:      nil,
+		}
+	}
+
+	return t.repository.DeleteTax(id, time.Now())
+}
+
+// AddRate implements TaxService.
+func (t *taxService) AddRate(caller *auth.Caller, id uuid.UUID, data dto.RateRequest) (*entity.Tax, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	if errRate := validateRate(data.Rate); errRate != nil {
+		return nil, errRate
+	}
+
+	model, errTax := t.GetTaxByID(id)
+	if errTax != nil {
+		return nil, errTax
+	}
+
+	if data.EffectiveFrom.IsZero() {
+		data.EffectiveFrom = time.Now()
+	}
+
+	// sales already made keep the rate they were priced with, a change in the past would not reach them
+	if len(model.Rates) > 0 && data.EffectiveFrom.Before(time.Now().Add(-time.Minute)) {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "effective_from of a new rate can not be in the past",
+			Error:      errors.New("effective_from of a new rate can not be in the past"),
+		}
+	}
+
+	errAdd := t.repository.AddRate(entity.Rate{TaxID: id, Rate: data.Rate, EffectiveFrom: data.EffectiveFrom})
+	if errAdd != nil {
+		return nil, errAdd
+	}
+
+	return t.repository.GetTaxByID(id)
+}
+
+// SaveAssignment implements TaxService.
+func (t *taxService) SaveAssignment(caller *auth.Caller, id uuid.UUID, data dto.AssignmentRequest) (*entity.Tax, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	if (data.CategoryID == nil) == (data.ProductID == nil) {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "either category_id or product_id is required",
+			Error:      errors.New("either category_id or product_id is required"),
+		}
+	}
+
+	if _, errTax := t.GetTaxByID(id); errTax != nil {
+		return nil, errTax
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	errSave := t.repository.SaveAssignment(entity.Assignment{
+		ID:         newUUID,
+		TaxID:      id,
+		CategoryID: data.CategoryID,
+		ProductID:  data.ProductID,
+	})
+	if errSave != nil {
+		return nil, errSave
+	}
+
+	return t.repository.GetTaxByID(id)
+}
+
+// DeleteAssignment implements TaxService.
+func (t *taxService) DeleteAssignment(caller *auth.Caller, id uuid.UUID, assignmentID uuid.UUID) *response.Error {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return errOwner
+	}
+
+	return t.repository.DeleteAssignment(id, assignmentID)
+}
+
+// GetStoreSetting implements TaxService.
+func (t *taxService) GetStoreSetting(caller *auth.Caller, storeID uuid.UUID) (*entity.StoreSetting, *response.Error) {
+	if !caller.CanAccessStore(storeID) {
+		return nil, auth.ForbiddenStore(storeID)
+	}
+
+	return t.repository.GetStoreSetting(storeID)
+}
+
+// SaveStoreSetting implements TaxService.
+func (t *taxService) SaveStoreSetting(caller *auth.Caller, storeID uuid.UUID, data dto.StoreSettingRequest) (*entity.StoreSetting, *response.Error) {
+	if errOwner := onlyOwner(caller); errOwner != nil {
+		return nil, errOwner
+	}
+
+	if !tax.IsValidMode(data.Mode) || !tax.IsValidRounding(data.Rounding) {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "mode must be inclusive or exclusive and rounding must be line or invoice",
+			Error:      errors.New("mode must be inclusive or exclusive and rounding must be line or invoice"),
+		}
+	}
+
+	currentTime := time.Now()
+
+	errSave := t.repository.SaveStoreSetting(entity.StoreSetting{
+		StoreID:   storeID,
+		Mode:      data.Mode,
+		Rounding:  data.Rounding,
+		UpdatedAt: &currentTime,
+	})
+	if errSave != nil {
+		return nil, errSave
+	}
+
+	return t.repository.GetStoreSetting(storeID)
+}
+
+// Quote implements TaxService.
+func (t *taxService) Quote(storeID uuid.UUID, at time.Time, lines []entity.QuoteLine) (*entity.Quote, *response.Error) {
+	setting, errSetting := t.repository.GetStoreSetting(storeID)
+	if errSetting != nil {
+		return nil, errSetting
+	}
+
+	productIDs := make([]uuid.UUID, len(lines))
+	for i, line := range lines {
+		productIDs[i] = line.ProductID
+	}
+
+	productTaxes, errProduct := t.repository.GetProductTax(productIDs, at)
+	if errProduct != nil {
+		return nil, errProduct
+	}
+
+	byProduct := map[uuid.UUID]entity.ProductTax{}
+	byTax := map[uuid.UUID]entity.ProductTax{}
+	for _, productTax := range productTaxes {
+		byProduct[productTax.ProductID] = productTax
+		byTax[productTax.TaxID] = productTax
+	}
+
+	quote := &entity.Quote{
+		Mode:     setting.Mode,
+		Rounding: setting.Rounding,
+		Lines:    make([]entity.QuoteLine, len(lines)),
+		Taxes:    []entity.QuoteTax{},
+	}
+
+	taxLines := make([]tax.Line, len(lines))
+	for i, line := range lines {
+		quote.Lines[i] = entity.QuoteLine{ProductID: line.ProductID, Net: line.Net}
+		taxLines[i] = tax.Line{Net: line.Net}
+
+		if productTax, ok := byProduct[line.ProductID]; ok {
+			quote.Lines[i].TaxID = &productTax.TaxID
+			quote.Lines[i].Rate = productTax.Rate
+			taxLines[i].TaxID = &productTax.TaxID
+			taxLines[i].Rate = productTax.Rate
+		}
+	}
+
+	lineTaxes, breakdowns := tax.Calculate(taxLines, setting.Mode, setting.Rounding)
+	for i := range quote.Lines {
+		quote.Lines[i].Tax = lineTaxes[i]
+	}
+
+	for _, breakdown := range breakdowns {
+		quote.Taxes = append(quote.Taxes, entity.QuoteTax{
+			TaxID:   breakdown.TaxID,
+			Code:    byTax[breakdown.TaxID].Code,
+			Name:    byTax[breakdown.TaxID].Name,
+			Rate:    breakdown.Rate,
+			Taxable: breakdown.Taxable,
+			Tax:     breakdown.Tax,
+		})
+
+		quote.Tax += breakdown.Tax
+	}
+
+	return quote, nil
+}
+
+func validateTax(model *entity.Tax) *response.Error {
+	if model.Code == "" || len(model.Code) > 50 || model.Name == "" || len(model.Name) > 100 {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "code and name are required, code up to 50 and name up to 100 characters",
+			Error:      errors.New("code and name are required, code up to 50 and name up to 100 characters"),
+		}
+	}
+
+	return nil
+}
+
+func validateRate(rate int) *response.Error {
+	if rate < 0 || rate > tax.RateScale {
+		return &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "rate must be between 0 and 10000 basis points",
+			Error:      errors.New("rate must be between 0 and 10000 basis points"),
+		}
+	}
+
+	return nil
+}
+
+// onlyOwner keeps taxes in the hands of the owner, every store follows the same tax rules.
+func onlyOwner(caller *auth.Caller) *response.Error {
+	if caller.IsOwner() {
+		return nil
+	}
+
+	return &response.Error{
+		StatusCode: fiber.StatusForbidden,
+		Message:    "only owner can manage taxes",
+		Error:      errors.New("only owner can manage taxes"),
+	}
+}
+
+func NewTaxService(repository repository.TaxRepository) TaxService {
+	return &taxService{repository}
+}
@@ -0,0 +1,21 @@
+package transfer
+
+import "github.com/google/uuid"
+
+type TransferItemRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type CreateTransferRequest struct {
+	SourceStoreID      uuid.UUID             `json:"source_store_id"`
+	DestinationStoreID uuid.UUID             `json:"destination_store_id"`
+	Note               string                `json:"note"`
+	Items              []TransferItemRequest `json:"items"`
+}
+
+type ReceiveTransferRequest struct {
+	Items []TransferItemRequest `json:"items"`
+	// Complete closes the transfer, quantities that are still in transit are written off
+	Complete bool `json:"complete"`
+}
@@ -0,0 +1,39 @@
+package transfer
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// status of a stock transfer, stock leaves the source store on ship and reaches the destination on receive
+const (
+	StatusRequested = "requested"
+	StatusShipped   = "shipped"
+	StatusReceived  = "received"
+	StatusCancelled = "cancelled"
+)
+
+type Transfer struct {
+	ID                 uuid.UUID      `json:"id" db:"id"`
+	SourceStoreID      uuid.UUID      `json:"source_store_id" db:"source_store_id"`
+	DestinationStoreID uuid.UUID      `json:"destination_store_id" db:"destination_store_id"`
+	Status             string         `json:"status" db:"status"`
+	Note               string         `json:"note" db:"note"`
+	RequestedBy        *uuid.UUID     `json:"requested_by" db:"requested_by"`
+	ShippedAt          *time.Time     `json:"shipped_at" db:"shipped_at"`
+	ReceivedAt         *time.Time     `json:"received_at" db:"received_at"`
+	CancelledAt        *time.Time     `json:"cancelled_at" db:"cancelled_at"`
+	CreatedAt          *time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt          *time.Time     `json:"-" db:"updated_at"`
+	Items              []TransferItem `json:"items,omitempty" db:"-"`
+}
+
+type TransferItem struct {
+	TransferID       uuid.UUID `json:"-" db:"transfer_id"`
+	ProductID        uuid.UUID `json:"product_id" db:"product_id"`
+	Quantity         int       `json:"quantity" db:"quantity"`
+	ReceivedQuantity int       `json:"received_quantity" db:"received_quantity"`
+	// UnitCost is the average cost of the source store when the item was shipped, nil before shipping
+	UnitCost *int64 `json:"-" db:"unit_cost"`
+}
@@ -0,0 +1,227 @@
+package transfer
+
+import (
+	dto "candyshop/internal/transfer/dto"
+	service "candyshop/internal/transfer/service"
+	"candyshop/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TransferHandler struct {
+	service service.TransferService
+}
+
+func NewTransferHandler(service service.TransferService) *TransferHandler {
+	return &TransferHandler{service}
+}
+
+func (h *TransferHandler) GetAllTransfer(c *fiber.Ctx) error {
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit", 20)
+
+	if offset < 0 || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "offset or limit is invalid",
+			"error":       nil,
+		})
+	}
+
+	transfers, errTransfer := h.service.GetAllTransfer(auth.GetCaller(c), c.Query("status"), offset, limit)
+	if errTransfer != nil {
+		return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+			"status_code": errTransfer.StatusCode,
+			"message":     "failed to fetch transfers",
+			"error":       errTransfer.Error.Error(),
+		})
+	}
+
+	if len(transfers) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status_code": fiber.StatusNotFound,
+			"message":     "failed to fetch transfers",
+			"error":       "transfer not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data transfers",
+		"data":        transfers,
+	})
+}
+
+func (h *TransferHandler) GetTransferByID(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	transfer, errTransfer := h.service.GetTransferByID(auth.GetCaller(c), parseID)
+	if errTransfer != nil {
+		return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+			"status_code": errTransfer.StatusCode,
+			"message":     "failed to fetch transfer",
+			"error":       errTransfer.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success get data transfer",
+		"data":        transfer,
+	})
+}
+
+func (h *TransferHandler) CreateTransfer(c *fiber.Ctx) error {
+	var req dto.CreateTransferRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data transfer",
+			"error":       err.Error(),
+		})
+	}
+
+	if req.SourceStoreID == uuid.Nil || req.DestinationStoreID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "source_store_id and destination_store_id is required",
+			"error":       nil,
+		})
+	}
+
+	transfer, errTransfer := h.service.CreateTransfer(auth.GetCaller(c), req)
+	if errTransfer != nil {
+		return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+			"status_code": errTransfer.StatusCode,
+			"message":     "failed to create transfer",
+			"error":       errTransfer.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status_code": fiber.StatusCreated,
+		"message":     "success create transfer",
+		"data":        transfer,
+	})
+}
+
+func (h *TransferHandler) ShipTransfer(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errTransfer := h.service.ShipTransfer(auth.GetCaller(c), parseID)
+	if errTransfer != nil {
+		if errTransfer.StatusCode == fiber.StatusConflict {
+			return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+				"status_code": errTransfer.StatusCode,
+				"message":     "failed to ship transfer",
+				"error":       errTransfer.Message,
+			})
+		}
+
+		return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+			"status_code": errTransfer.StatusCode,
+			"message":     "failed to ship transfer",
+			"error":       errTransfer.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success ship transfer",
+		"data":        nil,
+	})
+}
+
+func (h *TransferHandler) ReceiveTransfer(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	var req dto.ReceiveTransferRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "failed to input data transfer",
+			"error":       err.Error(),
+		})
+	}
+
+	errTransfer := h.service.ReceiveTransfer(auth.GetCaller(c), parseID, req)
+	if errTransfer != nil {
+		if errTransfer.StatusCode == fiber.StatusConflict {
+			return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+				"status_code": errTransfer.StatusCode,
+				"message":     "failed to receive transfer",
+				"error":       errTransfer.Message,
+			})
+		}
+
+		return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+			"status_code": errTransfer.StatusCode,
+			"message":     "failed to receive transfer",
+			"error":       errTransfer.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success receive transfer",
+		"data":        nil,
+	})
+}
+
+func (h *TransferHandler) CancelTransfer(c *fiber.Ctx) error {
+	parseID, errParse := uuid.Parse(c.Params("id"))
+	if errParse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status_code": fiber.StatusBadRequest,
+			"message":     "id is invalid",
+			"error":       errParse.Error(),
+		})
+	}
+
+	errTransfer := h.service.CancelTransfer(auth.GetCaller(c), parseID)
+	if errTransfer != nil {
+		if errTransfer.StatusCode == fiber.StatusConflict {
+			return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+				"status_code": errTransfer.StatusCode,
+				"message":     "failed to cancel transfer",
+				"error":       errTransfer.Message,
+			})
+		}
+
+		return c.Status(errTransfer.StatusCode).JSON(fiber.Map{
+			"status_code": errTransfer.StatusCode,
+			"message":     "failed to cancel transfer",
+			"error":       errTransfer.Error.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status_code": fiber.StatusOK,
+		"message":     "success cancel transfer",
+		"data":        nil,
+	})
+}
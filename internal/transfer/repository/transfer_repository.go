@@ -0,0 +1,563 @@
+package transfer
+
+import (
+	entity "candyshop/internal/transfer/entity"
+	"candyshop/pkg/ledger"
+	"candyshop/pkg/response"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type TransferRepository interface {
+	GetAllTransfer(storeIDs []uuid.UUID, status string, offset, limit int) ([]entity.Transfer, *response.Error)
+	GetTransferByID(id uuid.UUID) (*entity.Transfer, *response.Error)
+	CreateTransfer(data entity.Transfer) (*entity.Transfer, *response.Error)
+	ShipTransfer(id uuid.UUID, shippedAt time.Time) *response.Error
+	ReceiveTransfer(id uuid.UUID, items []entity.TransferItem, complete bool, receivedAt time.Time) *response.Error
+	CancelTransfer(id uuid.UUID, cancelledAt time.Time) *response.Error
+}
+
+type transferRepository struct {
+	db *sqlx.DB
+}
+
+// GetAllTransfer implements TransferRepository.
+func (t *transferRepository) GetAllTransfer(storeIDs []uuid.UUID, status string, offset int, limit int) ([]entity.Transfer, *response.Error) {
+	var transfers []entity.Transfer
+
+	// nil store ids means the caller is not limited to some stores
+	query := `
+		SELECT id, source_store_id, destination_store_id, status, note, requested_by, shipped_at, received_at, cancelled_at, created_at, updated_at
+		FROM stock_transfers
+		WHERE ($3::uuid[] IS NULL OR source_store_id = ANY($3) OR destination_store_id = ANY($3))
+			AND ($4 = '' OR status = $4)
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	err := t.db.Select(&transfers, query, limit, offset, pq.Array(storeIDs), status)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "get all transfer").Msg("failed to get all transfer")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch transfers",
+			Error:      err,
+		}
+	}
+
+	return transfers, nil
+}
+
+// GetTransferByID implements TransferRepository.
+func (t *transferRepository) GetTransferByID(id uuid.UUID) (*entity.Transfer, *response.Error) {
+	var transfer entity.Transfer
+
+	query := `
+		SELECT id, source_store_id, destination_store_id, status, note, requested_by, shipped_at, received_at, cancelled_at, created_at, updated_at
+		FROM stock_transfers
+		WHERE id = $1
+	`
+
+	err := t.db.Get(&transfer, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("status", 404).Str("function", "get transfer by id").Msg("failed to get transfer by id")
+			return nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch transfer",
+				Error:      err,
+			}
+		}
+
+		log.Error().Err(err).Int("status", 500).Str("function", "get transfer by id").Msg("failed to get transfer by id")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch transfer",
+			Error:      err,
+		}
+	}
+
+	errItems := t.db.Select(&transfer.Items, `
+		SELECT transfer_id, product_id, quantity, received_quantity
+		FROM stock_transfer_items
+		WHERE transfer_id = $1
+		ORDER BY product_id
+	`, id)
+	if errItems != nil {
+		log.Error().Err(errItems).Int("status", 500).Str("function", "get transfer by id").Msg("failed to get transfer items")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to fetch transfer",
+			Error:      errItems,
+		}
+	}
+
+	return &transfer, nil
+}
+
+// CreateTransfer implements TransferRepository.
+func (t *transferRepository) CreateTransfer(data entity.Transfer) (*entity.Transfer, *response.Error) {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create transfer").Msg("failed to create transfer")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO stock_transfers (id, source_store_id, destination_store_id, status, note, requested_by) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, source_store_id, destination_store_id, status, note, requested_by, created_at
+	`
+
+	model := entity.Transfer{Items: data.Items}
+
+	errInsert := tx.QueryRowx(query,
+		data.ID,
+		data.SourceStoreID,
+		data.DestinationStoreID,
+		data.Status,
+		data.Note,
+		data.RequestedBy).Scan(&model.ID,
+		&model.SourceStoreID,
+		&model.DestinationStoreID,
+		&model.Status,
+		&model.Note,
+		&model.RequestedBy,
+		&model.CreatedAt)
+
+	if errInsert != nil {
+		log.Error().Err(errInsert).Int("status", 500).Str("function", "create transfer").Msg("failed to create transfer")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to create transfer",
+			Error:      errInsert,
+		}
+	}
+
+	for _, item := range data.Items {
+		_, errItem := tx.Exec(`INSERT INTO stock_transfer_items (transfer_id, product_id, quantity) VALUES ($1, $2, $3)`,
+			model.ID, item.ProductID, item.Quantity)
+		if errItem != nil {
+			log.Error().Err(errItem).Int("status", 500).Str("function", "create transfer").Msg("failed to create transfer item")
+			return nil, &response.Error{
+				StatusCode: 500,
+				Message:    "failed to create transfer",
+				Error:      errItem,
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "create transfer").Msg("failed to create transfer")
+		return nil, &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return &model, nil
+}
+
+// ShipTransfer implements TransferRepository.
+func (t *transferRepository) ShipTransfer(id uuid.UUID, shippedAt time.Time) *response.Error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "ship transfer").Msg("failed to ship transfer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	transfer, items, errLock := lockTransfer(tx, id, entity.StatusRequested)
+	if errLock != nil {
+		return errLock
+	}
+
+	if errInventory := lockInventories(tx, transfer, items); errInventory != nil {
+		return errInventory
+	}
+
+	for _, item := range items {
+		// stock leaves the source store and waits in the in transit bucket of the destination
+		errSource := ledger.Move(tx, ledger.Movement{
+			StoreID:       transfer.SourceStoreID,
+			ProductID:     item.ProductID,
+			Type:          ledger.TypeTransferOut,
+			Quantity:      -item.Quantity,
+			ReferenceType: ledger.ReferenceTransfer,
+			ReferenceID:   &transfer.ID,
+			MovedAt:       shippedAt,
+		})
+		if errors.Is(errSource, ledger.ErrInsufficientStock) {
+			return &response.Error{
+				StatusCode: 409,
+				Message:    fmt.Sprintf("insufficient stock of product %s in source store", item.ProductID),
+				Error:      nil,
+			}
+		}
+
+		if errSource != nil {
+			return transferError("ship transfer", errSource)
+		}
+
+		// the stock keeps the cost it had in the source store
+		unitCost, errCost := ledger.Cost(tx, transfer.SourceStoreID, item.ProductID)
+		if errCost != nil {
+			return transferError("ship transfer", errCost)
+		}
+
+		_, errItem := tx.Exec(`UPDATE stock_transfer_items SET unit_cost = $3 WHERE transfer_id = $1 AND product_id = $2`,
+			id, item.ProductID, unitCost)
+		if errItem != nil {
+			return transferError("ship transfer", errItem)
+		}
+
+		_, errDestination := tx.Exec(`
+			UPDATE inventories SET in_transit_quantity = in_transit_quantity + $3, updated_at = $4
+			WHERE store_id = $1 AND product_id = $2
+		`, transfer.DestinationStoreID, item.ProductID, item.Quantity, shippedAt)
+		if errDestination != nil {
+			return transferError("ship transfer", errDestination)
+		}
+	}
+
+	_, errUpdate := tx.Exec(`UPDATE stock_transfers SET status = $2, shipped_at = $3, updated_at = $3 WHERE id = $1`,
+		id, entity.StatusShipped, shippedAt)
+	if errUpdate != nil {
+		return transferError("ship transfer", errUpdate)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "ship transfer").Msg("failed to ship transfer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// ReceiveTransfer implements TransferRepository.
+func (t *transferRepository) ReceiveTransfer(id uuid.UUID, received []entity.TransferItem, complete bool, receivedAt time.Time) *response.Error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "receive transfer").Msg("failed to receive transfer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	transfer, items, errLock := lockTransfer(tx, id, entity.StatusShipped)
+	if errLock != nil {
+		return errLock
+	}
+
+	if errInventory := lockInventories(tx, transfer, items); errInventory != nil {
+		return errInventory
+	}
+
+	remaining := map[uuid.UUID]int{}
+	unitCosts := map[uuid.UUID]*int64{}
+	for _, item := range items {
+		remaining[item.ProductID] = item.Quantity - item.ReceivedQuantity
+		unitCosts[item.ProductID] = item.UnitCost
+	}
+
+	for _, item := range received {
+		if item.Quantity > remaining[item.ProductID] {
+			return &response.Error{
+				StatusCode: 409,
+				Message:    fmt.Sprintf("received quantity of product %s is more than in transit quantity %d", item.ProductID, remaining[item.ProductID]),
+				Error:      nil,
+			}
+		}
+
+		_, errInTransit := tx.Exec(`
+			UPDATE inventories SET in_transit_quantity = in_transit_quantity - $3, updated_at = $4
+			WHERE store_id = $1 AND product_id = $2
+		`, transfer.DestinationStoreID, item.ProductID, item.Quantity, receivedAt)
+		if errInTransit != nil {
+			return transferError("receive transfer", errInTransit)
+		}
+
+		errInventory := ledger.Move(tx, ledger.Movement{
+			StoreID:       transfer.DestinationStoreID,
+			ProductID:     item.ProductID,
+			Type:          ledger.TypeTransferIn,
+			Quantity:      item.Quantity,
+			ReferenceType: ledger.ReferenceTransfer,
+			ReferenceID:   &transfer.ID,
+			MovedAt:       receivedAt,
+			UnitCost:      unitCosts[item.ProductID],
+		})
+		if errInventory != nil {
+			return transferError("receive transfer", errInventory)
+		}
+
+		_, errItem := tx.Exec(`UPDATE stock_transfer_items SET received_quantity = received_quantity + $3 WHERE transfer_id = $1 AND product_id = $2`,
+			id, item.ProductID, item.Quantity)
+		if errItem != nil {
+			return transferError("receive transfer", errItem)
+		}
+
+		remaining[item.ProductID] -= item.Quantity
+	}
+
+	outstanding := 0
+	for _, quantity := range remaining {
+		outstanding += quantity
+	}
+
+	if outstanding > 0 && !complete {
+		// partial receipt, the transfer stays shipped until the rest arrives
+		_, errUpdate := tx.Exec(`UPDATE stock_transfers SET updated_at = $2 WHERE id = $1`, id, receivedAt)
+		if errUpdate != nil {
+			return transferError("receive transfer", errUpdate)
+		}
+	} else {
+		// whatever did not arrive leaves the in transit bucket and is written off in the destination ledger,
+		// it comes in at the average cost and goes out again so the loss shows in the history of the store
+		for _, item := range items {
+			if remaining[item.ProductID] == 0 {
+				continue
+			}
+
+			_, errInTransit := tx.Exec(`
+				UPDATE inventories SET in_transit_quantity = in_transit_quantity - $3, updated_at = $4
+				WHERE store_id = $1 AND product_id = $2
+			`, transfer.DestinationStoreID, item.ProductID, remaining[item.ProductID], receivedAt)
+			if errInTransit != nil {
+				return transferError("receive transfer", errInTransit)
+			}
+
+			for _, movement := range []ledger.Movement{
+				{Type: ledger.TypeTransferIn, Quantity: remaining[item.ProductID], Note: "not delivered"},
+				{Type: ledger.TypeAdjustment, Quantity: -remaining[item.ProductID], Note: "not delivered, written off"},
+			} {
+				movement.StoreID = transfer.DestinationStoreID
+				movement.ProductID = item.ProductID
+				movement.ReferenceType = ledger.ReferenceTransfer
+				movement.ReferenceID = &transfer.ID
+				movement.MovedAt = receivedAt
+
+				if errWriteOff := ledger.Move(tx, movement); errWriteOff != nil {
+					return transferError("receive transfer", errWriteOff)
+				}
+			}
+		}
+
+		_, errUpdate := tx.Exec(`UPDATE stock_transfers SET status = $2, received_at = $3, updated_at = $3 WHERE id = $1`,
+			id, entity.StatusReceived, receivedAt)
+		if errUpdate != nil {
+			return transferError("receive transfer", errUpdate)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "receive transfer").Msg("failed to receive transfer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// CancelTransfer implements TransferRepository.
+func (t *transferRepository) CancelTransfer(id uuid.UUID, cancelledAt time.Time) *response.Error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "cancel transfer").Msg("failed to cancel transfer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to start transaction",
+			Error:      err,
+		}
+	}
+
+	defer tx.Rollback()
+
+	transfer, items, errLock := lockTransfer(tx, id, entity.StatusRequested, entity.StatusShipped)
+	if errLock != nil {
+		return errLock
+	}
+
+	// shipped stock that has not been received goes back to the source store
+	if transfer.Status == entity.StatusShipped {
+		if errInventory := lockInventories(tx, transfer, items); errInventory != nil {
+			return errInventory
+		}
+
+		for _, item := range items {
+			remaining := item.Quantity - item.ReceivedQuantity
+			if remaining == 0 {
+				continue
+			}
+
+			_, errDestination := tx.Exec(`
+				UPDATE inventories SET in_transit_quantity = in_transit_quantity - $3, updated_at = $4
+				WHERE store_id = $1 AND product_id = $2
+			`, transfer.DestinationStoreID, item.ProductID, remaining, cancelledAt)
+			if errDestination != nil {
+				return transferError("cancel transfer", errDestination)
+			}
+
+			errSource := ledger.Move(tx, ledger.Movement{
+				StoreID:       transfer.SourceStoreID,
+				ProductID:     item.ProductID,
+				Type:          ledger.TypeTransferIn,
+				Quantity:      remaining,
+				ReferenceType: ledger.ReferenceTransfer,
+				ReferenceID:   &transfer.ID,
+				Note:          "transfer cancelled",
+				MovedAt:       cancelledAt,
+				UnitCost:      item.UnitCost,
+			})
+			if errSource != nil {
+				return transferError("cancel transfer", errSource)
+			}
+		}
+	}
+
+	_, errUpdate := tx.Exec(`UPDATE stock_transfers SET status = $2, cancelled_at = $3, updated_at = $3 WHERE id = $1`,
+		id, entity.StatusCancelled, cancelledAt)
+	if errUpdate != nil {
+		return transferError("cancel transfer", errUpdate)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "cancel transfer").Msg("failed to cancel transfer")
+		return &response.Error{
+			StatusCode: 500,
+			Message:    "failed to commit transaction",
+			Error:      err,
+		}
+	}
+
+	return nil
+}
+
+// lockTransfer locks the transfer row and checks that it is in one of the expected statuses.
+func lockTransfer(tx *sqlx.Tx, id uuid.UUID, statuses ...string) (*entity.Transfer, []entity.TransferItem, *response.Error) {
+	var transfer entity.Transfer
+
+	errTransfer := tx.Get(&transfer, `
+		SELECT id, source_store_id, destination_store_id, status, note, requested_by, shipped_at, received_at, cancelled_at, created_at, updated_at
+		FROM stock_transfers
+		WHERE id = $1
+		FOR UPDATE
+	`, id)
+	if errTransfer != nil {
+		if errors.Is(errTransfer, sql.ErrNoRows) {
+			return nil, nil, &response.Error{
+				StatusCode: 404,
+				Message:    "failed to fetch transfer",
+				Error:      errTransfer,
+			}
+		}
+
+		return nil, nil, transferError("lock transfer", errTransfer)
+	}
+
+	allowed := false
+	for _, status := range statuses {
+		if transfer.Status == status {
+			allowed = true
+		}
+	}
+
+	if !allowed {
+		return nil, nil, &response.Error{
+			StatusCode: 409,
+			Message:    fmt.Sprintf("transfer is %s", transfer.Status),
+			Error:      nil,
+		}
+	}
+
+	var items []entity.TransferItem
+
+	errItems := tx.Select(&items, `
+		SELECT transfer_id, product_id, quantity, received_quantity, unit_cost
+		FROM stock_transfer_items
+		WHERE transfer_id = $1
+		ORDER BY product_id
+	`, id)
+	if errItems != nil {
+		return nil, nil, transferError("lock transfer", errItems)
+	}
+
+	return &transfer, items, nil
+}
+
+// lockInventories creates the missing inventory rows of both stores and locks them in a fixed order,
+// so concurrent transfers between the same stores can't deadlock.
+func lockInventories(tx *sqlx.Tx, transfer *entity.Transfer, items []entity.TransferItem) *response.Error {
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+
+	storeIDs := []uuid.UUID{transfer.SourceStoreID, transfer.DestinationStoreID}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i].String() < storeIDs[j].String() })
+
+	_, errInsert := tx.Exec(`
+		INSERT INTO inventories (store_id, product_id)
+		SELECT s, p FROM unnest($1::uuid[]) s CROSS JOIN unnest($2::uuid[]) p
+		ORDER BY s, p
+		ON CONFLICT DO NOTHING
+	`, pq.Array(storeIDs), pq.Array(productIDs))
+	if errInsert != nil {
+		return transferError("lock inventories", errInsert)
+	}
+
+	_, errLock := tx.Exec(`
+		SELECT 1 FROM inventories
+		WHERE store_id = ANY($1) AND product_id = ANY($2)
+		ORDER BY store_id, product_id
+		FOR UPDATE
+	`, pq.Array(storeIDs), pq.Array(productIDs))
+	if errLock != nil {
+		return transferError("lock inventories", errLock)
+	}
+
+	return nil
+}
+
+func transferError(function string, err error) *response.Error {
+	log.Error().Err(err).Int("status", 500).Str("function", function).Msg("failed to " + function)
+	return &response.Error{
+		StatusCode: 500,
+		Message:    "failed to " + function,
+		Error:      err,
+	}
+}
+
+func NewTransferRepository(db *sqlx.DB) TransferRepository {
+	return &transferRepository{db}
+}
@@ -0,0 +1,26 @@
+package transfer
+
+import (
+	staff "candyshop/internal/staff"
+	handler "candyshop/internal/transfer/handler"
+	repository "candyshop/internal/transfer/repository"
+	service "candyshop/internal/transfer/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+func Init(router fiber.Router, db *sqlx.DB) {
+	repo := repository.NewTransferRepository(db)
+	service := service.NewTransferService(repo)
+	handler := handler.NewTransferHandler(service)
+
+	transferRoute := router.Group("api/v1/transfers", staff.Authenticate(db))
+
+	transferRoute.Get("", handler.GetAllTransfer)
+	transferRoute.Post("", handler.CreateTransfer)
+	transferRoute.Get("/:id", handler.GetTransferByID)
+	transferRoute.Patch("/:id/ship", handler.ShipTransfer)
+	transferRoute.Patch("/:id/receive", handler.ReceiveTransfer)
+	transferRoute.Patch("/:id/cancel", handler.CancelTransfer)
+}
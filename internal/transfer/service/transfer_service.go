@@ -0,0 +1,182 @@
+package transfer
+
+import (
+	dto "candyshop/internal/transfer/dto"
+	entity "candyshop/internal/transfer/entity"
+	repository "candyshop/internal/transfer/repository"
+	"candyshop/pkg/auth"
+	"candyshop/pkg/response"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TransferService interface {
+	GetAllTransfer(caller *auth.Caller, status string, offset, limit int) ([]entity.Transfer, *response.Error)
+	GetTransferByID(caller *auth.Caller, id uuid.UUID) (*entity.Transfer, *response.Error)
+	CreateTransfer(caller *auth.Caller, data dto.CreateTransferRequest) (*entity.Transfer, *response.Error)
+	ShipTransfer(caller *auth.Caller, id uuid.UUID) *response.Error
+	ReceiveTransfer(caller *auth.Caller, id uuid.UUID, data dto.ReceiveTransferRequest) *response.Error
+	CancelTransfer(caller *auth.Caller, id uuid.UUID) *response.Error
+}
+
+type transferService struct {
+	repository repository.TransferRepository
+}
+
+// GetAllTransfer implements TransferService.
+func (t *transferService) GetAllTransfer(caller *auth.Caller, status string, offset int, limit int) ([]entity.Transfer, *response.Error) {
+	return t.repository.GetAllTransfer(caller.StoreScope(), status, offset, limit)
+}
+
+// GetTransferByID implements TransferService.
+func (t *transferService) GetTransferByID(caller *auth.Caller, id uuid.UUID) (*entity.Transfer, *response.Error) {
+	transfer, errTransfer := t.repository.GetTransferByID(id)
+	if errTransfer != nil {
+		return nil, errTransfer
+	}
+
+	if !caller.CanAccessStore(transfer.SourceStoreID) && !caller.CanAccessStore(transfer.DestinationStoreID) {
+		return nil, auth.ForbiddenStore(transfer.SourceStoreID)
+	}
+
+	return transfer, nil
+}
+
+// CreateTransfer implements TransferService.
+func (t *transferService) CreateTransfer(caller *auth.Caller, data dto.CreateTransferRequest) (*entity.Transfer, *response.Error) {
+	if !caller.CanAccessStore(data.SourceStoreID) && !caller.CanAccessStore(data.DestinationStoreID) {
+		return nil, auth.ForbiddenStore(data.DestinationStoreID)
+	}
+
+	if data.SourceStoreID == data.DestinationStoreID {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "source and destination store must be different",
+			Error:      errors.New("source and destination store must be different"),
+		}
+	}
+
+	items, errItems := transferItems(data.Items)
+	if errItems != nil {
+		return nil, errItems
+	}
+
+	newUUID, _ := uuid.NewV7()
+
+	dataTransfer := &entity.Transfer{
+		ID:                 newUUID,
+		SourceStoreID:      data.SourceStoreID,
+		DestinationStoreID: data.DestinationStoreID,
+		Status:             entity.StatusRequested,
+		Note:               data.Note,
+		RequestedBy:        &caller.UserID,
+		Items:              items,
+	}
+
+	return t.repository.CreateTransfer(*dataTransfer)
+}
+
+// ShipTransfer implements TransferService.
+func (t *transferService) ShipTransfer(caller *auth.Caller, id uuid.UUID) *response.Error {
+	transfer, errTransfer := t.repository.GetTransferByID(id)
+	if errTransfer != nil {
+		return errTransfer
+	}
+
+	if !caller.CanAccessStore(transfer.SourceStoreID) {
+		return auth.ForbiddenStore(transfer.SourceStoreID)
+	}
+
+	currentTime := time.Now()
+
+	return t.repository.ShipTransfer(id, currentTime)
+}
+
+// ReceiveTransfer implements TransferService.
+func (t *transferService) ReceiveTransfer(caller *auth.Caller, id uuid.UUID, data dto.ReceiveTransferRequest) *response.Error {
+	transfer, errTransfer := t.repository.GetTransferByID(id)
+	if errTransfer != nil {
+		return errTransfer
+	}
+
+	if !caller.CanAccessStore(transfer.DestinationStoreID) {
+		return auth.ForbiddenStore(transfer.DestinationStoreID)
+	}
+
+	var items []entity.TransferItem
+	if len(data.Items) == 0 {
+		// nothing listed means everything in transit has arrived
+		for _, item := range transfer.Items {
+			if remaining := item.Quantity - item.ReceivedQuantity; remaining > 0 {
+				items = append(items, entity.TransferItem{ProductID: item.ProductID, Quantity: remaining})
+			}
+		}
+	} else {
+		var errItems *response.Error
+		items, errItems = transferItems(data.Items)
+		if errItems != nil {
+			return errItems
+		}
+	}
+
+	currentTime := time.Now()
+
+	return t.repository.ReceiveTransfer(id, items, data.Complete, currentTime)
+}
+
+// CancelTransfer implements TransferService.
+func (t *transferService) CancelTransfer(caller *auth.Caller, id uuid.UUID) *response.Error {
+	transfer, errTransfer := t.repository.GetTransferByID(id)
+	if errTransfer != nil {
+		return errTransfer
+	}
+
+	if !caller.CanAccessStore(transfer.SourceStoreID) && !caller.CanAccessStore(transfer.DestinationStoreID) {
+		return auth.ForbiddenStore(transfer.SourceStoreID)
+	}
+
+	currentTime := time.Now()
+
+	return t.repository.CancelTransfer(id, currentTime)
+}
+
+// transferItems validates the requested items and merges lines of the same product.
+func transferItems(data []dto.TransferItemRequest) ([]entity.TransferItem, *response.Error) {
+	if len(data) == 0 {
+		return nil, &response.Error{
+			StatusCode: fiber.StatusBadRequest,
+			Message:    "items is required",
+			Error:      errors.New("items is required"),
+		}
+	}
+
+	var items []entity.TransferItem
+	index := map[uuid.UUID]int{}
+	for _, item := range data {
+		if item.ProductID == uuid.Nil || item.Quantity <= 0 {
+			return nil, &response.Error{
+				StatusCode: fiber.StatusBadRequest,
+				Message:    "item is invalid",
+				Error:      fmt.Errorf("product %s with quantity %d is invalid", item.ProductID, item.Quantity),
+			}
+		}
+
+		if i, ok := index[item.ProductID]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+
+		index[item.ProductID] = len(items)
+		items = append(items, entity.TransferItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	return items, nil
+}
+
+func NewTransferService(repository repository.TransferRepository) TransferService {
+	return &transferService{repository}
+}
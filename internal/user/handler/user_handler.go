@@ -3,6 +3,7 @@ package user
 import (
 	dto "candyshop/internal/user/dto"
 	service "candyshop/internal/user/service"
+	"candyshop/pkg/auth"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -55,7 +56,7 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	dataUser, errUser := h.service.CreateUser(req)
+	dataUser, errUser := h.service.CreateUser(auth.GetCaller(c), req)
 	if errUser != nil {
 		if errUser.StatusCode == 409 {
 			return c.Status(errUser.StatusCode).JSON(fiber.Map{
@@ -90,7 +91,7 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	errUpdate := h.service.UpdateUser(req)
+	errUpdate := h.service.UpdateUser(auth.GetCaller(c), req)
 	if errUpdate != nil {
 		if errUpdate.StatusCode == fiber.StatusConflict {
 			return c.Status(errUpdate.StatusCode).JSON(fiber.Map{
@@ -134,7 +135,7 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
-	errDelete := h.service.DeleteUser(parseID)
+	errDelete := h.service.DeleteUser(auth.GetCaller(c), parseID)
 	if errDelete != nil {
 		return c.Status(errDelete.StatusCode).JSON(fiber.Map{
 			"status_code": errDelete.StatusCode,
@@ -1,6 +1,7 @@
 package user
 
 import (
+	staff "candyshop/internal/staff"
 	handler "candyshop/internal/user/handler"
 	repository "candyshop/internal/user/repository"
 	service "candyshop/internal/user/service"
@@ -14,7 +15,7 @@ func Init(router fiber.Router, db *sqlx.DB) {
 	service := service.NewUserService(repo)
 	handler := handler.NewUserHandler(service)
 
-	userRoute := router.Group("api/v1/users")
+	userRoute := router.Group("api/v1/users", staff.Authenticate(db))
 
 	userRoute.Get("", handler.GetAllUser)
 	userRoute.Post("", handler.CreateUser)
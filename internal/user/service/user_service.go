@@ -4,20 +4,22 @@ import (
 	dto "candyshop/internal/user/dto"
 	entity "candyshop/internal/user/entity"
 	repository "candyshop/internal/user/repository"
+	"candyshop/pkg/auth"
 	"candyshop/pkg/response"
 	"errors"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
 	GetAllUser(offset, limit int) ([]entity.User, *response.Error)
-	CreateUser(data dto.CreateUserRequest) (*entity.User, *response.Error)
+	CreateUser(caller *auth.Caller, data dto.CreateUserRequest) (*entity.User, *response.Error)
 	GetUserByEmail(email string) (*entity.User, *response.Error)
-	UpdateUser(data dto.UpdateUserRequest) *response.Error
-	DeleteUser(id uuid.UUID) *response.Error
+	UpdateUser(caller *auth.Caller, data dto.UpdateUserRequest) *response.Error
+	DeleteUser(caller *auth.Caller, id uuid.UUID) *response.Error
 }
 
 type userService struct {
@@ -25,7 +27,11 @@ type userService struct {
 }
 
 // DeleteUser implements UserService.
-func (u *userService) DeleteUser(id uuid.UUID) *response.Error {
+func (u *userService) DeleteUser(caller *auth.Caller, id uuid.UUID) *response.Error {
+	if !caller.IsOwner() {
+		return forbidden("only owner can delete user")
+	}
+
 	// check if user is exist
 	checkUser, err := u.repository.GetUserByID(id)
 	if err != nil {
@@ -46,7 +52,15 @@ func (u *userService) DeleteUser(id uuid.UUID) *response.Error {
 }
 
 // UpdateUser implements UserService.
-func (u *userService) UpdateUser(data dto.UpdateUserRequest) *response.Error {
+func (u *userService) UpdateUser(caller *auth.Caller, data dto.UpdateUserRequest) *response.Error {
+	if !caller.IsOwner() && caller.UserID != data.ID {
+		return forbidden("only owner can update other users")
+	}
+
+	if data.Role != "" && !auth.IsValidRole(data.Role) {
+		return invalidRole()
+	}
+
 	name := data.Name
 	email := data.Email
 	role := data.Role
@@ -93,6 +107,11 @@ func (u *userService) UpdateUser(data dto.UpdateUserRequest) *response.Error {
 		role = checkUser.Role
 	}
 
+	// staff can change their own details but not what they are allowed to do
+	if !caller.IsOwner() && (role != checkUser.Role || status != checkUser.Status) {
+		return forbidden("only owner can change role or status of user")
+	}
+
 	if password != "" {
 		// generate hash for new password if input password is exists
 		newHashedPassword, errHashed := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -131,7 +150,15 @@ func (u *userService) GetUserByEmail(email string) (*entity.User, *response.Erro
 }
 
 // CreateUser implements UserService.
-func (u *userService) CreateUser(data dto.CreateUserRequest) (*entity.User, *response.Error) {
+func (u *userService) CreateUser(caller *auth.Caller, data dto.CreateUserRequest) (*entity.User, *response.Error) {
+	if !caller.IsOwner() {
+		return nil, forbidden("only owner can create user")
+	}
+
+	if !auth.IsValidRole(data.Role) {
+		return nil, invalidRole()
+	}
+
 	checkUser, err := u.repository.GetUserByEmail(data.Email)
 	if err != nil && err.StatusCode != 404 {
 		return nil, err
@@ -178,6 +205,22 @@ func (u *userService) GetAllUser(offset int, limit int) ([]entity.User, *respons
 	return u.repository.GetAllUser(offset, limit)
 }
 
+func forbidden(message string) *response.Error {
+	return &response.Error{
+		StatusCode: fiber.StatusForbidden,
+		Message:    message,
+		Error:      errors.New(message),
+	}
+}
+
+func invalidRole() *response.Error {
+	return &response.Error{
+		StatusCode: fiber.StatusBadRequest,
+		Message:    "role must be owner or staff",
+		Error:      errors.New("role must be owner or staff"),
+	}
+}
+
 func NewUserService(repository repository.UserRepository) UserService {
 	return &userService{repository}
 }
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"candyshop/pkg/response"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HeaderUserID carries the id of the user making the request.
+// There is no login in this service yet, the header has to be set by the gateway after authenticating the user.
+const HeaderUserID = "X-User-ID"
+
+// user roles, the owner is not limited to assigned stores and staff works in the stores it is assigned to
+const (
+	RoleOwner = "owner"
+	RoleStaff = "staff"
+)
+
+// roles of a user inside an assigned store
+const (
+	StoreRoleManager = "manager"
+	StoreRoleCashier = "cashier"
+	StoreRoleStaff   = "staff"
+)
+
+const callerKey = "caller"
+
+// Caller is the authenticated user of a request with the stores the user is assigned to.
+type Caller struct {
+	UserID uuid.UUID
+	Role   string
+	// Stores maps an assigned store to the role of the assignment
+	Stores map[uuid.UUID]string
+}
+
+func (c *Caller) IsOwner() bool {
+	return c.Role == RoleOwner
+}
+
+func (c *Caller) CanAccessStore(storeID uuid.UUID) bool {
+	if c.IsOwner() {
+		return true
+	}
+
+	_, ok := c.Stores[storeID]
+	return ok
+}
+
+// CanManageStore reports whether the caller may change the store itself and its staff.
+func (c *Caller) CanManageStore(storeID uuid.UUID) bool {
+	return c.IsOwner() || c.Stores[storeID] == StoreRoleManager
+}
+
+// StoreScope returns the stores the caller is limited to, nil means every store.
+func (c *Caller) StoreScope() []uuid.UUID {
+	if c.IsOwner() {
+		return nil
+	}
+
+	storeIDs := make([]uuid.UUID, 0, len(c.Stores))
+	for storeID := range c.Stores {
+		storeIDs = append(storeIDs, storeID)
+	}
+
+	return storeIDs
+}
+
+// ManagedStores returns the stores the caller manages, nil means every store.
+func (c *Caller) ManagedStores() []uuid.UUID {
+	if c.IsOwner() {
+		return nil
+	}
+
+	storeIDs := []uuid.UUID{}
+	for storeID, role := range c.Stores {
+		if role == StoreRoleManager {
+			storeIDs = append(storeIDs, storeID)
+		}
+	}
+
+	return storeIDs
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleOwner || role == RoleStaff
+}
+
+func IsValidStoreRole(role string) bool {
+	return role == StoreRoleManager || role == StoreRoleCashier || role == StoreRoleStaff
+}
+
+func SetCaller(c *fiber.Ctx, caller *Caller) {
+	c.Locals(callerKey, caller)
+}
+
+// GetCaller returns the caller set by the authenticate middleware.
+func GetCaller(c *fiber.Ctx) *Caller {
+	caller, _ := c.Locals(callerKey).(*Caller)
+	return caller
+}
+
+func ForbiddenStore(storeID uuid.UUID) *response.Error {
+	return &response.Error{
+		StatusCode: fiber.StatusForbidden,
+		Message:    "caller is not assigned to store " + storeID.String(),
+		Error:      errors.New("caller is not assigned to store " + storeID.String()),
+	}
+}
@@ -0,0 +1,74 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// barcode formats scanned at the register
+const (
+	FormatEAN13 = "ean13"
+	FormatUPCA  = "upca"
+	FormatEAN8  = "ean8"
+)
+
+var (
+	ErrInvalidBarcode  = errors.New("barcode must be 8, 12 or 13 digits")
+	ErrInvalidChecksum = errors.New("barcode check digit is invalid")
+)
+
+// Parse validates an EAN-13, UPC-A or EAN-8 barcode and returns its digits and format.
+// Spaces and dashes are ignored.
+func Parse(code string) (string, string, error) {
+	var digits strings.Builder
+	for _, r := range strings.TrimSpace(code) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-':
+			continue
+		default:
+			return "", "", ErrInvalidBarcode
+		}
+	}
+
+	result := digits.String()
+
+	var format string
+	switch len(result) {
+	case 13:
+		format = FormatEAN13
+	case 12:
+		format = FormatUPCA
+	case 8:
+		format = FormatEAN8
+	default:
+		return "", "", ErrInvalidBarcode
+	}
+
+	if checkDigit(result[:len(result)-1]) != result[len(result)-1] {
+		return "", "", ErrInvalidChecksum
+	}
+
+	return result, format, nil
+}
+
+// GTIN pads a barcode to the 14 digit GTIN, so a UPC-A and the EAN-13 with a leading zero
+// that scanners may report for the same product are equal.
+func GTIN(code string) string {
+	return strings.Repeat("0", 14-len(code)) + code
+}
+
+// checkDigit weights the digits 3 and 1 alternately from the right, the same rule serves all GTIN lengths.
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := range len(digits) {
+		digit := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
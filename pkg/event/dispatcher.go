@@ -0,0 +1,182 @@
+package event
+
+import (
+	"candyshop/pkg/job"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeDeliver delivers an event to one subscriber, TypePurge deletes the events dispatched more than RetentionDays ago.
+// A delivery is tried DeliveryAttempts times, the backoff of the job queue spreads them over about four hours.
+const (
+	TypeDeliver      = "event.deliver"
+	TypePurge        = "event.purge"
+	RetentionDays    = 30
+	DeliveryAttempts = 10
+)
+
+// Subscriber receives the events of its types. Deliver is retried with the backoff of the job queue until it
+// succeeds or runs out of attempts, so it may see an event again after a failure it did not notice.
+type Subscriber struct {
+	Name string
+	// Types are the event types it receives, no types receives every event
+	Types   []string
+	Deliver func(ctx context.Context, event Event) error
+}
+
+// Delivery is the payload of a TypeDeliver job.
+type Delivery struct {
+	EventID    int64  `json:"event_id"`
+	Subscriber string `json:"subscriber"`
+}
+
+// Dispatcher moves the events of the outbox to the job queue, one delivery job per event and subscriber.
+// A failing subscriber retries on its own and doesn't hold back the others, events are not delivered in order.
+type Dispatcher struct {
+	db          *sqlx.DB
+	subscribers map[string]Subscriber
+	// PollInterval is how long the dispatcher waits for new events when the outbox is empty
+	PollInterval time.Duration
+	// BatchSize is how many events are dispatched in one transaction
+	BatchSize int
+}
+
+func NewDispatcher(db *sqlx.DB) *Dispatcher {
+	return &Dispatcher{
+		db:           db,
+		subscribers:  map[string]Subscriber{},
+		PollInterval: time.Second,
+		BatchSize:    100,
+	}
+}
+
+// Subscribe adds a subscriber, a subscriber with the name of one added before replaces it.
+func (d *Dispatcher) Subscribe(subscriber Subscriber) {
+	d.subscribers[subscriber.Name] = subscriber
+}
+
+// InitJobs registers the delivery and the purge of the events on the worker, the worker that delivers
+// must know the same subscribers as the dispatcher.
+func (d *Dispatcher) InitJobs(worker *job.Worker) error {
+	job.Register(worker, TypeDeliver, d.deliver)
+	job.Register(worker, TypePurge, func(ctx context.Context, payload struct{}) error {
+		return d.purge(ctx)
+	})
+
+	return worker.Schedule(job.Schedule{Name: TypePurge, Spec: "45 3 * * *", Type: TypePurge})
+}
+
+// Run dispatches the events until the context ends. Dispatchers in several processes share the outbox,
+// each event is dispatched by one of them.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// dispatch while the batches come back full, the outbox may have more
+		for {
+			dispatched, err := d.dispatch()
+			if err != nil {
+				log.Error().Err(err).Int("status", 500).Str("function", "dispatch events").Msg("failed to dispatch events")
+			}
+
+			if err != nil || dispatched < d.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch queues the deliveries of the oldest undispatched events and marks them dispatched in one transaction,
+// an event is either dispatched with all of its deliveries or left for the next time.
+func (d *Dispatcher) dispatch() (int, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var events []Event
+
+	err = tx.Select(&events, `
+		SELECT id, type, subject_id, payload, occurred_at, dispatched_at FROM outbox_events
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, d.BatchSize)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		for _, subscriber := range d.subscribers {
+			if !Match(subscriber.Types, event.Type) {
+				continue
+			}
+
+			_, err := job.Enqueue(tx, TypeDeliver, Delivery{EventID: event.ID, Subscriber: subscriber.Name},
+				job.Options{MaxAttempts: DeliveryAttempts, UniqueKey: fmt.Sprintf("event:%d:%s", event.ID, subscriber.Name)})
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		ids = append(ids, event.ID)
+	}
+
+	if _, err := tx.Exec(`UPDATE outbox_events SET dispatched_at = now() WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return 0, err
+	}
+
+	return len(events), tx.Commit()
+}
+
+// deliver hands an event to a subscriber, an event that was purged or a subscriber that is gone is not retried.
+func (d *Dispatcher) deliver(ctx context.Context, delivery Delivery) error {
+	subscriber, ok := d.subscribers[delivery.Subscriber]
+	if !ok {
+		return job.Permanent(fmt.Errorf("subscriber %s is not registered", delivery.Subscriber))
+	}
+
+	var event Event
+
+	err := d.db.GetContext(ctx, &event, `SELECT id, type, subject_id, payload, occurred_at, dispatched_at FROM outbox_events WHERE id = $1`,
+		delivery.EventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job.Permanent(fmt.Errorf("event %d does not exist", delivery.EventID))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return subscriber.Deliver(ctx, event)
+}
+
+// purge deletes the dispatched events older than RetentionDays, their deliveries are done or dead by then.
+func (d *Dispatcher) purge(ctx context.Context) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM outbox_events WHERE dispatched_at < now() - $1::float8 * interval '1 day'`, RetentionDays)
+	if err != nil {
+		return err
+	}
+
+	purged, _ := result.RowsAffected()
+	log.Info().Int64("events", purged).Str("function", "purge events").Msg("dispatched events purged")
+
+	return nil
+}
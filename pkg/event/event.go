@@ -0,0 +1,70 @@
+package event
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// types of the domain events, named after the subject and what happened to it
+const (
+	ProductCreated = "product.created"
+	ProductUpdated = "product.updated"
+	// ProductDeleted is emitted for the product and for each of its variants deleted with it
+	ProductDeleted = "product.deleted"
+
+	CustomerCreated     = "customer.created"
+	CustomerUpdated     = "customer.updated"
+	CustomerDeactivated = "customer.deactivated"
+	// CustomerMerged is emitted for the source customer, the payload names the customer it was merged into
+	CustomerMerged = "customer.merged"
+
+	// SaleCompleted is emitted once a sale is fully paid, a cash sale completes when it is created
+	SaleCompleted = "sale.completed"
+)
+
+// Event is a change of a product, a customer or a sale. The id grows with every event, a subscriber
+// receives an event at least once and uses the id to tell a redelivery apart.
+type Event struct {
+	ID           int64           `json:"id" db:"id"`
+	Type         string          `json:"type" db:"type"`
+	SubjectID    uuid.UUID       `json:"subject_id" db:"subject_id"`
+	Payload      json.RawMessage `json:"payload" db:"payload"`
+	OccurredAt   time.Time       `json:"occurred_at" db:"occurred_at"`
+	DispatchedAt *time.Time      `json:"-" db:"dispatched_at"`
+}
+
+// Emit writes an event to the outbox inside the transaction of the change, so the event exists exactly when the change is committed.
+func Emit(tx *sqlx.Tx, eventType string, subjectID uuid.UUID, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO outbox_events (type, subject_id, payload) VALUES ($1, $2, $3)`, eventType, subjectID, string(body))
+
+	return err
+}
+
+// Match reports whether an event type is one of the types, a type ending in .* matches every event of the subject.
+// No types match every event.
+func Match(types []string, eventType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, pattern := range types {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+
+		if pattern == eventType {
+			return true
+		}
+	}
+
+	return false
+}
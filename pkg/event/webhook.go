@@ -0,0 +1,115 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// Webhook posts every event as JSON to a url. The body is signed with HMAC-SHA256 of the secret in the
+// X-Signature header, a receiver answering anything but 2xx gets the event again later.
+type Webhook struct {
+	Name   string
+	URL    string
+	Secret string
+	client *http.Client
+}
+
+func NewWebhook(name string, url string, secret string) *Webhook {
+	return &Webhook{
+		Name:   name,
+		URL:    url,
+		Secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ConnectWebhooks builds the webhook subscribers from the environment. EVENT_WEBHOOKS lists them as
+// name=url separated by commas, EVENT_WEBHOOK_<NAME>_TYPES limits one of them to some event types
+// and EVENT_WEBHOOK_SECRET signs the bodies. No webhooks is fine, the events are dispatched to nobody.
+func ConnectWebhooks() []Subscriber {
+	secret := os.Getenv("EVENT_WEBHOOK_SECRET")
+
+	var subscribers []Subscriber
+	for _, entry := range strings.Split(os.Getenv("EVENT_WEBHOOKS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, address, ok := strings.Cut(entry, "=")
+		if parsed, err := url.Parse(address); !ok || name == "" || err != nil || parsed.Host == "" {
+			log.Panic().Int("status", 500).Str("function", "webhook connection").Msg("failed to connect to webhook")
+			panic(fmt.Sprintf("Webhook %q must be name=url", entry))
+		}
+
+		if secret == "" {
+			log.Panic().Int("status", 500).Str("function", "webhook connection").Msg("failed to connect to webhook")
+			panic("EVENT_WEBHOOK_SECRET is missing")
+		}
+
+		var types []string
+		for _, eventType := range strings.Split(os.Getenv("EVENT_WEBHOOK_"+strings.ToUpper(name)+"_TYPES"), ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				types = append(types, eventType)
+			}
+		}
+
+		webhook := NewWebhook(name, address, secret)
+		subscribers = append(subscribers, Subscriber{Name: "webhook:" + name, Types: types, Deliver: webhook.Deliver})
+	}
+
+	return subscribers
+}
+
+// Deliver posts the event, the event id and type are also sent as headers so a receiver can skip a redelivery before reading the body.
+func (w *Webhook) Deliver(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+	req.Header.Set("X-Signature", "sha256="+w.sign(body))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("webhook %s: %s: %s", w.Name, res.Status, message)
+	}
+
+	return nil
+}
+
+func (w *Webhook) sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,30 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) Writer {
+	return &csvWriter{csv.NewWriter(w)}
+}
+
+// WriteHeader implements Writer.
+func (c *csvWriter) WriteHeader(columns []string) error {
+	return c.writer.Write(columns)
+}
+
+// WriteRow implements Writer.
+func (c *csvWriter) WriteRow(values []string) error {
+	return c.writer.Write(values)
+}
+
+// Close implements Writer.
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatXLSX   Format = "xlsx"
+)
+
+// Writer writes exported rows one by one so the whole result never has to be kept in memory.
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []string) error
+	Close() error
+}
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case FormatCSV, "":
+		return FormatCSV, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+
+	return "", fmt.Errorf("format %s is not supported", format)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// Filename returns the attachment name for the exported file, e.g. products.csv
+func (f Format) Filename(name string) string {
+	return fmt.Sprintf("%s.%s", name, f)
+}
+
+func NewWriter(format Format, w io.Writer) Writer {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+
+	return newCSVWriter(w)
+}
+
+// Column describes one exportable column of a row type.
+type Column[T any] struct {
+	Name  string
+	Value func(row T) string
+}
+
+type Columns[T any] []Column[T]
+
+// SplitColumns splits the comma separated columns query parameter.
+func SplitColumns(raw string) []string {
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Select returns the named columns in the requested order, no names selects every column.
+func (c Columns[T]) Select(names []string) (Columns[T], error) {
+	if len(names) == 0 {
+		return c, nil
+	}
+
+	var selected Columns[T]
+	for _, name := range names {
+		found := false
+		for _, column := range c {
+			if column.Name == name {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("column %s is not available", name)
+		}
+	}
+
+	return selected, nil
+}
+
+func (c Columns[T]) Names() []string {
+	names := make([]string, len(c))
+	for i, column := range c {
+		names[i] = column.Name
+	}
+
+	return names
+}
+
+func (c Columns[T]) Values(row T) []string {
+	values := make([]string, len(c))
+	for i, column := range c {
+		values[i] = column.Value(row)
+	}
+
+	return values
+}
+
+// FormatTime formats an optional timestamp as RFC 3339, nil becomes an empty cell.
+func FormatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
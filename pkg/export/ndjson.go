@@ -0,0 +1,49 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+type ndjsonWriter struct {
+	writer  io.Writer
+	columns []string
+}
+
+func newNDJSONWriter(w io.Writer) Writer {
+	return &ndjsonWriter{writer: w}
+}
+
+// WriteHeader implements Writer.
+func (n *ndjsonWriter) WriteHeader(columns []string) error {
+	n.columns = columns
+	return nil
+}
+
+// WriteRow implements Writer.
+func (n *ndjsonWriter) WriteRow(values []string) error {
+	// build the object by hand to keep the requested column order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range n.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(column)
+		value, _ := json.Marshal(values[i])
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteString("}\n")
+
+	_, err := n.writer.Write(buf.Bytes())
+	return err
+}
+
+// Close implements Writer.
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,108 @@
+package job
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// statuses of a job
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	// StatusDead is a job that failed its last attempt, it waits for someone to retry it
+	StatusDead = "dead"
+)
+
+const DefaultMaxAttempts = 5
+
+// Job is a unit of background work, the payload is the JSON its handler reads.
+type Job struct {
+	ID          int64           `db:"id"`
+	Type        string          `db:"type"`
+	Payload     json.RawMessage `db:"payload"`
+	Status      string          `db:"status"`
+	Attempts    int             `db:"attempts"`
+	MaxAttempts int             `db:"max_attempts"`
+	RunAt       time.Time       `db:"run_at"`
+}
+
+// Options change how a job is queued, the zero value runs it now with DefaultMaxAttempts.
+type Options struct {
+	RunAt       time.Time
+	MaxAttempts int
+	// UniqueKey skips the job while another one with the key is queued or running
+	UniqueKey string
+}
+
+// Enqueue queues a job with its payload as JSON. Given a transaction the job is only queued when the change it follows
+// is committed. It returns zero when the job was skipped for its unique key.
+func Enqueue(db sqlx.Queryer, jobType string, payload any, options Options) (int64, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+
+	runAt := options.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	var uniqueKey *string
+	if options.UniqueKey != "" {
+		uniqueKey = &options.UniqueKey
+	}
+
+	var id int64
+
+	err = sqlx.Get(db, &id, `
+		INSERT INTO jobs (type, payload, status, max_attempts, unique_key, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (unique_key) WHERE status IN ('queued', 'running') DO NOTHING
+		RETURNING id
+	`, jobType, string(body), StatusQueued, options.MaxAttempts, uniqueKey, runAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return id, err
+}
+
+// Backoff is how long a job waits after its nth failed attempt, doubling from 30 seconds up to 6 hours.
+// A tenth of it is random so jobs that failed together don't retry together.
+func Backoff(attempt int) time.Duration {
+	delay := min(30*time.Second<<min(max(attempt-1, 0), 10), 6*time.Hour)
+
+	return delay + rand.N(delay/10+1)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error of a job that fails the same way on every attempt, the job dies without retrying.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+func isPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,339 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// TypePurge deletes the succeeded jobs older than RetentionDays, every worker schedules it.
+const (
+	TypePurge     = "job.purge"
+	RetentionDays = 7
+)
+
+// Handler runs a job, an error fails the attempt. The context ends when the job runs out of time.
+type Handler func(ctx context.Context, job Job) error
+
+// Schedule queues a job of a type every time its cron spec matches.
+type Schedule struct {
+	Name    string
+	Spec    string
+	Type    string
+	Payload any
+	cron    *Cron
+}
+
+// Worker runs the queued jobs of the types it handles. Workers in several processes share the queue,
+// a job is claimed by one of them and a schedule queues its job once whichever worker sees it first.
+type Worker struct {
+	db        *sqlx.DB
+	handlers  map[string]Handler
+	schedules []Schedule
+	// Concurrency is how many jobs run at the same time
+	Concurrency int
+	// PollInterval is how long the worker waits for new jobs when the queue is empty
+	PollInterval time.Duration
+	// Timeout is how long a job may run, it is claimed again by another worker a minute after
+	Timeout time.Duration
+}
+
+func NewWorker(db *sqlx.DB) *Worker {
+	w := &Worker{
+		db:           db,
+		handlers:     map[string]Handler{},
+		Concurrency:  4,
+		PollInterval: 2 * time.Second,
+		Timeout:      5 * time.Minute,
+	}
+
+	w.Handle(TypePurge, w.purge)
+	_ = w.Schedule(Schedule{Name: TypePurge, Spec: "30 3 * * *", Type: TypePurge})
+
+	return w
+}
+
+// Handle registers the handler of a job type, a later handler of the same type replaces it.
+func (w *Worker) Handle(jobType string, handler Handler) {
+	w.handlers[jobType] = handler
+}
+
+// Register handles a job type whose payload is a T, a payload that can't be read kills the job without retrying.
+func Register[T any](w *Worker, jobType string, handle func(ctx context.Context, payload T) error) {
+	w.Handle(jobType, func(ctx context.Context, job Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("payload of %s is invalid: %w", jobType, err))
+		}
+
+		return handle(ctx, payload)
+	})
+}
+
+// Schedule adds a cron schedule, a schedule with the name of one added before replaces it.
+func (w *Worker) Schedule(schedule Schedule) error {
+	cron, err := ParseCron(schedule.Spec)
+	if err != nil {
+		return err
+	}
+
+	schedule.cron = cron
+
+	for i := range w.schedules {
+		if w.schedules[i].Name == schedule.Name {
+			w.schedules[i] = schedule
+			return nil
+		}
+	}
+
+	w.schedules = append(w.schedules, schedule)
+	return nil
+}
+
+// Run claims and runs jobs until the context ends, then waits for the running jobs to finish.
+func (w *Worker) Run(ctx context.Context) error {
+	if err := w.saveSchedules(); err != nil {
+		return err
+	}
+
+	types := make([]string, 0, len(w.handlers))
+	for jobType := range w.handlers {
+		types = append(types, jobType)
+	}
+
+	slots := make(chan struct{}, max(w.Concurrency, 1))
+	// a finished job wakes the worker up to claim the next one
+	wake := make(chan struct{}, 1)
+	var running sync.WaitGroup
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	w.queueDueSchedules()
+
+	for {
+		// claim jobs while there is a free slot and something to run
+	claim:
+		for {
+			select {
+			case slots <- struct{}{}:
+			default:
+				break claim
+			}
+
+			job, err := w.claim(types)
+			if err != nil || job == nil {
+				<-slots
+
+				if err != nil {
+					log.Error().Err(err).Int("status", 500).Str("function", "claim job").Msg("failed to claim job")
+				}
+
+				break
+			}
+
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				defer func() {
+					<-slots
+					select {
+					case wake <- struct{}{}:
+					default:
+					}
+				}()
+
+				w.run(*job)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			running.Wait()
+			return nil
+		case <-wake:
+		case <-ticker.C:
+			w.queueDueSchedules()
+			w.buryExpired()
+		}
+	}
+}
+
+// claim takes the next due job of the types, a running job whose worker let its lock expire is due again.
+func (w *Worker) claim(types []string) (*Job, error) {
+	var job Job
+
+	err := w.db.Get(&job, `
+		UPDATE jobs SET status = $2, attempts = attempts + 1, locked_until = now() + $3::float8 * interval '1 second', updated_at = now()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE type = ANY($1) AND (
+				(status = $4 AND run_at <= now()) OR (status = $2 AND locked_until < now() AND attempts < max_attempts)
+			)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, type, payload, status, attempts, max_attempts, run_at
+	`, pq.Array(types), StatusRunning, (w.Timeout + time.Minute).Seconds(), StatusQueued)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// run runs a claimed job and records how it ended, unless it ran so long that another worker claimed it meanwhile.
+func (w *Worker) run(job Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	errJob := w.call(ctx, job)
+
+	var err error
+	switch {
+	case errJob == nil:
+		_, err = w.db.Exec(`
+			UPDATE jobs SET status = $3, last_error = '', locked_until = NULL, updated_at = now(), finished_at = now()
+			WHERE id = $1 AND status = $4 AND attempts = $2
+		`, job.ID, job.Attempts, StatusSucceeded, StatusRunning)
+	case job.Attempts >= job.MaxAttempts || isPermanent(errJob):
+		log.Error().Err(errJob).Int64("job", job.ID).Str("type", job.Type).Int("attempts", job.Attempts).Msg("job is dead")
+
+		_, err = w.db.Exec(`
+			UPDATE jobs SET status = $3, last_error = $5, locked_until = NULL, updated_at = now(), finished_at = now()
+			WHERE id = $1 AND status = $4 AND attempts = $2
+		`, job.ID, job.Attempts, StatusDead, StatusRunning, errJob.Error())
+	default:
+		log.Warn().Err(errJob).Int64("job", job.ID).Str("type", job.Type).Int("attempts", job.Attempts).Msg("job failed, retrying")
+
+		_, err = w.db.Exec(`
+			UPDATE jobs SET status = $3, last_error = $5, run_at = $6, locked_until = NULL, updated_at = now()
+			WHERE id = $1 AND status = $4 AND attempts = $2
+		`, job.ID, job.Attempts, StatusQueued, StatusRunning, errJob.Error(), time.Now().Add(Backoff(job.Attempts)))
+	}
+
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "finish job").Msg("failed to finish job")
+		return
+	}
+
+	if errJob == nil {
+		log.Info().Int64("job", job.ID).Str("type", job.Type).Dur("duration", time.Since(started)).Msg("job succeeded")
+	}
+}
+
+// call runs the handler of a job, a panic fails the attempt like an error.
+func (w *Worker) call(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v\n%s", recovered, debug.Stack())
+		}
+	}()
+
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("job type %s has no handler", job.Type))
+	}
+
+	return handler(ctx, job)
+}
+
+// buryExpired kills the running jobs whose worker stopped during their last attempt.
+func (w *Worker) buryExpired() {
+	_, err := w.db.Exec(`
+		UPDATE jobs SET status = $2, last_error = 'worker stopped during the last attempt', locked_until = NULL, updated_at = now(), finished_at = now()
+		WHERE status = $1 AND locked_until < now() AND attempts >= max_attempts
+	`, StatusRunning, StatusDead)
+	if err != nil {
+		log.Error().Err(err).Int("status", 500).Str("function", "bury expired jobs").Msg("failed to bury expired jobs")
+	}
+}
+
+// saveSchedules stores the schedules of the worker, a schedule keeps its next run unless its spec changed.
+func (w *Worker) saveSchedules() error {
+	for _, schedule := range w.schedules {
+		payload, err := json.Marshal(schedule.Payload)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.db.Exec(`
+			INSERT INTO job_schedules (name, spec, type, payload, next_run_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, now())
+			ON CONFLICT (name) DO UPDATE SET spec = EXCLUDED.spec, type = EXCLUDED.type, payload = EXCLUDED.payload, updated_at = now(),
+				next_run_at = CASE WHEN job_schedules.spec = EXCLUDED.spec THEN job_schedules.next_run_at ELSE EXCLUDED.next_run_at END
+		`, schedule.Name, schedule.Spec, schedule.Type, string(payload), schedule.cron.Next(time.Now()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queueDueSchedules queues the job of every schedule whose next run has come. The schedule moves to its next run
+// in the transaction that queues the job, so a schedule seen by several workers is queued once.
+func (w *Worker) queueDueSchedules() {
+	for _, schedule := range w.schedules {
+		if err := w.queueSchedule(schedule); err != nil {
+			log.Error().Err(err).Int("status", 500).Str("function", "queue scheduled job").Str("schedule", schedule.Name).Msg("failed to queue scheduled job")
+		}
+	}
+}
+
+func (w *Worker) queueSchedule(schedule Schedule) error {
+	tx, err := w.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	result, err := tx.Exec(`UPDATE job_schedules SET next_run_at = $2, last_run_at = $3 WHERE name = $1 AND next_run_at <= $3`,
+		schedule.Name, schedule.cron.Next(now), now)
+	if err != nil {
+		return err
+	}
+
+	if due, _ := result.RowsAffected(); due == 0 {
+		return nil
+	}
+
+	// a run still waiting from the last time is not queued twice
+	_, err = Enqueue(tx, schedule.Type, schedule.Payload, Options{UniqueKey: "schedule:" + schedule.Name})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// purge deletes the succeeded jobs older than RetentionDays, dead jobs stay until someone looks at them.
+func (w *Worker) purge(ctx context.Context, job Job) error {
+	result, err := w.db.ExecContext(ctx, `DELETE FROM jobs WHERE status = $1 AND finished_at < now() - $2::float8 * interval '1 day'`,
+		StatusSucceeded, RetentionDays)
+	if err != nil {
+		return err
+	}
+
+	purged, _ := result.RowsAffected()
+	log.Info().Int64("jobs", purged).Str("function", "purge jobs").Msg("succeeded jobs purged")
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package ledger
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// movement types, the quantity of a movement is positive when stock comes in and negative when it goes out
+const (
+	TypeOpening     = "opening"
+	TypeReceipt     = "receipt"
+	TypeSale        = "sale"
+	TypeReturn      = "return"
+	TypeCancel      = "cancel"
+	TypeTransferOut = "transfer_out"
+	TypeTransferIn  = "transfer_in"
+	TypeAdjustment  = "adjustment"
+	TypeStockTake   = "stock_take"
+)
+
+// documents a movement can refer to
+const (
+	ReferenceSale      = "sale"
+	ReferenceTransfer  = "stock_transfer"
+	ReferenceStockTake = "stock_take"
+	ReferenceReturn    = "sale_return"
+	ReferencePurchase  = "purchase_receipt"
+)
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// Movement is one change of the stock on hand of a product in a store.
+type Movement struct {
+	StoreID       uuid.UUID
+	ProductID     uuid.UUID
+	Type          string
+	Quantity      int
+	ReferenceType string
+	ReferenceID   *uuid.UUID
+	CreatedBy     *uuid.UUID
+	Note          string
+	MovedAt       time.Time
+	// UnitCost is what a unit of the incoming stock cost, nil when it comes in at the average cost
+	UnitCost *int64
+}
+
+// Move changes the stock of a product in a store and appends the movement with the balance after it,
+// both inside the transaction of the caller. The inventory row is created when it is missing and stays locked
+// until the transaction ends, so the balances of a product follow the order of the movements.
+// Incoming stock with a unit cost moves the average cost to the weighted average of the stock on hand and the stock
+// coming in. A movement that would take the stock below zero returns ErrInsufficientStock, a zero quantity changes nothing.
+func Move(tx *sqlx.Tx, movement Movement) error {
+	if movement.Quantity == 0 {
+		return nil
+	}
+
+	_, errInit := tx.Exec(`INSERT INTO inventories (store_id, product_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		movement.StoreID, movement.ProductID)
+	if errInit != nil {
+		return errInit
+	}
+
+	var balance int
+
+	errUpdate := tx.Get(&balance, `
+		UPDATE inventories SET quantity = quantity + $3, updated_at = $4,
+			average_cost = CASE WHEN $5::bigint IS NULL OR $3 < 0 THEN average_cost
+				ELSE ROUND((quantity * average_cost + $3 * $5::numeric) / (quantity + $3)) END
+		WHERE store_id = $1 AND product_id = $2 AND quantity + $3 >= 0
+		RETURNING quantity
+	`, movement.StoreID, movement.ProductID, movement.Quantity, movement.MovedAt, movement.UnitCost)
+	if errUpdate != nil {
+		if errors.Is(errUpdate, sql.ErrNoRows) {
+			return ErrInsufficientStock
+		}
+
+		return errUpdate
+	}
+
+	_, errInsert := tx.Exec(`
+		INSERT INTO inventory_movements (store_id, product_id, type, quantity, balance_after, reference_type, reference_id, created_by, note, moved_at, unit_cost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, movement.StoreID,
+		movement.ProductID,
+		movement.Type,
+		movement.Quantity,
+		balance,
+		movement.ReferenceType,
+		movement.ReferenceID,
+		movement.CreatedBy,
+		movement.Note,
+		movement.MovedAt,
+		movement.UnitCost)
+
+	return errInsert
+}
+
+// Cost returns the average cost of a product in a store, zero when the store never had it in stock.
+func Cost(tx *sqlx.Tx, storeID, productID uuid.UUID) (int64, error) {
+	var cost int64
+
+	err := tx.Get(&cost, `SELECT average_cost FROM inventories WHERE store_id = $1 AND product_id = $2`, storeID, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return cost, err
+}
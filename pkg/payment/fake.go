@@ -0,0 +1,121 @@
+package payment
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// tokens that make the fake provider behave like a gateway that declines or waits
+const (
+	FakeTokenDeclined = "declined"
+	FakeTokenPending  = "pending"
+)
+
+type fakeCharge struct {
+	result   Result
+	amount   int64
+	refunded int64
+}
+
+// FakeProvider keeps charges in memory so card and e-wallet flows work without a gateway.
+// A token starting with declined fails, one starting with pending waits and is captured when its status is asked,
+// every other token is captured.
+type FakeProvider struct {
+	mu      sync.Mutex
+	charges map[string]*fakeCharge
+	refunds map[uuid.UUID]bool
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{charges: map[string]*fakeCharge{}, refunds: map[uuid.UUID]bool{}}
+}
+
+// Name implements Provider.
+func (f *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+// Charge implements Provider.
+func (f *FakeProvider) Charge(charge Charge) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reference := "fake_" + charge.PaymentID.String()
+	if existing, ok := f.charges[reference]; ok {
+		result := existing.result
+		return &result, nil
+	}
+
+	result := Result{Reference: reference, Status: StatusCaptured}
+
+	switch {
+	case strings.HasPrefix(charge.Token, FakeTokenDeclined):
+		result.Status = StatusFailed
+		result.Message = "card declined"
+	case strings.HasPrefix(charge.Token, FakeTokenPending):
+		result.Status = StatusPending
+	}
+
+	f.charges[reference] = &fakeCharge{result: result, amount: charge.Amount}
+
+	return &result, nil
+}
+
+// Status implements Provider.
+func (f *FakeProvider) Status(reference string) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, ok := f.charges[reference]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+
+	if existing.result.Status == StatusPending {
+		existing.result.Status = StatusCaptured
+	}
+
+	result := existing.result
+	return &result, nil
+}
+
+// Refund implements Provider.
+func (f *FakeProvider) Refund(refund Refund) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, ok := f.charges[refund.Reference]
+	if !ok {
+		return ErrChargeNotFound
+	}
+
+	if f.refunds[refund.ID] {
+		return nil
+	}
+
+	switch existing.result.Status {
+	case StatusPending:
+		existing.result.Status = StatusFailed
+		existing.result.Message = "cancelled"
+		f.refunds[refund.ID] = true
+		return nil
+	case StatusCaptured:
+	default:
+		return ErrRefundTooLarge
+	}
+
+	if existing.refunded+refund.Amount > existing.amount {
+		return ErrRefundTooLarge
+	}
+
+	existing.refunded += refund.Amount
+	if existing.refunded == existing.amount {
+		existing.result.Status = StatusRefunded
+	}
+
+	f.refunds[refund.ID] = true
+
+	return nil
+}
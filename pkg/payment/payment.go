@@ -0,0 +1,73 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	log "github.com/rs/zerolog/log"
+)
+
+// statuses of a payment, cash is captured as soon as it is taken
+const (
+	StatusPending  = "pending"
+	StatusCaptured = "captured"
+	StatusFailed   = "failed"
+	StatusRefunded = "refunded"
+)
+
+// payment providers selected with PAYMENT_PROVIDER
+const (
+	ProviderFake = "fake"
+)
+
+var (
+	ErrChargeNotFound = errors.New("charge not found")
+	ErrRefundTooLarge = errors.New("refund is larger than the captured amount")
+)
+
+// Charge asks the provider to take an amount, the payment id makes a retried charge return the first result.
+type Charge struct {
+	PaymentID uuid.UUID
+	Method    string
+	Amount    int64
+	// Token identifies the card or the wallet, it comes from the terminal
+	Token string
+}
+
+// Refund asks the provider to give back part of a charge, the refund id makes a retried refund return the first result.
+type Refund struct {
+	ID        uuid.UUID
+	Reference string
+	Amount    int64
+}
+
+// Result is what the provider knows about a charge.
+type Result struct {
+	Reference string
+	Status    string
+	Message   string
+}
+
+// Provider takes card and e-wallet payments, cash never goes through a provider.
+type Provider interface {
+	Name() string
+	Charge(charge Charge) (*Result, error)
+	// Status asks again for a charge that was pending
+	Status(reference string) (*Result, error)
+	// Refund gives back part of a captured charge, a pending charge is cancelled instead
+	Refund(refund Refund) error
+}
+
+// ConnectProvider builds the payment provider from the environment. The fake provider captures any token and keeps
+// its charges in memory, it has to be asked for explicitly so a server without a provider does not start.
+func ConnectProvider() Provider {
+	switch provider := os.Getenv("PAYMENT_PROVIDER"); provider {
+	case ProviderFake:
+		return NewFakeProvider()
+	default:
+		log.Panic().Int("status", 500).Str("function", "payment provider connection").Msg("failed to connect to payment provider")
+		panic(fmt.Sprintf("Unknown payment provider %q", provider))
+	}
+}
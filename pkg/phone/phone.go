@@ -0,0 +1,48 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultCountryCode is used for local numbers written with a leading 0, e.g. 0812... becomes +62812...
+const DefaultCountryCode = "62"
+
+var ErrInvalidPhoneNumber = errors.New("phone number is invalid")
+
+// Normalize converts a phone number to E.164 format (+ followed by up to 15 digits).
+// Spaces, dashes, dots and brackets are ignored.
+func Normalize(number string) (string, error) {
+	number = strings.TrimSpace(number)
+	international := strings.HasPrefix(number, "+")
+
+	var digits strings.Builder
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || (r == '+' && digits.Len() == 0):
+			continue
+		default:
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+
+	result := digits.String()
+	switch {
+	case international:
+	case strings.HasPrefix(result, "00"):
+		result = strings.TrimPrefix(result, "00")
+	case strings.HasPrefix(result, "0"):
+		result = DefaultCountryCode + strings.TrimPrefix(result, "0")
+	case !strings.HasPrefix(result, DefaultCountryCode):
+		result = DefaultCountryCode + result
+	}
+
+	// E.164 allows at most 15 digits, anything shorter than 8 can't be a subscriber number
+	if len(result) < 8 || len(result) > 15 || result[0] == '0' {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	return "+" + result, nil
+}
@@ -0,0 +1,170 @@
+package purge
+
+import (
+	"candyshop/pkg/job"
+	"candyshop/pkg/storage"
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeSoftDeleted deletes for good the products, customers and stores soft deleted more than RetentionDays ago.
+const (
+	TypeSoftDeleted = "purge.soft_deleted"
+	RetentionDays   = 90
+)
+
+// table is a soft deleted table. Details are the rows that only describe a row of the table and go with it,
+// a row still referenced by history like sales or stock movements stays soft deleted.
+type table struct {
+	name string
+	// order puts the rows other rows of the table point to last
+	order   string
+	details []string
+	// files deletes the rows of stored files and returns their keys, the files are removed after the commit
+	files string
+}
+
+var tables = []table{
+	{
+		name:  "products",
+		order: "parent_id IS NULL, id",
+		details: []string{
+			`DELETE FROM product_tags WHERE product_id = $1`,
+			`DELETE FROM product_barcodes WHERE product_id = $1`,
+			`DELETE FROM product_suppliers WHERE product_id = $1`,
+			`DELETE FROM promotion_products WHERE product_id = $1`,
+			`DELETE FROM tax_assignments WHERE product_id = $1`,
+			`DELETE FROM reorder_suggestions WHERE product_id = $1`,
+			`DELETE FROM inventories WHERE product_id = $1 AND quantity = 0 AND in_transit_quantity = 0`,
+		},
+		files: `DELETE FROM product_images WHERE product_id = $1 RETURNING storage_key, thumbnail_key`,
+	},
+	{
+		name:  "customers",
+		order: "merged_into IS NULL, id",
+	},
+	{
+		name:  "stores",
+		order: "id",
+		details: []string{
+			`DELETE FROM store_opening_hours WHERE store_id = $1`,
+			`DELETE FROM store_holidays WHERE store_id = $1`,
+			`DELETE FROM store_staff WHERE store_id = $1`,
+			`DELETE FROM store_tax_settings WHERE store_id = $1`,
+			`DELETE FROM receipt_templates WHERE store_id = $1`,
+			`DELETE FROM reorder_suggestions WHERE store_id = $1`,
+			`DELETE FROM inventories WHERE store_id = $1 AND quantity = 0 AND in_transit_quantity = 0`,
+			`DELETE FROM registers WHERE store_id = $1`,
+			`DELETE FROM daily_product_sales WHERE store_id = $1`,
+			`DELETE FROM daily_store_sales WHERE store_id = $1`,
+			`DELETE FROM sales_summary_queue WHERE store_id = $1`,
+		},
+	},
+}
+
+// InitJobs registers the purge of the soft deleted rows on the worker, it runs at night after the job purge.
+func InitJobs(worker *job.Worker, db *sqlx.DB, files storage.Storage) error {
+	job.Register(worker, TypeSoftDeleted, func(ctx context.Context, payload struct{}) error {
+		return SoftDeleted(ctx, db, files)
+	})
+
+	return worker.Schedule(job.Schedule{Name: TypeSoftDeleted, Spec: "0 4 * * *", Type: TypeSoftDeleted})
+}
+
+// SoftDeleted deletes the rows soft deleted more than RetentionDays ago one by one, each in its own transaction.
+func SoftDeleted(ctx context.Context, db *sqlx.DB, files storage.Storage) error {
+	for _, t := range tables {
+		var ids []string
+
+		err := db.SelectContext(ctx, &ids, `
+			SELECT id FROM `+t.name+`
+			WHERE deleted_at < now() - $1::float8 * interval '1 day'
+			ORDER BY `+t.order, RetentionDays)
+		if err != nil {
+			return err
+		}
+
+		purged, kept := 0, 0
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			keys, err := purgeRow(ctx, db, t, id)
+			if errors.Is(err, errReferenced) {
+				kept++
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			// the row is gone already, a file left behind only costs space
+			for _, key := range keys {
+				if err := files.Delete(key); err != nil {
+					log.Error().Err(err).Int("status", 500).Str("function", "purge soft deleted").Str("key", key).Msg("failed to remove file")
+				}
+			}
+
+			purged++
+		}
+
+		log.Info().Str("table", t.name).Int("purged", purged).Int("kept", kept).Str("function", "purge soft deleted").Msg("soft deleted rows purged")
+	}
+
+	return nil
+}
+
+var errReferenced = errors.New("row is still referenced")
+
+// purgeRow deletes a row with its details, a row something else still points to is left as it was.
+func purgeRow(ctx context.Context, db *sqlx.DB, t table, id string) ([]string, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, detail := range t.details {
+		if _, err := tx.ExecContext(ctx, detail, id); err != nil {
+			return nil, referenced(err)
+		}
+	}
+
+	var keys []string
+	if t.files != "" {
+		var stored []struct {
+			StorageKey   string `db:"storage_key"`
+			ThumbnailKey string `db:"thumbnail_key"`
+		}
+
+		if err := tx.SelectContext(ctx, &stored, t.files, id); err != nil {
+			return nil, referenced(err)
+		}
+
+		for _, file := range stored {
+			keys = append(keys, file.StorageKey, file.ThumbnailKey)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+t.name+` WHERE id = $1 AND deleted_at IS NOT NULL`, id); err != nil {
+		return nil, referenced(err)
+	}
+
+	return keys, tx.Commit()
+}
+
+// referenced turns a foreign key violation into errReferenced.
+func referenced(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return errReferenced
+	}
+
+	return err
+}
@@ -0,0 +1,64 @@
+package receipt
+
+import (
+	"bytes"
+)
+
+// ESC/POS commands understood by practically every thermal receipt printer
+var (
+	escInit     = []byte{0x1b, 0x40}
+	escCodePage = []byte{0x1b, 0x74, 0x10}
+	escBoldOn   = []byte{0x1b, 0x45, 0x01}
+	escBoldOff  = []byte{0x1b, 0x45, 0x00}
+	escLargeOn  = []byte{0x1d, 0x21, 0x11}
+	escLargeOff = []byte{0x1d, 0x21, 0x00}
+	escFeedCut  = []byte{0x1d, 0x56, 0x42, 0x03}
+)
+
+// ESCPOS renders the document for a thermal printer, the text is sent in code page 1252
+// and characters outside of it are printed as a question mark. The paper is cut at the end.
+func ESCPOS(d *Document) []byte {
+	var b bytes.Buffer
+
+	b.Write(escInit)
+	b.Write(escCodePage)
+
+	for _, line := range d.Layout() {
+		if line.Bold {
+			b.Write(escBoldOn)
+		}
+		if line.Large {
+			b.Write(escLargeOn)
+		}
+
+		b.Write(encode(line.Left))
+
+		if line.Large {
+			b.Write(escLargeOff)
+		}
+		if line.Bold {
+			b.Write(escBoldOff)
+		}
+
+		b.WriteByte('\n')
+	}
+
+	b.Write(escFeedCut)
+
+	return b.Bytes()
+}
+
+// encode converts text to code page 1252, which is Latin-1 for the printable characters receipts use.
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+
+	return encoded
+}
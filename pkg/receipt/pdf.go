@@ -0,0 +1,93 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// the pdf is a single page as wide as the paper roll in a monospaced font, so it prints the same lines as the printer
+const (
+	pdfFontSize   = 9.0
+	pdfCharWidth  = pdfFontSize * 0.6
+	pdfLineHeight = pdfFontSize * 1.25
+	pdfMargin     = 14.0
+)
+
+// PDF renders the document as a PDF with the standard Courier fonts, which every reader has,
+// the text is encoded in WinAnsi and characters outside of it are printed as a question mark.
+func PDF(d *Document) []byte {
+	lines := d.Layout()
+
+	height := 2 * pdfMargin
+	for _, line := range lines {
+		height += lineHeight(line)
+	}
+	width := 2*pdfMargin + float64(d.Width)*pdfCharWidth
+
+	var content bytes.Buffer
+	y := height - pdfMargin
+	for _, line := range lines {
+		y -= lineHeight(line)
+
+		font, size := "F1", pdfFontSize
+		if line.Bold {
+			font = "F2"
+		}
+		if line.Large {
+			size *= 2
+		}
+
+		// the baseline sits a quarter up the line so the descenders stay inside it
+		fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (", font, size, pdfMargin, y+lineHeight(line)/4)
+		content.Write(pdfEscape(encode(line.Left)))
+		content.WriteString(") Tj ET\n")
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>", width, height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return b.Bytes()
+}
+
+func lineHeight(line Line) float64 {
+	if line.Large {
+		return 2 * pdfLineHeight
+	}
+
+	return pdfLineHeight
+}
+
+// pdfEscape escapes the characters that end or escape a pdf string
+func pdfEscape(text []byte) []byte {
+	escaped := make([]byte, 0, len(text))
+	for _, c := range text {
+		if c == '(' || c == ')' || c == '\\' {
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, c)
+	}
+
+	return escaped
+}
@@ -0,0 +1,222 @@
+package receipt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// paper widths in characters of the common thermal printers, 58mm and 80mm rolls
+const (
+	MinWidth     = 24
+	MaxWidth     = 64
+	DefaultWidth = 42
+)
+
+// Line is one line of a receipt. A line with a right text is a row, the right text is aligned to the end of the paper.
+type Line struct {
+	Left      string
+	Right     string
+	Center    bool
+	Bold      bool
+	Large     bool
+	Separator bool
+}
+
+// Document is a receipt laid out for a paper of Width characters, both renderers print the same lines.
+type Document struct {
+	Width int
+	Lines []Line
+}
+
+func New(width int) *Document {
+	if width < MinWidth || width > MaxWidth {
+		width = DefaultWidth
+	}
+
+	return &Document{Width: width}
+}
+
+// Title adds a large centered bold line.
+func (d *Document) Title(text string) {
+	d.Lines = append(d.Lines, Line{Left: text, Center: true, Bold: true, Large: true})
+}
+
+// Text adds every line of a possibly multi line text, centered or not.
+func (d *Document) Text(text string, center bool) {
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		d.Lines = append(d.Lines, Line{Left: line, Center: center})
+	}
+}
+
+func (d *Document) Row(left string, right string) {
+	d.Lines = append(d.Lines, Line{Left: left, Right: right})
+}
+
+func (d *Document) BoldRow(left string, right string) {
+	d.Lines = append(d.Lines, Line{Left: left, Right: right, Bold: true})
+}
+
+func (d *Document) Separator() {
+	d.Lines = append(d.Lines, Line{Separator: true})
+}
+
+func (d *Document) Blank() {
+	d.Lines = append(d.Lines, Line{})
+}
+
+// Layout returns the printed text of every line without styles, long texts are wrapped at spaces
+// and a row whose texts do not fit on one line puts its right text on a line of its own.
+func (d *Document) Layout() []Line {
+	var lines []Line
+
+	for _, line := range d.Lines {
+		width := d.Width
+		if line.Large {
+			width /= 2
+		}
+
+		switch {
+		case line.Separator:
+			lines = append(lines, Line{Left: strings.Repeat("-", d.Width)})
+		case line.Right != "":
+			right := truncate(line.Right, width)
+			left := wrap(line.Left, width)
+
+			last := left[len(left)-1]
+			if utf8.RuneCountInString(last)+1+utf8.RuneCountInString(right) > width {
+				left = append(left, "")
+				last = ""
+			}
+
+			for _, text := range left[:len(left)-1] {
+				lines = append(lines, Line{Left: text, Bold: line.Bold, Large: line.Large})
+			}
+
+			padding := width - utf8.RuneCountInString(last) - utf8.RuneCountInString(right)
+			lines = append(lines, Line{Left: last + strings.Repeat(" ", padding) + right, Bold: line.Bold, Large: line.Large})
+		default:
+			for _, text := range wrap(line.Left, width) {
+				if line.Center {
+					text = strings.Repeat(" ", (width-utf8.RuneCountInString(text))/2) + text
+				}
+
+				lines = append(lines, Line{Left: text, Bold: line.Bold, Large: line.Large})
+			}
+		}
+	}
+
+	return lines
+}
+
+// Amount formats an amount in the smallest currency unit with thousands separators, e.g. 12500 becomes 12.500
+func Amount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+
+	return sign + b.String()
+}
+
+// Rate formats a rate in basis points as a percentage, e.g. 1100 becomes 11% and 1250 becomes 12.5%
+func Rate(rate int) string {
+	text := strconv.FormatFloat(float64(rate)/100, 'f', -1, 64)
+
+	return text + "%"
+}
+
+func wrap(text string, width int) []string {
+	var lines []string
+
+	current := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+
+	return append(lines, current)
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+
+	return text
+}
+
+type Format string
+
+const (
+	FormatPDF    Format = "pdf"
+	FormatESCPOS Format = "escpos"
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case FormatPDF, "":
+		return FormatPDF, nil
+	case FormatESCPOS:
+		return FormatESCPOS, nil
+	}
+
+	return "", fmt.Errorf("format %s is not supported", format)
+}
+
+func (f Format) ContentType() string {
+	if f == FormatESCPOS {
+		return "application/octet-stream"
+	}
+
+	return "application/pdf"
+}
+
+// Filename returns the attachment name of the rendered receipt, e.g. receipt.pdf or receipt.bin for the printer
+func (f Format) Filename(name string) string {
+	if f == FormatESCPOS {
+		return name + ".bin"
+	}
+
+	return name + ".pdf"
+}
+
+// Render renders the document in the format.
+func (f Format) Render(d *Document) []byte {
+	if f == FormatESCPOS {
+		return ESCPOS(d)
+	}
+
+	return PDF(d)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage writes files under a directory, the server serves the directory on PublicURL.
+type LocalStorage struct {
+	Dir       string
+	PublicURL string
+}
+
+func NewLocalStorage(dir string, publicURL string) *LocalStorage {
+	return &LocalStorage{Dir: dir, PublicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// Put implements Storage.
+func (l *LocalStorage) Put(key string, contentType string, body []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	path := filepath.Join(l.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// write next to the target and rename, a reader never sees half a file
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(body); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// Delete implements Storage.
+func (l *LocalStorage) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(l.Dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// URL implements Storage.
+func (l *LocalStorage) URL(key string) string {
+	return l.PublicURL + "/" + key
+}
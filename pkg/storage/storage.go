@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// Storage keeps uploaded files, keys are slash separated paths like products/<id>/<file>.
+type Storage interface {
+	Put(key string, contentType string, body []byte) error
+	Delete(key string) error
+	// URL returns the address clients download the file from
+	URL(key string) string
+}
+
+// storage drivers selected with STORAGE_DRIVER
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var ErrInvalidKey = errors.New("storage key is invalid")
+
+// ConnectStorage builds the storage from the environment, local filesystem is the default.
+func ConnectStorage() Storage {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", DriverLocal:
+		return NewLocalStorage(envOr("STORAGE_LOCAL_DIR", "./uploads"), envOr("STORAGE_PUBLIC_URL", "/media"))
+	case DriverS3:
+		endpoint := os.Getenv("S3_ENDPOINT")
+		bucket := os.Getenv("S3_BUCKET")
+		accessKey := os.Getenv("S3_ACCESS_KEY_ID")
+		secretKey := os.Getenv("S3_SECRET_ACCESS_KEY")
+
+		if endpoint == "" || bucket == "" || accessKey == "" || secretKey == "" {
+			log.Panic().Int("status", 500).Str("function", "storage connection").Msg("failed to connect to s3 storage")
+			panic("One or more s3 environment variables are missing")
+		}
+
+		return NewS3Storage(S3Config{
+			Endpoint:  endpoint,
+			Region:    envOr("S3_REGION", "us-east-1"),
+			Bucket:    bucket,
+			AccessKey: accessKey,
+			SecretKey: secretKey,
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+	default:
+		log.Panic().Int("status", 500).Str("function", "storage connection").Msg("failed to connect to storage")
+		panic(fmt.Sprintf("Unknown storage driver %s", driver))
+	}
+}
+
+// checkKey refuses keys that could leave the storage root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
@@ -0,0 +1,195 @@
+package summary
+
+import (
+	"candyshop/pkg/job"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// Day is a day of a store in the store timezone, formatted as YYYY-MM-DD.
+type Day struct {
+	StoreID uuid.UUID `db:"store_id"`
+	Day     string    `db:"day"`
+}
+
+// Queue queues the day a sale was sold on to be recomputed, inside the transaction that changed the sale
+// so the day is only queued when the change is committed. A day already queued is touched instead, the row stays
+// locked until the sale commits so a refresh skips the day rather than recomputing it without the sale.
+func Queue(tx *sqlx.Tx, saleID uuid.UUID) error {
+	_, err := tx.Exec(`
+		INSERT INTO sales_summary_queue (store_id, day)
+		SELECT s.store_id, (s.sold_at AT TIME ZONE st.timezone)::date
+		FROM sales s
+		JOIN stores st ON st.id = s.store_id
+		WHERE s.id = $1
+		ON CONFLICT (store_id, day) DO UPDATE SET queued_at = now()
+	`, saleID)
+
+	return err
+}
+
+// RefreshQueued recomputes the queued days of the stores one by one, nil store ids refreshes every store.
+// A day being refreshed by someone else is skipped, limit stops after that many days and zero refreshes all.
+func RefreshQueued(db *sqlx.DB, storeIDs []uuid.UUID, limit int) (int, error) {
+	refreshed := 0
+
+	for limit == 0 || refreshed < limit {
+		done, err := refreshNext(db, storeIDs)
+		if err != nil || !done {
+			return refreshed, err
+		}
+
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+// Rebuild recomputes every day from the first to the last date of the stores, nil store ids rebuilds every store.
+// It repairs the summaries after a change they did not follow, e.g. a store moved to another timezone.
+func Rebuild(db *sqlx.DB, storeIDs []uuid.UUID, from, to time.Time) (int, error) {
+	var stores []uuid.UUID
+
+	err := db.Select(&stores, `SELECT id FROM stores WHERE ($1::uuid[] IS NULL OR id = ANY($1)) ORDER BY id`, pq.Array(storeIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	rebuilt := 0
+	for _, storeID := range stores {
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			if err := rebuildDay(db, Day{StoreID: storeID, Day: day.Format(time.DateOnly)}); err != nil {
+				return rebuilt, err
+			}
+
+			rebuilt++
+		}
+	}
+
+	return rebuilt, nil
+}
+
+// TypeRefresh refreshes the queued days of every store, the worker runs it every minute.
+const TypeRefresh = "summary.refresh"
+
+// InitJobs registers the refresh of the queued days on the worker.
+func InitJobs(worker *job.Worker, db *sqlx.DB) error {
+	job.Register(worker, TypeRefresh, func(ctx context.Context, payload struct{}) error {
+		refreshed, err := RefreshQueued(db, nil, 0)
+		if refreshed > 0 {
+			log.Info().Int("days", refreshed).Str("function", "refresh sales summary").Msg("sales summary refreshed")
+		}
+
+		return err
+	})
+
+	return worker.Schedule(job.Schedule{Name: TypeRefresh, Spec: "* * * * *", Type: TypeRefresh})
+}
+
+// refreshNext takes the oldest queued day off the queue and recomputes it in one transaction. A day a sale is still
+// being written for is locked and skipped, a sale queueing the day while it is recomputed waits for the refresh
+// to commit and queues the day again. It reports false when nothing is queued.
+func refreshNext(db *sqlx.DB, storeIDs []uuid.UUID) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var day Day
+
+	err = tx.Get(&day, `
+		DELETE FROM sales_summary_queue
+		WHERE (store_id, day) = (
+			SELECT store_id, day FROM sales_summary_queue
+			WHERE ($1::uuid[] IS NULL OR store_id = ANY($1))
+			ORDER BY queued_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING store_id, day::text
+	`, pq.Array(storeIDs))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if err := refreshDay(tx, day); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func rebuildDay(db *sqlx.DB, day Day) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM sales_summary_queue WHERE store_id = $1 AND day = $2`, day.StoreID, day.Day)
+	if err != nil {
+		return err
+	}
+
+	if err := refreshDay(tx, day); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dayLines are the lines of the completed sales of store $1 on day $2 in the store timezone with what has been
+// refunded for them, the bounds are computed once so the sales are read through their store and time index.
+const dayLines = `
+	WITH bounds AS (
+		SELECT ($2::date)::timestamp AT TIME ZONE timezone AS day_start, ($2::date + 1)::timestamp AT TIME ZONE timezone AS day_end
+		FROM stores
+		WHERE id = $1
+	),
+	lines AS (
+		SELECT s.id AS sale_id, i.product_id, i.quantity, i.returned_quantity, i.subtotal, i.discount, i.tax_amount, i.total, i.cost,
+			COALESCE((SELECT SUM(ri.refund_amount) FROM sale_return_items ri WHERE ri.sale_item_id = i.id), 0) AS refunded
+		FROM sales s
+		JOIN sale_items i ON i.sale_id = s.id
+		WHERE s.store_id = $1 AND s.status = 'completed'
+			AND s.sold_at >= (SELECT day_start FROM bounds) AND s.sold_at < (SELECT day_end FROM bounds)
+	)`
+
+const dayMetrics = `COUNT(DISTINCT l.sale_id), SUM(l.quantity), SUM(l.returned_quantity), SUM(l.subtotal), SUM(l.discount),
+	SUM(l.tax_amount), SUM(l.total - l.tax_amount), SUM(l.refunded), SUM(l.cost)`
+
+// refreshDay replaces the summaries of a day with the sums of its sales, a day without sales has no rows.
+func refreshDay(tx *sqlx.Tx, day Day) error {
+	for _, statement := range []string{
+		`DELETE FROM daily_store_sales WHERE store_id = $1 AND day = $2`,
+		`DELETE FROM daily_product_sales WHERE store_id = $1 AND day = $2`,
+		dayLines + `
+		INSERT INTO daily_store_sales (store_id, day, sales_count, quantity, returned_quantity, gross_sales, discount, tax, net_sales, refunded, cost, refreshed_at)
+		SELECT $1, $2, ` + dayMetrics + `, now()
+		FROM lines l
+		HAVING COUNT(*) > 0`,
+		dayLines + `
+		INSERT INTO daily_product_sales (store_id, day, product_id, sales_count, quantity, returned_quantity, gross_sales, discount, tax, net_sales, refunded, cost, refreshed_at)
+		SELECT $1, $2, l.product_id, ` + dayMetrics + `, now()
+		FROM lines l
+		GROUP BY l.product_id`,
+	} {
+		if _, err := tx.Exec(statement, day.StoreID, day.Day); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package tax
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// whether the prices of a store already contain the tax or the tax is added on top
+const (
+	ModeInclusive = "inclusive"
+	ModeExclusive = "exclusive"
+)
+
+// where the tax is rounded to the smallest currency unit
+const (
+	RoundingLine    = "line"
+	RoundingInvoice = "invoice"
+)
+
+// RateScale is one hundred percent, rates are in basis points so 1100 is 11%
+const RateScale = 10000
+
+func IsValidMode(mode string) bool {
+	return mode == ModeInclusive || mode == ModeExclusive
+}
+
+func IsValidRounding(rounding string) bool {
+	return rounding == RoundingLine || rounding == RoundingInvoice
+}
+
+// Line is a priced line after its discounts, a line without a tax id is not taxed.
+type Line struct {
+	TaxID *uuid.UUID
+	Rate  int
+	Net   int64
+}
+
+// Breakdown is the tax of all lines with the same tax. Taxable is the amount before tax.
+type Breakdown struct {
+	TaxID   uuid.UUID
+	Rate    int
+	Taxable int64
+	Tax     int64
+}
+
+// Calculate returns the tax of every line and the tax per tax id in the order the taxes first appear.
+// Line rounding rounds every line and adds them up, invoice rounding rounds the sum of the lines of a tax once
+// and spreads it over its lines by their amount so the lines still add up to the invoice.
+func Calculate(lines []Line, mode string, rounding string) ([]int64, []Breakdown) {
+	taxes := make([]int64, len(lines))
+
+	var breakdowns []Breakdown
+	groups := map[uuid.UUID][]int{}
+
+	for i, line := range lines {
+		if line.TaxID == nil {
+			continue
+		}
+
+		if _, ok := groups[*line.TaxID]; !ok {
+			breakdowns = append(breakdowns, Breakdown{TaxID: *line.TaxID, Rate: line.Rate})
+		}
+
+		groups[*line.TaxID] = append(groups[*line.TaxID], i)
+	}
+
+	for b := range breakdowns {
+		indexes := groups[breakdowns[b].TaxID]
+
+		var net int64
+		for _, i := range indexes {
+			net += lines[i].Net
+		}
+
+		if rounding == RoundingInvoice {
+			total := taxOf(net, breakdowns[b].Rate, mode)
+			for i, share := range spread(total, indexes, lines) {
+				taxes[i] = share
+			}
+		} else {
+			for _, i := range indexes {
+				taxes[i] = taxOf(lines[i].Net, breakdowns[b].Rate, mode)
+			}
+		}
+
+		for _, i := range indexes {
+			breakdowns[b].Tax += taxes[i]
+		}
+
+		breakdowns[b].Taxable = net
+		if mode == ModeInclusive {
+			breakdowns[b].Taxable = net - breakdowns[b].Tax
+		}
+	}
+
+	return taxes, breakdowns
+}
+
+// taxOf is the tax of an amount rounded half up, an inclusive amount already contains it.
+func taxOf(amount int64, rate int, mode string) int64 {
+	if mode == ModeInclusive {
+		return divRound(amount*int64(rate), int64(RateScale+rate))
+	}
+
+	return divRound(amount*int64(rate), RateScale)
+}
+
+// spread divides a tax over lines by their amount, the units left after rounding down go to the largest remainders.
+func spread(total int64, indexes []int, lines []Line) map[int]int64 {
+	shares := map[int]int64{}
+
+	var net int64
+	for _, i := range indexes {
+		net += lines[i].Net
+	}
+
+	if net <= 0 {
+		return shares
+	}
+
+	type remainder struct {
+		index int
+		value int64
+	}
+
+	remainders := make([]remainder, 0, len(indexes))
+	left := total
+	for _, i := range indexes {
+		shares[i] = total * lines[i].Net / net
+		left -= shares[i]
+		remainders = append(remainders, remainder{index: i, value: total * lines[i].Net % net})
+	}
+
+	slices.SortStableFunc(remainders, func(a, b remainder) int { return cmp.Compare(b.value, a.value) })
+	for r := 0; left > 0 && r < len(remainders); r++ {
+		shares[remainders[r].index]++
+		left--
+	}
+
+	return shares
+}
+
+func divRound(a int64, b int64) int64 {
+	return (a + b/2) / b
+}
@@ -0,0 +1,74 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+)
+
+// Resize scales the image down to fit in a size x size square keeping its aspect ratio,
+// every target pixel is the average of the source pixels it covers. Smaller images keep their size.
+func Resize(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, max(1, sh*size/sw)
+		} else {
+			dw, dh = max(1, sw*size/sh), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		sy0 := bounds.Min.Y + y*sh/dh
+		sy1 := max(sy0+1, bounds.Min.Y+(y+1)*sh/dh)
+
+		for x := range dw {
+			sx0 := bounds.Min.X + x*sw/dw
+			sx1 := max(sx0+1, bounds.Min.X+(x+1)*sw/dw)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+// Encode writes the thumbnail as JPEG, or as PNG when the source may be transparent.
+// It returns the bytes with their content type.
+func Encode(img image.Image, transparent bool) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if transparent {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+
+		return buf.Bytes(), "image/png", nil
+	}
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), "image/jpeg", nil
+}